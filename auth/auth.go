@@ -0,0 +1,162 @@
+// Package auth authenticates and authorizes requests to the server API with
+// bearer tokens, HTTP basic auth or TLS client certificates.
+package auth
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Role is the role of an identity, every role is allowed what the roles
+// before it are.
+type Role int
+
+const (
+	// Public routes are allowed without credentials.
+	Public Role = iota
+	// Viewer can read plans, runs and reports.
+	Viewer
+	// Operator can start, pause, resume and cancel runs.
+	Operator
+	// Admin can add and delete plans.
+	Admin
+)
+
+var roleNames = []string{"public", "viewer", "operator", "admin"}
+
+// ParseRole returns the role with the name s.
+func ParseRole(s string) (Role, error) {
+	for i, name := range roleNames {
+		if name == s && Role(i) != Public {
+			return Role(i), nil
+		}
+	}
+	return Public, fmt.Errorf("invalid role %q", s)
+}
+
+// String returns the name of the role.
+func (r Role) String() string {
+	if r < 0 || int(r) >= len(roleNames) {
+		return fmt.Sprintf("Role(%d)", int(r))
+	}
+	return roleNames[r]
+}
+
+// UnmarshalYAML parses a role from its name.
+func (r *Role) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	role, err := ParseRole(s)
+	if err != nil {
+		return err
+	}
+	*r = role
+	return nil
+}
+
+// MarshalYAML returns the name of the role.
+func (r Role) MarshalYAML() (interface{}, error) {
+	return r.String(), nil
+}
+
+// ErrInvalidCredentials is returned for requests with unknown credentials.
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// Identity is an authenticated client.
+type Identity struct {
+	Name string
+	Role Role
+}
+
+// Authenticator authenticates requests.
+type Authenticator interface {
+	// Authenticate returns the identity of the client of a request, or
+	// nil if the request has none of the credentials it checks.
+	Authenticate(r *http.Request) (*Identity, error)
+}
+
+// Authenticators authenticates requests with the first Authenticator that
+// finds credentials.
+type Authenticators []Authenticator
+
+// Authenticate implements the Authenticator interface.
+func (as Authenticators) Authenticate(r *http.Request) (*Identity, error) {
+	for _, a := range as {
+		id, err := a.Authenticate(r)
+		if id != nil || err != nil {
+			return id, err
+		}
+	}
+	return nil, nil
+}
+
+// Tokens authenticates requests by their bearer token. Tokens are kept as
+// hashes so lookups do not leak them through timing.
+type Tokens map[[sha256.Size]byte]Identity
+
+// Add adds a token.
+func (t Tokens) Add(token string, id Identity) {
+	t[sha256.Sum256([]byte(token))] = id
+}
+
+// Authenticate implements the Authenticator interface.
+func (t Tokens) Authenticate(r *http.Request) (*Identity, error) {
+	h := r.Header.Get("Authorization")
+	if !strings.HasPrefix(h, "Bearer ") {
+		return nil, nil
+	}
+	id, ok := t[sha256.Sum256([]byte(strings.TrimPrefix(h, "Bearer ")))]
+	if !ok {
+		return nil, ErrInvalidCredentials
+	}
+	return &id, nil
+}
+
+// Users authenticates requests with HTTP basic auth.
+type Users map[string]User
+
+// User is a basic auth user.
+type User struct {
+	Identity
+	// PasswordHash is the bcrypt hash of the password.
+	PasswordHash []byte
+}
+
+// Authenticate implements the Authenticator interface.
+func (u Users) Authenticate(r *http.Request) (*Identity, error) {
+	name, password, ok := r.BasicAuth()
+	if !ok {
+		return nil, nil
+	}
+	user, ok := u[name]
+	if !ok {
+		return nil, ErrInvalidCredentials
+	}
+	if err := bcrypt.CompareHashAndPassword(user.PasswordHash, []byte(password)); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	return &user.Identity, nil
+}
+
+// Certs authenticates requests by the common name of their verified TLS
+// client certificate.
+type Certs map[string]Identity
+
+// Authenticate implements the Authenticator interface.
+func (c Certs) Authenticate(r *http.Request) (*Identity, error) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 {
+		return nil, nil
+	}
+	id, ok := c[r.TLS.VerifiedChains[0][0].Subject.CommonName]
+	if !ok {
+		return nil, ErrInvalidCredentials
+	}
+	return &id, nil
+}
@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// TestParseRole tests parsing role names.
+func TestParseRole(t *testing.T) {
+	for _, role := range []Role{Viewer, Operator, Admin} {
+		parsed, err := ParseRole(role.String())
+		require.NoError(t, err)
+		assert.Equal(t, role, parsed)
+	}
+	_, err := ParseRole("public")
+	require.Error(t, err)
+	_, err = ParseRole("root")
+	require.Error(t, err)
+}
+
+// TestAuthenticate tests authenticating requests with tokens, basic auth
+// and client certificates.
+func TestAuthenticate(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	require.NoError(t, err)
+	conf := &Config{
+		Tokens: []TokenConfig{{Name: "ci", Token: "t0k3n", Role: Operator}},
+		Users:  []UserConfig{{Name: "alice", Password: string(hash), Role: Admin}},
+		Certs:  []CertConfig{{Name: "agent", Role: Operator}},
+	}
+	a, err := conf.Authenticator()
+	require.NoError(t, err)
+
+	r := httptest.NewRequest("GET", "/plans", nil)
+	id, err := a.Authenticate(r)
+	require.NoError(t, err)
+	assert.Nil(t, id)
+
+	r.Header.Set("Authorization", "Bearer t0k3n")
+	id, err = a.Authenticate(r)
+	require.NoError(t, err)
+	assert.Equal(t, &Identity{Name: "ci", Role: Operator}, id)
+	r.Header.Set("Authorization", "Bearer wrong")
+	_, err = a.Authenticate(r)
+	assert.Equal(t, ErrInvalidCredentials, err)
+
+	r.SetBasicAuth("alice", "secret")
+	id, err = a.Authenticate(r)
+	require.NoError(t, err)
+	assert.Equal(t, &Identity{Name: "alice", Role: Admin}, id)
+	r.SetBasicAuth("alice", "wrong")
+	_, err = a.Authenticate(r)
+	assert.Equal(t, ErrInvalidCredentials, err)
+
+	r = httptest.NewRequest("GET", "/plans", nil)
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "agent"}}
+	r.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+	id, err = a.Authenticate(r)
+	require.NoError(t, err)
+	assert.Equal(t, &Identity{Name: "agent", Role: Operator}, id)
+	cert.Subject.CommonName = "other"
+	_, err = a.Authenticate(r)
+	assert.Equal(t, ErrInvalidCredentials, err)
+}
+
+// TestLoad tests reading and validating auth files.
+func TestLoad(t *testing.T) {
+	load := func(s string) (*Config, error) {
+		path := filepath.Join(t.TempDir(), "auth.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(s), 0600))
+		return Load(path)
+	}
+	conf, err := load(`
+tokens:
+  - name: ci
+    token: t0k3n
+    role: operator
+certs:
+  - name: agent
+    role: viewer
+`)
+	require.NoError(t, err)
+	assert.Equal(t, []TokenConfig{{Name: "ci", Token: "t0k3n", Role: Operator}}, conf.Tokens)
+	assert.Equal(t, []CertConfig{{Name: "agent", Role: Viewer}}, conf.Certs)
+	_, err = conf.Authenticator()
+	require.NoError(t, err)
+
+	_, err = load("tokens:\n  - name: ci\n    token: t0k3n\n    role: root\n")
+	require.Error(t, err)
+	_, err = load("token: []\n")
+	require.Error(t, err)
+
+	for _, conf := range []*Config{
+		{Tokens: []TokenConfig{{Name: "ci", Role: Viewer}}},
+		{Tokens: []TokenConfig{{Name: "ci", Token: "t0k3n"}}},
+		{Users: []UserConfig{{Name: "alice", Password: "secret", Role: Admin}}},
+		{Certs: []CertConfig{{Role: Admin}}},
+	} {
+		_, err := conf.Authenticator()
+		assert.Error(t, err)
+	}
+}
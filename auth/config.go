@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"golang.org/x/crypto/bcrypt"
+	"gopkg.in/yaml.v2"
+)
+
+// Config is an auth file with the clients allowed to use the server API.
+type Config struct {
+	Tokens []TokenConfig `yaml:"tokens,omitempty"`
+	Users  []UserConfig  `yaml:"users,omitempty"`
+	Certs  []CertConfig  `yaml:"certs,omitempty"`
+}
+
+// TokenConfig is a client with a bearer token.
+type TokenConfig struct {
+	Name  string `yaml:"name"`
+	Token string `yaml:"token"`
+	Role  Role   `yaml:"role"`
+}
+
+// UserConfig is a basic auth user.
+type UserConfig struct {
+	Name string `yaml:"name"`
+	// Password is the bcrypt hash of the password of the user.
+	Password string `yaml:"password"`
+	Role     Role   `yaml:"role"`
+}
+
+// CertConfig is a client with a TLS certificate.
+type CertConfig struct {
+	// Name is the common name of the certificate.
+	Name string `yaml:"name"`
+	Role Role   `yaml:"role"`
+}
+
+// Load reads an auth file.
+func Load(path string) (*Config, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c Config
+	if err := yaml.UnmarshalStrict(b, &c); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &c, nil
+}
+
+// Authenticator returns an Authenticator for the clients of the config.
+func (c *Config) Authenticator() (Authenticator, error) {
+	tokens := Tokens{}
+	for _, t := range c.Tokens {
+		if t.Token == "" {
+			return nil, fmt.Errorf("token %q is empty", t.Name)
+		}
+		if err := validIdentity(t.Name, t.Role); err != nil {
+			return nil, err
+		}
+		tokens.Add(t.Token, Identity{Name: t.Name, Role: t.Role})
+	}
+	users := Users{}
+	for _, u := range c.Users {
+		if err := validIdentity(u.Name, u.Role); err != nil {
+			return nil, err
+		}
+		if _, err := bcrypt.Cost([]byte(u.Password)); err != nil {
+			return nil, fmt.Errorf("user %q: password must be a bcrypt hash: %w", u.Name, err)
+		}
+		if _, ok := users[u.Name]; ok {
+			return nil, fmt.Errorf("duplicate user %q", u.Name)
+		}
+		users[u.Name] = User{
+			Identity:     Identity{Name: u.Name, Role: u.Role},
+			PasswordHash: []byte(u.Password),
+		}
+	}
+	certs := Certs{}
+	for _, cert := range c.Certs {
+		if err := validIdentity(cert.Name, cert.Role); err != nil {
+			return nil, err
+		}
+		certs[cert.Name] = Identity{Name: cert.Name, Role: cert.Role}
+	}
+	return Authenticators{certs, tokens, users}, nil
+}
+
+func validIdentity(name string, role Role) error {
+	if name == "" {
+		return errors.New("client requires a name")
+	}
+	if role == Public {
+		return fmt.Errorf("client %q requires a role", name)
+	}
+	return nil
+}
+
+// TLSConfig returns the TLS config of a server with the certificate and key
+// files. If clientCA is set, client certificates signed by it are verified.
+func TLSConfig(certFile, keyFile, clientCA string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	conf := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if clientCA == "" {
+		return conf, nil
+	}
+	b, err := ioutil.ReadFile(clientCA)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(b) {
+		return nil, fmt.Errorf("%s: no certificates found", clientCA)
+	}
+	conf.ClientCAs = pool
+	// Clients without a certificate can still use tokens or basic auth.
+	conf.ClientAuth = tls.VerifyClientCertIfGiven
+	return conf, nil
+}
@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryInterceptor returns a gRPC interceptor that authenticates calls with
+// a and authorizes them with roles, which are keyed by full method name.
+// Methods without a role require an admin.
+func UnaryInterceptor(a Authenticator, roles map[string]Role) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := authorize(ctx, a, roles, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamInterceptor is the streaming counterpart of UnaryInterceptor.
+func StreamInterceptor(a Authenticator, roles map[string]Role) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := authorize(ss.Context(), a, roles, info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+func authorize(ctx context.Context, a Authenticator, roles map[string]Role, method string) error {
+	required, ok := roles[method]
+	if !ok {
+		required = Admin
+	}
+	if required == Public {
+		return nil
+	}
+	// The credentials of the call are checked as those of a HTTP request:
+	// the authorization metadata and the TLS client certificate.
+	r := &http.Request{Header: http.Header{}}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for _, v := range md.Get("authorization") {
+			r.Header.Add("Authorization", v)
+		}
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			r.TLS = &info.State
+		}
+	}
+	id, err := a.Authenticate(r)
+	if err != nil {
+		return status.Error(codes.Unauthenticated, err.Error())
+	}
+	if id == nil {
+		return status.Error(codes.Unauthenticated, "authentication required")
+	}
+	if id.Role < required {
+		return status.Error(codes.PermissionDenied, id.Name+" requires the "+required.String()+" role")
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Rules are the roles required by routes, keyed by the method and path of
+// the route, such as "POST /plan/:name/runs".
+type Rules map[string]Role
+
+// DefaultRules are the rules of the server API. Reading is allowed to
+// viewers and every other route not listed requires an admin. Registering
+// an agent and running a plan on an agent require an admin, the controller
+// sends every distributed plan to the URL an agent registers and an agent
+// runs any plan it is sent.
+var DefaultRules = Rules{
+	"GET /ping":                Public,
+	"POST /plan/:name/execute": Operator,
+	"POST /plan/:name/queue":   Operator,
+	"POST /plan/:name/runs":    Operator,
+	"POST /runs/:id/pause":     Operator,
+	"POST /runs/:id/resume":    Operator,
+	"POST /runs/:id/cancel":    Operator,
+}
+
+// Role returns the role required by a route.
+func (rules Rules) Role(method, path string) Role {
+	if role, ok := rules[method+" "+path]; ok {
+		return role
+	}
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return Viewer
+	}
+	return Admin
+}
+
+// Middleware returns gin middleware that authenticates requests with a and
+// authorizes them with rules. It must be added before the routes of the
+// engine.
+func Middleware(a Authenticator, rules Rules) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		required := rules.Role(c.Request.Method, c.FullPath())
+		if required == Public {
+			c.Next()
+			return
+		}
+		id, err := a.Authenticate(c.Request)
+		if err != nil {
+			c.AbortWithStatusJSON(401, gin.H{"msg": err.Error()})
+			return
+		}
+		if id == nil {
+			c.Header("WWW-Authenticate", `Basic realm="dlg"`)
+			c.AbortWithStatusJSON(401, gin.H{"msg": "authentication required"})
+			return
+		}
+		if id.Role < required {
+			c.AbortWithStatusJSON(403, gin.H{"msg": id.Name + " requires the " + required.String() + " role"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// Transport is a http.RoundTripper that sends a bearer token.
+type Transport struct {
+	Token string
+	// Base is the underlying RoundTripper, http.DefaultTransport if nil.
+	Base http.RoundTripper
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (t *Transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	r = r.Clone(r.Context())
+	r.Header.Set("Authorization", "Bearer "+t.Token)
+	return base.RoundTrip(r)
+}
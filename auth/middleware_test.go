@@ -0,0 +1,164 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testEngine(t *testing.T, a Authenticator) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	e := gin.New()
+	e.Use(Middleware(a, DefaultRules))
+	ok := func(c *gin.Context) { c.JSON(200, gin.H{"status": "ok"}) }
+	e.GET("/ping", ok)
+	e.GET("/plans", ok)
+	e.POST("/plan", ok)
+	e.POST("/plan/:name/runs", ok)
+	e.POST("/runs/:id/cancel", ok)
+	return e
+}
+
+// TestMiddleware tests requests are allowed by the role of their client.
+func TestMiddleware(t *testing.T) {
+	tokens := Tokens{}
+	tokens.Add("viewer", Identity{Name: "v", Role: Viewer})
+	tokens.Add("operator", Identity{Name: "o", Role: Operator})
+	tokens.Add("admin", Identity{Name: "a", Role: Admin})
+	e := testEngine(t, tokens)
+
+	for _, tc := range []struct {
+		method, path, token string
+		code                int
+	}{
+		{"GET", "/ping", "", 200},
+		{"GET", "/plans", "", 401},
+		{"GET", "/plans", "wrong", 401},
+		{"GET", "/plans", "viewer", 200},
+		{"POST", "/plan/test/runs", "viewer", 403},
+		{"POST", "/plan/test/runs", "operator", 200},
+		{"POST", "/runs/1/cancel", "operator", 200},
+		{"POST", "/plan", "operator", 403},
+		{"POST", "/plan", "admin", 200},
+		{"GET", "/missing", "", 401},
+		{"GET", "/missing", "viewer", 404},
+	} {
+		r := httptest.NewRequest(tc.method, tc.path, nil)
+		if tc.token != "" {
+			r.Header.Set("Authorization", "Bearer "+tc.token)
+		}
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, r)
+		assert.Equal(t, tc.code, w.Code, "%s %s with %q", tc.method, tc.path, tc.token)
+	}
+}
+
+// TestRulesRole tests the default roles of routes.
+func TestRulesRole(t *testing.T) {
+	assert.Equal(t, Public, DefaultRules.Role("GET", "/ping"))
+	assert.Equal(t, Viewer, DefaultRules.Role("GET", "/runs/:id/events"))
+	assert.Equal(t, Operator, DefaultRules.Role("POST", "/runs/:id/pause"))
+	assert.Equal(t, Admin, DefaultRules.Role("DELETE", "/plan/:name"))
+	assert.Equal(t, Admin, DefaultRules.Role("DELETE", "/history/:id"))
+	assert.Equal(t, Admin, DefaultRules.Role("POST", "/agents"))
+	assert.Equal(t, Admin, DefaultRules.Role("POST", "/agent/run"))
+}
+
+// TestTransport tests clients authenticate with the token of a Transport.
+func TestTransport(t *testing.T) {
+	tokens := Tokens{}
+	tokens.Add("t0k3n", Identity{Name: "ci", Role: Viewer})
+	srv := httptest.NewServer(testEngine(t, tokens))
+	defer srv.Close()
+
+	client := &http.Client{Transport: &Transport{Token: "t0k3n"}}
+	res, err := client.Get(srv.URL + "/plans")
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, 200, res.StatusCode)
+}
+
+// testCert writes a certificate signed by parent, or a self-signed CA if
+// parent is nil, and its key to dir.
+func testCert(t *testing.T, dir, name string, parent *tls.Certificate) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	signer, signerKey := tmpl, interface{}(key)
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature
+	} else {
+		signer, signerKey = parent.Leaf, parent.PrivateKey
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name+".crt"), certPEM, 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name+".key"), keyPEM, 0600))
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	require.NoError(t, err)
+	cert.Leaf, err = x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert
+}
+
+// TestClientCert tests clients authenticate with certificates over mTLS.
+func TestClientCert(t *testing.T) {
+	dir := t.TempDir()
+	ca := testCert(t, dir, "ca", nil)
+	testCert(t, dir, "server", &ca)
+	agent := testCert(t, dir, "agent", &ca)
+
+	conf, err := TLSConfig(
+		filepath.Join(dir, "server.crt"),
+		filepath.Join(dir, "server.key"),
+		filepath.Join(dir, "ca.crt"),
+	)
+	require.NoError(t, err)
+	srv := httptest.NewUnstartedServer(testEngine(t, Certs{"agent": {Name: "agent", Role: Operator}}))
+	srv.TLS = conf
+	srv.StartTLS()
+	defer srv.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Leaf)
+	do := func(certs []tls.Certificate) int {
+		client := &http.Client{Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: certs},
+		}}
+		res, err := client.Post(srv.URL+"/plan/test/runs", "", nil)
+		require.NoError(t, err)
+		res.Body.Close()
+		return res.StatusCode
+	}
+	assert.Equal(t, 200, do([]tls.Certificate{agent}))
+	assert.Equal(t, 401, do(nil))
+}
@@ -0,0 +1,209 @@
+// Package client is a client of the gRPC control API of dlg servers, it is
+// meant to drive load tests from Go programs such as integration test
+// suites.
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+
+	"github.com/hodgesds/dlg/config"
+	"github.com/hodgesds/dlg/control/controlpb"
+	"github.com/hodgesds/dlg/report"
+	"google.golang.org/grpc"
+	"gopkg.in/yaml.v2"
+)
+
+// Client is a client of a dlg server.
+type Client struct {
+	conn *grpc.ClientConn
+	c    controlpb.ControlClient
+}
+
+// Dial returns a client of the server at target.
+func Dial(ctx context.Context, target string, opts ...grpc.DialOption) (*Client, error) {
+	conn, err := grpc.DialContext(ctx, target, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{conn: conn, c: controlpb.NewControlClient(conn)}, nil
+}
+
+// New returns a client that uses an existing connection, which is not
+// closed by Close.
+func New(conn grpc.ClientConnInterface) *Client {
+	return &Client{c: controlpb.NewControlClient(conn)}
+}
+
+// Close closes the connection of the client.
+func (c *Client) Close() error {
+	if c.conn == nil {
+		return nil
+	}
+	return c.conn.Close()
+}
+
+// tokenCreds sends a bearer token with every call.
+type tokenCreds string
+
+func (t tokenCreds) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + string(t)}, nil
+}
+
+// RequireTransportSecurity allows tokens on insecure connections, which
+// should only be used on trusted networks.
+func (t tokenCreds) RequireTransportSecurity() bool {
+	return false
+}
+
+// WithToken returns a DialOption that authenticates calls with a bearer
+// token.
+func WithToken(token string) grpc.DialOption {
+	return grpc.WithPerRPCCredentials(tokenCreds(token))
+}
+
+func unmarshalPlan(p *controlpb.Plan) (*config.Plan, error) {
+	var plan config.Plan
+	if err := yaml.Unmarshal([]byte(p.Yaml), &plan); err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// Plans returns the plans of the server.
+func (c *Client) Plans(ctx context.Context) ([]*config.Plan, error) {
+	res, err := c.c.ListPlans(ctx, &controlpb.ListPlansRequest{})
+	if err != nil {
+		return nil, err
+	}
+	plans := make([]*config.Plan, 0, len(res.Plans))
+	for _, p := range res.Plans {
+		plan, err := unmarshalPlan(p)
+		if err != nil {
+			return nil, err
+		}
+		plans = append(plans, plan)
+	}
+	return plans, nil
+}
+
+// Plan returns a plan by name.
+func (c *Client) Plan(ctx context.Context, name string) (*config.Plan, error) {
+	p, err := c.c.GetPlan(ctx, &controlpb.GetPlanRequest{Name: name})
+	if err != nil {
+		return nil, err
+	}
+	return unmarshalPlan(p)
+}
+
+// AddPlan adds a plan or replaces the plan with the same name.
+func (c *Client) AddPlan(ctx context.Context, plan *config.Plan) error {
+	b, err := yaml.Marshal(plan)
+	if err != nil {
+		return err
+	}
+	_, err = c.c.AddPlan(ctx, &controlpb.AddPlanRequest{Yaml: string(b)})
+	return err
+}
+
+// DeletePlan removes a plan.
+func (c *Client) DeletePlan(ctx context.Context, name string) error {
+	_, err := c.c.DeletePlan(ctx, &controlpb.DeletePlanRequest{Name: name})
+	return err
+}
+
+// StartRun starts a run of a plan.
+func (c *Client) StartRun(ctx context.Context, plan string) (*controlpb.Run, error) {
+	return c.c.StartRun(ctx, &controlpb.StartRunRequest{Plan: plan})
+}
+
+// Runs returns the active and recently finished runs.
+func (c *Client) Runs(ctx context.Context) ([]*controlpb.Run, error) {
+	res, err := c.c.ListRuns(ctx, &controlpb.ListRunsRequest{})
+	if err != nil {
+		return nil, err
+	}
+	return res.Runs, nil
+}
+
+// Run returns a run by ID.
+func (c *Client) Run(ctx context.Context, id string) (*controlpb.Run, error) {
+	return c.c.GetRun(ctx, &controlpb.GetRunRequest{Id: id})
+}
+
+// PauseRun pauses a run.
+func (c *Client) PauseRun(ctx context.Context, id string) (*controlpb.Run, error) {
+	return c.c.PauseRun(ctx, &controlpb.PauseRunRequest{Id: id})
+}
+
+// ResumeRun resumes a paused run.
+func (c *Client) ResumeRun(ctx context.Context, id string) (*controlpb.Run, error) {
+	return c.c.ResumeRun(ctx, &controlpb.ResumeRunRequest{Id: id})
+}
+
+// CancelRun cancels a run.
+func (c *Client) CancelRun(ctx context.Context, id string) (*controlpb.Run, error) {
+	return c.c.CancelRun(ctx, &controlpb.CancelRunRequest{Id: id})
+}
+
+// Watch calls f with the events of a run, starting with a snapshot, until
+// the done event or until f returns an error.
+func (c *Client) Watch(ctx context.Context, id string, f func(*controlpb.RunEvent) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := c.c.WatchRun(ctx, &controlpb.WatchRunRequest{Id: id})
+	if err != nil {
+		return err
+	}
+	for {
+		e, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := f(e); err != nil {
+			return err
+		}
+	}
+}
+
+// Wait waits for a run to complete and returns its final status.
+func (c *Client) Wait(ctx context.Context, id string) (*controlpb.Run, error) {
+	var run *controlpb.Run
+	err := c.Watch(ctx, id, func(e *controlpb.RunEvent) error {
+		// The snapshot of a finished run is its final status.
+		if e.Type == "done" || (e.Type == "snapshot" && e.Status.End != nil) {
+			run = e.Status
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if run == nil {
+		return nil, errors.New("run events ended without a done event")
+	}
+	return run, nil
+}
+
+// Report returns the report of a run.
+func (c *Client) Report(ctx context.Context, id string) (*report.Report, error) {
+	res, err := c.c.GetReport(ctx, &controlpb.GetReportRequest{Id: id, Format: "json"})
+	if err != nil {
+		return nil, err
+	}
+	return report.ReadJSON(bytes.NewReader(res.Data))
+}
+
+// ReportHTML returns the HTML report of a run.
+func (c *Client) ReportHTML(ctx context.Context, id string) ([]byte, error) {
+	res, err := c.c.GetReport(ctx, &controlpb.GetReportRequest{Id: id, Format: "html"})
+	if err != nil {
+		return nil, err
+	}
+	return res.Data, nil
+}
@@ -0,0 +1,132 @@
+package client
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/hodgesds/dlg"
+	"github.com/hodgesds/dlg/config"
+	"github.com/hodgesds/dlg/control"
+	"github.com/hodgesds/dlg/control/controlpb"
+	"github.com/hodgesds/dlg/stats"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// testPlanExec records an operation, plans named block then run until they
+// are canceled.
+type testPlanExec struct{}
+
+func (testPlanExec) Execute(ctx context.Context, p *config.Plan) error {
+	stats.FromContext(ctx).Record(stats.Result{
+		Time:     time.Now(),
+		Stage:    "get",
+		Protocol: "http",
+		Latency:  time.Millisecond,
+	})
+	if p.Name == "block" {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return nil
+}
+
+func testClient(t *testing.T) *Client {
+	m := dlg.NewManager(testPlanExec{})
+	runs := dlg.NewRuns(m, dlg.RunsConfig{})
+	t.Cleanup(func() { runs.Close() })
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	control.Register(s, m, runs)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	c, err := Dial(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		WithToken("t0k3n"),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+// TestClientPlans tests adding, listing and deleting plans.
+func TestClientPlans(t *testing.T) {
+	c := testClient(t)
+	ctx := context.Background()
+	require.NoError(t, c.AddPlan(ctx, &config.Plan{Name: "test", Tags: []string{"a"}}))
+
+	plan, err := c.Plan(ctx, "test")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a"}, plan.Tags)
+	plans, err := c.Plans(ctx)
+	require.NoError(t, err)
+	require.Len(t, plans, 1)
+	assert.Equal(t, "test", plans[0].Name)
+
+	require.NoError(t, c.DeletePlan(ctx, "test"))
+	_, err = c.Plan(ctx, "test")
+	require.Error(t, err)
+}
+
+// TestClientRuns tests running plans and following their events.
+func TestClientRuns(t *testing.T) {
+	c := testClient(t)
+	ctx := context.Background()
+	for _, name := range []string{"test", "block"} {
+		require.NoError(t, c.AddPlan(ctx, &config.Plan{Name: name}))
+	}
+
+	run, err := c.StartRun(ctx, "test")
+	require.NoError(t, err)
+	done, err := c.Wait(ctx, run.Id)
+	require.NoError(t, err)
+	assert.Equal(t, dlg.RunPassed, done.State)
+	assert.NotNil(t, done.End)
+	rep, err := c.Report(ctx, run.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "test", rep.Plan)
+	require.Len(t, rep.Stages, 1)
+	assert.Equal(t, int64(1), rep.Stages[0].Ops)
+	html, err := c.ReportHTML(ctx, run.Id)
+	require.NoError(t, err)
+	assert.Contains(t, string(html), "<html")
+
+	run, err = c.StartRun(ctx, "block")
+	require.NoError(t, err)
+	paused, err := c.PauseRun(ctx, run.Id)
+	require.NoError(t, err)
+	assert.Equal(t, dlg.RunPaused, paused.State)
+	_, err = c.ResumeRun(ctx, run.Id)
+	require.NoError(t, err)
+
+	var types []string
+	watched := make(chan error, 1)
+	go func() {
+		watched <- c.Watch(ctx, run.Id, func(e *controlpb.RunEvent) error {
+			types = append(types, e.Type)
+			return nil
+		})
+	}()
+	require.Eventually(t, func() bool {
+		runs, err := c.Runs(ctx)
+		return err == nil && len(runs) == 2
+	}, time.Second, time.Millisecond)
+	_, err = c.CancelRun(ctx, run.Id)
+	require.NoError(t, err)
+	done, err = c.Wait(ctx, run.Id)
+	require.NoError(t, err)
+	assert.Equal(t, dlg.RunCanceled, done.State)
+	require.NoError(t, <-watched)
+	require.NotEmpty(t, types)
+	assert.Equal(t, dlg.EventSnapshot, types[0])
+	assert.Equal(t, dlg.EventDone, types[len(types)-1])
+}
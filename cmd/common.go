@@ -17,26 +17,44 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"strings"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/hodgesds/dlg/config"
 	"github.com/hodgesds/dlg/executor"
+	"github.com/hodgesds/dlg/metrics"
+	"github.com/hodgesds/dlg/monitor"
+	"github.com/hodgesds/dlg/progress"
+	"github.com/hodgesds/dlg/report"
+	"github.com/hodgesds/dlg/stats"
+	"github.com/hodgesds/dlg/tracing"
 	"github.com/hodgesds/dlg/util"
 	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/multierr"
+	"gopkg.in/yaml.v2"
 )
 
 func defaultPlan(planType string) *config.Plan {
 	stage := &config.Stage{
-		Name:       fmt.Sprintf("%s-%s", name, planType),
-		Tags:       tags,
-		Repeat:     repeat,
-		Concurrent: concurrent,
-		Children:   []*config.Stage{},
+		Name:        fmt.Sprintf("%s-%s", name, planType),
+		Tags:        tags,
+		Repeat:      repeat,
+		Concurrent:  concurrent,
+		Concurrency: concurrency,
+		WarmupOps:   warmupOps,
+		Churn:       churn,
+		Children:    []*config.Stage{},
 	}
 	if dur > 0 {
 		stage.Duration = &dur
 	}
+	if warmup > 0 {
+		stage.Warmup = &warmup
+	}
 
 	return &config.Plan{
 		Name:   name,
@@ -50,20 +68,272 @@ func execPlan(
 	reg *prometheus.Registry,
 	stageExec executor.Stage,
 ) {
+	if output != "summary" && output != "prom" {
+		log.Fatalf("unknown output %q", output)
+	}
+	switch progressMode {
+	case "auto", "tui", "lines", "off":
+	default:
+		log.Fatalf("unknown progress %q", progressMode)
+	}
+	if progressInterval <= 0 {
+		log.Fatalf("invalid progress interval %v", progressInterval)
+	}
+	collector := stats.NewCollector()
+	var recorder stats.Recorder = collector
+	var closeLog func() error
+	if results != "" {
+		resultLog, closeFn, err := openResultLog(results, resultsSample)
+		if err != nil {
+			log.Fatal(err)
+		}
+		recorder = stats.MultiRecorder(collector, resultLog)
+		closeLog = closeFn
+	}
+	runID := uuid.New().String()
+	labels := metrics.RunLabels(plan, runID)
+	tracer, err := newTracer(plan, runID)
+	if err != nil {
+		log.Fatal(err)
+	}
+	sinks, err := planSinks(plan)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var stopSinks func() error
+	if len(sinks) > 0 {
+		sinkRecorder, stop, err := metrics.StartSinks(context.Background(), sinks, labels)
+		if err != nil {
+			log.Fatal(err)
+		}
+		recorder = stats.MultiRecorder(recorder, sinkRecorder)
+		stopSinks = stop
+	}
+	control := executor.NewControl()
+	prog := progress.New(plan, control)
+	recorder = stats.MultiRecorder(recorder, prog)
 	planExec, err := executor.NewPlan(
-		executor.Params{Registry: reg},
+		executor.Params{
+			Registry: reg,
+			Recorder: recorder,
+			Tracer:   tracer,
+		},
 		stageExec,
 	)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	err = planExec.Execute(context.Background(), plan)
+	scope, err := metrics.NewRunScope(plan, runID)
+	if err != nil {
+		log.Fatal(err)
+	}
+	mon := monitor.New(plan, control)
+	if err := scope.Register(mon); err != nil {
+		log.Fatal(err)
+	}
+	// The progress of the run is only sent with remote write, Pushgateway
+	// rejects metrics that have the plan and run_id grouping labels.
+	gatherer := prometheus.Gatherers{reg, scope}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctx = executor.WithControl(ctx, control)
+	go mon.Run(ctx)
+	stopProgress := showProgress(prog, cancel)
+	var remoteDone chan struct{}
+	if remoteWrite != "" {
+		if remoteWriteEvery <= 0 {
+			log.Fatalf("invalid remote write interval %v", remoteWriteEvery)
+		}
+		w := metrics.NewRemoteWriter(remoteWrite, gatherer, labels)
+		remoteDone = make(chan struct{})
+		go func() {
+			defer close(remoteDone)
+			w.Run(ctx, remoteWriteEvery)
+		}()
+	}
+
+	// The plan is marshaled for the HTML report before it is executed,
+	// which updates its stages. Results are written even if the plan fails
+	// so that partial runs can be inspected.
+	planYAML, err := yaml.Marshal(plan)
 	if err != nil {
 		log.Fatal(err)
 	}
+	execErr := planExec.Execute(ctx, plan)
+	cancel()
+	stopProgress()
+	if remoteDone != nil {
+		<-remoteDone
+	}
+	if stopSinks != nil {
+		if err := stopSinks(); err != nil {
+			log.Printf("metrics sinks: %v", err)
+		}
+	}
+	if tracer != nil {
+		closeCtx, closeCancel := context.WithTimeout(context.Background(), 30*time.Second)
+		if err := tracer.Close(closeCtx); err != nil {
+			log.Printf("tracing: %v", err)
+		}
+		closeCancel()
+	}
+	if pushgateway != "" {
+		if err := metrics.Push(context.Background(), pushgateway, reg, labels); err != nil {
+			log.Printf("pushgateway: %v", err)
+		}
+	}
+	if closeLog != nil {
+		if err := closeLog(); err != nil {
+			log.Fatal(err)
+		}
+	}
 
-	if err := util.RegistryGather(reg, os.Stdout); err != nil {
+	rep := report.New(plan, collector.Stages(), execErr)
+	rep.TraceURL = traceURL
+	rep.Warnings = mon.Warnings()
+	switch output {
+	case "prom":
+		err = util.RegistryGather(reg, os.Stdout)
+	default:
+		err = rep.WriteSummary(os.Stdout)
+	}
+	if err != nil {
 		log.Fatal(err)
 	}
+	if err := writeReports(rep, planYAML); err != nil {
+		log.Fatal(err)
+	}
+	if execErr != nil {
+		log.Fatal(execErr)
+	}
+	if !rep.Passed {
+		log.Fatal("thresholds failed")
+	}
+}
+
+// showProgress shows the live progress of a run according to --progress
+// until the returned function is called, cancel is called when the run is
+// quit from the dashboard. Progress lines are written to stderr so that the
+// output stays parseable.
+func showProgress(p *progress.Progress, cancel func()) func() {
+	mode := progressMode
+	if mode == "auto" {
+		mode = "lines"
+		if progress.IsTerminal(os.Stdout) {
+			mode = "tui"
+		}
+	}
+	ctx, stop := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		switch mode {
+		case "tui":
+			progress.RunDashboard(ctx, p, os.Stdout, os.Stdin, cancel)
+		case "lines":
+			progress.RunLines(ctx, p, os.Stderr, progressInterval)
+		}
+	}()
+	return func() {
+		stop()
+		<-done
+	}
+}
+
+// newTracer returns a Tracer exporting to the OTLP endpoint, or nil if no
+// endpoint is set.
+func newTracer(plan *config.Plan, runID string) (*tracing.Tracer, error) {
+	if otlpEndpoint == "" {
+		return nil, nil
+	}
+	if traceSample <= 0 || traceSample > 1 {
+		return nil, fmt.Errorf("invalid trace sample %v", traceSample)
+	}
+	exporter, err := tracing.NewOTLPExporter(
+		otlpEndpoint,
+		tracing.String("dlg.plan", plan.Name),
+		tracing.String("dlg.run_id", runID),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return tracing.NewTracer(exporter, traceSample), nil
+}
+
+// planSinks returns the metrics sinks of the plan and those set by flags as
+// type=address.
+func planSinks(plan *config.Plan) ([]*config.Sink, error) {
+	sinks := []*config.Sink{}
+	if plan.Metrics != nil {
+		sinks = append(sinks, plan.Metrics.Sinks...)
+	}
+	for _, flag := range metricsSinks {
+		typ, addr, ok := strings.Cut(flag, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid metrics sink %q, expected type=address", flag)
+		}
+		sink := &config.Sink{Type: typ, Address: addr}
+		if metricsInterval > 0 {
+			interval := metricsInterval
+			sink.Interval = &interval
+		}
+		if err := sink.Validate(); err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, sink)
+	}
+	return sinks, nil
+}
+
+// openResultLog creates a log of operation results, logs with a .gz
+// extension are compressed.
+func openResultLog(path string, sample float64) (*stats.LogWriter, func() error, error) {
+	if sample <= 0 || sample > 1 {
+		return nil, nil, fmt.Errorf("invalid results sample %v", sample)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	l := stats.NewLogWriter(f, sample, strings.HasSuffix(path, ".gz"))
+	return l, func() error {
+		return multierr.Append(l.Close(), f.Close())
+	}, nil
+}
+
+// writeReports writes the report files requested by flags, planYAML is the
+// plan that was run.
+func writeReports(rep *report.Report, planYAML []byte) error {
+	files := []struct {
+		path  string
+		write func(io.Writer) error
+	}{
+		{reportJSON, rep.WriteJSON},
+		{reportCSV, rep.WriteCSV},
+		{reportJUnit, rep.WriteJUnit},
+		{reportHTML, func(w io.Writer) error { return rep.WriteHTML(w, planYAML) }},
+	}
+	for _, f := range files {
+		if f.path == "" {
+			continue
+		}
+		if err := writeFile(f.path, f.write); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeFile(path string, write func(io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
 }
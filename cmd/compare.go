@@ -0,0 +1,135 @@
+// Copyright © 2020 Daniel Hodges <hodges.daniel.scott@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/hodgesds/dlg/config"
+	"github.com/hodgesds/dlg/report"
+	"github.com/spf13/cobra"
+)
+
+var (
+	compareFormat     string
+	compareTolerances = report.DefaultTolerances
+)
+
+// compareCmd represents the compare command
+var compareCmd = &cobra.Command{
+	Use:   "compare <baseline> <candidate>",
+	Short: "Compare the results of two runs",
+	Long: `Compare a candidate run to a baseline run by stage. Runs are JSON reports
+written with --report-json or result logs written with --results. The command
+exits with a non-zero status when a metric regressed by more than its
+tolerance.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if compareFormat != "text" && compareFormat != "markdown" {
+			log.Fatalf("unknown format %q", compareFormat)
+		}
+		baseline, err := loadReport(args[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+		candidate, err := loadReport(args[1])
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		c := report.Compare(baseline, candidate, compareTolerances)
+		switch compareFormat {
+		case "markdown":
+			err = c.WriteMarkdown(os.Stdout)
+		default:
+			err = c.WriteText(os.Stdout)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		if c.Regressed() {
+			os.Exit(1)
+		}
+	},
+}
+
+// loadReport loads a JSON report or creates one from a result log.
+func loadReport(path string) (*report.Report, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	isReport, err := isJSONReport(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if isReport {
+		rep, err := report.ReadJSON(f)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return rep, nil
+	}
+	stages, err := readLog(f, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return report.New(&config.Plan{Name: path}, stages, nil), nil
+}
+
+// isJSONReport returns if r contains a JSON report rather than a result log.
+func isJSONReport(r io.Reader) (bool, error) {
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		return false, nil
+	}
+	var fields map[string]json.RawMessage
+	if err := json.NewDecoder(br).Decode(&fields); err != nil {
+		return false, err
+	}
+	_, ok := fields["stages"]
+	return ok, nil
+}
+
+func init() {
+	RootCmd.AddCommand(compareCmd)
+
+	compareCmd.Flags().StringVarP(&compareFormat, "format", "f", "text", "output format (text, markdown)")
+	compareCmd.Flags().Float64Var(
+		&compareTolerances.Throughput,
+		"throughput-tolerance", report.DefaultTolerances.Throughput,
+		"allowed relative decrease of throughput",
+	)
+	compareCmd.Flags().Float64Var(
+		&compareTolerances.Latency,
+		"latency-tolerance", report.DefaultTolerances.Latency,
+		"allowed relative increase of latency percentiles",
+	)
+	compareCmd.Flags().Float64Var(
+		&compareTolerances.ErrorRate,
+		"error-rate-tolerance", report.DefaultTolerances.ErrorRate,
+		"allowed absolute increase of the error rate",
+	)
+}
@@ -20,18 +20,40 @@ import (
 	"strings"
 	"time"
 
+	"github.com/hodgesds/dlg/metrics"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 )
 
 var (
-	count      int
-	concurrent int
-	debug      bool
-	dur        time.Duration
-	name       string
-	repeat     int
-	tags       = []string{}
+	churn            bool
+	count            int
+	concurrent       int
+	concurrency      int
+	debug            bool
+	dur              time.Duration
+	metricsInterval  time.Duration
+	metricsSinks     []string
+	name             string
+	otlpEndpoint     string
+	output           string
+	progressMode     string
+	progressInterval time.Duration
+	pushgateway      string
+	remoteWrite      string
+	remoteWriteEvery time.Duration
+	repeat           int
+	reportCSV        string
+	reportHTML       string
+	reportJSON       string
+	reportJUnit      string
+	results          string
+	resultsSample    float64
+	tags             = []string{}
+	traceSample      float64
+	traceURL         string
+	warmup           time.Duration
+	warmupOps        int
 )
 
 func planFlags() *pflag.FlagSet {
@@ -41,8 +63,29 @@ func planFlags() *pflag.FlagSet {
 	planFlags.IntVarP(&repeat, "repeat", "r", 0, "number of times to repeat")
 	planFlags.IntVarP(&count, "num", "n", 100, "number of times to execute")
 	planFlags.IntVarP(&concurrent, "con", "c", 1, "concurrent executions")
+	planFlags.IntVar(&concurrency, "concurrency", 0, "workers running the operations of executors with a count, such as HTTP requests, 0 for the default")
 	planFlags.DurationVarP(&dur, "duration", "d", 0, "execution duration")
 	planFlags.StringSliceVar(&tags, "tags", nil, "metrics tags")
+	planFlags.DurationVar(&warmup, "warmup", 0, "warm-up duration excluded from results")
+	planFlags.IntVar(&warmupOps, "warmup-ops", 0, "warm-up operations excluded from results")
+	planFlags.BoolVar(&churn, "churn", false, "dial a new connection on every execution")
+	planFlags.StringVar(&output, "output", "summary", "output format (summary, prom)")
+	planFlags.StringVar(&reportJSON, "report-json", "", "write a JSON report to the file")
+	planFlags.StringVar(&reportCSV, "report-csv", "", "write a CSV report to the file")
+	planFlags.StringVar(&reportJUnit, "report-junit", "", "write a JUnit XML report to the file")
+	planFlags.StringVar(&reportHTML, "report-html", "", "write an HTML report with charts to the file")
+	planFlags.StringVar(&results, "results", "", "write every operation result as JSON lines to the file, gzip compressed with a .gz extension")
+	planFlags.Float64Var(&resultsSample, "results-sample", 1, "fraction of operation results written to --results")
+	planFlags.StringVar(&remoteWrite, "remote-write", "", "Prometheus remote write URL metrics are sent to during the run")
+	planFlags.DurationVar(&remoteWriteEvery, "remote-write-interval", 15*time.Second, "interval metrics are sent to --remote-write")
+	planFlags.StringVar(&otlpEndpoint, "otlp-endpoint", "", "OpenTelemetry collector OTLP/HTTP endpoint a span of every operation is exported to, such as http://localhost:4318")
+	planFlags.Float64Var(&traceSample, "trace-sample", 1, "fraction of operation spans exported to --otlp-endpoint")
+	planFlags.StringVar(&traceURL, "trace-url", "", "trace link of the HTML report, {traceId} is replaced with the trace ID")
+	planFlags.StringSliceVar(&metricsSinks, "metrics-sink", nil, "metrics sink as type=address, such as statsd=udp://localhost:8125, dogstatsd=udp://localhost:8125 or influx=http://localhost:8086/write?db=dlg")
+	planFlags.DurationVar(&metricsInterval, "metrics-interval", metrics.DefaultSinkInterval, "interval metrics are written to --metrics-sink sinks")
+	planFlags.StringVar(&pushgateway, "pushgateway", "", "Prometheus Pushgateway URL metrics are pushed to after the run")
+	planFlags.StringVar(&progressMode, "progress", "auto", "live progress (auto, tui, lines, off), auto shows a dashboard on a terminal and progress lines otherwise")
+	planFlags.DurationVar(&progressInterval, "progress-interval", 10*time.Second, "interval progress lines are written to stderr at")
 	return planFlags
 }
 
@@ -0,0 +1,181 @@
+// Copyright © 2020 Daniel Hodges <hodges.daniel.scott@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/hodgesds/dlg/config"
+	"github.com/hodgesds/dlg/report"
+	"github.com/hodgesds/dlg/stats"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	reportName        string
+	reportPlan        string
+	reportFrom        time.Duration
+	reportTo          time.Duration
+	reportPercentiles []float64
+)
+
+// reportCmd represents the report command
+var reportCmd = &cobra.Command{
+	Use:   "report <results.jsonl>...",
+	Short: "Generate reports from operation result logs",
+	Long: `Generate reports from logs written with --results. Results can be
+limited to a time window relative to the earliest result with --from and
+--to. The counts of sampled logs are scaled by their sample rate.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		plan := &config.Plan{Name: reportName}
+		var planYAML []byte
+		if reportPlan != "" {
+			b, err := ioutil.ReadFile(reportPlan)
+			if err != nil {
+				log.Fatal(err)
+			}
+			if err := yaml.Unmarshal(b, plan); err != nil {
+				log.Fatal(err)
+			}
+			planYAML = b
+		} else {
+			b, err := yaml.Marshal(plan)
+			if err != nil {
+				log.Fatal(err)
+			}
+			planYAML = b
+		}
+
+		// Results of parallel stages are not logged in order, so a window
+		// is relative to the earliest result of all logs.
+		var keep func(stats.Result) bool
+		if reportFrom != 0 || reportTo != 0 {
+			var first time.Time
+			for _, path := range args {
+				err := readLogFile(path, func(r io.Reader) error {
+					_, err := stats.ReadLog(r, stats.RecorderFunc(func(r stats.Result) {
+						if first.IsZero() || r.Time.Before(first) {
+							first = r.Time
+						}
+					}))
+					return err
+				})
+				if err != nil {
+					log.Fatal(err)
+				}
+			}
+			keep = func(r stats.Result) bool {
+				offset := r.Time.Sub(first)
+				return offset >= reportFrom && (reportTo == 0 || offset < reportTo)
+			}
+		}
+
+		// The stages of each log are merged as a source, so that the
+		// counts of every log are scaled by its own sample rate.
+		collector := stats.NewCollector()
+		for i, path := range args {
+			err := readLogFile(path, func(r io.Reader) error {
+				stages, err := readLog(r, keep)
+				collector.MergeSource(strconv.Itoa(i), stages)
+				return err
+			})
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
+
+		rep := report.New(plan, collector.Stages(), nil)
+		rep.TraceURL = traceURL
+		if err := rep.WriteSummary(os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		if len(reportPercentiles) > 0 {
+			quantiles := make([]float64, len(reportPercentiles))
+			for i, p := range reportPercentiles {
+				quantiles[i] = p / 100
+			}
+			os.Stdout.WriteString("\n")
+			if err := report.WritePercentiles(
+				os.Stdout, collector.Stages(), quantiles,
+			); err != nil {
+				log.Fatal(err)
+			}
+		}
+		if err := writeReports(rep, planYAML); err != nil {
+			log.Fatal(err)
+		}
+		if !rep.Passed {
+			log.Fatal("thresholds failed")
+		}
+	},
+}
+
+// readLogFile calls read with an opened log file.
+func readLogFile(path string, read func(io.Reader) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := read(f); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// readLog returns the stages of the results in a log for which keep, if it
+// is not nil, returns true. The counts of a sampled log are scaled to
+// estimate the results of the run.
+func readLog(r io.Reader, keep func(stats.Result) bool) ([]*stats.Stage, error) {
+	collector := stats.NewCollector()
+	sample, err := stats.ReadLog(r, stats.RecorderFunc(func(r stats.Result) {
+		if keep == nil || keep(r) {
+			collector.Record(r)
+		}
+	}))
+	if err != nil {
+		return nil, err
+	}
+	stages := collector.Stages()
+	if sample < 1 {
+		for _, s := range stages {
+			s.Scale(1 / sample)
+		}
+	}
+	return stages, nil
+}
+
+func init() {
+	RootCmd.AddCommand(reportCmd)
+
+	reportCmd.Flags().StringVar(&reportName, "name", "report", "report name, used without --plan")
+	reportCmd.Flags().StringVar(&reportPlan, "plan", "", "YAML plan that was run, used for thresholds and the HTML report")
+	reportCmd.Flags().DurationVar(&reportFrom, "from", 0, "exclude results before this offset from the earliest result")
+	reportCmd.Flags().DurationVar(&reportTo, "to", 0, "exclude results from this offset from the earliest result")
+	reportCmd.Flags().Float64SliceVar(&reportPercentiles, "percentiles", nil, "additional latency percentiles to print, such as 75,99.9")
+	reportCmd.Flags().StringVar(&reportJSON, "report-json", "", "write a JSON report to the file")
+	reportCmd.Flags().StringVar(&reportCSV, "report-csv", "", "write a CSV report to the file")
+	reportCmd.Flags().StringVar(&reportJUnit, "report-junit", "", "write a JUnit XML report to the file")
+	reportCmd.Flags().StringVar(&reportHTML, "report-html", "", "write an HTML report with charts to the file")
+	reportCmd.Flags().StringVar(&traceURL, "trace-url", "", "trace link of the HTML report, {traceId} is replaced with the trace ID")
+}
@@ -16,21 +16,56 @@ package cmd
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/hodgesds/dlg"
+	"github.com/hodgesds/dlg/auth"
 	etcdconf "github.com/hodgesds/dlg/config/etcd"
+	"github.com/hodgesds/dlg/control"
+	"github.com/hodgesds/dlg/distributed"
 	"github.com/hodgesds/dlg/executor"
 	"github.com/hodgesds/dlg/executor/stage"
+	"github.com/hodgesds/dlg/history"
+	"github.com/hodgesds/dlg/manager/dir"
 	"github.com/hodgesds/dlg/manager/etcd"
+	"github.com/hodgesds/dlg/metrics"
 	xhttp "github.com/hodgesds/dlg/util/http"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 var (
-	serverHTTPBind string
+	serverHTTPBind   string
+	serverAgent      bool
+	serverController string
+	serverAdvertise  string
+	serverMaxRuns    int
+	serverPlansDir   string
+	serverGRPCBind   string
+	serverScheduler  bool
+	serverLabels     map[string]string
+
+	serverAuthFile    string
+	serverInsecure    bool
+	serverAuthToken   string
+	serverTLSCert     string
+	serverTLSKey      string
+	serverTLSClientCA string
+
+	serverHistory        string
+	serverHistoryMaxAge  time.Duration
+	serverHistoryMaxRuns int
+	serverHistoryResults float64
 )
 
 // serverCmd represents the server command
@@ -52,19 +87,163 @@ var serverCmd = &cobra.Command{
 			log.Fatal(err)
 		}
 
-		managerConfig := &etcdconf.Config{
-			Endpoints: etcdEndpoints,
+		scopes := metrics.NewScopes(reg, 10)
+		r := gin.Default()
+		srv := &http.Server{Addr: serverHTTPBind, Handler: r}
+		if serverTLSCert != "" || serverTLSKey != "" {
+			srv.TLSConfig, err = auth.TLSConfig(serverTLSCert, serverTLSKey, serverTLSClientCA)
+			if err != nil {
+				log.Fatal(err)
+			}
+		} else if serverTLSClientCA != "" {
+			log.Fatal("--tls-client-ca requires --tls-cert and --tls-key")
 		}
-		m, err := etcd.NewManager(managerConfig, planExec)
-		if err != nil {
-			log.Fatal(err)
+		var grpcOpts []grpc.ServerOption
+		if srv.TLSConfig != nil {
+			grpcOpts = append(grpcOpts, grpc.Creds(credentials.NewTLS(srv.TLSConfig)))
 		}
+		// An agent registers a token of its own with the controller, which
+		// runs plans on the agent with it.
+		var agentToken string
+		if serverAgent {
+			if agentToken, err = distributed.NewAgentToken(); err != nil {
+				log.Fatal(err)
+			}
+		}
+		if serverAuthFile != "" {
+			conf, err := auth.Load(serverAuthFile)
+			if err != nil {
+				log.Fatal(err)
+			}
+			if len(conf.Certs) > 0 && serverTLSClientCA == "" {
+				log.Fatal("client certificates in the auth file require --tls-client-ca")
+			}
+			if serverAgent {
+				conf.Tokens = append(conf.Tokens, auth.TokenConfig{
+					Name:  "controller",
+					Token: agentToken,
+					Role:  auth.Admin,
+				})
+			}
+			authenticator, err := conf.Authenticator()
+			if err != nil {
+				log.Fatal(err)
+			}
+			// The middleware must be added before the routes.
+			r.Use(auth.Middleware(authenticator, auth.DefaultRules))
+			grpcOpts = append(grpcOpts,
+				grpc.UnaryInterceptor(auth.UnaryInterceptor(authenticator, control.Roles)),
+				grpc.StreamInterceptor(auth.StreamInterceptor(authenticator, control.Roles)),
+			)
+		}
+		// Requests of agents to the controller carry the token.
+		var transport http.RoundTripper
+		if serverAuthToken != "" {
+			transport = &auth.Transport{Token: serverAuthToken}
+		}
+
+		r.GET("/metrics", gin.WrapH(scopes.Handler()))
+		if serverAgent {
+			if serverController == "" {
+				log.Fatal("--agent requires a --controller")
+			}
+			advertise := serverAdvertise
+			if advertise == "" {
+				advertise, err = advertiseURL(serverHTTPBind, srv.TLSConfig != nil)
+				if err != nil {
+					log.Fatal(err)
+				}
+			}
+			// The controller sends plans and the agent token to the
+			// agent, which sends its token to the controller.
+			for _, u := range []string{advertise, serverController} {
+				if !strings.HasPrefix(u, "https://") && !serverInsecure {
+					log.Fatalf("%s must use https, or set --insecure", u)
+				}
+			}
+			distributed.NewAgentRouter(r, planExec, agentToken)
+			go distributed.Register(context.Background(), transport, serverController, distributed.Agent{
+				ID:    agentID(),
+				URL:   advertise,
+				Token: agentToken,
+			})
+		} else {
+			// Agents can only register with an authenticated
+			// controller, which sends them plans and their tokens.
+			controller := distributed.NewController(planExec, nil)
+			controller.Insecure = serverInsecure
+			if serverAuthFile != "" {
+				distributed.NewControllerRouter(r, controller)
+			} else {
+				log.Println("agent registration is disabled without --auth-file")
+			}
 
-		p := defaultPlan("server")
-		m.Add(context.Background(), p)
+			// With etcd endpoints plans are kept in etcd and the server
+			// is a worker that runs the plans queued in etcd, with a plans
+			// directory plans are kept in its files, otherwise plans are
+			// kept in memory.
+			etcdConf := &etcdconf.Config{
+				Endpoints:   etcdEndpoints,
+				DialTimeout: 5 * time.Second,
+			}
+			var etcdManager etcd.Manager
+			m := dlg.NewManager(controller)
+			switch {
+			case len(etcdEndpoints) > 0 && serverPlansDir != "":
+				log.Fatal("--plans-dir cannot be used with --endpoints")
+			case len(etcdEndpoints) > 0:
+				etcdManager, err = etcd.NewManager(etcdConf, controller)
+				m = etcdManager
+			case serverPlansDir != "":
+				m, err = dir.NewManager(serverPlansDir, controller)
+			}
+			if err != nil {
+				log.Fatal(err)
+			}
+			dlg.NewManagerRouter(r, m)
 
-		r := gin.Default()
-		dlg.NewManagerRouter(r, m)
+			runsConf := dlg.RunsConfig{
+				MaxRuns:      serverMaxRuns,
+				Scopes:       scopes,
+				ResultSample: serverHistoryResults,
+			}
+			if serverHistoryResults < 0 || serverHistoryResults > 1 {
+				log.Fatalf("invalid history results fraction %v", serverHistoryResults)
+			}
+			if serverHistory != "" {
+				store, err := history.Open(serverHistory, history.Retention{
+					MaxAge:  serverHistoryMaxAge,
+					MaxRuns: serverHistoryMaxRuns,
+				})
+				if err != nil {
+					log.Fatal(err)
+				}
+				history.NewHistoryRouter(r, store)
+				runsConf.History = store
+			}
+			runs := dlg.NewRuns(m, runsConf)
+			dlg.NewRunsRouter(r, runs)
+			if etcdManager != nil {
+				etcd.NewQueueRouter(r, etcdManager)
+				if _, err := etcd.NewWorker(etcdConf, runs, serverLabels); err != nil {
+					log.Fatal(err)
+				}
+			}
+			if serverScheduler {
+				dlg.NewSchedulerRouter(r, dlg.NewScheduler(runs))
+			}
+			if serverGRPCBind != "" {
+				lis, err := net.Listen("tcp", serverGRPCBind)
+				if err != nil {
+					log.Fatal(err)
+				}
+				grpcSrv := grpc.NewServer(grpcOpts...)
+				control.Register(grpcSrv, m, runs)
+				go func() {
+					log.Fatal(grpcSrv.Serve(lis))
+				}()
+			}
+		}
 
 		r.Use(gin.WrapH(xhttp.StageMiddleware(nil)))
 		r.GET("/ping", func(c *gin.Context) {
@@ -72,10 +251,41 @@ var serverCmd = &cobra.Command{
 				"message": "pong",
 			})
 		})
-		r.Run(serverHTTPBind)
+		if srv.TLSConfig != nil {
+			err = srv.ListenAndServeTLS("", "")
+		} else {
+			err = srv.ListenAndServe()
+		}
+		log.Fatal(err)
 	},
 }
 
+// advertiseURL returns the URL of the HTTP address on this host.
+func advertiseURL(bind string, tls bool) (string, error) {
+	host, port, err := net.SplitHostPort(bind)
+	if err != nil {
+		return "", err
+	}
+	if host == "" {
+		if host, err = os.Hostname(); err != nil {
+			return "", err
+		}
+	}
+	scheme := "http://"
+	if tls {
+		scheme = "https://"
+	}
+	return scheme + net.JoinHostPort(host, port), nil
+}
+
+func agentID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "agent"
+	}
+	return fmt.Sprintf("%s-%s", host, uuid.New().String()[:8])
+}
+
 func init() {
 	RootCmd.AddCommand(serverCmd)
 	serverCmd.PersistentFlags().
@@ -86,4 +296,94 @@ func init() {
 		[]string{},
 		"ETCD endpoints",
 	)
+	serverCmd.PersistentFlags().StringToStringVar(
+		&serverLabels,
+		"labels", map[string]string{},
+		"Labels of the server matched against the workers of etcd plans",
+	)
+	serverCmd.PersistentFlags().BoolVar(
+		&serverAgent,
+		"agent", false,
+		"Run plans of a controller",
+	)
+	serverCmd.PersistentFlags().StringVar(
+		&serverController,
+		"controller", "",
+		"Controller URL agents register with",
+	)
+	serverCmd.PersistentFlags().BoolVar(
+		&serverScheduler,
+		"scheduler", false,
+		"Start runs of plans with a schedule, only enable on one server sharing etcd plans",
+	)
+	serverCmd.PersistentFlags().StringVar(
+		&serverAdvertise,
+		"advertise", "",
+		"URL the controller reaches the agent at, defaults to the host name and bind port",
+	)
+	serverCmd.PersistentFlags().IntVar(
+		&serverMaxRuns,
+		"max-runs", 10,
+		"Maximum number of concurrent runs, 0 for no limit",
+	)
+	serverCmd.PersistentFlags().StringVar(
+		&serverGRPCBind,
+		"grpc-bind", "",
+		"gRPC control API address, the API is disabled if empty",
+	)
+	serverCmd.PersistentFlags().StringVar(
+		&serverPlansDir,
+		"plans-dir", "",
+		"Directory of YAML plan files, plans are kept in memory if empty",
+	)
+	serverCmd.PersistentFlags().StringVar(
+		&serverAuthFile,
+		"auth-file", "",
+		"Path of the file with the tokens, users and client certificates allowed to use the API, authentication is disabled if empty",
+	)
+	serverCmd.PersistentFlags().BoolVar(
+		&serverInsecure,
+		"insecure", false,
+		"Allow agents and controllers to use http URLs, plans and tokens are sent unencrypted",
+	)
+	serverCmd.PersistentFlags().StringVar(
+		&serverAuthToken,
+		"auth-token", "",
+		"Bearer token agents register with the controller with",
+	)
+	serverCmd.PersistentFlags().StringVar(
+		&serverTLSCert,
+		"tls-cert", "",
+		"Path of the TLS certificate of the server",
+	)
+	serverCmd.PersistentFlags().StringVar(
+		&serverTLSKey,
+		"tls-key", "",
+		"Path of the TLS key of the server",
+	)
+	serverCmd.PersistentFlags().StringVar(
+		&serverTLSClientCA,
+		"tls-client-ca", "",
+		"Path of the CA certificates client certificates are verified with",
+	)
+	serverCmd.PersistentFlags().StringVar(
+		&serverHistory,
+		"history", "",
+		"Path of the run history database, the history is disabled if empty",
+	)
+	serverCmd.PersistentFlags().DurationVar(
+		&serverHistoryMaxAge,
+		"history-max-age", 0,
+		"Age after which runs are removed from the history, 0 keeps all runs",
+	)
+	serverCmd.PersistentFlags().IntVar(
+		&serverHistoryMaxRuns,
+		"history-max-runs", 1000,
+		"Maximum number of runs kept in the history, 0 for no limit",
+	)
+	serverCmd.PersistentFlags().Float64Var(
+		&serverHistoryResults,
+		"history-results", 0,
+		"Fraction of the results of runs stored in the history, 0 disables result logs",
+	)
 }
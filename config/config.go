@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	ghttp "net/http"
+	"net/url"
 	"sync"
 	"time"
 
@@ -45,6 +46,7 @@ import (
 	"github.com/hodgesds/dlg/config/tftp"
 	"github.com/hodgesds/dlg/config/udp"
 	"github.com/hodgesds/dlg/config/websocket"
+	"gopkg.in/yaml.v2"
 )
 
 // ExecutionState is a execution state
@@ -74,24 +76,143 @@ type Limiter struct {
 	SlowStart  *time.Duration `yaml:"slowStart,omitempty"`
 }
 
+// Thresholds are pass or fail criteria for the measured results of a stage,
+// latencies are upper bounds.
+type Thresholds struct {
+	MaxErrorRate  *float64       `yaml:"maxErrorRate,omitempty"`  // ratio of failed operations
+	MinThroughput *float64       `yaml:"minThroughput,omitempty"` // operations per second
+	P50           *time.Duration `yaml:"p50,omitempty"`
+	P90           *time.Duration `yaml:"p90,omitempty"`
+	P99           *time.Duration `yaml:"p99,omitempty"`
+	Max           *time.Duration `yaml:"max,omitempty"`
+}
+
+// Validate is used to validate Thresholds.
+func (t *Thresholds) Validate() error {
+	if t.MaxErrorRate != nil && (*t.MaxErrorRate < 0 || *t.MaxErrorRate > 1) {
+		return errors.New("invalid max error rate threshold")
+	}
+	if t.MinThroughput != nil && *t.MinThroughput < 0 {
+		return errors.New("invalid min throughput threshold")
+	}
+	for _, d := range []*time.Duration{t.P50, t.P90, t.P99, t.Max} {
+		if d != nil && *d < 0 {
+			return errors.New("invalid latency threshold")
+		}
+	}
+	return nil
+}
+
+// Metrics configures where the metrics of a plan are sent.
+type Metrics struct {
+	Sinks []*Sink `yaml:"sinks,omitempty"`
+}
+
+// Validate is used to validate Metrics.
+func (m *Metrics) Validate() error {
+	for _, s := range m.Sinks {
+		if err := s.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Sink is a metrics sink the aggregated results of every interval are
+// written to. StatsD and DogStatsD sinks use UDP addresses such as
+// udp://localhost:8125, InfluxDB sinks also accept HTTP write URLs.
+type Sink struct {
+	Type     string         `yaml:"type"` // statsd, dogstatsd or influx
+	Address  string         `yaml:"address"`
+	Interval *time.Duration `yaml:"interval,omitempty"`
+	Prefix   string         `yaml:"prefix,omitempty"` // metric prefix, dlg by default
+}
+
+// Validate is used to validate a Sink.
+func (s *Sink) Validate() error {
+	u, err := url.Parse(s.Address)
+	if err != nil {
+		return fmt.Errorf("invalid %s sink address: %w", s.Type, err)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("invalid %s sink address %q", s.Type, s.Address)
+	}
+	switch s.Type {
+	case "statsd", "dogstatsd":
+		if u.Scheme != "udp" {
+			return fmt.Errorf("%s sink requires a udp address", s.Type)
+		}
+	case "influx":
+		if u.Scheme != "udp" && u.Scheme != "http" && u.Scheme != "https" {
+			return errors.New("influx sink requires a udp or http address")
+		}
+	default:
+		return fmt.Errorf("unknown sink type %q", s.Type)
+	}
+	if s.Interval != nil && *s.Interval <= 0 {
+		return errors.New("invalid sink interval")
+	}
+	return nil
+}
+
 // Distributed is configuration for distributed generators.
 type Distributed struct {
-	Manager string `yaml:"manager"`
+	Manager string `yaml:"manager,omitempty"`
+	// Agents is the number of agents the plan is split across, the rate
+	// and concurrency of the plan are divided between them.
+	Agents int `yaml:"agents"`
+	// Workers are the labels a server must have to run the plan when it
+	// is kept in etcd, any server runs it if there are none.
+	Workers map[string]string `yaml:"workers,omitempty"`
 }
 
 // Plan is a load testing plan.
 type Plan struct {
-	mu    sync.RWMutex   `yaml:"-"`
-	state ExecutionState `yaml:"-"`
+	mu     sync.RWMutex `yaml:"-"`
+	status *Status      `yaml:"-"`
 
 	Name      string   `yaml:"name"`
-	Executors int      `yaml:"executors"`
+	Executors int      `yaml:"executors"` // default concurrency of stages
 	Stages    []*Stage `yaml:"stages"`
 	Tags      []string `yaml:"tags,omitempty"`
 
 	Repeat   int            `yaml:"repeat,omitempty"`
 	Duration *time.Duration `yaml:"duration,omitempty"`
 	Start    *time.Time     `yaml:"start,omitempty"`
+	// Schedule starts runs of the plan periodically when it is kept by a
+	// server.
+	Schedule *Schedule `yaml:"schedule,omitempty"`
+
+	// Seed identifies the random seed of the plan, it is included in
+	// reports so that runs can be reproduced.
+	Seed int64 `yaml:"seed,omitempty"`
+
+	Metrics *Metrics `yaml:"metrics,omitempty"`
+
+	Distributed *Distributed `yaml:"distributed,omitempty"`
+}
+
+// Status returns the live status of the execution of the plan.
+func (p *Plan) Status() *Status {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.status == nil {
+		p.status = &Status{}
+	}
+	return p.status
+}
+
+// ResetStatus resets the status of the plan and all of its stages.
+func (p *Plan) ResetStatus() {
+	p.Status().Reset()
+	var reset func(stages []*Stage)
+	reset = func(stages []*Stage) {
+		for _, s := range stages {
+			s.Status().Reset()
+			reset(s.Children)
+		}
+	}
+	reset(p.Stages)
 }
 
 // WaitStart is used to wait until the start of the plan if configured.
@@ -108,11 +229,82 @@ func (p *Plan) WaitStart(ctx context.Context) error {
 	}
 }
 
+// Split returns n copies of the plan to be run by different agents. The
+// rate of every stage is divided evenly and the plan executors and stage
+// concurrency are divided with the remainder going to the first copies, every
+// copy keeps at least one worker.
+func (p *Plan) Split(n int) ([]*Plan, error) {
+	if n < 1 {
+		return nil, errors.New("invalid number of agents")
+	}
+	plans := make([]*Plan, n)
+	for i := range plans {
+		plan, err := p.Copy()
+		if err != nil {
+			return nil, err
+		}
+		plan.Distributed = nil
+		plan.Executors = splitWorkers(plan.Executors, n, i)
+		splitStages(plan.Stages, n, i)
+		plans[i] = plan
+	}
+	return plans, nil
+}
+
+// Copy returns a copy of the plan that does not share its status.
+func (p *Plan) Copy() (*Plan, error) {
+	b, err := yaml.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	var plan Plan
+	if err := yaml.Unmarshal(b, &plan); err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+func splitStages(stages []*Stage, n, i int) {
+	for _, s := range stages {
+		s.Concurrency = splitWorkers(s.Concurrency, n, i)
+		s.Rate /= float64(n)
+		splitStages(s.Children, n, i)
+	}
+}
+
+// splitWorkers returns the share of copy i of n of a number of workers.
+func splitWorkers(workers, n, i int) int {
+	if workers == 0 {
+		return 0
+	}
+	share := workers / n
+	if i < workers%n {
+		share++
+	}
+	if share < 1 {
+		share = 1
+	}
+	return share
+}
+
 // Validate is used to validate a Plan.
 func (p *Plan) Validate() error {
 	if len(p.Stages) == 0 {
 		return errors.New("plan has no stages")
 	}
+	if p.Metrics != nil {
+		if err := p.Metrics.Validate(); err != nil {
+			return err
+		}
+	}
+	if p.Schedule != nil {
+		if err := p.Schedule.Validate(); err != nil {
+			return err
+		}
+	}
+	if p.Distributed != nil && p.Distributed.Agents < 0 {
+		return errors.New("invalid number of agents")
+	}
 	names := map[string]struct{}{}
 	for _, stage := range p.Stages {
 		if stage.validateName(names) {
@@ -128,7 +320,8 @@ func (p *Plan) Validate() error {
 // Stage is a part of a plan.
 type Stage struct {
 	// Internal fields for handling state.
-	mu sync.RWMutex
+	mu     sync.RWMutex
+	status *Status
 
 	State ExecutionState `yaml:"state"`
 
@@ -138,9 +331,31 @@ type Stage struct {
 	Concurrent int      `yaml:"concurrent"` // if children should execute concurrent
 	Repeat     int      `yaml:"repeat"`     // number of times to repeat the stage
 
+	// Concurrency is the number of workers running the operations of the
+	// stage and its children, it defaults to the plan executors. It only
+	// applies to executors with a count of operations, such as HTTP.
+	Concurrency int `yaml:"concurrency,omitempty"`
+	// Rate limits the operations started by the stage and its children
+	// per second, it is unlimited by default.
+	Rate float64 `yaml:"rate,omitempty"`
+
 	Duration *time.Duration `yaml:"duration,omitempty"`
 	Timeout  *time.Duration `yaml:"timeout,omitempty"`
 
+	// Warmup is a period at the start of the stage where load is
+	// generated normally but results are kept apart from the measured
+	// results. WarmupOps does the same for a number of operations.
+	Warmup    *time.Duration `yaml:"warmup,omitempty"`
+	WarmupOps int            `yaml:"warmupOps,omitempty"`
+
+	// Churn disables client reuse for the stage and its children, every
+	// execution dials a new connection which is closed afterwards.
+	Churn bool `yaml:"churn,omitempty"`
+
+	// Thresholds are checked against the measured results of the stage
+	// once the plan completes.
+	Thresholds *Thresholds `yaml:"thresholds,omitempty"`
+
 	// Stage types
 	ArangoDB      *arangodb.Config      `yaml:"arangodb,omitempty"`
 	Cassandra     *cassandra.Config     `yaml:"cassandra,omitempty"`
@@ -179,6 +394,16 @@ type Stage struct {
 	Websocket     *websocket.Config     `yaml:"websocket,omitempty"`
 }
 
+// Status returns the live status of the execution of the stage.
+func (s *Stage) Status() *Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.status == nil {
+		s.status = &Status{}
+	}
+	return s.status
+}
+
 func (s *Stage) validateName(names map[string]struct{}) bool {
 	if _, ok := names[s.Name]; ok {
 		return true
@@ -197,6 +422,23 @@ func (s *Stage) Validate() error {
 	if s.Repeat < 0 {
 		return errors.New("invalid number of repeats")
 	}
+	if s.Warmup != nil && *s.Warmup < 0 {
+		return errors.New("invalid warmup duration")
+	}
+	if s.WarmupOps < 0 {
+		return errors.New("invalid number of warmup ops")
+	}
+	if s.Concurrency < 0 {
+		return errors.New("invalid concurrency")
+	}
+	if s.Rate < 0 {
+		return errors.New("invalid rate")
+	}
+	if s.Thresholds != nil {
+		if err := s.Thresholds.Validate(); err != nil {
+			return err
+		}
+	}
 	stageTypes := 0
 	if s.ArangoDB != nil {
 		stageTypes++
@@ -309,6 +551,84 @@ func (s *Stage) Validate() error {
 	return nil
 }
 
+// Protocol returns the name of the protocol configured for the stage, or an
+// empty string if the stage only has children.
+func (s *Stage) Protocol() string {
+	switch {
+	case s.ArangoDB != nil:
+		return "arangodb"
+	case s.Cassandra != nil:
+		return "cassandra"
+	case s.ClickHouse != nil:
+		return "clickhouse"
+	case s.CouchDB != nil:
+		return "couchdb"
+	case s.DHCP4 != nil:
+		return "dhcp4"
+	case s.DNS != nil:
+		return "dns"
+	case s.Elasticsearch != nil:
+		return "elasticsearch"
+	case s.ETCD != nil:
+		return "etcd"
+	case s.FTP != nil:
+		return "ftp"
+	case s.GraphQL != nil:
+		return "graphql"
+	case s.GRPC != nil:
+		return "grpc"
+	case s.HTTP != nil:
+		return "http"
+	case s.ICMP != nil:
+		return "icmp"
+	case s.InfluxDB != nil:
+		return "influxdb"
+	case s.Kafka != nil:
+		return "kafka"
+	case s.LDAP != nil:
+		return "ldap"
+	case s.Memcache != nil:
+		return "memcache"
+	case s.MongoDB != nil:
+		return "mongodb"
+	case s.MQTT != nil:
+		return "mqtt"
+	case s.NATS != nil:
+		return "nats"
+	case s.Neo4j != nil:
+		return "neo4j"
+	case s.NTP != nil:
+		return "ntp"
+	case s.Pulsar != nil:
+		return "pulsar"
+	case s.RabbitMQ != nil:
+		return "rabbitmq"
+	case s.Redis != nil:
+		return "redis"
+	case s.ScyllaDB != nil:
+		return "scylladb"
+	case s.SNMP != nil:
+		return "snmp"
+	case s.SQL != nil:
+		return "sql"
+	case s.SSH != nil:
+		return "ssh"
+	case s.Syslog != nil:
+		return "syslog"
+	case s.TCP != nil:
+		return "tcp"
+	case s.Telnet != nil:
+		return "telnet"
+	case s.TFTP != nil:
+		return "tftp"
+	case s.UDP != nil:
+		return "udp"
+	case s.Websocket != nil:
+		return "websocket"
+	}
+	return ""
+}
+
 // StageFrom is used to convert a HTTP request to a Stage config.
 func StageFrom(r *ghttp.Request) (*Stage, error) {
 	defer r.Body.Close()
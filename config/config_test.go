@@ -211,3 +211,201 @@ func TestDuplicateStageNameError(t *testing.T) {
 	}
 	require.Error(t, p.Validate())
 }
+
+func TestWarmupValidation(t *testing.T) {
+	s := &Stage{
+		Name:      "warmup",
+		Warmup:    util.DurPtr(-time.Second),
+		WarmupOps: 1,
+		HTTP:      &http.Config{},
+	}
+	require.Error(t, s.Validate())
+	s.Warmup = util.DurPtr(time.Second)
+	require.NoError(t, s.Validate())
+	s.WarmupOps = -1
+	require.Error(t, s.Validate())
+}
+
+func TestRateValidation(t *testing.T) {
+	s := &Stage{
+		Name: "rate",
+		HTTP: &http.Config{},
+		Rate: -1,
+	}
+	require.Error(t, s.Validate())
+	s.Rate = 100
+	require.NoError(t, s.Validate())
+}
+
+func TestThresholdsValidation(t *testing.T) {
+	s := &Stage{
+		Name: "thresholds",
+		HTTP: &http.Config{},
+		Thresholds: &Thresholds{
+			MaxErrorRate: util.Float64Ptr(1.5),
+		},
+	}
+	require.Error(t, s.Validate())
+	s.Thresholds.MaxErrorRate = util.Float64Ptr(0.01)
+	require.NoError(t, s.Validate())
+	s.Thresholds.P99 = util.DurPtr(-time.Millisecond)
+	require.Error(t, s.Validate())
+	s.Thresholds.P99 = util.DurPtr(100 * time.Millisecond)
+	s.Thresholds.MinThroughput = util.Float64Ptr(-1)
+	require.Error(t, s.Validate())
+}
+
+func TestMetricsValidation(t *testing.T) {
+	p := &Plan{
+		Name:   "metrics",
+		Stages: []*Stage{{Name: "a", HTTP: &http.Config{}}},
+		Metrics: &Metrics{Sinks: []*Sink{
+			{Type: "statsd", Address: "udp://localhost:8125"},
+			{Type: "influx", Address: "http://localhost:8086/write?db=dlg"},
+		}},
+	}
+	require.NoError(t, p.Validate())
+	p.Metrics.Sinks[0].Address = "http://localhost:8125"
+	require.Error(t, p.Validate())
+	p.Metrics.Sinks[0].Address = "udp://localhost:8125"
+	p.Metrics.Sinks[0].Type = "graphite"
+	require.Error(t, p.Validate())
+	p.Metrics.Sinks[0].Type = "dogstatsd"
+	p.Metrics.Sinks[0].Interval = util.DurPtr(0)
+	require.Error(t, p.Validate())
+	p.Metrics.Sinks[0].Interval = nil
+	p.Metrics.Sinks[1].Address = "localhost:8086"
+	require.Error(t, p.Validate())
+}
+
+func TestPlanSplit(t *testing.T) {
+	start := time.Now()
+	p := &Plan{
+		Name:        "split",
+		Executors:   5,
+		Start:       &start,
+		Distributed: &Distributed{Agents: 2},
+		Stages: []*Stage{
+			{
+				Name:        "parent",
+				Concurrency: 3,
+				Rate:        100,
+				Children: []*Stage{
+					{
+						Name:        "child",
+						Concurrency: 1,
+						Rate:        10,
+						HTTP:        &http.Config{},
+					},
+				},
+			},
+		},
+	}
+	_, err := p.Split(0)
+	require.Error(t, err)
+
+	plans, err := p.Split(2)
+	require.NoError(t, err)
+	require.Len(t, plans, 2)
+	for i, want := range []int{3, 2} {
+		plan := plans[i]
+		require.Nil(t, plan.Distributed)
+		require.True(t, start.Equal(*plan.Start))
+		require.Equal(t, want, plan.Executors)
+		require.Equal(t, 2-i, plan.Stages[0].Concurrency)
+		require.Equal(t, 50.0, plan.Stages[0].Rate)
+		require.Equal(t, 1, plan.Stages[0].Children[0].Concurrency)
+		require.Equal(t, 5.0, plan.Stages[0].Children[0].Rate)
+		require.NotNil(t, plan.Stages[0].Children[0].HTTP)
+	}
+	require.Equal(t, 2, p.Distributed.Agents)
+	require.Equal(t, 5, p.Executors)
+}
+
+func TestPlanCopy(t *testing.T) {
+	p := &Plan{
+		Name:   "copy",
+		Stages: []*Stage{{Name: "http", HTTP: &http.Config{}}},
+	}
+	p.Status().SetState(Complete)
+	plan, err := p.Copy()
+	require.NoError(t, err)
+	require.Equal(t, "copy", plan.Name)
+	require.Equal(t, "http", plan.Stages[0].Name)
+	require.Equal(t, Waiting, plan.Status().State())
+	plan.Stages[0].Name = "other"
+	require.Equal(t, "http", p.Stages[0].Name)
+}
+
+func TestDistributedValidation(t *testing.T) {
+	p := &Plan{
+		Name:        "distributed",
+		Stages:      []*Stage{{Name: "http", HTTP: &http.Config{}}},
+		Distributed: &Distributed{Agents: -1},
+	}
+	require.Error(t, p.Validate())
+	p.Distributed.Agents = 2
+	require.NoError(t, p.Validate())
+}
+
+func TestScheduleNext(t *testing.T) {
+	hour, day := time.Hour, 24*time.Hour
+	now := time.Date(2026, 10, 16, 10, 7, 30, 0, time.UTC)
+	tests := []struct {
+		schedule Schedule
+		next     time.Time
+	}{
+		{Schedule{Cron: "*/15 * * * *"}, time.Date(2026, 10, 16, 10, 15, 0, 0, time.UTC)},
+		{Schedule{Cron: "5,50 10-12 * * *"}, time.Date(2026, 10, 16, 10, 50, 0, 0, time.UTC)},
+		{Schedule{Cron: "30 9 * * mon-fri"}, time.Date(2026, 10, 19, 9, 30, 0, 0, time.UTC)},
+		{Schedule{Cron: "0 0 * * 7"}, time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)},
+		{Schedule{Cron: "0 0 1 jan *"}, time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{Schedule{Cron: "0 0 20 * fri"}, time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC)},
+		{Schedule{Cron: "@daily"}, time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)},
+		{Schedule{Cron: "0 9 * * *", TimeZone: "America/New_York"}, time.Date(2026, 10, 16, 13, 0, 0, 0, time.UTC)},
+		{Schedule{Every: &hour}, time.Date(2026, 10, 16, 11, 0, 0, 0, time.UTC)},
+		{Schedule{Every: &hour, TimeZone: "Asia/Kolkata"}, time.Date(2026, 10, 16, 10, 30, 0, 0, time.UTC)},
+		{Schedule{Every: &day, TimeZone: "America/New_York"}, time.Date(2026, 10, 17, 4, 0, 0, 0, time.UTC)},
+	}
+	for _, test := range tests {
+		require.NoError(t, test.schedule.Validate(), test.schedule.Cron)
+		next, err := test.schedule.Next(now)
+		require.NoError(t, err)
+		require.True(t, test.next.Equal(next), "%s: %v", test.schedule.Cron, next)
+	}
+}
+
+func TestScheduleValidation(t *testing.T) {
+	zero := time.Duration(0)
+	hour := time.Hour
+	for _, s := range []Schedule{
+		{},
+		{Cron: "* * *"},
+		{Cron: "61 * * * *"},
+		{Cron: "* * * * mon-foo"},
+		{Cron: "*/0 * * * *"},
+		{Cron: "5-1 * * * *"},
+		{Cron: "0 0 30 2 *"},
+		{Cron: "@hourly", Every: &hour},
+		{Cron: "@hourly", TimeZone: "Nowhere/Special"},
+		{Cron: "@hourly", Overlap: "sometimes"},
+		{Every: &zero},
+	} {
+		require.Error(t, s.Validate(), "%+v", s)
+	}
+
+	var p Plan
+	require.NoError(t, yaml.Unmarshal([]byte(`
+name: scheduled
+stages:
+- name: http
+  http: {}
+schedule:
+  every: 30m
+  overlap: queue
+`), &p))
+	require.NoError(t, p.Validate())
+	require.Equal(t, 30*time.Minute, *p.Schedule.Every)
+	p.Schedule.Overlap = "never"
+	require.Error(t, p.Validate())
+}
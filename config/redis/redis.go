@@ -30,6 +30,12 @@ type Config struct {
 // Execute is used to execute the config.
 func (c *Config) Execute(ctx context.Context) error {
 	client := c.Client()
+	defer client.Close()
+	return c.ExecuteClient(ctx, client)
+}
+
+// ExecuteClient is used to execute the config with an existing client.
+func (c *Config) ExecuteClient(ctx context.Context, client *v7.Client) error {
 	var err error
 	for _, command := range c.Commands {
 		select {
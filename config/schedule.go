@@ -0,0 +1,248 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Overlap policies of a Schedule.
+const (
+	// OverlapSkip skips a run that is due while the previous run is
+	// active.
+	OverlapSkip = "skip"
+	// OverlapQueue starts a run that is due while the previous run is
+	// active once it completes, at most one run is queued.
+	OverlapQueue = "queue"
+	// OverlapCancelPrevious cancels the previous run if it is active when
+	// a run is due.
+	OverlapCancelPrevious = "cancel-previous"
+)
+
+// Schedule starts runs of a plan periodically, it has either a cron
+// expression or an interval.
+type Schedule struct {
+	// Cron is a cron expression with minute, hour, day of month, month
+	// and day of week fields, or one of @hourly, @daily, @weekly,
+	// @monthly and @yearly.
+	Cron string `yaml:"cron,omitempty"`
+	// Every is the interval between runs, runs are aligned to the
+	// interval in TimeZone so that an hourly schedule runs on the hour
+	// and a daily schedule runs at midnight.
+	Every *time.Duration `yaml:"every,omitempty"`
+	// TimeZone is the IANA time zone the cron expression is evaluated
+	// and the interval is aligned in, UTC by default.
+	TimeZone string `yaml:"timeZone,omitempty"`
+	// Overlap is the policy for a run that is due while the previous run
+	// is active, skip by default.
+	Overlap string `yaml:"overlap,omitempty"`
+}
+
+// Validate is used to validate a Schedule.
+func (s *Schedule) Validate() error {
+	next, err := s.next()
+	if err != nil {
+		return err
+	}
+	if next(time.Now()).IsZero() {
+		return fmt.Errorf("cron expression %q never matches", s.Cron)
+	}
+	return nil
+}
+
+// Next returns the first time a run is due after t, or the zero time if
+// the cron expression never matches.
+func (s *Schedule) Next(t time.Time) (time.Time, error) {
+	next, err := s.next()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return next(t), nil
+}
+
+func (s *Schedule) next() (func(time.Time) time.Time, error) {
+	switch s.Overlap {
+	case "", OverlapSkip, OverlapQueue, OverlapCancelPrevious:
+	default:
+		return nil, fmt.Errorf("invalid overlap policy %q", s.Overlap)
+	}
+	loc, err := time.LoadLocation(s.TimeZone)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case s.Cron != "" && s.Every != nil:
+		return nil, errors.New("schedule has both a cron expression and an interval")
+	case s.Every != nil:
+		every := *s.Every
+		if every <= 0 {
+			return nil, fmt.Errorf("invalid schedule interval %v", every)
+		}
+		return func(t time.Time) time.Time {
+			// Truncate aligns to UTC, shift t by the offset of loc so
+			// that runs are aligned to the wall clock of loc.
+			_, offset := t.In(loc).Zone()
+			shift := time.Duration(offset) * time.Second
+			return t.Add(shift).Truncate(every).Add(every).Add(-shift)
+		}, nil
+	case s.Cron != "":
+		c, err := parseCron(s.Cron)
+		if err != nil {
+			return nil, err
+		}
+		return func(t time.Time) time.Time {
+			return c.next(t.In(loc))
+		}, nil
+	}
+	return nil, errors.New("schedule has no cron expression or interval")
+}
+
+// cron is a parsed cron expression, each field is a bit set of the values
+// it matches.
+type cron struct {
+	minute, hour, dom, month, dow uint64
+	// domAny and dowAny are set if the day fields start with *. If both
+	// day fields are restricted a day matches either of them.
+	domAny, dowAny bool
+}
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var (
+	monthNames = []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+	dayNames   = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+)
+
+func parseCron(expr string) (*cron, error) {
+	if macro, ok := cronMacros[expr]; ok {
+		expr = macro
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields", expr)
+	}
+	var c cron
+	var err error
+	if c.minute, err = parseCronField(fields[0], 0, 59, nil); err != nil {
+		return nil, err
+	}
+	if c.hour, err = parseCronField(fields[1], 0, 23, nil); err != nil {
+		return nil, err
+	}
+	if c.dom, err = parseCronField(fields[2], 1, 31, nil); err != nil {
+		return nil, err
+	}
+	if c.month, err = parseCronField(fields[3], 1, 12, monthNames); err != nil {
+		return nil, err
+	}
+	// Sunday is 0 or 7.
+	if c.dow, err = parseCronField(fields[4], 0, 7, dayNames); err != nil {
+		return nil, err
+	}
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+	c.domAny = strings.HasPrefix(fields[2], "*")
+	c.dowAny = strings.HasPrefix(fields[4], "*")
+	return &c, nil
+}
+
+// parseCronField parses a comma separated list of *, values, ranges and
+// steps. Names are matched case insensitively to values starting at min.
+func parseCronField(field string, min, max int, names []string) (uint64, error) {
+	value := func(s string) (int, error) {
+		for i, name := range names {
+			if strings.EqualFold(s, name) {
+				return min + i, nil
+			}
+		}
+		v, err := strconv.Atoi(s)
+		if err != nil || v < min || v > max {
+			return 0, fmt.Errorf("invalid cron value %q", s)
+		}
+		return v, nil
+	}
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			rng = part[:i]
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid cron step %q", part)
+			}
+		}
+		lo, hi := min, max
+		switch {
+		case rng == "*":
+		case strings.Contains(rng, "-"):
+			i := strings.Index(rng, "-")
+			var err error
+			if lo, err = value(rng[:i]); err != nil {
+				return 0, err
+			}
+			if hi, err = value(rng[i+1:]); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("invalid cron range %q", rng)
+			}
+		default:
+			v, err := value(rng)
+			if err != nil {
+				return 0, err
+			}
+			lo, hi = v, v
+			if step > 1 {
+				// a/n is every n from a.
+				hi = max
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func (c *cron) dayMatches(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.domAny || c.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// next returns the first minute after t matched by the expression in the
+// location of t, or the zero time if there is none within five years.
+func (c *cron) next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case c.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !c.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case c.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case c.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Truncate(time.Minute).Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,173 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// String returns the name of the state.
+func (s ExecutionState) String() string {
+	switch s {
+	case Waiting:
+		return "waiting"
+	case Running:
+		return "running"
+	case Paused:
+		return "paused"
+	case Complete:
+		return "complete"
+	default:
+		return "unknown"
+	}
+}
+
+// ExecutionStates are all execution states.
+var ExecutionStates = []ExecutionState{Waiting, Running, Paused, Complete}
+
+// MarshalJSON encodes the state as its name.
+func (s ExecutionState) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// UnmarshalJSON decodes a state from its name or number.
+func (s *ExecutionState) UnmarshalJSON(b []byte) error {
+	var n int
+	if err := json.Unmarshal(b, &n); err == nil {
+		*s = ExecutionState(n)
+		return nil
+	}
+	var name string
+	if err := json.Unmarshal(b, &name); err != nil {
+		return err
+	}
+	for _, state := range ExecutionStates {
+		if state.String() == name {
+			*s = state
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown execution state %q", name)
+}
+
+// Status is the live status of the execution of a plan or stage, it is safe
+// for concurrent use. The zero value is a waiting execution.
+type Status struct {
+	workers    int64
+	iterations int64
+
+	mu    sync.Mutex
+	state ExecutionState
+	start time.Time
+	end   time.Time
+	// Operations completed in the second sec and the second before it.
+	sec     int64
+	ops     int64
+	prevOps int64
+}
+
+// Reset resets the status to a waiting execution.
+func (s *Status) Reset() {
+	atomic.StoreInt64(&s.workers, 0)
+	atomic.StoreInt64(&s.iterations, 0)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state = Waiting
+	s.start, s.end = time.Time{}, time.Time{}
+	s.sec, s.ops, s.prevOps = 0, 0, 0
+}
+
+// SetState sets the state, the first Running state starts the execution
+// and Complete ends it.
+func (s *Status) SetState(state ExecutionState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state = state
+	switch state {
+	case Running:
+		if s.start.IsZero() {
+			s.start = time.Now()
+		}
+	case Complete:
+		if s.end.IsZero() {
+			s.end = time.Now()
+		}
+	}
+}
+
+// State returns the state.
+func (s *Status) State() ExecutionState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state
+}
+
+// Elapsed returns the time since the execution started, or its duration
+// once complete.
+func (s *Status) Elapsed(now time.Time) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case s.start.IsZero():
+		return 0
+	case !s.end.IsZero():
+		return s.end.Sub(s.start)
+	default:
+		return now.Sub(s.start)
+	}
+}
+
+// AddWorkers adds n to the number of active workers.
+func (s *Status) AddWorkers(n int64) {
+	atomic.AddInt64(&s.workers, n)
+}
+
+// Workers returns the number of active workers.
+func (s *Status) Workers() int64 {
+	return atomic.LoadInt64(&s.workers)
+}
+
+// AddIteration counts a completed iteration.
+func (s *Status) AddIteration() {
+	atomic.AddInt64(&s.iterations, 1)
+}
+
+// Iterations returns the number of completed iterations.
+func (s *Status) Iterations() int64 {
+	return atomic.LoadInt64(&s.iterations)
+}
+
+// AddOp counts an operation completed at t.
+func (s *Status) AddOp(t time.Time) {
+	sec := t.Unix()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case sec == s.sec:
+	case sec == s.sec+1:
+		s.sec, s.prevOps, s.ops = sec, s.ops, 0
+	case sec > s.sec:
+		s.sec, s.prevOps, s.ops = sec, 0, 0
+	default:
+		// Operations of past seconds are no longer counted.
+		return
+	}
+	s.ops++
+}
+
+// Rate returns the number of operations completed in the last full second.
+func (s *Status) Rate(now time.Time) float64 {
+	sec := now.Unix()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch sec {
+	case s.sec:
+		return float64(s.prevOps)
+	case s.sec + 1:
+		return float64(s.ops)
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestStatusState tests the state transitions and elapsed time of a
+// Status.
+func TestStatusState(t *testing.T) {
+	s := &Status{}
+	require.Equal(t, Waiting, s.State())
+	require.Equal(t, time.Duration(0), s.Elapsed(time.Now()))
+
+	s.SetState(Running)
+	require.Equal(t, Running, s.State())
+	assert.Equal(t, "running", s.State().String())
+	require.True(t, s.Elapsed(time.Now().Add(time.Second)) >= time.Second)
+
+	s.SetState(Complete)
+	elapsed := s.Elapsed(time.Now())
+	require.Equal(t, elapsed, s.Elapsed(time.Now().Add(time.Hour)))
+
+	s.AddWorkers(2)
+	s.AddIteration()
+	s.Reset()
+	assert.Equal(t, Waiting, s.State())
+	assert.Equal(t, int64(0), s.Workers())
+	assert.Equal(t, int64(0), s.Iterations())
+	assert.Equal(t, time.Duration(0), s.Elapsed(time.Now()))
+}
+
+// TestExecutionStateJSON tests states are encoded by name.
+func TestExecutionStateJSON(t *testing.T) {
+	b, err := json.Marshal(Paused)
+	require.NoError(t, err)
+	assert.Equal(t, `"paused"`, string(b))
+
+	var s ExecutionState
+	require.NoError(t, json.Unmarshal(b, &s))
+	assert.Equal(t, Paused, s)
+	require.NoError(t, json.Unmarshal([]byte("3"), &s))
+	assert.Equal(t, Complete, s)
+	assert.Error(t, json.Unmarshal([]byte(`"done"`), &s))
+}
+
+// TestStatusRate tests the rate is the number of operations in the last
+// full second.
+func TestStatusRate(t *testing.T) {
+	s := &Status{}
+	now := time.Unix(100, 0)
+	for i := 0; i < 3; i++ {
+		s.AddOp(now.Add(time.Duration(i) * 100 * time.Millisecond))
+	}
+	assert.Equal(t, 0.0, s.Rate(now.Add(500*time.Millisecond)))
+	assert.Equal(t, 3.0, s.Rate(now.Add(1500*time.Millisecond)))
+
+	s.AddOp(now.Add(1200 * time.Millisecond))
+	assert.Equal(t, 3.0, s.Rate(now.Add(1500*time.Millisecond)))
+	assert.Equal(t, 1.0, s.Rate(now.Add(2500*time.Millisecond)))
+	assert.Equal(t, 0.0, s.Rate(now.Add(5*time.Second)))
+
+	// Operations of past seconds are not counted.
+	s.AddOp(now)
+	assert.Equal(t, 1.0, s.Rate(now.Add(2500*time.Millisecond)))
+}
+
+// TestPlanResetStatus tests resetting the status of a plan resets its
+// stages.
+func TestPlanResetStatus(t *testing.T) {
+	child := &Stage{Name: "child"}
+	p := &Plan{Stages: []*Stage{{Name: "parent", Children: []*Stage{child}}}}
+	p.Status().SetState(Complete)
+	child.Status().AddIteration()
+
+	p.ResetStatus()
+	assert.Equal(t, Waiting, p.Status().State())
+	assert.Equal(t, int64(0), child.Status().Iterations())
+}
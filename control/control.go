@@ -0,0 +1,313 @@
+// Package control serves the gRPC control API of a dlg server, which
+// mirrors the plan and run routes of its HTTP API.
+package control
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/hodgesds/dlg"
+	"github.com/hodgesds/dlg/auth"
+	"github.com/hodgesds/dlg/config"
+	"github.com/hodgesds/dlg/control/controlpb"
+	"github.com/hodgesds/dlg/progress"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gopkg.in/yaml.v2"
+)
+
+//go:generate protoc --go_out=.. --go_opt=paths=source_relative --go-grpc_out=.. --go-grpc_opt=paths=source_relative -I .. control/controlpb/control.proto
+
+// Roles are the roles required by the methods of the Control service, the
+// same as those of the matching HTTP routes.
+var Roles = map[string]auth.Role{
+	controlpb.Control_ListPlans_FullMethodName:  auth.Viewer,
+	controlpb.Control_GetPlan_FullMethodName:    auth.Viewer,
+	controlpb.Control_AddPlan_FullMethodName:    auth.Admin,
+	controlpb.Control_DeletePlan_FullMethodName: auth.Admin,
+	controlpb.Control_StartRun_FullMethodName:   auth.Operator,
+	controlpb.Control_ListRuns_FullMethodName:   auth.Viewer,
+	controlpb.Control_GetRun_FullMethodName:     auth.Viewer,
+	controlpb.Control_PauseRun_FullMethodName:   auth.Operator,
+	controlpb.Control_ResumeRun_FullMethodName:  auth.Operator,
+	controlpb.Control_CancelRun_FullMethodName:  auth.Operator,
+	controlpb.Control_WatchRun_FullMethodName:   auth.Viewer,
+	controlpb.Control_GetReport_FullMethodName:  auth.Viewer,
+}
+
+type server struct {
+	controlpb.UnimplementedControlServer
+	m    dlg.Manager
+	runs *dlg.Runs
+}
+
+// Register registers the Control service for the plans of m and the runs
+// of runs with s.
+func Register(s *grpc.Server, m dlg.Manager, runs *dlg.Runs) {
+	controlpb.RegisterControlServer(s, &server{m: m, runs: runs})
+}
+
+// ListPlans implements the ControlServer interface.
+func (s *server) ListPlans(ctx context.Context, req *controlpb.ListPlansRequest) (*controlpb.ListPlansResponse, error) {
+	plans, err := s.m.Plans(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	res := &controlpb.ListPlansResponse{}
+	for _, plan := range plans {
+		p, err := planProto(plan)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		res.Plans = append(res.Plans, p)
+	}
+	return res, nil
+}
+
+// GetPlan implements the ControlServer interface.
+func (s *server) GetPlan(ctx context.Context, req *controlpb.GetPlanRequest) (*controlpb.Plan, error) {
+	plan, err := s.m.Get(ctx, req.Name)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	p, err := planProto(plan)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return p, nil
+}
+
+// AddPlan implements the ControlServer interface.
+func (s *server) AddPlan(ctx context.Context, req *controlpb.AddPlanRequest) (*controlpb.AddPlanResponse, error) {
+	var plan config.Plan
+	if err := yaml.Unmarshal([]byte(req.Yaml), &plan); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := plan.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := s.m.Add(ctx, &plan); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return &controlpb.AddPlanResponse{}, nil
+}
+
+// DeletePlan implements the ControlServer interface.
+func (s *server) DeletePlan(ctx context.Context, req *controlpb.DeletePlanRequest) (*controlpb.DeletePlanResponse, error) {
+	if err := s.m.Delete(ctx, req.Name); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return &controlpb.DeletePlanResponse{}, nil
+}
+
+// StartRun implements the ControlServer interface.
+func (s *server) StartRun(ctx context.Context, req *controlpb.StartRunRequest) (*controlpb.Run, error) {
+	run, err := s.runs.Start(ctx, req.Plan)
+	if errors.Is(err, dlg.ErrMaxRuns) {
+		return nil, status.Error(codes.ResourceExhausted, err.Error())
+	}
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return runProto(run.Status()), nil
+}
+
+// ListRuns implements the ControlServer interface.
+func (s *server) ListRuns(ctx context.Context, req *controlpb.ListRunsRequest) (*controlpb.ListRunsResponse, error) {
+	res := &controlpb.ListRunsResponse{}
+	for _, run := range s.runs.List() {
+		res.Runs = append(res.Runs, runProto(run.Status()))
+	}
+	return res, nil
+}
+
+// GetRun implements the ControlServer interface.
+func (s *server) GetRun(ctx context.Context, req *controlpb.GetRunRequest) (*controlpb.Run, error) {
+	run, err := s.run(req.Id)
+	if err != nil {
+		return nil, err
+	}
+	return runProto(run.Status()), nil
+}
+
+// PauseRun implements the ControlServer interface.
+func (s *server) PauseRun(ctx context.Context, req *controlpb.PauseRunRequest) (*controlpb.Run, error) {
+	return s.control(req.Id, (*dlg.Run).Pause)
+}
+
+// ResumeRun implements the ControlServer interface.
+func (s *server) ResumeRun(ctx context.Context, req *controlpb.ResumeRunRequest) (*controlpb.Run, error) {
+	return s.control(req.Id, (*dlg.Run).Resume)
+}
+
+// CancelRun implements the ControlServer interface.
+func (s *server) CancelRun(ctx context.Context, req *controlpb.CancelRunRequest) (*controlpb.Run, error) {
+	return s.control(req.Id, (*dlg.Run).Cancel)
+}
+
+func (s *server) run(id string) (*dlg.Run, error) {
+	run, err := s.runs.Get(id)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	return run, nil
+}
+
+func (s *server) control(id string, f func(*dlg.Run) error) (*controlpb.Run, error) {
+	run, err := s.run(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := f(run); err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return runProto(run.Status()), nil
+}
+
+// WatchRun implements the ControlServer interface.
+func (s *server) WatchRun(req *controlpb.WatchRunRequest, stream controlpb.Control_WatchRunServer) error {
+	run, err := s.run(req.Id)
+	if err != nil {
+		return err
+	}
+	snap, events, cancel := run.Subscribe()
+	defer cancel()
+	if err := stream.Send(eventProto(snap)); err != nil {
+		return err
+	}
+	for {
+		select {
+		case e, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if err := stream.Send(eventProto(e)); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		}
+	}
+}
+
+// GetReport implements the ControlServer interface.
+func (s *server) GetReport(ctx context.Context, req *controlpb.GetReportRequest) (*controlpb.Report, error) {
+	run, err := s.run(req.Id)
+	if err != nil {
+		return nil, err
+	}
+	rep := run.Report()
+	var buf bytes.Buffer
+	res := &controlpb.Report{}
+	switch req.Format {
+	case "", "json":
+		res.ContentType = "application/json"
+		err = rep.WriteJSON(&buf)
+	case "html":
+		res.ContentType = "text/html; charset=utf-8"
+		err = rep.WriteHTML(&buf, run.PlanYAML)
+	default:
+		return nil, status.Error(codes.InvalidArgument, "format must be json or html")
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	res.Data = buf.Bytes()
+	return res, nil
+}
+
+func planProto(plan *config.Plan) (*controlpb.Plan, error) {
+	b, err := yaml.Marshal(plan)
+	if err != nil {
+		return nil, err
+	}
+	return &controlpb.Plan{Name: plan.Name, Yaml: string(b)}, nil
+}
+
+func runProto(s dlg.RunStatus) *controlpb.Run {
+	run := &controlpb.Run{
+		Id:    s.ID,
+		Plan:  s.Plan,
+		State: s.State,
+		Start: timestamppb.New(s.Start),
+		Error: s.Error,
+	}
+	if s.End != nil {
+		run.End = timestamppb.New(*s.End)
+	}
+	return run
+}
+
+func eventProto(e dlg.Event) *controlpb.RunEvent {
+	event := &controlpb.RunEvent{
+		Type:  e.Type,
+		Run:   e.Run,
+		Time:  timestamppb.New(e.Time),
+		Stage: e.Stage,
+	}
+	if e.Stage != "" {
+		event.State = e.State.String()
+	}
+	if e.Status != nil {
+		event.Status = runProto(*e.Status)
+	}
+	if e.Progress != nil {
+		event.Progress = progressProto(e.Progress)
+	}
+	for _, c := range e.Checks {
+		event.Checks = append(event.Checks, &controlpb.Check{
+			Stage:     c.Stage,
+			Threshold: c.Threshold,
+			Limit:     c.Limit,
+			Value:     c.Value,
+			Pass:      c.Pass,
+		})
+	}
+	return event
+}
+
+func progressProto(snap *progress.Snapshot) *controlpb.Progress {
+	p := &controlpb.Progress{
+		Plan:      snap.Plan,
+		State:     snap.State.String(),
+		Elapsed:   durationpb.New(snap.Elapsed),
+		Remaining: optionalDuration(snap.Remaining),
+		RateScale: snap.RateScale,
+		Total:     stageProto(snap.Total),
+	}
+	for _, s := range snap.Stages {
+		p.Stages = append(p.Stages, stageProto(s))
+	}
+	return p
+}
+
+func stageProto(s progress.Stage) *controlpb.StageProgress {
+	return &controlpb.StageProgress{
+		Name:       s.Name,
+		Depth:      int32(s.Depth),
+		State:      s.State.String(),
+		Done:       s.Done,
+		Ops:        s.Ops,
+		Errors:     s.Errors,
+		Rate:       s.Rate,
+		TargetRate: s.TargetRate,
+		ErrorRate:  s.ErrorRate,
+		P50:        durationpb.New(s.P50),
+		P99:        durationpb.New(s.P99),
+		Active:     s.Active,
+		Elapsed:    durationpb.New(s.Elapsed),
+		Remaining:  optionalDuration(s.Remaining),
+	}
+}
+
+func optionalDuration(d *time.Duration) *durationpb.Duration {
+	if d == nil {
+		return nil
+	}
+	return durationpb.New(*d)
+}
@@ -0,0 +1,134 @@
+package control
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/hodgesds/dlg"
+	"github.com/hodgesds/dlg/auth"
+	"github.com/hodgesds/dlg/config"
+	"github.com/hodgesds/dlg/control/controlpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// testPlanExec runs plans named block until they are canceled.
+type testPlanExec struct{}
+
+func (testPlanExec) Execute(ctx context.Context, p *config.Plan) error {
+	if p.Name == "block" {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return nil
+}
+
+func testClient(t *testing.T, opts ...grpc.ServerOption) controlpb.ControlClient {
+	m := dlg.NewManager(testPlanExec{})
+	for _, name := range []string{"test", "block"} {
+		require.NoError(t, m.Add(context.Background(), &config.Plan{Name: name}))
+	}
+	runs := dlg.NewRuns(m, dlg.RunsConfig{})
+	t.Cleanup(func() { runs.Close() })
+
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer(opts...)
+	Register(s, m, runs)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return controlpb.NewControlClient(conn)
+}
+
+// TestControlErrors tests errors are returned with matching status codes.
+func TestControlErrors(t *testing.T) {
+	c := testClient(t)
+	ctx := context.Background()
+
+	_, err := c.GetPlan(ctx, &controlpb.GetPlanRequest{Name: "missing"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = c.AddPlan(ctx, &controlpb.AddPlanRequest{Yaml: "name: ["})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = c.AddPlan(ctx, &controlpb.AddPlanRequest{Yaml: "name: nostages"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = c.GetPlan(ctx, &controlpb.GetPlanRequest{Name: "nostages"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = c.StartRun(ctx, &controlpb.StartRunRequest{Plan: "missing"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = c.GetRun(ctx, &controlpb.GetRunRequest{Id: "missing"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	run, err := c.StartRun(ctx, &controlpb.StartRunRequest{Plan: "test"})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		run, err = c.GetRun(ctx, &controlpb.GetRunRequest{Id: run.Id})
+		return err == nil && run.End != nil
+	}, 5*time.Second, time.Millisecond)
+	assert.Equal(t, dlg.RunPassed, run.State)
+	_, err = c.CancelRun(ctx, &controlpb.CancelRunRequest{Id: run.Id})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	_, err = c.GetReport(ctx, &controlpb.GetReportRequest{Id: run.Id, Format: "csv"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+// TestControlAuth tests calls are authorized by the role of their client.
+func TestControlAuth(t *testing.T) {
+	tokens := auth.Tokens{}
+	tokens.Add("viewer", auth.Identity{Name: "v", Role: auth.Viewer})
+	tokens.Add("operator", auth.Identity{Name: "o", Role: auth.Operator})
+	c := testClient(t,
+		grpc.UnaryInterceptor(auth.UnaryInterceptor(tokens, Roles)),
+		grpc.StreamInterceptor(auth.StreamInterceptor(tokens, Roles)),
+	)
+	withToken := func(token string) context.Context {
+		return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+	}
+
+	_, err := c.ListPlans(context.Background(), &controlpb.ListPlansRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = c.ListPlans(withToken("wrong"), &controlpb.ListPlansRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = c.ListPlans(withToken("viewer"), &controlpb.ListPlansRequest{})
+	require.NoError(t, err)
+	_, err = c.StartRun(withToken("viewer"), &controlpb.StartRunRequest{Plan: "test"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	run, err := c.StartRun(withToken("operator"), &controlpb.StartRunRequest{Plan: "test"})
+	require.NoError(t, err)
+	_, err = c.AddPlan(withToken("operator"), &controlpb.AddPlanRequest{Yaml: "name: other"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	stream, err := c.WatchRun(context.Background(), &controlpb.WatchRunRequest{Id: run.Id})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	stream, err = c.WatchRun(withToken("viewer"), &controlpb.WatchRunRequest{Id: run.Id})
+	require.NoError(t, err)
+	e, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, dlg.EventSnapshot, e.Type)
+}
+
+// TestRoles tests every method of the Control service has a role.
+func TestRoles(t *testing.T) {
+	for _, m := range controlpb.Control_ServiceDesc.Methods {
+		assert.Contains(t, Roles, "/"+controlpb.Control_ServiceDesc.ServiceName+"/"+m.MethodName)
+	}
+	for _, s := range controlpb.Control_ServiceDesc.Streams {
+		assert.Contains(t, Roles, "/"+controlpb.Control_ServiceDesc.ServiceName+"/"+s.StreamName)
+	}
+}
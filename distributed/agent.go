@@ -0,0 +1,181 @@
+package distributed
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hodgesds/dlg/config"
+	"github.com/hodgesds/dlg/executor"
+	"github.com/hodgesds/dlg/stats"
+)
+
+// UpdateInterval is the interval agents send the results of a running plan
+// at.
+const UpdateInterval = time.Second
+
+// Update is a message with the results of a plan run by an agent. The
+// stages contain the aggregated results of the run, except for the
+// intervals which are those that changed since the previous update.
+type Update struct {
+	Stages []*stats.Stage `json:"stages"`
+	// Done is set on the last update, Error is the error of the run.
+	Done  bool   `json:"done,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// agentRouter runs the plans of a controller.
+type agentRouter struct {
+	planExec executor.Plan
+	token    string
+}
+
+// NewAgentRouter adds the route a controller runs plans with to e. If token
+// is not empty plans are only run for requests with it as bearer token, the
+// agent registers the token with the controller.
+func NewAgentRouter(e *gin.Engine, planExec executor.Plan, token string) {
+	r := &agentRouter{planExec: planExec, token: token}
+	e.POST("/agent/run", r.Run)
+}
+
+// NewAgentToken returns a random token for an agent.
+func NewAgentToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Run executes a plan and streams its results as JSON lines of Updates,
+// one every UpdateInterval and a final one once the plan completes.
+func (r *agentRouter) Run(c *gin.Context) {
+	if r.token != "" && subtle.ConstantTimeCompare(
+		[]byte(c.GetHeader("Authorization")), []byte("Bearer "+r.token),
+	) != 1 {
+		c.JSON(401, gin.H{"msg": "invalid agent token"})
+		return
+	}
+	var p config.Plan
+	if err := c.BindYAML(&p); err != nil {
+		c.JSON(400, gin.H{"msg": err.Error()})
+		return
+	}
+	collector := stats.NewCollector()
+	done := make(chan error, 1)
+	go func() {
+		done <- r.planExec.Execute(stats.NewContext(c.Request.Context(), collector), &p)
+	}()
+
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Status(200)
+	enc := json.NewEncoder(c.Writer)
+	ticker := time.NewTicker(UpdateInterval)
+	defer ticker.Stop()
+	sent := sentIntervals{}
+	for {
+		var u Update
+		select {
+		case <-ticker.C:
+		case err := <-done:
+			u.Done = true
+			if err != nil {
+				u.Error = err.Error()
+			}
+		}
+		u.Stages = sent.update(collector.Stages())
+		if err := enc.Encode(&u); err != nil {
+			// The controller is gone and the request context is
+			// canceled, which stops the plan.
+			if !u.Done {
+				<-done
+			}
+			return
+		}
+		c.Writer.Flush()
+		if u.Done {
+			return
+		}
+	}
+}
+
+// sentIntervals are the operations of the intervals of each stage sent in
+// previous updates.
+type sentIntervals map[string]map[int64]int64
+
+// update removes the intervals that did not change since the previous update
+// from stages. Results are recorded in the interval they started in once
+// they complete, so an earlier interval can change.
+func (sent sentIntervals) update(stages []*stats.Stage) []*stats.Stage {
+	for _, s := range stages {
+		ops, ok := sent[s.Name]
+		if !ok {
+			ops = map[int64]int64{}
+			sent[s.Name] = ops
+		}
+		changed := s.Intervals[:0]
+		for _, i := range s.Intervals {
+			sec := i.Time.Unix()
+			if ops[sec] != i.Bucket.Ops {
+				ops[sec] = i.Bucket.Ops
+				changed = append(changed, i)
+			}
+		}
+		s.Intervals = changed
+	}
+	return stages
+}
+
+// Register registers the agent with the controller every RegisterInterval
+// until the context is done, then deregisters it. Requests are sent with
+// transport, http.DefaultTransport if nil.
+func Register(ctx context.Context, transport http.RoundTripper, controller string, a Agent) {
+	client := &http.Client{Transport: transport, Timeout: RegisterInterval}
+	ticker := time.NewTicker(RegisterInterval)
+	defer ticker.Stop()
+	for {
+		if err := register(ctx, client, controller, a); err != nil {
+			log.Printf("register with controller: %v", err)
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			req, err := http.NewRequest(http.MethodDelete, controller+"/agents/"+a.ID, nil)
+			if err == nil {
+				if res, err := client.Do(req); err == nil {
+					res.Body.Close()
+				}
+			}
+			return
+		}
+	}
+}
+
+func register(ctx context.Context, client *http.Client, controller string, a Agent) error {
+	b, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, controller+"/agents", bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("controller returned %s", res.Status)
+	}
+	return nil
+}
@@ -0,0 +1,245 @@
+// Package distributed runs plans across several dlg servers. Agents
+// register with a controller, which splits a distributed plan between them
+// and starts every part at the same time.
+package distributed
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hodgesds/dlg/config"
+	"github.com/hodgesds/dlg/executor"
+	"github.com/hodgesds/dlg/stats"
+	"go.uber.org/multierr"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// RegisterInterval is the interval agents register with the controller
+	// at, agents that have not registered for three intervals are
+	// dropped.
+	RegisterInterval = 5 * time.Second
+	// StartDelay is the default delay from the dispatch of a plan to its
+	// start on the agents, it allows every agent to receive its part.
+	StartDelay = 2 * time.Second
+)
+
+// Agent is an agent registered with a controller.
+type Agent struct {
+	ID string `json:"id"`
+	// URL is the base URL of the HTTP API of the agent.
+	URL string `json:"url"`
+	// Token is the bearer token the controller runs plans on the agent
+	// with, it is not listed.
+	Token string `json:"token,omitempty"`
+	// Seen is the time of the last registration of the agent.
+	Seen time.Time `json:"seen"`
+}
+
+// Controller is a Plan executor that runs plans with a Distributed config on
+// registered agents and all other plans with a local executor.
+type Controller struct {
+	// Insecure allows agents with http URLs to register, the plans and
+	// tokens sent to them are not encrypted. It must be set before agents
+	// register.
+	Insecure bool
+
+	mu         sync.Mutex
+	local      executor.Plan
+	agents     map[string]Agent
+	client     *http.Client
+	startDelay time.Duration
+	now        func() time.Time
+}
+
+// NewController returns a new Controller that calls agents with transport,
+// http.DefaultTransport if nil. Requests to an agent are authenticated with
+// the token of the agent, so transport should not add credentials.
+func NewController(local executor.Plan, transport http.RoundTripper) *Controller {
+	return &Controller{
+		local:      local,
+		agents:     map[string]Agent{},
+		client:     &http.Client{Transport: transport},
+		startDelay: StartDelay,
+		now:        time.Now,
+	}
+}
+
+// NewControllerRouter adds the routes agents register with to e.
+func NewControllerRouter(e *gin.Engine, c *Controller) {
+	e.GET("/agents", c.list)
+	e.POST("/agents", c.register)
+	e.DELETE("/agents/:id", c.deregister)
+}
+
+func (c *Controller) list(ctx *gin.Context) {
+	agents := c.Agents()
+	for i := range agents {
+		agents[i].Token = ""
+	}
+	ctx.JSON(200, agents)
+}
+
+func (c *Controller) register(ctx *gin.Context) {
+	var a Agent
+	if err := ctx.BindJSON(&a); err != nil {
+		ctx.JSON(400, gin.H{"msg": err.Error()})
+		return
+	}
+	if a.ID == "" || a.URL == "" {
+		ctx.JSON(400, gin.H{"msg": "agent requires an id and url"})
+		return
+	}
+	if err := c.Register(a); err != nil {
+		ctx.JSON(400, gin.H{"msg": err.Error()})
+		return
+	}
+	ctx.JSON(200, gin.H{"status": "ok"})
+}
+
+func (c *Controller) deregister(ctx *gin.Context) {
+	c.mu.Lock()
+	delete(c.agents, ctx.Param("id"))
+	c.mu.Unlock()
+	ctx.JSON(200, gin.H{"status": "ok"})
+}
+
+// Register registers an agent or renews its registration. The URL of the
+// agent must use https unless the Controller is Insecure.
+func (c *Controller) Register(a Agent) error {
+	u, err := url.Parse(a.URL)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "https" && (!c.Insecure || u.Scheme != "http") {
+		return fmt.Errorf("agent url %q must use https", a.URL)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	a.Seen = c.now()
+	c.agents[a.ID] = a
+	return nil
+}
+
+// Agents returns the live agents ordered by ID.
+func (c *Controller) Agents() []Agent {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	expired := c.now().Add(-3 * RegisterInterval)
+	agents := make([]Agent, 0, len(c.agents))
+	for id, a := range c.agents {
+		if a.Seen.Before(expired) {
+			delete(c.agents, id)
+			continue
+		}
+		agents = append(agents, a)
+	}
+	sort.Slice(agents, func(i, j int) bool {
+		return agents[i].ID < agents[j].ID
+	})
+	return agents
+}
+
+// Execute implements the executor.Plan interface. A distributed plan is
+// split between the agents, which start at the same time, the plan fails if
+// any agent fails.
+func (c *Controller) Execute(ctx context.Context, plan *config.Plan) error {
+	if plan.Distributed == nil || plan.Distributed.Agents == 0 {
+		return c.local.Execute(ctx, plan)
+	}
+	if err := plan.Validate(); err != nil {
+		return err
+	}
+	agents := c.Agents()
+	n := plan.Distributed.Agents
+	if len(agents) < n {
+		return fmt.Errorf("plan requires %d agents, %d registered", n, len(agents))
+	}
+	agents = agents[:n]
+	plans, err := plan.Split(n)
+	if err != nil {
+		return err
+	}
+	start := c.now().Add(c.startDelay)
+	if plan.Start != nil && plan.Start.After(start) {
+		start = *plan.Start
+	}
+
+	plan.ResetStatus()
+	status := plan.Status()
+	status.SetState(config.Running)
+	defer status.SetState(config.Complete)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := range agents {
+		plans[i].Start = &start
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := c.run(ctx, agents[i], plans[i]); err != nil {
+				errs[i] = fmt.Errorf("agent %s: %w", agents[i].ID, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	return multierr.Combine(errs...)
+}
+
+// run runs a plan on an agent, the results of the agent are merged into
+// the Recorder of the context if it is a stats.Merger.
+func (c *Controller) run(ctx context.Context, a Agent, plan *config.Plan) error {
+	b, err := yaml.Marshal(plan)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.URL+"/agent/run", bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-yaml")
+	if a.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+a.Token)
+	}
+	res, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		var msg struct {
+			Msg string `json:"msg"`
+		}
+		json.NewDecoder(res.Body).Decode(&msg)
+		return fmt.Errorf("%s: %s", res.Status, msg.Msg)
+	}
+	merger, _ := stats.FromContext(ctx).(stats.Merger)
+	dec := json.NewDecoder(res.Body)
+	for {
+		var u Update
+		if err := dec.Decode(&u); err != nil {
+			if err == io.EOF {
+				return errors.New("agent closed the connection before the plan completed")
+			}
+			return err
+		}
+		if merger != nil {
+			merger.MergeSource(a.ID, u.Stages)
+		}
+		if u.Done {
+			if u.Error != "" {
+				return errors.New(u.Error)
+			}
+			return nil
+		}
+	}
+}
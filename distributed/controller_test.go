@@ -0,0 +1,243 @@
+package distributed
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hodgesds/dlg/config"
+	httpconf "github.com/hodgesds/dlg/config/http"
+	"github.com/hodgesds/dlg/stats"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testPlanExec records the plans it executes and the time they start, and
+// records an operation of the first stage of each plan.
+type testPlanExec struct {
+	mu     sync.Mutex
+	plans  []*config.Plan
+	starts []time.Time
+	err    error
+}
+
+func (e *testPlanExec) Execute(ctx context.Context, p *config.Plan) error {
+	if err := p.WaitStart(ctx); err != nil {
+		return err
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.plans = append(e.plans, p)
+	e.starts = append(e.starts, time.Now())
+	stats.FromContext(ctx).Record(stats.Result{
+		Time:     time.Now(),
+		Stage:    p.Stages[0].Name,
+		Protocol: "http",
+		Latency:  time.Millisecond,
+		Err:      e.err,
+	})
+	return e.err
+}
+
+func testPlan(agents int) *config.Plan {
+	return &config.Plan{
+		Name:        "distributed",
+		Executors:   3,
+		Distributed: &config.Distributed{Agents: agents},
+		Stages: []*config.Stage{
+			{
+				Name: "http",
+				Rate: 90,
+				HTTP: &httpconf.Config{},
+			},
+		},
+	}
+}
+
+// testCluster starts a controller and n agents with TLS on localhost.
+func testCluster(t *testing.T, n int) (*Controller, []*testPlanExec) {
+	gin.SetMode(gin.TestMode)
+	local := &testPlanExec{}
+	e := gin.New()
+	srv := httptest.NewTLSServer(e)
+	t.Cleanup(srv.Close)
+	// The test servers share a certificate that the client of srv trusts.
+	c := NewController(local, srv.Client().Transport)
+	c.startDelay = 100 * time.Millisecond
+	NewControllerRouter(e, c)
+
+	execs := make([]*testPlanExec, n)
+	for i := range execs {
+		execs[i] = &testPlanExec{}
+		e := gin.New()
+		token, err := NewAgentToken()
+		require.NoError(t, err)
+		NewAgentRouter(e, execs[i], token)
+		agent := httptest.NewTLSServer(e)
+		t.Cleanup(agent.Close)
+		a := Agent{ID: string(rune('a' + i)), URL: agent.URL, Token: token}
+		require.NoError(t, register(context.Background(), srv.Client(), srv.URL, a))
+	}
+	return c, append([]*testPlanExec{local}, execs...)
+}
+
+// TestControllerAgents tests agents expire when they stop registering.
+func TestControllerAgents(t *testing.T) {
+	c := NewController(nil, nil)
+	now := time.Unix(1000, 0)
+	c.now = func() time.Time { return now }
+	require.NoError(t, c.Register(Agent{ID: "b", URL: "https://b"}))
+	require.NoError(t, c.Register(Agent{ID: "a", URL: "https://a"}))
+	agents := c.Agents()
+	require.Len(t, agents, 2)
+	assert.Equal(t, "a", agents[0].ID)
+	assert.Equal(t, now, agents[0].Seen)
+
+	now = now.Add(2 * RegisterInterval)
+	require.NoError(t, c.Register(Agent{ID: "b", URL: "https://b"}))
+	now = now.Add(2 * RegisterInterval)
+	agents = c.Agents()
+	require.Len(t, agents, 1)
+	assert.Equal(t, "b", agents[0].ID)
+}
+
+// TestControllerInsecure tests agents with http URLs only register with an
+// Insecure controller.
+func TestControllerInsecure(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c := NewController(nil, nil)
+	e := gin.New()
+	NewControllerRouter(e, c)
+	register := func(url string) int {
+		w := httptest.NewRecorder()
+		body := strings.NewReader(`{"id":"a","url":"` + url + `"}`)
+		e.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/agents", body))
+		return w.Code
+	}
+	assert.Equal(t, 400, register("http://a"))
+	assert.Equal(t, 400, register("ftp://a"))
+	assert.Empty(t, c.Agents())
+
+	c.Insecure = true
+	assert.Equal(t, 200, register("http://a"))
+	assert.Equal(t, 400, register("ftp://a"))
+	assert.Len(t, c.Agents(), 1)
+}
+
+// TestControllerExecute tests a plan is split between agents which start
+// at the same time and their results are merged.
+func TestControllerExecute(t *testing.T) {
+	c, execs := testCluster(t, 3)
+	require.Len(t, c.Agents(), 3)
+
+	plan := testPlan(2)
+	collector := stats.NewCollector()
+	require.NoError(t, c.Execute(stats.NewContext(context.Background(), collector), plan))
+	assert.Equal(t, []string{"a", "b"}, collector.Sources())
+	results := collector.Stages()
+	require.Len(t, results, 1)
+	assert.Equal(t, int64(2), results[0].Main.Ops)
+	assert.Equal(t, int64(1), collector.SourceStages("b")[0].Main.Ops)
+	assert.Equal(t, config.Complete, plan.Status().State())
+	assert.Empty(t, execs[0].plans)
+	assert.Empty(t, execs[3].plans)
+	for i, executors := range []int{2, 1} {
+		exec := execs[i+1]
+		require.Len(t, exec.plans, 1)
+		assert.Nil(t, exec.plans[0].Distributed)
+		assert.Equal(t, executors, exec.plans[0].Executors)
+		assert.Equal(t, 45.0, exec.plans[0].Stages[0].Rate)
+		assert.True(t, exec.plans[0].Start.Equal(*execs[1].plans[0].Start))
+	}
+	assert.WithinDuration(t, execs[1].starts[0], execs[2].starts[0], 50*time.Millisecond)
+
+	err := c.Execute(context.Background(), testPlan(4))
+	require.EqualError(t, err, "plan requires 4 agents, 3 registered")
+
+	// Plans that are not distributed run locally.
+	require.NoError(t, c.Execute(context.Background(), testPlan(0)))
+	assert.Len(t, execs[0].plans, 1)
+}
+
+// TestControllerExecuteError tests the errors of agents fail the plan.
+func TestControllerExecuteError(t *testing.T) {
+	c, execs := testCluster(t, 2)
+	execs[2].err = errors.New("fail")
+	collector := stats.NewCollector()
+	err := c.Execute(stats.NewContext(context.Background(), collector), testPlan(2))
+	require.EqualError(t, err, "agent b: fail")
+	assert.Len(t, execs[1].plans, 1)
+	results := collector.Stages()
+	require.Len(t, results, 1)
+	assert.Equal(t, int64(1), results[0].Main.Errors)
+}
+
+// TestAgentToken tests agents only run plans with their token and the
+// tokens of agents are not listed.
+func TestAgentToken(t *testing.T) {
+	c, execs := testCluster(t, 1)
+	agent := c.Agents()[0]
+	for _, token := range []string{"", "invalid"} {
+		require.NoError(t, c.Register(Agent{ID: agent.ID, URL: agent.URL, Token: token}))
+		err := c.Execute(context.Background(), testPlan(1))
+		require.EqualError(t, err, "agent a: 401 Unauthorized: invalid agent token")
+	}
+	assert.Empty(t, execs[1].plans)
+
+	e := gin.New()
+	NewControllerRouter(e, c)
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/agents", nil))
+	require.Equal(t, 200, w.Code)
+	assert.Contains(t, w.Body.String(), `"id":"a"`)
+	assert.NotContains(t, w.Body.String(), "token")
+}
+
+// TestSentIntervals tests updates only contain the intervals that changed.
+func TestSentIntervals(t *testing.T) {
+	start := time.Unix(1000, 0)
+	c := stats.NewCollector()
+	c.Record(stats.Result{Time: start, Stage: "a"})
+	c.Record(stats.Result{Time: start.Add(time.Second), Stage: "a"})
+	sent := sentIntervals{}
+	stages := sent.update(c.Stages())
+	require.Len(t, stages, 1)
+	assert.Len(t, stages[0].Intervals, 2)
+	assert.Empty(t, sent.update(c.Stages())[0].Intervals)
+
+	// A result recorded late changes an earlier interval.
+	c.Record(stats.Result{Time: start, Stage: "a"})
+	stages = sent.update(c.Stages())
+	require.Len(t, stages[0].Intervals, 1)
+	assert.True(t, start.Equal(stages[0].Intervals[0].Time))
+	assert.Equal(t, int64(3), stages[0].Main.Ops)
+}
+
+// TestRegister tests agents register until the context is done.
+func TestRegister(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c := NewController(nil, nil)
+	e := gin.New()
+	NewControllerRouter(e, c)
+	srv := httptest.NewServer(e)
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		Register(ctx, nil, srv.URL, Agent{ID: "a", URL: "https://a"})
+		close(done)
+	}()
+	require.Eventually(t, func() bool {
+		return len(c.Agents()) == 1
+	}, time.Second, time.Millisecond)
+	cancel()
+	<-done
+	assert.Empty(t, c.Agents())
+}
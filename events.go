@@ -0,0 +1,173 @@
+package dlg
+
+import (
+	"time"
+
+	"github.com/hodgesds/dlg/config"
+	"github.com/hodgesds/dlg/progress"
+	"github.com/hodgesds/dlg/report"
+)
+
+// EventInterval is the interval progress events of a run are published at.
+const EventInterval = time.Second
+
+// eventBuffer is the number of events a subscriber can fall behind before it
+// is dropped.
+const eventBuffer = 64
+
+// Types of run events.
+const (
+	// EventSnapshot is the first event of a subscription with the status,
+	// progress and threshold breaches of the run so far.
+	EventSnapshot = "snapshot"
+	// EventProgress is published every EventInterval.
+	EventProgress = "progress"
+	// EventStage is published when a stage changes state.
+	EventStage = "stage"
+	// EventThreshold is published when thresholds of a stage start
+	// failing.
+	EventThreshold = "threshold"
+	// EventDone is the last event of a run with its final status.
+	EventDone = "done"
+)
+
+// Event is an event of a run.
+type Event struct {
+	Type string    `json:"type"`
+	Run  string    `json:"run"`
+	Time time.Time `json:"time"`
+	// Status is set on snapshot and done events.
+	Status *RunStatus `json:"status,omitempty"`
+	// Progress is set on snapshot and progress events.
+	Progress *progress.Snapshot `json:"progress,omitempty"`
+	// Stage and State are set on stage events.
+	Stage string                `json:"stage,omitempty"`
+	State config.ExecutionState `json:"state,omitempty"`
+	// Checks are the failed threshold checks of snapshot and threshold
+	// events.
+	Checks []report.Check `json:"checks,omitempty"`
+}
+
+// Subscribe returns a snapshot event of the run and a channel of its later
+// events, which is closed after the done event or when cancel is called. A
+// subscriber that falls behind is dropped by closing its channel.
+func (run *Run) Subscribe() (Event, <-chan Event, func()) {
+	events := make(chan Event, eventBuffer)
+	run.mu.Lock()
+	if run.subs == nil {
+		close(events)
+	} else {
+		run.subs[events] = struct{}{}
+	}
+	run.mu.Unlock()
+
+	now := time.Now()
+	status := run.Status()
+	snap := run.progress.Snapshot(now)
+	return Event{
+		Type:     EventSnapshot,
+		Run:      run.ID,
+		Time:     now,
+		Status:   &status,
+		Progress: &snap,
+		Checks:   run.breaches(),
+	}, events, func() {
+		run.mu.Lock()
+		defer run.mu.Unlock()
+		if _, ok := run.subs[events]; ok {
+			delete(run.subs, events)
+			close(events)
+		}
+	}
+}
+
+func (run *Run) publish(e Event) {
+	e.Run = run.ID
+	run.mu.Lock()
+	defer run.mu.Unlock()
+	for events := range run.subs {
+		select {
+		case events <- e:
+		default:
+			delete(run.subs, events)
+			close(events)
+		}
+	}
+}
+
+// watch publishes the events of the run until it completes.
+func (run *Run) watch() {
+	ticker := time.NewTicker(EventInterval)
+	defer ticker.Stop()
+	states := map[string]config.ExecutionState{}
+	failed := map[string]bool{}
+	for {
+		select {
+		case <-ticker.C:
+		case <-run.done:
+		}
+		now := time.Now()
+		snap := run.progress.Snapshot(now)
+		for _, s := range snap.Stages {
+			if states[s.Name] != s.State {
+				states[s.Name] = s.State
+				run.publish(Event{Type: EventStage, Time: now, Stage: s.Name, State: s.State})
+			}
+		}
+		run.publish(Event{Type: EventProgress, Time: now, Progress: &snap})
+
+		var breaches []report.Check
+		failing := map[string]bool{}
+		for _, c := range run.breaches() {
+			key := c.Stage + " " + c.Threshold
+			failing[key] = true
+			if !failed[key] {
+				breaches = append(breaches, c)
+			}
+		}
+		failed = failing
+		if len(breaches) > 0 {
+			run.publish(Event{Type: EventThreshold, Time: now, Checks: breaches})
+		}
+
+		if run.finished() {
+			status := run.Status()
+			run.publish(Event{Type: EventDone, Time: now, Status: &status})
+			run.mu.Lock()
+			for events := range run.subs {
+				close(events)
+			}
+			run.subs = nil
+			run.mu.Unlock()
+			return
+		}
+	}
+}
+
+// breaches returns the failed threshold checks of the stages with results.
+func (run *Run) breaches() []report.Check {
+	results := run.collector.Stages()
+	measured := map[string]bool{}
+	for _, r := range results {
+		measured[r.Name] = r.Main.Ops > 0
+	}
+	var walk func(s *config.Stage) bool
+	walk = func(s *config.Stage) bool {
+		ok := measured[s.Name]
+		for _, child := range s.Children {
+			ok = walk(child) || ok
+		}
+		measured[s.Name] = ok
+		return ok
+	}
+	for _, s := range run.Plan.Stages {
+		walk(s)
+	}
+	var failed []report.Check
+	for _, c := range report.Checks(run.Plan, results) {
+		if !c.Pass && measured[c.Stage] {
+			failed = append(failed, c)
+		}
+	}
+	return failed
+}
@@ -0,0 +1,162 @@
+package dlg
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/hodgesds/dlg/config"
+	"github.com/hodgesds/dlg/stats"
+	"github.com/hodgesds/dlg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testEventsExec runs the first stage of a plan with a failed operation
+// until it is canceled.
+type testEventsExec struct{}
+
+func (testEventsExec) Execute(ctx context.Context, p *config.Plan) error {
+	p.Status().SetState(config.Running)
+	p.Stages[0].Status().SetState(config.Running)
+	stats.FromContext(ctx).Record(stats.Result{
+		Time:    time.Now(),
+		Stage:   p.Stages[0].Name,
+		Latency: time.Millisecond,
+		Err:     errors.New("fail"),
+	})
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func testEventsRun(t *testing.T) *Run {
+	m := NewManager(testEventsExec{})
+	require.NoError(t, m.Add(context.Background(), &config.Plan{
+		Name: "events",
+		Stages: []*config.Stage{{
+			Name:       "get",
+			Thresholds: &config.Thresholds{MaxErrorRate: util.Float64Ptr(0)},
+		}},
+	}))
+	runs := NewRuns(m, RunsConfig{})
+	t.Cleanup(func() { runs.Close() })
+	run, err := runs.Start(context.Background(), "events")
+	require.NoError(t, err)
+	return run
+}
+
+// nextEvent returns the next event of a type and the events before it.
+func nextEvent(t *testing.T, events <-chan Event, typ string) (Event, []Event) {
+	var skipped []Event
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case e, ok := <-events:
+			require.True(t, ok, "events closed before a %s event", typ)
+			if e.Type == typ {
+				return e, skipped
+			}
+			skipped = append(skipped, e)
+		case <-timeout:
+			t.Fatalf("no %s event", typ)
+		}
+	}
+}
+
+// TestRunEvents tests the events of a run and the snapshot of late
+// subscribers.
+func TestRunEvents(t *testing.T) {
+	run := testEventsRun(t)
+	snap, events, cancel := run.Subscribe()
+	defer cancel()
+	assert.Equal(t, EventSnapshot, snap.Type)
+	assert.Equal(t, run.ID, snap.Run)
+	require.NotNil(t, snap.Status)
+	require.NotNil(t, snap.Progress)
+
+	e, skipped := nextEvent(t, events, EventProgress)
+	require.NotEmpty(t, skipped)
+	assert.Equal(t, EventStage, skipped[0].Type)
+	assert.Equal(t, "get", skipped[0].Stage)
+	assert.Equal(t, config.Running, skipped[0].State)
+	require.Len(t, e.Progress.Stages, 1)
+	assert.Equal(t, int64(1), e.Progress.Stages[0].Errors)
+	e, _ = nextEvent(t, events, EventThreshold)
+	require.Len(t, e.Checks, 1)
+	assert.Equal(t, "error rate", e.Checks[0].Threshold)
+
+	late, lateEvents, lateCancel := run.Subscribe()
+	defer lateCancel()
+	assert.Equal(t, int64(1), late.Progress.Stages[0].Ops)
+	require.Len(t, late.Checks, 1)
+	assert.Len(t, late.Status.Stages, 1)
+
+	require.NoError(t, run.Cancel())
+	for _, events := range []<-chan Event{events, lateEvents} {
+		e, _ = nextEvent(t, events, EventDone)
+		assert.Equal(t, RunCanceled, e.Status.State)
+		_, ok := <-events
+		assert.False(t, ok)
+	}
+
+	snap, events, _ = run.Subscribe()
+	assert.Equal(t, RunCanceled, snap.Status.State)
+	_, ok := <-events
+	assert.False(t, ok)
+}
+
+// TestRunEventsStreams tests streaming events over SSE and WebSocket.
+func TestRunEventsStreams(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	run := testEventsRun(t)
+	runs := &Runs{runs: map[string]*Run{run.ID: run}}
+	e := gin.New()
+	NewRunsRouter(e, runs)
+	srv := httptest.NewServer(e)
+	defer srv.Close()
+
+	res, err := srv.Client().Get(srv.URL + "/runs/" + run.ID + "/events")
+	require.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+	sse := bufio.NewScanner(res.Body)
+	sse.Buffer(nil, 1<<20)
+	require.True(t, sse.Scan())
+	assert.Equal(t, "event:snapshot", sse.Text())
+
+	ws, _, err := websocket.DefaultDialer.Dial(
+		"ws"+strings.TrimPrefix(srv.URL, "http")+"/runs/"+run.ID+"/ws", nil,
+	)
+	require.NoError(t, err)
+	defer ws.Close()
+	var snap Event
+	require.NoError(t, ws.ReadJSON(&snap))
+	assert.Equal(t, EventSnapshot, snap.Type)
+
+	require.NoError(t, run.Cancel())
+	var types []string
+	for sse.Scan() {
+		if strings.HasPrefix(sse.Text(), "event:") {
+			types = append(types, strings.TrimPrefix(sse.Text(), "event:"))
+		}
+	}
+	require.NotEmpty(t, types)
+	assert.Equal(t, EventDone, types[len(types)-1])
+
+	var last Event
+	for {
+		var e Event
+		if err := ws.ReadJSON(&e); err != nil {
+			assert.True(t, websocket.IsCloseError(err, websocket.CloseNormalClosure))
+			break
+		}
+		last = e
+	}
+	assert.Equal(t, EventDone, last.Type)
+}
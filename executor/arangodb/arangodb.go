@@ -7,6 +7,7 @@ import (
 	driver "github.com/arangodb/go-driver"
 	"github.com/arangodb/go-driver/http"
 	arangoconfig "github.com/hodgesds/dlg/config/arangodb"
+	"github.com/hodgesds/dlg/executor"
 )
 
 type arangoExecutor struct{}
@@ -43,6 +44,12 @@ func (e *arangoExecutor) Execute(ctx context.Context, config *arangoconfig.Confi
 		return err
 	}
 
+	return executor.Run(ctx, config.Operation, 1, func(ctx context.Context, _ []byte) (int64, error) {
+		return 0, e.exec(ctx, db, config)
+	})
+}
+
+func (e *arangoExecutor) exec(ctx context.Context, db driver.Database, config *arangoconfig.Config) error {
 	switch config.Operation {
 	case "query":
 		if config.Query == "" {
@@ -50,7 +57,7 @@ func (e *arangoExecutor) Execute(ctx context.Context, config *arangoconfig.Confi
 		}
 		cursor, err := db.Query(ctx, config.Query, config.BindVars)
 		if err != nil {
-			return err
+			return executor.Failed(err)
 		}
 		defer cursor.Close()
 		var doc interface{}
@@ -58,7 +65,7 @@ func (e *arangoExecutor) Execute(ctx context.Context, config *arangoconfig.Confi
 		if driver.IsNoMoreDocuments(err) {
 			return nil
 		}
-		return err
+		return executor.Failed(err)
 
 	case "insert":
 		if config.Collection == "" || config.Document == nil {
@@ -66,10 +73,10 @@ func (e *arangoExecutor) Execute(ctx context.Context, config *arangoconfig.Confi
 		}
 		col, err := db.Collection(ctx, config.Collection)
 		if err != nil {
-			return err
+			return executor.Failed(err)
 		}
 		_, err = col.CreateDocument(ctx, config.Document)
-		return err
+		return executor.Failed(err)
 
 	case "update":
 		if config.Collection == "" || config.Key == "" || config.Document == nil {
@@ -77,10 +84,10 @@ func (e *arangoExecutor) Execute(ctx context.Context, config *arangoconfig.Confi
 		}
 		col, err := db.Collection(ctx, config.Collection)
 		if err != nil {
-			return err
+			return executor.Failed(err)
 		}
 		_, err = col.UpdateDocument(ctx, config.Key, config.Document)
-		return err
+		return executor.Failed(err)
 
 	case "delete":
 		if config.Collection == "" || config.Key == "" {
@@ -88,10 +95,10 @@ func (e *arangoExecutor) Execute(ctx context.Context, config *arangoconfig.Confi
 		}
 		col, err := db.Collection(ctx, config.Collection)
 		if err != nil {
-			return err
+			return executor.Failed(err)
 		}
 		_, err = col.RemoveDocument(ctx, config.Key)
-		return err
+		return executor.Failed(err)
 
 	default:
 		return fmt.Errorf("unknown operation: %s", config.Operation)
@@ -2,6 +2,7 @@ package cassandra
 
 import (
 	"context"
+	"strings"
 	"time"
 
 	cassandraconfig "github.com/hodgesds/dlg/config/cassandra"
@@ -9,15 +10,89 @@ import (
 	"github.com/gocql/gocql"
 )
 
-type cassandraExecutor struct{}
+// clientKey contains the config fields used to create a session.
+type clientKey struct {
+	hosts          string
+	keyspace       string
+	consistency    cassandraconfig.Consistency
+	username       string
+	password       string
+	connectTimeout time.Duration
+	timeout        time.Duration
+	numConns       int
+}
+
+type cassandraExecutor struct {
+	*executor.ClientCache[clientKey, *gocql.Session]
+}
 
 // New returns a new Cassandra executor.
 func New() executor.Cassandra {
-	return &cassandraExecutor{}
+	return &cassandraExecutor{
+		ClientCache: executor.NewClientCache[clientKey](
+			func(s *gocql.Session) error {
+				s.Close()
+				return nil
+			},
+		),
+	}
 }
 
 // Execute implements the Cassandra executor interface.
 func (e *cassandraExecutor) Execute(ctx context.Context, config *cassandraconfig.Config) error {
+	key := clientKey{
+		hosts:       strings.Join(config.Hosts, ","),
+		keyspace:    config.Keyspace,
+		consistency: config.Consistency,
+		username:    config.Username,
+		password:    config.Password,
+	}
+	if config.ConnectTimeout != nil {
+		key.connectTimeout = *config.ConnectTimeout
+	}
+	if config.Timeout != nil {
+		key.timeout = *config.Timeout
+	}
+	if config.NumConns != nil {
+		key.numConns = *config.NumConns
+	}
+	session, release, err := e.Get(ctx, key, func(context.Context) (*gocql.Session, error) {
+		return newSession(config)
+	})
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	// Execute the configured number of iterations, each iteration
+	// executes all queries in sequence.
+	return executor.Run(ctx, "queries", config.Count, func(ctx context.Context, _ []byte) (int64, error) {
+		for _, q := range config.Queries {
+			query := session.Query(q.CQL, q.Values...)
+
+			if q.Scan {
+				// For SELECT queries, scan results
+				iter := query.WithContext(ctx).Iter()
+				row := map[string]interface{}{}
+				for iter.MapScan(row) {
+					// Consume results
+					row = make(map[string]interface{})
+				}
+				if err := iter.Close(); err != nil {
+					return 0, executor.Failed(err)
+				}
+			} else {
+				// For INSERT/UPDATE/DELETE queries
+				if err := query.WithContext(ctx).Exec(); err != nil {
+					return 0, executor.Failed(err)
+				}
+			}
+		}
+		return 0, nil
+	})
+}
+
+func newSession(config *cassandraconfig.Config) (*gocql.Session, error) {
 	cluster := gocql.NewCluster(config.Hosts...)
 	cluster.Keyspace = config.Keyspace
 
@@ -64,45 +139,5 @@ func (e *cassandraExecutor) Execute(ctx context.Context, config *cassandraconfig
 		cluster.Consistency = gocql.Quorum
 	}
 
-	session, err := cluster.CreateSession()
-	if err != nil {
-		return err
-	}
-	defer session.Close()
-
-	// Execute the configured number of iterations
-	for i := 0; i < config.Count; i++ {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-			// Execute all queries in sequence
-			for _, q := range config.Queries {
-				query := session.Query(q.CQL, q.Values...)
-
-				if q.Scan {
-					// For SELECT queries, scan results
-					iter := query.Iter()
-					var row map[string]interface{}
-					for iter.MapScan(row) {
-						// Consume results
-						row = make(map[string]interface{})
-					}
-					if err := iter.Close(); err != nil {
-						return err
-					}
-				} else {
-					// For INSERT/UPDATE/DELETE queries
-					if err := query.WithContext(ctx).Exec(); err != nil {
-						return err
-					}
-				}
-
-				// Small delay between queries
-				time.Sleep(10 * time.Millisecond)
-			}
-		}
-	}
-
-	return nil
+	return cluster.CreateSession()
 }
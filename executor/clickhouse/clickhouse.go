@@ -12,20 +12,56 @@ import (
 	"github.com/hodgesds/dlg/executor"
 )
 
-type clickhouseExecutor struct{}
+// clientKey contains the config fields used to open a database.
+type clientKey struct {
+	dsn            string
+	maxOpenConns   int
+	maxIdleConns   int
+	connectTimeout time.Duration
+}
+
+type clickhouseExecutor struct {
+	*executor.ClientCache[clientKey, *sql.DB]
+}
 
 // New returns a new ClickHouse executor.
 func New() executor.ClickHouse {
-	return &clickhouseExecutor{}
+	return &clickhouseExecutor{
+		ClientCache: executor.NewClientCache[clientKey](
+			func(db *sql.DB) error { return db.Close() },
+		),
+	}
 }
 
 // Execute implements the ClickHouse executor interface.
 func (e *clickhouseExecutor) Execute(ctx context.Context, config *clickhouseconfig.Config) error {
+	key := clientKey{
+		dsn:          config.DSN,
+		maxOpenConns: config.MaxOpenConns,
+		maxIdleConns: config.MaxIdleConns,
+	}
+	if config.ConnectTimeout != nil {
+		key.connectTimeout = *config.ConnectTimeout
+	}
+	db, release, err := e.Get(ctx, key, func(ctx context.Context) (*sql.DB, error) {
+		return open(ctx, config)
+	})
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	// Execute the configured number of operations
+	return executor.Run(ctx, string(config.Operation), config.Count, func(ctx context.Context, _ []byte) (int64, error) {
+		return 0, e.executeOperation(ctx, db, config)
+	})
+}
+
+func open(ctx context.Context, config *clickhouseconfig.Config) (*sql.DB, error) {
 	db, err := sql.Open("clickhouse", config.DSN)
 	if err != nil {
-		return fmt.Errorf("failed to open connection: %w", err)
+		return nil, fmt.Errorf("failed to open connection: %w", err)
 	}
-	defer db.Close()
 
 	if config.MaxOpenConns > 0 {
 		db.SetMaxOpenConns(config.MaxOpenConns)
@@ -41,22 +77,10 @@ func (e *clickhouseExecutor) Execute(ctx context.Context, config *clickhouseconf
 
 	// Ping to verify connection
 	if err := db.PingContext(ctx); err != nil {
-		return fmt.Errorf("failed to ping database: %w", err)
-	}
-
-	// Execute the configured number of operations
-	for i := 0; i < config.Count; i++ {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-			if err := e.executeOperation(ctx, db, config); err != nil {
-				return err
-			}
-		}
+		db.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
-
-	return nil
+	return db, nil
 }
 
 func (e *clickhouseExecutor) executeOperation(ctx context.Context, db *sql.DB, config *clickhouseconfig.Config) error {
@@ -107,7 +131,7 @@ func (e *clickhouseExecutor) executeInsert(ctx context.Context, db *sql.DB, conf
 	)
 
 	_, err := db.ExecContext(ctx, query, values...)
-	return err
+	return executor.Failed(err)
 }
 
 func (e *clickhouseExecutor) executeBatchInsert(ctx context.Context, db *sql.DB, config *clickhouseconfig.Config) error {
@@ -142,7 +166,7 @@ func (e *clickhouseExecutor) executeBatchInsert(ctx context.Context, db *sql.DB,
 func (e *clickhouseExecutor) insertBatch(ctx context.Context, db *sql.DB, config *clickhouseconfig.Config, batch [][]interface{}) error {
 	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
-		return err
+		return executor.Failed(err)
 	}
 	defer tx.Rollback()
 
@@ -161,17 +185,17 @@ func (e *clickhouseExecutor) insertBatch(ctx context.Context, db *sql.DB, config
 
 	stmt, err := tx.PrepareContext(ctx, query)
 	if err != nil {
-		return err
+		return executor.Failed(err)
 	}
 	defer stmt.Close()
 
 	for _, row := range batch {
 		if _, err := stmt.ExecContext(ctx, row...); err != nil {
-			return err
+			return executor.Failed(err)
 		}
 	}
 
-	return tx.Commit()
+	return executor.Failed(tx.Commit())
 }
 
 func (e *clickhouseExecutor) executeSelect(ctx context.Context, db *sql.DB, config *clickhouseconfig.Config) error {
@@ -182,7 +206,7 @@ func (e *clickhouseExecutor) executeSelect(ctx context.Context, db *sql.DB, conf
 
 	rows, err := db.QueryContext(ctx, query)
 	if err != nil {
-		return err
+		return executor.Failed(err)
 	}
 	defer rows.Close()
 
@@ -191,7 +215,7 @@ func (e *clickhouseExecutor) executeSelect(ctx context.Context, db *sql.DB, conf
 		// Just iterate, don't need to scan
 	}
 
-	return rows.Err()
+	return executor.Failed(rows.Err())
 }
 
 func (e *clickhouseExecutor) executeCount(ctx context.Context, db *sql.DB, config *clickhouseconfig.Config) error {
@@ -202,7 +226,7 @@ func (e *clickhouseExecutor) executeCount(ctx context.Context, db *sql.DB, confi
 
 	var count int64
 	err := db.QueryRowContext(ctx, query).Scan(&count)
-	return err
+	return executor.Failed(err)
 }
 
 func (e *clickhouseExecutor) executeCreateTable(ctx context.Context, db *sql.DB, config *clickhouseconfig.Config) error {
@@ -211,11 +235,11 @@ func (e *clickhouseExecutor) executeCreateTable(ctx context.Context, db *sql.DB,
 	}
 
 	_, err := db.ExecContext(ctx, config.TableSchema)
-	return err
+	return executor.Failed(err)
 }
 
 func (e *clickhouseExecutor) executeOptimize(ctx context.Context, db *sql.DB, config *clickhouseconfig.Config) error {
 	query := fmt.Sprintf("OPTIMIZE TABLE %s.%s", config.Database, config.Table)
 	_, err := db.ExecContext(ctx, query)
-	return err
+	return executor.Failed(err)
 }
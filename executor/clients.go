@@ -0,0 +1,215 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"go.uber.org/multierr"
+)
+
+// Lifecycle is implemented by executors that hold resources across
+// executions outside of a plan executor, which closes the clients dialed
+// during each of its executions itself.
+type Lifecycle interface {
+	Init(context.Context) error
+	Close() error
+}
+
+type churnKey struct{}
+
+// WithChurn returns a context where clients are not reused, every execution
+// dials a new client and closes it when done.
+func WithChurn(ctx context.Context) context.Context {
+	return context.WithValue(ctx, churnKey{}, true)
+}
+
+// Churn returns if connection churn is enabled for the context.
+func Churn(ctx context.Context) bool {
+	churn, _ := ctx.Value(churnKey{}).(bool)
+	return churn
+}
+
+type cachedClient[C any] struct {
+	ready  chan struct{}
+	client C
+	err    error
+}
+
+type clientsKey struct{}
+
+// Clients are the clients dialed by executors during an execution of a plan.
+// Executions that overlap, such as concurrent runs of a server, each have
+// their own clients, so one execution completing does not close the clients
+// of another.
+type Clients struct {
+	mu   sync.Mutex
+	sets map[interface{}]io.Closer
+}
+
+// WithClients returns a context where the executors dial their clients into
+// a new Clients, which must be closed once the execution completes.
+func WithClients(ctx context.Context) (context.Context, *Clients) {
+	c := &Clients{sets: map[interface{}]io.Closer{}}
+	return context.WithValue(ctx, clientsKey{}, c), c
+}
+
+// Close closes the clients.
+func (c *Clients) Close() error {
+	c.mu.Lock()
+	sets := c.sets
+	c.sets = map[interface{}]io.Closer{}
+	c.mu.Unlock()
+
+	var err error
+	for _, set := range sets {
+		err = multierr.Append(err, set.Close())
+	}
+	return err
+}
+
+// clientSet is a set of cached clients.
+type clientSet[K comparable, C any] struct {
+	mu      sync.Mutex
+	clients map[K]*cachedClient[C]
+	close   func(C) error
+}
+
+func newClientSet[K comparable, C any](close func(C) error) *clientSet[K, C] {
+	return &clientSet[K, C]{
+		clients: map[K]*cachedClient[C]{},
+		close:   close,
+	}
+}
+
+func (s *clientSet[K, C]) get(
+	ctx context.Context,
+	key K,
+	dial func(context.Context) (C, error),
+) (C, error) {
+	s.mu.Lock()
+	cached, ok := s.clients[key]
+	if !ok {
+		cached = &cachedClient[C]{ready: make(chan struct{})}
+		s.clients[key] = cached
+	}
+	s.mu.Unlock()
+
+	if ok {
+		select {
+		case <-cached.ready:
+		case <-ctx.Done():
+			var client C
+			return client, ctx.Err()
+		}
+	} else {
+		cached.client, cached.err = dial(ctx)
+		close(cached.ready)
+	}
+	if cached.err != nil {
+		// Drop failed clients so the next execution dials again.
+		s.mu.Lock()
+		if s.clients[key] == cached {
+			delete(s.clients, key)
+		}
+		s.mu.Unlock()
+		var client C
+		return client, cached.err
+	}
+	return cached.client, nil
+}
+
+func (s *clientSet[K, C]) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.clients)
+}
+
+// Close closes the clients of the set, it can be used again after.
+func (s *clientSet[K, C]) Close() error {
+	s.mu.Lock()
+	clients := s.clients
+	s.clients = map[K]*cachedClient[C]{}
+	s.mu.Unlock()
+
+	var err error
+	for _, cached := range clients {
+		<-cached.ready
+		if cached.err != nil {
+			continue
+		}
+		err = multierr.Append(err, s.close(cached.client))
+	}
+	return err
+}
+
+// ClientCache is a cache of clients keyed by their connection config. It is
+// used by executors so that clients persist across stage repeats instead of
+// being dialed on every execution. Keys must contain every field used to
+// dial a client. Clients are cached in the Clients of the context, or in
+// the cache itself if the context has none.
+type ClientCache[K comparable, C any] struct {
+	set   *clientSet[K, C]
+	close func(C) error
+}
+
+// NewClientCache returns a new ClientCache, close is called for every client
+// when it is no longer used.
+func NewClientCache[K comparable, C any](close func(C) error) *ClientCache[K, C] {
+	return &ClientCache[K, C]{
+		set:   newClientSet[K](close),
+		close: close,
+	}
+}
+
+// clients returns the set of clients of the context.
+func (c *ClientCache[K, C]) clients(ctx context.Context) *clientSet[K, C] {
+	clients, ok := ctx.Value(clientsKey{}).(*Clients)
+	if !ok {
+		return c.set
+	}
+	clients.mu.Lock()
+	defer clients.mu.Unlock()
+	set, ok := clients.sets[c]
+	if !ok {
+		set = newClientSet[K](c.close)
+		clients.sets[c] = set
+	}
+	return set.(*clientSet[K, C])
+}
+
+// Get returns the client for the key, dialing one if none is cached. The
+// returned release func must be called once the client is no longer used by
+// the caller, it only closes the client when churn is enabled.
+func (c *ClientCache[K, C]) Get(
+	ctx context.Context,
+	key K,
+	dial func(context.Context) (C, error),
+) (C, func(), error) {
+	if Churn(ctx) {
+		client, err := dial(ctx)
+		if err != nil {
+			return client, func() {}, err
+		}
+		return client, func() { c.close(client) }, nil
+	}
+	client, err := c.clients(ctx).get(ctx, key, dial)
+	return client, func() {}, err
+}
+
+// Len returns the number of clients cached in the cache itself.
+func (c *ClientCache[K, C]) Len() int {
+	return c.set.len()
+}
+
+// Init implements the Lifecycle interface, clients are dialed lazily on
+// first use.
+func (c *ClientCache[K, C]) Init(ctx context.Context) error {
+	return nil
+}
+
+// Close implements the Lifecycle interface, it closes the clients cached in
+// the cache itself. The cache can be used again after it is closed.
+func (c *ClientCache[K, C]) Close() error {
+	return c.set.Close()
+}
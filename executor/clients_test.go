@@ -0,0 +1,158 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testClient struct {
+	id     int
+	closed bool
+}
+
+func newTestCache() (*ClientCache[string, *testClient], func(context.Context) (*testClient, error), *int) {
+	dials := 0
+	cache := NewClientCache[string](func(c *testClient) error {
+		c.closed = true
+		return nil
+	})
+	dial := func(context.Context) (*testClient, error) {
+		dials++
+		return &testClient{id: dials}, nil
+	}
+	return cache, dial, &dials
+}
+
+// TestClientCacheReuse tests that clients are reused for the same key.
+func TestClientCacheReuse(t *testing.T) {
+	cache, dial, dials := newTestCache()
+	ctx := context.Background()
+
+	c1, release, err := cache.Get(ctx, "a", dial)
+	require.NoError(t, err)
+	release()
+	c2, release, err := cache.Get(ctx, "a", dial)
+	require.NoError(t, err)
+	release()
+	require.Same(t, c1, c2)
+	require.False(t, c1.closed)
+
+	c3, _, err := cache.Get(ctx, "b", dial)
+	require.NoError(t, err)
+	require.NotSame(t, c1, c3)
+	require.Equal(t, 2, *dials)
+	require.Equal(t, 2, cache.Len())
+
+	require.NoError(t, cache.Close())
+	require.True(t, c1.closed)
+	require.True(t, c3.closed)
+	require.Equal(t, 0, cache.Len())
+
+	// The cache can be used again after it is closed.
+	c4, _, err := cache.Get(ctx, "a", dial)
+	require.NoError(t, err)
+	require.NotSame(t, c1, c4)
+}
+
+// TestClientCacheChurn tests that clients are not cached with churn.
+func TestClientCacheChurn(t *testing.T) {
+	cache, dial, dials := newTestCache()
+	ctx := WithChurn(context.Background())
+	require.True(t, Churn(ctx))
+	require.False(t, Churn(context.Background()))
+
+	c1, release, err := cache.Get(ctx, "a", dial)
+	require.NoError(t, err)
+	require.False(t, c1.closed)
+	release()
+	require.True(t, c1.closed)
+
+	c2, release, err := cache.Get(ctx, "a", dial)
+	require.NoError(t, err)
+	release()
+	require.NotSame(t, c1, c2)
+	require.Equal(t, 2, *dials)
+	require.Equal(t, 0, cache.Len())
+}
+
+// TestClientCacheDialError tests that failed dials are not cached.
+func TestClientCacheDialError(t *testing.T) {
+	cache, dial, dials := newTestCache()
+	ctx := context.Background()
+
+	_, _, err := cache.Get(ctx, "a", func(context.Context) (*testClient, error) {
+		return nil, errors.New("dial")
+	})
+	require.Error(t, err)
+	require.Equal(t, 0, cache.Len())
+
+	c, _, err := cache.Get(ctx, "a", dial)
+	require.NoError(t, err)
+	require.NotNil(t, c)
+	require.Equal(t, 1, *dials)
+	require.NoError(t, cache.Close())
+}
+
+// TestClientCacheConcurrent tests that concurrent callers share one client.
+func TestClientCacheConcurrent(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		dials int
+		wg    sync.WaitGroup
+	)
+	cache := NewClientCache[string](func(*testClient) error { return nil })
+	clients := make([]*testClient, 16)
+	for i := range clients {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			c, release, err := cache.Get(
+				context.Background(),
+				"a",
+				func(context.Context) (*testClient, error) {
+					mu.Lock()
+					defer mu.Unlock()
+					dials++
+					return &testClient{id: dials}, nil
+				},
+			)
+			assert.NoError(t, err)
+			release()
+			clients[i] = c
+		}(i)
+	}
+	wg.Wait()
+	require.Equal(t, 1, dials)
+	for _, c := range clients {
+		require.Same(t, clients[0], c)
+	}
+}
+
+// TestClients tests executions with their own Clients do not share clients
+// and closing them leaves the clients of other executions open.
+func TestClients(t *testing.T) {
+	cache, dial, _ := newTestCache()
+	ctx1, clients1 := WithClients(context.Background())
+	ctx2, clients2 := WithClients(context.Background())
+
+	c1, _, err := cache.Get(ctx1, "a", dial)
+	require.NoError(t, err)
+	c2, _, err := cache.Get(ctx2, "a", dial)
+	require.NoError(t, err)
+	require.NotSame(t, c1, c2)
+	require.Equal(t, 0, cache.Len())
+
+	require.NoError(t, clients1.Close())
+	require.True(t, c1.closed)
+	require.False(t, c2.closed)
+	c, _, err := cache.Get(ctx2, "a", dial)
+	require.NoError(t, err)
+	require.Same(t, c2, c)
+	require.NoError(t, clients2.Close())
+	require.True(t, c2.closed)
+}
@@ -0,0 +1,105 @@
+package executor
+
+import (
+	"context"
+	"sync"
+)
+
+// Control pauses and resumes the operations of Pools and scales the rate of
+// rate limited stages while a plan runs. The zero value is not usable, use
+// NewControl.
+type Control struct {
+	mu     sync.Mutex
+	scale  float64
+	resume chan struct{}
+}
+
+// NewControl returns a running Control with a rate scale of 1.
+func NewControl() *Control {
+	return &Control{scale: 1}
+}
+
+// Pause stops Pools from starting operations until Resume is called,
+// operations that already started complete.
+func (c *Control) Pause() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.resume == nil {
+		c.resume = make(chan struct{})
+	}
+}
+
+// Resume resumes paused operations.
+func (c *Control) Resume() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.resume != nil {
+		close(c.resume)
+		c.resume = nil
+	}
+}
+
+// Paused returns if operations are paused.
+func (c *Control) Paused() bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.resume != nil
+}
+
+// SetRateScale sets the factor the rate of rate limited stages is
+// multiplied with, it must be positive.
+func (c *Control) SetRateScale(scale float64) {
+	if scale <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.scale = scale
+}
+
+// RateScale returns the factor the rate of rate limited stages is
+// multiplied with.
+func (c *Control) RateScale() float64 {
+	if c == nil {
+		return 1
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.scale
+}
+
+// wait blocks while operations are paused.
+func (c *Control) wait(ctx context.Context) error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	resume := c.resume
+	c.mu.Unlock()
+	if resume == nil {
+		return nil
+	}
+	select {
+	case <-resume:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+type controlKey struct{}
+
+// WithControl returns a context with the Control of the operations of
+// Pools.
+func WithControl(ctx context.Context, c *Control) context.Context {
+	return context.WithValue(ctx, controlKey{}, c)
+}
+
+// ControlFromContext returns the Control of the context or nil.
+func ControlFromContext(ctx context.Context) *Control {
+	c, _ := ctx.Value(controlKey{}).(*Control)
+	return c
+}
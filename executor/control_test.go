@@ -0,0 +1,73 @@
+package executor
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestControlPause tests a paused Control stops Pools from starting
+// operations until it is resumed.
+func TestControlPause(t *testing.T) {
+	c := NewControl()
+	c.Pause()
+	require.True(t, c.Paused())
+	ctx := WithControl(context.Background(), c)
+	require.Equal(t, c, ControlFromContext(ctx))
+
+	var ops int32
+	done := make(chan error)
+	go func() {
+		done <- NewPool(2).Run(ctx, "op", 4, func(context.Context, []byte) (int64, error) {
+			atomic.AddInt32(&ops, 1)
+			return 0, nil
+		})
+	}()
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&ops))
+
+	c.Resume()
+	require.False(t, c.Paused())
+	require.NoError(t, <-done)
+	assert.Equal(t, int32(4), atomic.LoadInt32(&ops))
+}
+
+// TestControlPauseCanceled tests waiting while paused ends when the context
+// is canceled.
+func TestControlPauseCanceled(t *testing.T) {
+	c := NewControl()
+	c.Pause()
+	ctx, cancel := context.WithTimeout(WithControl(context.Background(), c), 20*time.Millisecond)
+	defer cancel()
+	err := NewPool(1).Run(ctx, "op", 1, func(context.Context, []byte) (int64, error) {
+		return 0, nil
+	})
+	require.Equal(t, context.DeadlineExceeded, err)
+}
+
+// TestControlRateScale tests the rate of a context is scaled by its
+// Control.
+func TestControlRateScale(t *testing.T) {
+	var c *Control
+	require.Equal(t, 1.0, c.RateScale())
+	require.False(t, c.Paused())
+
+	c = NewControl()
+	c.SetRateScale(0)
+	require.Equal(t, 1.0, c.RateScale())
+	c.SetRateScale(10)
+	ctx := WithRate(WithControl(context.Background(), c), 5)
+
+	start := time.Now()
+	err := NewPool(1).Run(ctx, "op", 5, func(context.Context, []byte) (int64, error) {
+		return 0, nil
+	})
+	require.NoError(t, err)
+	// Unscaled 5 operations take 800ms, at 50 per second 80ms.
+	assert.Less(t, int64(time.Since(start)), int64(500*time.Millisecond))
+	assert.GreaterOrEqual(t, int64(time.Since(start)), int64(70*time.Millisecond))
+}
@@ -8,6 +8,7 @@ import (
 	kivik "github.com/go-kivik/kivik/v4"
 	_ "github.com/go-kivik/kivik/v4/couchdb"
 	couchconfig "github.com/hodgesds/dlg/config/couchdb"
+	"github.com/hodgesds/dlg/executor"
 )
 
 type couchExecutor struct{}
@@ -36,20 +37,26 @@ func (e *couchExecutor) Execute(ctx context.Context, config *couchconfig.Config)
 
 	db := client.DB(config.Database)
 
+	return executor.Run(ctx, config.Operation, 1, func(ctx context.Context, _ []byte) (int64, error) {
+		return 0, e.exec(ctx, db, config)
+	})
+}
+
+func (e *couchExecutor) exec(ctx context.Context, db *kivik.DB, config *couchconfig.Config) error {
 	switch config.Operation {
 	case "get":
 		if config.DocumentID == "" {
 			return fmt.Errorf("document_id required for get")
 		}
 		var doc interface{}
-		return db.Get(ctx, config.DocumentID).ScanDoc(&doc)
+		return executor.Failed(db.Get(ctx, config.DocumentID).ScanDoc(&doc))
 
 	case "put":
 		if config.DocumentID == "" || config.Document == "" {
 			return fmt.Errorf("document_id and document required for put")
 		}
 		_, err := db.Put(ctx, config.DocumentID, config.Document)
-		return err
+		return executor.Failed(err)
 
 	case "delete":
 		if config.DocumentID == "" {
@@ -58,14 +65,14 @@ func (e *couchExecutor) Execute(ctx context.Context, config *couchconfig.Config)
 		var doc map[string]interface{}
 		err := db.Get(ctx, config.DocumentID).ScanDoc(&doc)
 		if err != nil {
-			return err
+			return executor.Failed(err)
 		}
 		rev, ok := doc["_rev"].(string)
 		if !ok {
-			return fmt.Errorf("failed to get revision")
+			return executor.Failed(fmt.Errorf("failed to get revision"))
 		}
 		_, err = db.Delete(ctx, config.DocumentID, rev)
-		return err
+		return executor.Failed(err)
 
 	case "query":
 		if config.Query == "" {
@@ -75,9 +82,9 @@ func (e *couchExecutor) Execute(ctx context.Context, config *couchconfig.Config)
 		defer rows.Close()
 		if rows.Next() {
 			var doc interface{}
-			return rows.ScanDoc(&doc)
+			return executor.Failed(rows.ScanDoc(&doc))
 		}
-		return rows.Err()
+		return executor.Failed(rows.Err())
 
 	default:
 		return fmt.Errorf("unknown operation: %s", config.Operation)
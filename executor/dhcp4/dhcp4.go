@@ -32,6 +32,12 @@ func New() executor.DHCP4 {
 
 // Execute implements the DHCP4 interface.
 func (e *dhcp4Executor) Execute(ctx context.Context, config *dhcp4config.Config) error {
+	return executor.Run(ctx, "discover", 1, func(ctx context.Context, _ []byte) (int64, error) {
+		return 0, e.exec(ctx, config)
+	})
+}
+
+func (e *dhcp4Executor) exec(ctx context.Context, config *dhcp4config.Config) error {
 	opts := []nclient4.ClientOpt{}
 	if config.Retry != nil {
 		opts = append(opts, nclient4.WithRetry(*config.Retry))
@@ -48,7 +54,7 @@ func (e *dhcp4Executor) Execute(ctx context.Context, config *dhcp4config.Config)
 	}
 	o, err := c.DiscoverOffer(ctx)
 	if err != nil {
-		return err
+		return executor.Failed(err)
 	}
 	fmt.Printf("%+v\n", o)
 	return c.Close()
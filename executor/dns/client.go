@@ -17,8 +17,14 @@ func New() executor.DNS {
 
 // Execute implements the DNS executor interface.
 func (e *dnsExecutor) Execute(ctx context.Context, config *dnsconfig.Config) error {
+	return executor.Run(ctx, "query", 1, func(ctx context.Context, _ []byte) (int64, error) {
+		return 0, e.exec(ctx, config)
+	})
+}
+
+func (e *dnsExecutor) exec(ctx context.Context, config *dnsconfig.Config) error {
 	c := new(dns.Client)
 	m1 := new(dns.Msg)
 	_, _, err := c.Exchange(m1, "127.0.0.1:53")
-	return err
+	return executor.Failed(err)
 }
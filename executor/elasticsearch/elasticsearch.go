@@ -13,15 +13,57 @@ import (
 	"github.com/elastic/go-elasticsearch/v8/esapi"
 )
 
-type elasticsearchExecutor struct{}
+// clientKey contains the config fields used to create a client.
+type clientKey struct {
+	addresses  string
+	username   string
+	password   string
+	cloudID    string
+	apiKey     string
+	maxRetries int
+}
+
+type elasticsearchExecutor struct {
+	*executor.ClientCache[clientKey, *elasticsearch.Client]
+}
 
 // New returns a new Elasticsearch executor.
 func New() executor.Elasticsearch {
-	return &elasticsearchExecutor{}
+	return &elasticsearchExecutor{
+		// Clients only hold a HTTP transport, there is nothing to close.
+		ClientCache: executor.NewClientCache[clientKey](
+			func(*elasticsearch.Client) error { return nil },
+		),
+	}
 }
 
 // Execute implements the Elasticsearch executor interface.
 func (e *elasticsearchExecutor) Execute(ctx context.Context, config *elasticsearchconfig.Config) error {
+	key := clientKey{
+		addresses: strings.Join(config.Addresses, ","),
+		username:  config.Username,
+		password:  config.Password,
+		cloudID:   config.CloudID,
+		apiKey:    config.APIKey,
+	}
+	if config.MaxRetries != nil {
+		key.maxRetries = *config.MaxRetries
+	}
+	client, release, err := e.Get(ctx, key, func(context.Context) (*elasticsearch.Client, error) {
+		return newClient(config)
+	})
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	// Execute the configured number of operations
+	return executor.Run(ctx, string(config.Operation), config.Count, func(ctx context.Context, _ []byte) (int64, error) {
+		return 0, e.executeOperation(ctx, client, config)
+	})
+}
+
+func newClient(config *elasticsearchconfig.Config) (*elasticsearch.Client, error) {
 	cfg := elasticsearch.Config{
 		Addresses: config.Addresses,
 	}
@@ -43,24 +85,7 @@ func (e *elasticsearchExecutor) Execute(ctx context.Context, config *elasticsear
 		cfg.MaxRetries = *config.MaxRetries
 	}
 
-	client, err := elasticsearch.NewClient(cfg)
-	if err != nil {
-		return err
-	}
-
-	// Execute the configured number of operations
-	for i := 0; i < config.Count; i++ {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-			if err := e.executeOperation(ctx, client, config); err != nil {
-				return err
-			}
-		}
-	}
-
-	return nil
+	return elasticsearch.NewClient(cfg)
 }
 
 func (e *elasticsearchExecutor) executeOperation(ctx context.Context, client *elasticsearch.Client, config *elasticsearchconfig.Config) error {
@@ -81,12 +106,12 @@ func (e *elasticsearchExecutor) executeOperation(ctx context.Context, client *el
 
 			res, err := req.Do(ctx, client)
 			if err != nil {
-				return err
+				return executor.Failed(err)
 			}
 			defer res.Body.Close()
 
 			if res.IsError() {
-				return fmt.Errorf("error indexing document: %s", res.Status())
+				return executor.Failed(fmt.Errorf("error indexing document: %s", res.Status()))
 			}
 		}
 
@@ -98,12 +123,12 @@ func (e *elasticsearchExecutor) executeOperation(ctx context.Context, client *el
 
 		res, err := req.Do(ctx, client)
 		if err != nil {
-			return err
+			return executor.Failed(err)
 		}
 		defer res.Body.Close()
 
 		if res.IsError() {
-			return fmt.Errorf("error getting document: %s", res.Status())
+			return executor.Failed(fmt.Errorf("error getting document: %s", res.Status()))
 		}
 
 	case elasticsearchconfig.OpSearch:
@@ -122,12 +147,12 @@ func (e *elasticsearchExecutor) executeOperation(ctx context.Context, client *el
 
 			res, err := req.Do(ctx, client)
 			if err != nil {
-				return err
+				return executor.Failed(err)
 			}
 			defer res.Body.Close()
 
 			if res.IsError() {
-				return fmt.Errorf("error searching: %s", res.Status())
+				return executor.Failed(fmt.Errorf("error searching: %s", res.Status()))
 			}
 		}
 
@@ -148,12 +173,12 @@ func (e *elasticsearchExecutor) executeOperation(ctx context.Context, client *el
 
 			res, err := req.Do(ctx, client)
 			if err != nil {
-				return err
+				return executor.Failed(err)
 			}
 			defer res.Body.Close()
 
 			if res.IsError() {
-				return fmt.Errorf("error updating document: %s", res.Status())
+				return executor.Failed(fmt.Errorf("error updating document: %s", res.Status()))
 			}
 		}
 
@@ -165,12 +190,12 @@ func (e *elasticsearchExecutor) executeOperation(ctx context.Context, client *el
 
 		res, err := req.Do(ctx, client)
 		if err != nil {
-			return err
+			return executor.Failed(err)
 		}
 		defer res.Body.Close()
 
 		if res.IsError() && !strings.Contains(res.Status(), "404") {
-			return fmt.Errorf("error deleting document: %s", res.Status())
+			return executor.Failed(fmt.Errorf("error deleting document: %s", res.Status()))
 		}
 	}
 
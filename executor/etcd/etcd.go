@@ -2,27 +2,69 @@ package etcd
 
 import (
 	"context"
+	"strings"
+	"time"
 
 	etcdconf "github.com/hodgesds/dlg/config/etcd"
 	"github.com/hodgesds/dlg/executor"
 	"go.etcd.io/etcd/clientv3"
 )
 
-type etcdExecutor struct{}
+// clientKey contains the config fields used to create a client.
+type clientKey struct {
+	endpoints            string
+	dialTimeout          time.Duration
+	dialKeepAliveTime    time.Duration
+	dialKeepAliveTimeout time.Duration
+	maxCallSendMsgSize   int
+	maxCallRecvMsgSize   int
+	username             string
+	password             string
+	rejectOldCluster     bool
+}
+
+func newClientKey(c *etcdconf.Config) clientKey {
+	cc := c.ClientConfig()
+	return clientKey{
+		endpoints:            strings.Join(cc.Endpoints, ","),
+		dialTimeout:          cc.DialTimeout,
+		dialKeepAliveTime:    cc.DialKeepAliveTime,
+		dialKeepAliveTimeout: cc.DialKeepAliveTimeout,
+		maxCallSendMsgSize:   cc.MaxCallSendMsgSize,
+		maxCallRecvMsgSize:   cc.MaxCallRecvMsgSize,
+		username:             cc.Username,
+		password:             cc.Password,
+		rejectOldCluster:     cc.RejectOldCluster,
+	}
+}
+
+type etcdExecutor struct {
+	*executor.ClientCache[clientKey, *clientv3.Client]
+}
 
 // New returns a ETCD executor.
 func New() executor.ETCD {
-	return &etcdExecutor{}
+	return &etcdExecutor{
+		ClientCache: executor.NewClientCache[clientKey](
+			func(c *clientv3.Client) error { return c.Close() },
+		),
+	}
 }
 
 // Execute implements the ETCD executor interface.
 func (e *etcdExecutor) Execute(ctx context.Context, c *etcdconf.Config) error {
-	client, err := clientv3.New(c.ClientConfig())
+	client, release, err := e.Get(ctx, newClientKey(c), func(context.Context) (*clientv3.Client, error) {
+		return clientv3.New(c.ClientConfig())
+	})
 	if err != nil {
 		return err
 	}
+	defer release()
 	for _, kv := range c.KV {
-		if err := e.execKv(ctx, client, kv); err != nil {
+		err := executor.Run(ctx, "kv", 1, func(ctx context.Context, _ []byte) (int64, error) {
+			return 0, executor.Failed(e.execKv(ctx, client, kv))
+		})
+		if err != nil {
 			return err
 		}
 	}
@@ -11,6 +11,7 @@ import (
 	"github.com/pkg/sftp"
 	"golang.org/x/crypto/ssh"
 	ftpconfig "github.com/hodgesds/dlg/config/ftp"
+	"github.com/hodgesds/dlg/executor"
 )
 
 type ftpExecutor struct{}
@@ -23,7 +24,12 @@ func (e *ftpExecutor) Execute(ctx context.Context, config *ftpconfig.Config) err
 	if err := config.Validate(); err != nil {
 		return err
 	}
+	return executor.Run(ctx, config.Operation, 1, func(ctx context.Context, _ []byte) (int64, error) {
+		return 0, e.exec(ctx, config)
+	})
+}
 
+func (e *ftpExecutor) exec(ctx context.Context, config *ftpconfig.Config) error {
 	if config.Protocol == "ftp" {
 		return e.executeFTP(ctx, config)
 	}
@@ -34,18 +40,18 @@ func (e *ftpExecutor) executeFTP(ctx context.Context, config *ftpconfig.Config)
 	addr := fmt.Sprintf("%s:%d", config.Host, config.Port)
 	conn, err := goftp.Dial(addr, goftp.DialWithTimeout(config.Timeout))
 	if err != nil {
-		return err
+		return executor.Failed(err)
 	}
 	defer conn.Quit()
 
 	if err := conn.Login(config.Username, config.Password); err != nil {
-		return err
+		return executor.Failed(err)
 	}
 
 	switch config.Operation {
 	case "list":
 		_, err := conn.List(config.RemotePath)
-		return err
+		return executor.Failed(err)
 	case "upload":
 		var reader io.Reader
 		if config.Data != "" {
@@ -60,17 +66,17 @@ func (e *ftpExecutor) executeFTP(ctx context.Context, config *ftpconfig.Config)
 		} else {
 			return fmt.Errorf("data or local_path required")
 		}
-		return conn.Stor(config.RemotePath, reader)
+		return executor.Failed(conn.Stor(config.RemotePath, reader))
 	case "download":
 		resp, err := conn.Retr(config.RemotePath)
 		if err != nil {
-			return err
+			return executor.Failed(err)
 		}
 		defer resp.Close()
 		_, err = io.Copy(io.Discard, resp)
-		return err
+		return executor.Failed(err)
 	case "delete":
-		return conn.Delete(config.RemotePath)
+		return executor.Failed(conn.Delete(config.RemotePath))
 	default:
 		return fmt.Errorf("unknown operation: %s", config.Operation)
 	}
@@ -90,30 +96,30 @@ func (e *ftpExecutor) executeSFTP(ctx context.Context, config *ftpconfig.Config)
 	addr := fmt.Sprintf("%s:%d", config.Host, config.Port)
 	sshClient, err := ssh.Dial("tcp", addr, sshConfig)
 	if err != nil {
-		return err
+		return executor.Failed(err)
 	}
 	defer sshClient.Close()
 
 	client, err := sftp.NewClient(sshClient)
 	if err != nil {
-		return err
+		return executor.Failed(err)
 	}
 	defer client.Close()
 
 	switch config.Operation {
 	case "list":
 		_, err := client.ReadDir(config.RemotePath)
-		return err
+		return executor.Failed(err)
 	case "upload":
 		dstFile, err := client.Create(config.RemotePath)
 		if err != nil {
-			return err
+			return executor.Failed(err)
 		}
 		defer dstFile.Close()
 
 		if config.Data != "" {
 			_, err = io.Copy(dstFile, strings.NewReader(config.Data))
-			return err
+			return executor.Failed(err)
 		} else if config.LocalPath != "" {
 			srcFile, err := os.Open(config.LocalPath)
 			if err != nil {
@@ -121,19 +127,19 @@ func (e *ftpExecutor) executeSFTP(ctx context.Context, config *ftpconfig.Config)
 			}
 			defer srcFile.Close()
 			_, err = io.Copy(dstFile, srcFile)
-			return err
+			return executor.Failed(err)
 		}
 		return fmt.Errorf("data or local_path required")
 	case "download":
 		srcFile, err := client.Open(config.RemotePath)
 		if err != nil {
-			return err
+			return executor.Failed(err)
 		}
 		defer srcFile.Close()
 		_, err = io.Copy(io.Discard, srcFile)
-		return err
+		return executor.Failed(err)
 	case "delete":
-		return client.Remove(config.RemotePath)
+		return executor.Failed(client.Remove(config.RemotePath))
 	default:
 		return fmt.Errorf("unknown operation: %s", config.Operation)
 	}
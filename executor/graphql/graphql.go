@@ -20,42 +20,31 @@ func (e *graphqlExecutor) Execute(ctx context.Context, config *graphqlconfig.Con
 	client := graphql.NewClient(config.Endpoint)
 
 	// Execute the configured number of queries
-	for i := 0; i < config.Count; i++ {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-			req := graphql.NewRequest(config.Query)
-
-			// Add variables if provided
-			if config.Variables != nil {
-				for key, value := range config.Variables {
-					req.Var(key, value)
-				}
-			}
+	return executor.Run(ctx, "query", config.Count, func(ctx context.Context, _ []byte) (int64, error) {
+		req := graphql.NewRequest(config.Query)
 
-			// Add headers if provided
-			if config.Headers != nil {
-				for key, value := range config.Headers {
-					req.Header.Set(key, value)
-				}
+		// Add variables if provided
+		if config.Variables != nil {
+			for key, value := range config.Variables {
+				req.Var(key, value)
 			}
+		}
 
-			var response interface{}
-
-			// Apply timeout if configured
-			execCtx := ctx
-			if config.Timeout != nil {
-				var cancel context.CancelFunc
-				execCtx, cancel = context.WithTimeout(ctx, *config.Timeout)
-				defer cancel()
+		// Add headers if provided
+		if config.Headers != nil {
+			for key, value := range config.Headers {
+				req.Header.Set(key, value)
 			}
+		}
 
-			if err := client.Run(execCtx, req, &response); err != nil {
-				return err
-			}
+		// Apply timeout if configured
+		if config.Timeout != nil {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, *config.Timeout)
+			defer cancel()
 		}
-	}
 
-	return nil
+		var response interface{}
+		return 0, executor.Failed(client.Run(ctx, req, &response))
+	})
 }
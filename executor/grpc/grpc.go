@@ -2,41 +2,54 @@ package grpc
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	grpcconfig "github.com/hodgesds/dlg/config/grpc"
 	"github.com/hodgesds/dlg/executor"
+	"github.com/hodgesds/dlg/tracing"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/metadata"
 )
 
-type grpcExecutor struct{}
+// clientKey contains the config fields used to dial a connection.
+type clientKey struct {
+	target        string
+	insecure      bool
+	maxConcurrent int
+}
+
+type grpcExecutor struct {
+	*executor.ClientCache[clientKey, *grpc.ClientConn]
+}
 
 // New returns a new gRPC executor.
 func New() executor.GRPC {
-	return &grpcExecutor{}
+	return &grpcExecutor{
+		ClientCache: executor.NewClientCache[clientKey](
+			func(c *grpc.ClientConn) error { return c.Close() },
+		),
+	}
 }
 
 // Execute implements the gRPC executor interface.
 func (e *grpcExecutor) Execute(ctx context.Context, config *grpcconfig.Config) error {
-	opts := []grpc.DialOption{}
-
-	if config.Insecure {
-		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	key := clientKey{
+		target:   config.Target,
+		insecure: config.Insecure,
 	}
-
 	if config.MaxConcurrent != nil {
-		opts = append(opts, grpc.WithDefaultCallOptions(
-			grpc.MaxCallRecvMsgSize(*config.MaxConcurrent),
-		))
+		key.maxConcurrent = *config.MaxConcurrent
 	}
-
-	conn, err := grpc.DialContext(ctx, config.Target, opts...)
+	conn, release, err := e.Get(ctx, key, func(ctx context.Context) (*grpc.ClientConn, error) {
+		return dial(ctx, config)
+	})
 	if err != nil {
 		return err
 	}
-	defer conn.Close()
+	defer release()
 
 	// Add metadata if provided
 	if len(config.Metadata) > 0 {
@@ -52,21 +65,33 @@ func (e *grpcExecutor) Execute(ctx context.Context, config *grpcconfig.Config) e
 	}
 
 	// Execute the configured number of calls
-	for i := 0; i < config.Count; i++ {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-			// Generic health check or connection test
-			// In a real implementation, this would invoke the specific method
-			// For now, we just test the connection
-			state := conn.GetState()
-			if state.String() == "SHUTDOWN" {
-				return nil
-			}
-			time.Sleep(10 * time.Millisecond)
+	return executor.Run(ctx, config.Method, config.Count, func(ctx context.Context, _ []byte) (int64, error) {
+		tracing.Inject(ctx, func(k, v string) {
+			ctx = metadata.AppendToOutgoingContext(ctx, k, v)
+		})
+		// Generic health check or connection test
+		// In a real implementation, this would invoke the specific method
+		// For now, we just test the connection
+		if conn.GetState() == connectivity.Shutdown {
+			return 0, executor.Failed(errors.New("connection shutdown"))
 		}
+		time.Sleep(10 * time.Millisecond)
+		return 0, nil
+	})
+}
+
+func dial(ctx context.Context, config *grpcconfig.Config) (*grpc.ClientConn, error) {
+	opts := []grpc.DialOption{}
+
+	if config.Insecure {
+		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	}
+
+	if config.MaxConcurrent != nil {
+		opts = append(opts, grpc.WithDefaultCallOptions(
+			grpc.MaxCallRecvMsgSize(*config.MaxConcurrent),
+		))
 	}
 
-	return nil
+	return grpc.DialContext(ctx, config.Target, opts...)
 }
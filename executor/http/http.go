@@ -2,15 +2,18 @@ package http
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"strconv"
 	"sync"
 
 	httpconf "github.com/hodgesds/dlg/config/http"
 	"github.com/hodgesds/dlg/executor"
+	"github.com/hodgesds/dlg/stats"
+	"github.com/hodgesds/dlg/tracing"
 	"github.com/prometheus/client_golang/prometheus"
 	prom "github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
-	"go.uber.org/multierr"
 )
 
 // httpExecutor is a HTTP executor.
@@ -24,7 +27,10 @@ type httpExecutor struct {
 
 // New returns a new HTTP executor.
 func New(reg *prometheus.Registry) executor.HTTP {
-	t := &http.Transport{}
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	// All requests of an executor go to the same hosts, so idle
+	// connections are kept for every worker.
+	t.MaxIdleConnsPerHost = t.MaxIdleConns
 	c := &http.Client{Transport: t}
 	c = makeInstrumentedClient(reg, c)
 	return &httpExecutor{
@@ -38,6 +44,7 @@ func (e *httpExecutor) Execute(ctx context.Context, conf *httpconf.Config) error
 	if conf.MaxIdleConns != nil {
 		e.mu.Lock()
 		e.trans.MaxIdleConns = *conf.MaxIdleConns
+		e.trans.MaxIdleConnsPerHost = *conf.MaxIdleConns
 		e.mu.Unlock()
 	}
 	if conf.MaxConns != nil {
@@ -45,35 +52,41 @@ func (e *httpExecutor) Execute(ctx context.Context, conf *httpconf.Config) error
 		e.trans.MaxConnsPerHost = *conf.MaxConns
 		e.mu.Unlock()
 	}
-	var (
-		wg  sync.WaitGroup
-		mu  sync.Mutex
-		err error
-	)
-	for count := conf.Count; count > 0; count-- {
-		wg.Add(1)
-		go func(ctx context.Context) {
-			ctx2, cancel := context.WithCancel(ctx)
-			defer cancel()
-			req, err2 := conf.Payload.Request(ctx2)
-			if err2 != nil {
-				mu.Lock()
-				err = multierr.Append(err, err2)
-				mu.Unlock()
-				return
-			}
+	return executor.Run(ctx, conf.Payload.Method, conf.Count, func(ctx context.Context, buf []byte) (int64, error) {
+		req, err := conf.Payload.Request(ctx)
+		if err != nil {
+			return 0, err
+		}
+		tracing.Inject(ctx, req.Header.Set)
+		res, err := e.client.Do(req)
+		if err != nil {
+			return 0, executor.Failed(err)
+		}
+		defer res.Body.Close()
+		// The body must be read to the end for the connection to be
+		// reused.
+		n, err := executor.Drain(res.Body, buf)
+		if err != nil {
+			return n, executor.Failed(err)
+		}
+		return n, statusError(req, res)
+	})
+}
 
-			_, err2 = e.client.Do(req)
-			if err2 != nil {
-				mu.Lock()
-				err = multierr.Append(err, err2)
-				mu.Unlock()
-			}
-			wg.Done()
-		}(ctx)
+// statusError returns a classified error for server error and
+// unauthorized responses, they are recorded without stopping the stage.
+func statusError(req *http.Request, res *http.Response) error {
+	var class string
+	switch {
+	case res.StatusCode >= 500:
+		class = stats.ClassProtocol
+	case res.StatusCode == http.StatusUnauthorized, res.StatusCode == http.StatusForbidden:
+		class = stats.ClassAuth
+	default:
+		return nil
 	}
-	wg.Wait()
-	return err
+	err := fmt.Errorf("%s %s: %s", req.Method, req.URL, res.Status)
+	return executor.Failed(stats.Classify(class, strconv.Itoa(res.StatusCode), err))
 }
 
 func makeInstrumentedClient(reg *prom.Registry, client *http.Client) *http.Client {
@@ -137,7 +150,7 @@ func makeInstrumentedClient(reg *prom.Registry, client *http.Client) *http.Clien
 	client.Transport = promhttp.InstrumentRoundTripperInFlight(inFlightGauge,
 		promhttp.InstrumentRoundTripperCounter(counter,
 			promhttp.InstrumentRoundTripperTrace(trace,
-				promhttp.InstrumentRoundTripperDuration(histVec, http.DefaultTransport),
+				promhttp.InstrumentRoundTripperDuration(histVec, client.Transport),
 			),
 		),
 	)
@@ -2,12 +2,16 @@ package http
 
 import (
 	"context"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"sync/atomic"
 	"testing"
+	"time"
 
 	httpconf "github.com/hodgesds/dlg/config/http"
+	"github.com/hodgesds/dlg/executor"
+	"github.com/hodgesds/dlg/stats"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -270,3 +274,110 @@ func TestExecuteInvalidURL(t *testing.T) {
 	err := exec.Execute(context.Background(), conf)
 	assert.Error(t, err)
 }
+
+// TestExecuteConcurrency tests requests are bounded by the pool concurrency
+// and every request is recorded.
+func TestExecuteConcurrency(t *testing.T) {
+	var inFlight, maxInFlight int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			max := atomic.LoadInt32(&maxInFlight)
+			if n <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		w.Write([]byte("OK"))
+	}))
+	defer server.Close()
+
+	exec := New(prometheus.NewRegistry())
+	c := stats.NewCollector()
+	ctx := stats.NewContext(context.Background(), c)
+	ctx = executor.WithPool(ctx, executor.NewPool(3))
+
+	conf := &httpconf.Config{
+		Count: 20,
+		Payload: httpconf.Payload{
+			URL:    server.URL,
+			Method: "GET",
+		},
+	}
+	require.NoError(t, exec.Execute(ctx, conf))
+	assert.LessOrEqual(t, atomic.LoadInt32(&maxInFlight), int32(3))
+
+	stages := c.Stages()
+	require.Len(t, stages, 1)
+	assert.Equal(t, int64(20), stages[0].Main.Ops)
+	assert.Equal(t, int64(40), stages[0].Main.Bytes)
+}
+
+// TestExecuteErrorStatus tests server error and unauthorized responses are
+// recorded as classified errors without failing the execution.
+func TestExecuteErrorStatus(t *testing.T) {
+	var requestCount int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requestCount, 1)%2 == 0 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	exec := New(prometheus.NewRegistry())
+	c := stats.NewCollector()
+	ctx := stats.NewContext(context.Background(), c)
+
+	conf := &httpconf.Config{
+		Count: 4,
+		Payload: httpconf.Payload{
+			URL:    server.URL,
+			Method: "GET",
+		},
+	}
+	require.NoError(t, exec.Execute(ctx, conf))
+	assert.Equal(t, int32(4), atomic.LoadInt32(&requestCount))
+
+	stages := c.Stages()
+	require.Len(t, stages, 1)
+	assert.Equal(t, int64(4), stages[0].Main.Errors)
+	require.Len(t, stages[0].Errors, 2)
+	assert.Equal(t, stats.ClassAuth, stages[0].Errors[0].Class)
+	assert.Equal(t, "401", stages[0].Errors[0].Code)
+	assert.Equal(t, stats.ClassProtocol, stages[0].Errors[1].Class)
+	assert.Equal(t, "503", stages[0].Errors[1].Code)
+	assert.Contains(t, stages[0].Errors[1].Example, "503 Service Unavailable")
+}
+
+// TestExecuteConnectionRefused tests refused connections are recorded for
+// every request without failing the execution.
+func TestExecuteConnectionRefused(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+	require.NoError(t, l.Close())
+
+	exec := New(prometheus.NewRegistry())
+	c := stats.NewCollector()
+	ctx := stats.NewContext(context.Background(), c)
+
+	conf := &httpconf.Config{
+		Count: 5,
+		Payload: httpconf.Payload{
+			URL:    "http://" + addr,
+			Method: "GET",
+		},
+	}
+	require.NoError(t, exec.Execute(ctx, conf))
+
+	stages := c.Stages()
+	require.Len(t, stages, 1)
+	assert.Equal(t, int64(5), stages[0].Main.Ops)
+	assert.Equal(t, int64(5), stages[0].Main.Errors)
+	require.Len(t, stages[0].Errors, 1)
+	assert.Equal(t, stats.ClassRefused, stages[0].Errors[0].Class)
+	assert.Equal(t, int64(5), stages[0].Errors[0].Count)
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/go-ping/ping"
 	icmpconfig "github.com/hodgesds/dlg/config/icmp"
+	"github.com/hodgesds/dlg/executor"
 )
 
 type icmpExecutor struct{}
@@ -20,7 +21,12 @@ func (e *icmpExecutor) Execute(ctx context.Context, config *icmpconfig.Config) e
 	if err := config.Validate(); err != nil {
 		return err
 	}
+	return executor.Run(ctx, "ping", 1, func(ctx context.Context, _ []byte) (int64, error) {
+		return 0, e.exec(ctx, config)
+	})
+}
 
+func (e *icmpExecutor) exec(ctx context.Context, config *icmpconfig.Config) error {
 	pinger, err := ping.NewPinger(config.Host)
 	if err != nil {
 		return fmt.Errorf("failed to create pinger: %w", err)
@@ -40,11 +46,11 @@ func (e *icmpExecutor) Execute(ctx context.Context, config *icmpconfig.Config) e
 	select {
 	case err := <-done:
 		if err != nil {
-			return fmt.Errorf("ping failed: %w", err)
+			return executor.Failed(fmt.Errorf("ping failed: %w", err))
 		}
 		stats := pinger.Statistics()
 		if stats.PacketsRecv == 0 {
-			return fmt.Errorf("no packets received from %s", config.Host)
+			return executor.Failed(fmt.Errorf("no packets received from %s", config.Host))
 		}
 		return nil
 	case <-ctx.Done():
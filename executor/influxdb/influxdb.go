@@ -34,18 +34,9 @@ func (e *influxdbExecutor) Execute(ctx context.Context, config *influxdbconfig.C
 	defer client.Close()
 
 	// Execute the configured number of operations
-	for i := 0; i < config.Count; i++ {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-			if err := e.executeOperation(ctx, client, config); err != nil {
-				return err
-			}
-		}
-	}
-
-	return nil
+	return executor.Run(ctx, string(config.Operation), config.Count, func(ctx context.Context, _ []byte) (int64, error) {
+		return 0, executor.Failed(e.executeOperation(ctx, client, config))
+	})
 }
 
 func (e *influxdbExecutor) executeOperation(ctx context.Context, client influxdb2.Client, config *influxdbconfig.Config) error {
@@ -2,18 +2,66 @@ package kafka
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/IBM/sarama"
 	kafkaconfig "github.com/hodgesds/dlg/config/kafka"
+	"github.com/hodgesds/dlg/executor"
+	"github.com/hodgesds/dlg/stats"
+	"github.com/hodgesds/dlg/tracing"
+	"go.uber.org/multierr"
 )
 
-type kafkaExecutor struct{}
+// clientKey contains the config fields used to create a client.
+type clientKey struct {
+	brokers      string
+	timeout      time.Duration
+	saslEnabled  bool
+	saslUsername string
+	saslPassword string
+	tlsEnabled   bool
+}
+
+func newClientKey(config *kafkaconfig.Config) clientKey {
+	return clientKey{
+		brokers:      strings.Join(config.Brokers, ","),
+		timeout:      config.Timeout,
+		saslEnabled:  config.SASLEnabled,
+		saslUsername: config.SASLUsername,
+		saslPassword: config.SASLPassword,
+		tlsEnabled:   config.TLSEnabled,
+	}
+}
+
+type kafkaExecutor struct {
+	producers *executor.ClientCache[clientKey, sarama.SyncProducer]
+	consumers *executor.ClientCache[clientKey, sarama.Consumer]
+}
 
 // New returns a new Kafka executor.
 func New() *kafkaExecutor {
-	return &kafkaExecutor{}
+	return &kafkaExecutor{
+		producers: executor.NewClientCache[clientKey](
+			func(p sarama.SyncProducer) error { return p.Close() },
+		),
+		consumers: executor.NewClientCache[clientKey](
+			func(c sarama.Consumer) error { return c.Close() },
+		),
+	}
+}
+
+// Init implements the executor.Lifecycle interface.
+func (e *kafkaExecutor) Init(ctx context.Context) error {
+	return nil
+}
+
+// Close implements the executor.Lifecycle interface.
+func (e *kafkaExecutor) Close() error {
+	return multierr.Append(e.producers.Close(), e.consumers.Close())
 }
 
 // Execute implements the Kafka executor interface.
@@ -41,11 +89,17 @@ func (e *kafkaExecutor) Execute(ctx context.Context, config *kafkaconfig.Config)
 
 	switch config.Operation {
 	case "produce":
-		producer, err := sarama.NewSyncProducer(config.Brokers, saramaCfg)
+		producer, release, err := e.producers.Get(
+			ctx,
+			newClientKey(config),
+			func(context.Context) (sarama.SyncProducer, error) {
+				return sarama.NewSyncProducer(config.Brokers, saramaCfg)
+			},
+		)
 		if err != nil {
 			return fmt.Errorf("failed to create producer: %w", err)
 		}
-		defer producer.Close()
+		defer release()
 
 		msg := &sarama.ProducerMessage{
 			Topic: config.Topic,
@@ -58,15 +112,29 @@ func (e *kafkaExecutor) Execute(ctx context.Context, config *kafkaconfig.Config)
 			msg.Partition = config.Partition
 		}
 
-		_, _, err = producer.SendMessage(msg)
-		return err
+		return executor.Run(ctx, config.Operation, 1, func(ctx context.Context, _ []byte) (int64, error) {
+			tracing.Inject(ctx, func(k, v string) {
+				msg.Headers = []sarama.RecordHeader{{
+					Key:   []byte(k),
+					Value: []byte(v),
+				}}
+			})
+			_, _, err := producer.SendMessage(msg)
+			return int64(len(config.Message)), executor.Failed(classifyError(err))
+		})
 
 	case "consume":
-		consumer, err := sarama.NewConsumer(config.Brokers, saramaCfg)
+		consumer, release, err := e.consumers.Get(
+			ctx,
+			newClientKey(config),
+			func(context.Context) (sarama.Consumer, error) {
+				return sarama.NewConsumer(config.Brokers, saramaCfg)
+			},
+		)
 		if err != nil {
 			return fmt.Errorf("failed to create consumer: %w", err)
 		}
-		defer consumer.Close()
+		defer release()
 
 		partition := config.Partition
 		if partition < 0 {
@@ -79,18 +147,41 @@ func (e *kafkaExecutor) Execute(ctx context.Context, config *kafkaconfig.Config)
 		}
 		defer partitionConsumer.Close()
 
-		select {
-		case <-partitionConsumer.Messages():
-			return nil
-		case err := <-partitionConsumer.Errors():
-			return err
-		case <-time.After(config.Timeout):
-			return fmt.Errorf("consume timeout")
-		case <-ctx.Done():
-			return ctx.Err()
-		}
+		return executor.Run(ctx, config.Operation, 1, func(ctx context.Context, _ []byte) (int64, error) {
+			select {
+			case msg := <-partitionConsumer.Messages():
+				return int64(len(msg.Value)), nil
+			case err := <-partitionConsumer.Errors():
+				return 0, executor.Failed(classifyError(err))
+			case <-time.After(config.Timeout):
+				return 0, executor.Failed(fmt.Errorf("consume timeout"))
+			case <-ctx.Done():
+				return 0, ctx.Err()
+			}
+		})
 
 	default:
 		return fmt.Errorf("unknown operation: %s", config.Operation)
 	}
 }
+
+// classifyError classifies Kafka error codes returned by brokers.
+func classifyError(err error) error {
+	var kerr sarama.KError
+	if !errors.As(err, &kerr) {
+		return err
+	}
+	code := strconv.Itoa(int(kerr))
+	switch kerr {
+	case sarama.ErrTopicAuthorizationFailed,
+		sarama.ErrGroupAuthorizationFailed,
+		sarama.ErrClusterAuthorizationFailed,
+		sarama.ErrTransactionalIDAuthorizationFailed,
+		sarama.ErrSASLAuthenticationFailed,
+		sarama.ErrDelegationTokenAuthorizationFailed:
+		return stats.Classify(stats.ClassAuth, code, err)
+	case sarama.ErrRequestTimedOut:
+		return stats.Classify(stats.ClassTimeout, code, err)
+	}
+	return stats.Classify(stats.ClassProtocol, code, err)
+}
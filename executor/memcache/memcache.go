@@ -22,25 +22,35 @@ func (e *memcacheExecutor) Execute(ctx context.Context, config *memcacheconf.Con
 		return err
 	}
 	for _, op := range config.Ops {
-		if op.Get != nil {
-			_, err := client.Get(op.Get.Key)
-			if err != nil {
-				return err
-			}
+		err := executor.Run(ctx, "op", 1, func(context.Context, []byte) (int64, error) {
+			return 0, executor.Failed(e.execOp(client, op))
+		})
+		if err != nil {
+			return err
 		}
-		if op.Delete != nil {
-			if err := client.Delete(op.Delete.Key); err != nil {
-				return err
-			}
+	}
+	return nil
+}
+
+func (e *memcacheExecutor) execOp(client *memcache.Client, op *memcacheconf.Op) error {
+	if op.Get != nil {
+		_, err := client.Get(op.Get.Key)
+		if err != nil {
+			return err
 		}
-		if op.Set != nil {
-			err := client.Set(&memcache.Item{
-				Key:   op.Set.Key,
-				Value: []byte(op.Set.Value),
-			})
-			if err != nil {
-				return err
-			}
+	}
+	if op.Delete != nil {
+		if err := client.Delete(op.Delete.Key); err != nil {
+			return err
+		}
+	}
+	if op.Set != nil {
+		err := client.Set(&memcache.Item{
+			Key:   op.Set.Key,
+			Value: []byte(op.Set.Value),
+		})
+		if err != nil {
+			return err
 		}
 	}
 	return nil
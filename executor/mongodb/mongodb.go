@@ -10,15 +10,62 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-type mongoExecutor struct{}
+// clientKey contains the config fields used to create a client.
+type clientKey struct {
+	uri            string
+	maxPoolSize    uint64
+	minPoolSize    uint64
+	connectTimeout time.Duration
+}
+
+func newClientKey(config *mongoconfig.Config) clientKey {
+	k := clientKey{uri: config.URI}
+	if config.MaxPoolSize != nil {
+		k.maxPoolSize = *config.MaxPoolSize
+	}
+	if config.MinPoolSize != nil {
+		k.minPoolSize = *config.MinPoolSize
+	}
+	if config.ConnectTimeout != nil {
+		k.connectTimeout = *config.ConnectTimeout
+	}
+	return k
+}
+
+type mongoExecutor struct {
+	*executor.ClientCache[clientKey, *mongo.Client]
+}
 
 // New returns a new MongoDB executor.
 func New() executor.MongoDB {
-	return &mongoExecutor{}
+	return &mongoExecutor{
+		ClientCache: executor.NewClientCache[clientKey](
+			func(c *mongo.Client) error {
+				return c.Disconnect(context.Background())
+			},
+		),
+	}
 }
 
 // Execute implements the MongoDB executor interface.
 func (e *mongoExecutor) Execute(ctx context.Context, config *mongoconfig.Config) error {
+	client, release, err := e.Get(ctx, newClientKey(config), func(ctx context.Context) (*mongo.Client, error) {
+		return e.connect(ctx, config)
+	})
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	collection := client.Database(config.Database).Collection(config.Collection)
+
+	// Execute the configured number of operations
+	return executor.Run(ctx, string(config.Operation), config.Count, func(ctx context.Context, _ []byte) (int64, error) {
+		return 0, executor.Failed(e.executeOperation(ctx, collection, config))
+	})
+}
+
+func (e *mongoExecutor) connect(ctx context.Context, config *mongoconfig.Config) (*mongo.Client, error) {
 	clientOpts := options.Client().ApplyURI(config.URI)
 
 	if config.MaxPoolSize != nil {
@@ -35,30 +82,15 @@ func (e *mongoExecutor) Execute(ctx context.Context, config *mongoconfig.Config)
 
 	client, err := mongo.Connect(ctx, clientOpts)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer client.Disconnect(ctx)
 
 	// Ping to verify connection
 	if err := client.Ping(ctx, nil); err != nil {
-		return err
-	}
-
-	collection := client.Database(config.Database).Collection(config.Collection)
-
-	// Execute the configured number of operations
-	for i := 0; i < config.Count; i++ {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-			if err := e.executeOperation(ctx, collection, config); err != nil {
-				return err
-			}
-		}
+		client.Disconnect(ctx)
+		return nil, err
 	}
-
-	return nil
+	return client, nil
 }
 
 func (e *mongoExecutor) executeOperation(ctx context.Context, collection *mongo.Collection, config *mongoconfig.Config) error {
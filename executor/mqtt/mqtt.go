@@ -80,22 +80,14 @@ func (e *mqttExecutor) Execute(ctx context.Context, config *mqttconfig.Config) e
 	}
 
 	// Execute the configured number of publishes
-	for i := 0; i < config.Count; i++ {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-			token := client.Publish(
-				config.Topic,
-				byte(config.QoS),
-				config.Retained,
-				payload,
-			)
-			if token.Wait() && token.Error() != nil {
-				return token.Error()
-			}
-		}
-	}
-
-	return nil
+	return executor.Run(ctx, "publish", config.Count, func(context.Context, []byte) (int64, error) {
+		token := client.Publish(
+			config.Topic,
+			byte(config.QoS),
+			config.Retained,
+			payload,
+		)
+		token.Wait()
+		return int64(len(payload)), executor.Failed(token.Error())
+	})
 }
@@ -7,13 +7,33 @@ import (
 
 	"github.com/nats-io/nats.go"
 	natsconfig "github.com/hodgesds/dlg/config/nats"
+	"github.com/hodgesds/dlg/executor"
 )
 
-type natsExecutor struct{}
+// clientKey contains the config fields used to connect.
+type clientKey struct {
+	url        string
+	username   string
+	password   string
+	token      string
+	tlsEnabled bool
+	timeout    time.Duration
+}
+
+type natsExecutor struct {
+	*executor.ClientCache[clientKey, *nats.Conn]
+}
 
 // New returns a new NATS executor.
 func New() *natsExecutor {
-	return &natsExecutor{}
+	return &natsExecutor{
+		ClientCache: executor.NewClientCache[clientKey](
+			func(c *nats.Conn) error {
+				c.Close()
+				return nil
+			},
+		),
+	}
 }
 
 // Execute implements the NATS executor interface.
@@ -34,15 +54,32 @@ func (e *natsExecutor) Execute(ctx context.Context, config *natsconfig.Config) e
 	}
 	opts = append(opts, nats.Timeout(config.Timeout))
 
-	conn, err := nats.Connect(config.URL, opts...)
+	key := clientKey{
+		url:        config.URL,
+		username:   config.Username,
+		password:   config.Password,
+		token:      config.Token,
+		tlsEnabled: config.TLSEnabled,
+		timeout:    config.Timeout,
+	}
+	conn, release, err := e.Get(ctx, key, func(context.Context) (*nats.Conn, error) {
+		return nats.Connect(config.URL, opts...)
+	})
 	if err != nil {
 		return fmt.Errorf("failed to connect: %w", err)
 	}
-	defer conn.Close()
+	defer release()
+
+	return executor.Run(ctx, config.Operation, 1, func(ctx context.Context, _ []byte) (int64, error) {
+		return 0, e.exec(ctx, conn, config)
+	})
+}
 
+func (e *natsExecutor) exec(ctx context.Context, conn *nats.Conn, config *natsconfig.Config) error {
+	var err error
 	switch config.Operation {
 	case "publish":
-		return conn.Publish(config.Subject, []byte(config.Message))
+		return executor.Failed(conn.Publish(config.Subject, []byte(config.Message)))
 
 	case "subscribe":
 		ch := make(chan *nats.Msg, 1)
@@ -53,7 +90,7 @@ func (e *natsExecutor) Execute(ctx context.Context, config *natsconfig.Config) e
 			sub, err = conn.SubscribeSyncWithContext(ctx, config.Subject, ch)
 		}
 		if err != nil {
-			return err
+			return executor.Failed(err)
 		}
 		defer sub.Unsubscribe()
 
@@ -61,14 +98,14 @@ func (e *natsExecutor) Execute(ctx context.Context, config *natsconfig.Config) e
 		case <-ch:
 			return nil
 		case <-time.After(config.Timeout):
-			return fmt.Errorf("subscribe timeout")
+			return executor.Failed(fmt.Errorf("subscribe timeout"))
 		case <-ctx.Done():
 			return ctx.Err()
 		}
 
 	case "request":
 		_, err := conn.RequestWithContext(ctx, config.Subject, []byte(config.Message))
-		return err
+		return executor.Failed(err)
 
 	default:
 		return fmt.Errorf("unknown operation: %s", config.Operation)
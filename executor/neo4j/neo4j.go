@@ -6,6 +6,7 @@ import (
 
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 	neo4jconfig "github.com/hodgesds/dlg/config/neo4j"
+	"github.com/hodgesds/dlg/executor"
 )
 
 type neo4jExecutor struct{}
@@ -18,7 +19,12 @@ func (e *neo4jExecutor) Execute(ctx context.Context, config *neo4jconfig.Config)
 	if err := config.Validate(); err != nil {
 		return err
 	}
+	return executor.Run(ctx, "query", 1, func(ctx context.Context, _ []byte) (int64, error) {
+		return 0, e.exec(ctx, config)
+	})
+}
 
+func (e *neo4jExecutor) exec(ctx context.Context, config *neo4jconfig.Config) error {
 	driver, err := neo4j.NewDriverWithContext(config.URI, neo4j.BasicAuth(config.Username, config.Password, ""))
 	if err != nil {
 		return fmt.Errorf("failed to create driver: %w", err)
@@ -29,5 +35,5 @@ func (e *neo4jExecutor) Execute(ctx context.Context, config *neo4jconfig.Config)
 	defer session.Close(ctx)
 
 	_, err = session.Run(ctx, config.Query, config.Parameters)
-	return err
+	return executor.Failed(err)
 }
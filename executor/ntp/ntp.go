@@ -6,6 +6,7 @@ import (
 
 	"github.com/beevik/ntp"
 	ntpconfig "github.com/hodgesds/dlg/config/ntp"
+	"github.com/hodgesds/dlg/executor"
 )
 
 type ntpExecutor struct{}
@@ -20,7 +21,12 @@ func (e *ntpExecutor) Execute(ctx context.Context, config *ntpconfig.Config) err
 	if err := config.Validate(); err != nil {
 		return err
 	}
+	return executor.Run(ctx, "query", 1, func(ctx context.Context, _ []byte) (int64, error) {
+		return 0, e.exec(ctx, config)
+	})
+}
 
+func (e *ntpExecutor) exec(ctx context.Context, config *ntpconfig.Config) error {
 	options := ntp.QueryOptions{
 		Timeout: config.Timeout,
 		Version: config.Version,
@@ -29,15 +35,15 @@ func (e *ntpExecutor) Execute(ctx context.Context, config *ntpconfig.Config) err
 
 	response, err := ntp.QueryWithOptions(config.Host, options)
 	if err != nil {
-		return fmt.Errorf("NTP query failed: %w", err)
+		return executor.Failed(fmt.Errorf("NTP query failed: %w", err))
 	}
 
 	if response == nil {
-		return fmt.Errorf("NTP query returned nil response")
+		return executor.Failed(fmt.Errorf("NTP query returned nil response"))
 	}
 
 	if err := response.Validate(); err != nil {
-		return fmt.Errorf("NTP response validation failed: %w", err)
+		return executor.Failed(fmt.Errorf("NTP response validation failed: %w", err))
 	}
 
 	return nil
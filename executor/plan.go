@@ -4,17 +4,27 @@ import (
 	"context"
 
 	"github.com/hodgesds/dlg/config"
+	"github.com/hodgesds/dlg/stats"
+	"github.com/hodgesds/dlg/tracing"
 	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/multierr"
 )
 
 // Params are used for configuring a Plan.
 type Params struct {
 	Registry *prometheus.Registry
+	// Recorder receives the result of every operation, it is passed to
+	// stage executors through the execution context.
+	Recorder stats.Recorder
+	// Tracer is optional, if set every operation is traced.
+	Tracer *tracing.Tracer
 }
 
 type planExecutor struct {
-	stage   Stage
-	metrics *metrics
+	stage    Stage
+	metrics  *metrics
+	recorder stats.Recorder
+	tracer   *tracing.Tracer
 }
 
 // NewPlan returns a new Plan executor.
@@ -27,19 +37,32 @@ func NewPlan(p Params, s Stage) (Plan, error) {
 		return nil, err
 	}
 	return &planExecutor{
-		stage:   s,
-		metrics: m,
+		stage:    s,
+		metrics:  m,
+		recorder: p.Recorder,
+		tracer:   p.Tracer,
 	}, nil
 }
 
 // Executor implements the Plan interface.
-func (e *planExecutor) Execute(ctx context.Context, p *config.Plan) error {
+func (e *planExecutor) Execute(ctx context.Context, p *config.Plan) (err error) {
 	if err := p.Validate(); err != nil {
 		return err
 	}
+	p.ResetStatus()
+	status := p.Status()
+	defer status.SetState(config.Complete)
 	if err := p.WaitStart(ctx); err != nil {
 		return err
 	}
+	status.SetState(config.Running)
+	// Clients are kept open for the duration of the plan so that repeated
+	// stages don't measure connection setup. Every execution has its own
+	// clients, so overlapping executions don't close each other's.
+	ctx, clients := WithClients(ctx)
+	defer func() {
+		err = multierr.Append(err, clients.Close())
+	}()
 	var cancel func()
 	if p.Duration != nil {
 		ctx, cancel = context.WithTimeout(ctx, *p.Duration)
@@ -47,6 +70,16 @@ func (e *planExecutor) Execute(ctx context.Context, p *config.Plan) error {
 		ctx, cancel = context.WithCancel(ctx)
 	}
 	defer cancel()
+	if e.recorder != nil {
+		ctx = stats.NewContext(ctx, e.recorder)
+	}
+	if e.tracer != nil {
+		ctx = tracing.NewContext(ctx, e.tracer)
+		ctx = tracing.WithAttributes(ctx, tracing.String("dlg.plan", p.Name))
+	}
+	if p.Executors > 0 {
+		ctx = WithPool(ctx, NewPool(p.Executors))
+	}
 
 	for _, stage := range p.Stages {
 		e.metrics.StagesTotal.WithLabelValues(p.Name).Add(1)
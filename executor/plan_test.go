@@ -0,0 +1,86 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/hodgesds/dlg/config"
+	httpconf "github.com/hodgesds/dlg/config/http"
+	"github.com/stretchr/testify/require"
+)
+
+// cacheStage uses a client of a ClientCache, stages named long wait until
+// released before they use their client again.
+type cacheStage struct {
+	cache   *ClientCache[string, *testClient]
+	started chan struct{}
+	release chan struct{}
+
+	mu     sync.Mutex
+	closed map[*testClient]bool
+}
+
+func newCacheStage() *cacheStage {
+	s := &cacheStage{
+		started: make(chan struct{}),
+		release: make(chan struct{}),
+		closed:  map[*testClient]bool{},
+	}
+	s.cache = NewClientCache[string](func(c *testClient) error {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.closed[c] = true
+		return nil
+	})
+	return s
+}
+
+func (s *cacheStage) Execute(ctx context.Context, stage *config.Stage) error {
+	c, release, err := s.cache.Get(ctx, "a", func(context.Context) (*testClient, error) {
+		return &testClient{}, nil
+	})
+	if err != nil {
+		return err
+	}
+	defer release()
+	if stage.Name == "long" {
+		close(s.started)
+		<-s.release
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed[c] {
+		return errors.New("client is closed")
+	}
+	return nil
+}
+
+func cachePlan(name string) *config.Plan {
+	return &config.Plan{
+		Name:   name,
+		Stages: []*config.Stage{{Name: name, HTTP: &httpconf.Config{}}},
+	}
+}
+
+// TestPlanOverlappingClients tests an execution that completes while
+// another one is running does not close the clients of the other.
+func TestPlanOverlappingClients(t *testing.T) {
+	s := newCacheStage()
+	p, err := NewPlan(Params{}, s)
+	require.NoError(t, err)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- p.Execute(context.Background(), cachePlan("long"))
+	}()
+	<-s.started
+	require.NoError(t, p.Execute(context.Background(), cachePlan("short")))
+	close(s.release)
+	require.NoError(t, <-done)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	require.Len(t, s.closed, 2)
+}
@@ -0,0 +1,274 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hodgesds/dlg/config"
+	"github.com/hodgesds/dlg/stats"
+	"github.com/hodgesds/dlg/tracing"
+	"golang.org/x/time/rate"
+)
+
+const bufferSize = 32 * 1024
+
+// Op is an operation executed by a Pool, it returns the number of bytes
+// transferred. The buffer is scratch space owned by the worker running the
+// operation and is reused for later operations.
+type Op func(ctx context.Context, buf []byte) (int64, error)
+
+// failedError is an operation error that is recorded but does not stop the
+// Pool.
+type failedError struct {
+	err error
+}
+
+func (e *failedError) Error() string { return e.err.Error() }
+
+func (e *failedError) Unwrap() error { return e.err }
+
+// Failed returns err as an error that is recorded for the operation without
+// stopping the Pool, such as an error response of the target or a refused
+// connection. Configuration errors are returned as is to stop the Pool.
+func Failed(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &failedError{err: err}
+}
+
+// Pool executes operations with a bounded number of workers. Every
+// operation is timed and recorded to the stats.Recorder of the context, if
+// the context has a tracing.Tracer the operation is run in a span. The rate
+// limit and config.Status of the context are shared by all workers.
+type Pool struct {
+	concurrency int
+	buffers     sync.Pool
+}
+
+// NewPool returns a new Pool with the given number of workers.
+func NewPool(concurrency int) *Pool {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return &Pool{
+		concurrency: concurrency,
+		buffers: sync.Pool{
+			New: func() interface{} {
+				buf := make([]byte, bufferSize)
+				return &buf
+			},
+		},
+	}
+}
+
+// Concurrency returns the number of workers of the Pool.
+func (p *Pool) Concurrency() int {
+	return p.concurrency
+}
+
+// Run executes op n times using at most Concurrency workers. No more
+// operations are started after the first error, which is returned, errors
+// returned with Failed are only recorded.
+func (p *Pool) Run(ctx context.Context, name string, n int, op Op) error {
+	if n <= 0 {
+		return nil
+	}
+	rec := stats.FromContext(ctx)
+	workers := p.concurrency
+	if workers > n {
+		workers = n
+	}
+	if workers == 1 {
+		buf := p.buffers.Get().(*[]byte)
+		defer p.buffers.Put(buf)
+		for i := 0; i < n; i++ {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := p.exec(ctx, rec, name, op, *buf); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// runCtx is canceled on the first error to stop the workers, operations
+	// use ctx so that those already running complete.
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		wg   sync.WaitGroup
+		once sync.Once
+		next int64
+		err  error
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			buf := p.buffers.Get().(*[]byte)
+			defer p.buffers.Put(buf)
+			for atomic.AddInt64(&next, 1) <= int64(n) {
+				if runCtx.Err() != nil {
+					return
+				}
+				if err2 := p.exec(ctx, rec, name, op, *buf); err2 != nil {
+					once.Do(func() {
+						err = err2
+						cancel()
+					})
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+func (p *Pool) exec(
+	ctx context.Context,
+	rec stats.Recorder,
+	name string,
+	op Op,
+	buf []byte,
+) error {
+	if err := ControlFromContext(ctx).wait(ctx); err != nil {
+		return err
+	}
+	if err := waitRate(ctx); err != nil {
+		return err
+	}
+	status := statusFromContext(ctx)
+	if status != nil {
+		status.AddWorkers(1)
+		defer status.AddWorkers(-1)
+	}
+	ctx, span := tracing.Start(ctx, name)
+	start := time.Now()
+	n, err := op(ctx, buf)
+	latency := time.Since(start)
+	var failed *failedError
+	if errors.As(err, &failed) {
+		err = failed.err
+	}
+	span.Finish(err)
+	rec.Record(stats.Result{
+		Time:    start,
+		Op:      name,
+		Latency: latency,
+		Bytes:   n,
+		Err:     err,
+		TraceID: span.SampledTraceID(),
+	})
+	if status != nil {
+		status.AddOp(start.Add(latency))
+	}
+	if failed != nil {
+		return nil
+	}
+	return err
+}
+
+// waitRate waits until the rate limit of the context allows an operation,
+// the limit is scaled by the Control of the context.
+func waitRate(ctx context.Context) error {
+	l, ok := ctx.Value(rateKey{}).(*limiter)
+	if !ok {
+		return nil
+	}
+	limit := rate.Limit(l.perSecond * ControlFromContext(ctx).RateScale())
+	if l.Limit() != limit {
+		l.SetLimit(limit)
+	}
+	r := l.Reserve()
+	d := r.Delay()
+	if d <= 0 {
+		return nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		r.Cancel()
+		return ctx.Err()
+	}
+}
+
+// Drain reads r to the end using buf and returns the number of bytes read.
+func Drain(r io.Reader, buf []byte) (int64, error) {
+	var n int64
+	for {
+		m, err := r.Read(buf)
+		n += int64(m)
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+	}
+}
+
+type poolKey struct{}
+
+var defaultPool = NewPool(1)
+
+// WithPool returns a context with the Pool used to run operations.
+func WithPool(ctx context.Context, p *Pool) context.Context {
+	return context.WithValue(ctx, poolKey{}, p)
+}
+
+// PoolFromContext returns the Pool of the context, if none is set a Pool with
+// a single worker is returned.
+func PoolFromContext(ctx context.Context) *Pool {
+	if p, ok := ctx.Value(poolKey{}).(*Pool); ok {
+		return p
+	}
+	return defaultPool
+}
+
+type rateKey struct{}
+
+// limiter is a rate.Limiter for a configured rate.
+type limiter struct {
+	*rate.Limiter
+	perSecond float64
+}
+
+// WithRate returns a context that limits the operations started by Pools to
+// perSecond, replacing the limit of the parent context.
+func WithRate(ctx context.Context, perSecond float64) context.Context {
+	return context.WithValue(ctx, rateKey{}, &limiter{
+		Limiter:   rate.NewLimiter(rate.Limit(perSecond), 1),
+		perSecond: perSecond,
+	})
+}
+
+type statusKey struct{}
+
+// WithStatus returns a context with the config.Status the active workers and
+// completed operations of Pools are counted in.
+func WithStatus(ctx context.Context, s *config.Status) context.Context {
+	return context.WithValue(ctx, statusKey{}, s)
+}
+
+func statusFromContext(ctx context.Context) *config.Status {
+	s, _ := ctx.Value(statusKey{}).(*config.Status)
+	return s
+}
+
+// Run executes op n times with the Pool of the context.
+func Run(ctx context.Context, name string, n int, op Op) error {
+	return PoolFromContext(ctx).Run(ctx, name, n, op)
+}
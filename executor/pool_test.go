@@ -0,0 +1,186 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hodgesds/dlg/config"
+	"github.com/hodgesds/dlg/stats"
+	"github.com/hodgesds/dlg/tracing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPoolRun tests operations are bounded by the pool concurrency and
+// every operation is recorded.
+func TestPoolRun(t *testing.T) {
+	var ops, inFlight, maxInFlight int32
+	c := stats.NewCollector()
+	ctx := stats.NewContext(context.Background(), c)
+
+	p := NewPool(4)
+	require.Equal(t, 4, p.Concurrency())
+	err := p.Run(ctx, "op", 50, func(_ context.Context, buf []byte) (int64, error) {
+		assert.Len(t, buf, bufferSize)
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			max := atomic.LoadInt32(&maxInFlight)
+			if n <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, n) {
+				break
+			}
+		}
+		atomic.AddInt32(&ops, 1)
+		time.Sleep(time.Millisecond)
+		return 10, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int32(50), ops)
+	assert.LessOrEqual(t, maxInFlight, int32(4))
+
+	stages := c.Stages()
+	require.Len(t, stages, 1)
+	assert.Equal(t, int64(50), stages[0].Main.Ops)
+	assert.Equal(t, int64(500), stages[0].Main.Bytes)
+}
+
+// TestPoolRunError tests no operations are started after an error.
+func TestPoolRunError(t *testing.T) {
+	for _, concurrency := range []int{1, 4} {
+		var ops int32
+		errOp := errors.New("op")
+		err := NewPool(concurrency).Run(
+			context.Background(),
+			"op",
+			1000,
+			func(context.Context, []byte) (int64, error) {
+				if atomic.AddInt32(&ops, 1) == 10 {
+					return 0, errOp
+				}
+				return 0, nil
+			},
+		)
+		require.Equal(t, errOp, err)
+		assert.Less(t, atomic.LoadInt32(&ops), int32(1000))
+	}
+}
+
+// TestPoolRunFailed tests operations failed with Failed are recorded without
+// stopping the pool.
+func TestPoolRunFailed(t *testing.T) {
+	var errs []error
+	ctx := stats.NewContext(context.Background(), stats.RecorderFunc(func(r stats.Result) {
+		if r.Err != nil {
+			errs = append(errs, r.Err)
+		}
+	}))
+	errOp := errors.New("op")
+	var ops int32
+	require.Nil(t, Failed(nil))
+	require.NoError(t, NewPool(1).Run(ctx, "op", 10, func(context.Context, []byte) (int64, error) {
+		if atomic.AddInt32(&ops, 1)%2 == 0 {
+			return 0, Failed(errOp)
+		}
+		return 0, nil
+	}))
+	assert.Equal(t, int32(10), ops)
+	require.Len(t, errs, 5)
+	assert.Same(t, errOp, errs[0])
+}
+
+// TestPoolFromContext tests the pool is passed through the context.
+func TestPoolFromContext(t *testing.T) {
+	require.Equal(t, 1, PoolFromContext(context.Background()).Concurrency())
+
+	p := NewPool(8)
+	ctx := WithPool(context.Background(), p)
+	require.Same(t, p, PoolFromContext(ctx))
+
+	var ops int32
+	require.NoError(t, Run(ctx, "op", 16, func(context.Context, []byte) (int64, error) {
+		atomic.AddInt32(&ops, 1)
+		return 0, nil
+	}))
+	require.Equal(t, int32(16), ops)
+}
+
+type testExporter struct {
+	mu    sync.Mutex
+	spans []*tracing.Span
+}
+
+func (e *testExporter) Export(_ context.Context, spans []*tracing.Span) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+// TestPoolRunTraced tests operations are traced when the context has a
+// Tracer.
+func TestPoolRunTraced(t *testing.T) {
+	exp := &testExporter{}
+	tracer := tracing.NewTracer(exp, 1)
+	var results []stats.Result
+	ctx := stats.NewContext(context.Background(), stats.RecorderFunc(func(r stats.Result) {
+		results = append(results, r)
+	}))
+	ctx = tracing.NewContext(ctx, tracer)
+
+	err := NewPool(1).Run(ctx, "op", 3, func(ctx context.Context, _ []byte) (int64, error) {
+		assert.NotNil(t, tracing.SpanFromContext(ctx))
+		return 0, nil
+	})
+	require.NoError(t, err)
+	require.NoError(t, tracer.Close(context.Background()))
+
+	require.Len(t, exp.spans, 3)
+	require.Len(t, results, 3)
+	for i, s := range exp.spans {
+		assert.Equal(t, "op", s.Name)
+		assert.Equal(t, s.TraceID.String(), results[i].TraceID)
+	}
+}
+
+// TestPoolRunRate tests operations are limited to the rate of the context
+// and counted in its status.
+func TestPoolRunRate(t *testing.T) {
+	status := &config.Status{}
+	ctx := WithStatus(WithRate(context.Background(), 50), status)
+
+	var maxWorkers int64
+	var mu sync.Mutex
+	start := time.Now()
+	err := NewPool(4).Run(ctx, "op", 10, func(context.Context, []byte) (int64, error) {
+		mu.Lock()
+		if w := status.Workers(); w > maxWorkers {
+			maxWorkers = w
+		}
+		mu.Unlock()
+		return 0, nil
+	})
+	require.NoError(t, err)
+	// The first operation is not delayed, the other 9 are 20ms apart.
+	assert.GreaterOrEqual(t, time.Since(start), 160*time.Millisecond)
+	assert.Equal(t, int64(0), status.Workers())
+	assert.GreaterOrEqual(t, maxWorkers, int64(1))
+}
+
+// TestPoolRunRateCanceled tests waiting for the rate ends when the context
+// is canceled.
+func TestPoolRunRateCanceled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(WithRate(context.Background(), 1), 50*time.Millisecond)
+	defer cancel()
+
+	var ops int32
+	err := NewPool(1).Run(ctx, "op", 5, func(context.Context, []byte) (int64, error) {
+		atomic.AddInt32(&ops, 1)
+		return 0, nil
+	})
+	require.Error(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&ops))
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/apache/pulsar-client-go/pulsar"
 	pulsarconfig "github.com/hodgesds/dlg/config/pulsar"
+	"github.com/hodgesds/dlg/executor"
 )
 
 type pulsarExecutor struct{}
@@ -33,16 +34,22 @@ func (e *pulsarExecutor) Execute(ctx context.Context, config *pulsarconfig.Confi
 	}
 	defer client.Close()
 
+	return executor.Run(ctx, config.Operation, 1, func(ctx context.Context, _ []byte) (int64, error) {
+		return 0, e.exec(ctx, client, config)
+	})
+}
+
+func (e *pulsarExecutor) exec(ctx context.Context, client pulsar.Client, config *pulsarconfig.Config) error {
 	switch config.Operation {
 	case "produce":
 		producer, err := client.CreateProducer(pulsar.ProducerOptions{Topic: config.Topic})
 		if err != nil {
-			return err
+			return executor.Failed(err)
 		}
 		defer producer.Close()
 
 		_, err = producer.Send(ctx, &pulsar.ProducerMessage{Payload: []byte(config.Message)})
-		return err
+		return executor.Failed(err)
 
 	case "consume":
 		subscriptionType := pulsar.Exclusive
@@ -61,13 +68,13 @@ func (e *pulsarExecutor) Execute(ctx context.Context, config *pulsarconfig.Confi
 			Type:             subscriptionType,
 		})
 		if err != nil {
-			return err
+			return executor.Failed(err)
 		}
 		defer consumer.Close()
 
 		msg, err := consumer.Receive(ctx)
 		if err != nil {
-			return err
+			return executor.Failed(err)
 		}
 		consumer.Ack(msg)
 		return nil
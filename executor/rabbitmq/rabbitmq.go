@@ -7,13 +7,23 @@ import (
 
 	amqp "github.com/rabbitmq/amqp091-go"
 	rabbitmqconfig "github.com/hodgesds/dlg/config/rabbitmq"
+	"github.com/hodgesds/dlg/executor"
+	"github.com/hodgesds/dlg/tracing"
 )
 
-type rabbitmqExecutor struct{}
+type rabbitmqExecutor struct {
+	// Connections are cached by URL, channels are not safe for
+	// concurrent use so one is opened per execution.
+	*executor.ClientCache[string, *amqp.Connection]
+}
 
 // New returns a new RabbitMQ executor.
 func New() *rabbitmqExecutor {
-	return &rabbitmqExecutor{}
+	return &rabbitmqExecutor{
+		ClientCache: executor.NewClientCache[string](
+			func(c *amqp.Connection) error { return c.Close() },
+		),
+	}
 }
 
 // Execute implements the RabbitMQ executor interface.
@@ -22,11 +32,13 @@ func (e *rabbitmqExecutor) Execute(ctx context.Context, config *rabbitmqconfig.C
 		return err
 	}
 
-	conn, err := amqp.Dial(config.URL)
+	conn, release, err := e.Get(ctx, config.URL, func(context.Context) (*amqp.Connection, error) {
+		return amqp.Dial(config.URL)
+	})
 	if err != nil {
 		return fmt.Errorf("failed to connect: %w", err)
 	}
-	defer conn.Close()
+	defer release()
 
 	ch, err := conn.Channel()
 	if err != nil {
@@ -41,19 +53,28 @@ func (e *rabbitmqExecutor) Execute(ctx context.Context, config *rabbitmqconfig.C
 		}
 	}
 
+	return executor.Run(ctx, config.Operation, 1, func(ctx context.Context, _ []byte) (int64, error) {
+		return 0, e.exec(ctx, ch, config)
+	})
+}
+
+func (e *rabbitmqExecutor) exec(ctx context.Context, ch *amqp.Channel, config *rabbitmqconfig.Config) error {
 	switch config.Operation {
 	case "publish":
 		routingKey := config.RoutingKey
 		if routingKey == "" {
 			routingKey = config.Queue
 		}
-		return ch.PublishWithContext(ctx, config.Exchange, routingKey, false, false,
-			amqp.Publishing{ContentType: "text/plain", Body: []byte(config.Message)})
+		msg := amqp.Publishing{ContentType: "text/plain", Body: []byte(config.Message)}
+		tracing.Inject(ctx, func(k, v string) {
+			msg.Headers = amqp.Table{k: v}
+		})
+		return executor.Failed(ch.PublishWithContext(ctx, config.Exchange, routingKey, false, false, msg))
 
 	case "consume":
 		msgs, err := ch.Consume(config.Queue, "", config.AutoAck, config.Exclusive, false, config.NoWait, nil)
 		if err != nil {
-			return fmt.Errorf("failed to consume: %w", err)
+			return executor.Failed(fmt.Errorf("failed to consume: %w", err))
 		}
 
 		select {
@@ -63,7 +84,7 @@ func (e *rabbitmqExecutor) Execute(ctx context.Context, config *rabbitmqconfig.C
 			}
 			return nil
 		case <-time.After(config.Timeout):
-			return fmt.Errorf("consume timeout")
+			return executor.Failed(fmt.Errorf("consume timeout"))
 		case <-ctx.Done():
 			return ctx.Err()
 		}
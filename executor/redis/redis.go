@@ -2,19 +2,123 @@ package redis
 
 import (
 	"context"
+	"errors"
+	"strings"
+	"time"
 
+	v7 "github.com/go-redis/redis/v7"
 	redisconf "github.com/hodgesds/dlg/config/redis"
 	"github.com/hodgesds/dlg/executor"
+	"github.com/hodgesds/dlg/stats"
 )
 
-type redisExecutor struct{}
+// clientKey contains the config fields used to create a client.
+type clientKey struct {
+	network            string
+	addr               string
+	db                 int
+	password           string
+	poolSize           int
+	maxRetries         int
+	minRetryBackoff    time.Duration
+	maxRetryBackoff    time.Duration
+	dialTimeout        time.Duration
+	readTimeout        time.Duration
+	writeTimeout       time.Duration
+	maxConnAge         time.Duration
+	poolTimeout        time.Duration
+	idleTimeout        time.Duration
+	idleCheckFrequency time.Duration
+}
+
+func newClientKey(c *redisconf.Config) clientKey {
+	k := clientKey{
+		network:  c.Network,
+		addr:     c.Addr,
+		db:       c.DB,
+		password: c.Password,
+	}
+	if c.PoolSize != nil {
+		k.poolSize = *c.PoolSize
+	}
+	if c.MaxRetries != nil {
+		k.maxRetries = *c.MaxRetries
+	}
+	if c.MinRetryBackoff != nil {
+		k.minRetryBackoff = *c.MinRetryBackoff
+	}
+	if c.MaxRetryBackoff != nil {
+		k.maxRetryBackoff = *c.MaxRetryBackoff
+	}
+	if c.DialTimeout != nil {
+		k.dialTimeout = *c.DialTimeout
+	}
+	if c.ReadTimeout != nil {
+		k.readTimeout = *c.ReadTimeout
+	}
+	if c.WriteTimeout != nil {
+		k.writeTimeout = *c.WriteTimeout
+	}
+	if c.MaxConnAge != nil {
+		k.maxConnAge = *c.MaxConnAge
+	}
+	if c.PoolTimeout != nil {
+		k.poolTimeout = *c.PoolTimeout
+	}
+	if c.IdleTimeout != nil {
+		k.idleTimeout = *c.IdleTimeout
+	}
+	if c.IdleCheckFrequency != nil {
+		k.idleCheckFrequency = *c.IdleCheckFrequency
+	}
+	return k
+}
+
+type redisExecutor struct {
+	*executor.ClientCache[clientKey, *v7.Client]
+}
 
 // New returns a new Redis executor.
 func New() executor.Redis {
-	return &redisExecutor{}
+	return &redisExecutor{
+		ClientCache: executor.NewClientCache[clientKey](
+			func(c *v7.Client) error { return c.Close() },
+		),
+	}
 }
 
 // Execute implements the Redis interface.
 func (e *redisExecutor) Execute(ctx context.Context, conf *redisconf.Config) error {
-	return conf.Execute(ctx)
+	client, release, err := e.Get(ctx, newClientKey(conf), func(context.Context) (*v7.Client, error) {
+		return conf.Client(), nil
+	})
+	if err != nil {
+		return err
+	}
+	defer release()
+	return executor.Run(ctx, "commands", 1, func(ctx context.Context, _ []byte) (int64, error) {
+		return 0, executor.Failed(classifyError(conf.ExecuteClient(ctx, client)))
+	})
+}
+
+// authErrors are the prefixes of Redis error replies of failed
+// authentication or authorization.
+var authErrors = map[string]bool{
+	"NOAUTH":    true,
+	"WRONGPASS": true,
+	"NOPERM":    true,
+}
+
+// classifyError classifies Redis error replies by their prefix, such as ERR
+// or WRONGTYPE.
+func classifyError(err error) error {
+	var redisErr v7.Error
+	if !errors.As(err, &redisErr) {
+		return err
+	}
+	code := strings.SplitN(redisErr.Error(), " ", 2)[0]
+	if authErrors[code] {
+		return stats.Classify(stats.ClassAuth, code, err)
+	}
+	return stats.Classify(stats.ClassProtocol, code, err)
 }
@@ -3,15 +3,38 @@ package scylladb
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/gocql/gocql"
 	scyllaconfig "github.com/hodgesds/dlg/config/scylladb"
+	"github.com/hodgesds/dlg/executor"
 )
 
-type scyllaExecutor struct{}
+// clientKey contains the config fields used to create a session.
+type clientKey struct {
+	hosts       string
+	keyspace    string
+	port        int
+	timeout     time.Duration
+	consistency string
+	username    string
+	password    string
+}
+
+type scyllaExecutor struct {
+	*executor.ClientCache[clientKey, *gocql.Session]
+}
 
 func New() *scyllaExecutor {
-	return &scyllaExecutor{}
+	return &scyllaExecutor{
+		ClientCache: executor.NewClientCache[clientKey](
+			func(s *gocql.Session) error {
+				s.Close()
+				return nil
+			},
+		),
+	}
 }
 
 func (e *scyllaExecutor) Execute(ctx context.Context, config *scyllaconfig.Config) error {
@@ -19,6 +42,29 @@ func (e *scyllaExecutor) Execute(ctx context.Context, config *scyllaconfig.Confi
 		return err
 	}
 
+	key := clientKey{
+		hosts:       strings.Join(config.Hosts, ","),
+		keyspace:    config.Keyspace,
+		port:        config.Port,
+		timeout:     config.Timeout,
+		consistency: config.Consistency,
+		username:    config.Username,
+		password:    config.Password,
+	}
+	session, release, err := e.Get(ctx, key, func(context.Context) (*gocql.Session, error) {
+		return newSession(config)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+	defer release()
+
+	return executor.Run(ctx, "query", 1, func(ctx context.Context, _ []byte) (int64, error) {
+		return 0, executor.Failed(session.Query(config.Query, config.Values...).WithContext(ctx).Exec())
+	})
+}
+
+func newSession(config *scyllaconfig.Config) (*gocql.Session, error) {
 	cluster := gocql.NewCluster(config.Hosts...)
 	cluster.Keyspace = config.Keyspace
 	cluster.Port = config.Port
@@ -44,11 +90,5 @@ func (e *scyllaExecutor) Execute(ctx context.Context, config *scyllaconfig.Confi
 		cluster.Consistency = gocql.Quorum
 	}
 
-	session, err := cluster.CreateSession()
-	if err != nil {
-		return fmt.Errorf("failed to create session: %w", err)
-	}
-	defer session.Close()
-
-	return session.Query(config.Query, config.Values...).WithContext(ctx).Exec()
+	return cluster.CreateSession()
 }
@@ -19,12 +19,20 @@ func New() executor.SNMP {
 
 // Execute implements the SNMP executor interface.
 func (e *snmpExecutor) Execute(ctx context.Context, config *config.Config) error {
+	return executor.Run(ctx, "get", 1, func(ctx context.Context, _ []byte) (int64, error) {
+		return 0, e.exec(ctx, config)
+	})
+}
+
+func (e *snmpExecutor) exec(ctx context.Context, config *config.Config) error {
 	snmp := config.SNMP()
 	if err := snmp.Connect(); err != nil {
-		return err
+		return executor.Failed(err)
 	}
 	oids, err := snmp.Get(config.Oids)
 	if err != nil {
+		snmp.Conn.Close()
+		return executor.Failed(err)
 	}
 	if e.debug {
 		fmt.Printf("%+v\n", oids)
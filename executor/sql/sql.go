@@ -3,19 +3,37 @@ package sql
 import (
 	"context"
 	"database/sql"
-	"sync"
+	"errors"
+	"strconv"
+	"strings"
 
+	"github.com/go-sql-driver/mysql"
 	sqlconf "github.com/hodgesds/dlg/config/sql"
 	"github.com/hodgesds/dlg/executor"
-	"go.uber.org/multierr"
+	"github.com/hodgesds/dlg/stats"
+	"github.com/lib/pq"
 )
 
+// clientKey contains the config fields used to open a database.
+type clientKey struct {
+	postgresDSN   string
+	mysqlDSN      string
+	clickhouseDSN string
+	maxConns      int
+	maxIdleConns  int
+}
+
 type sqlExecutor struct {
+	*executor.ClientCache[clientKey, *sql.DB]
 }
 
 // New returns a SQL executor.
 func New() executor.SQL {
-	return &sqlExecutor{}
+	return &sqlExecutor{
+		ClientCache: executor.NewClientCache[clientKey](
+			func(db *sql.DB) error { return db.Close() },
+		),
+	}
 }
 
 // Execute implements the SQL executor interface.
@@ -23,18 +41,36 @@ func (e *sqlExecutor) Execute(ctx context.Context, c *sqlconf.Config) error {
 	if err := c.Validate(); err != nil {
 		return err
 	}
-	db, err := c.DB()
-	if err != nil {
-		return nil
+	key := clientKey{
+		postgresDSN:   c.PostgresDSN,
+		mysqlDSN:      c.MysqlDSN,
+		clickhouseDSN: c.ClickHouseDSN,
+		maxConns:      c.MaxConns,
+		maxIdleConns:  c.MaxIdleConns,
 	}
-	if err := setupDB(db, c); err != nil {
+	db, release, err := e.Get(ctx, key, func(context.Context) (*sql.DB, error) {
+		db, err := c.DB()
+		if err != nil {
+			return nil, err
+		}
+		if err := setupDB(db, c); err != nil {
+			db.Close()
+			return nil, err
+		}
+		return db, nil
+	})
+	if err != nil {
 		return err
 	}
+	defer release()
 	if c.Concurrent {
 		return e.execParallel(ctx, db, c)
 	}
 	for _, payload := range c.Payloads {
-		_, err := db.ExecContext(ctx, payload.Exec)
+		err := executor.Run(ctx, "exec", 1, func(ctx context.Context, _ []byte) (int64, error) {
+			_, err := db.ExecContext(ctx, payload.Exec)
+			return 0, executor.Failed(classifyError(err))
+		})
 		if err != nil {
 			return err
 		}
@@ -42,30 +78,46 @@ func (e *sqlExecutor) Execute(ctx context.Context, c *sqlconf.Config) error {
 	return nil
 }
 
+// execParallel executes all payloads at the same time.
 func (e *sqlExecutor) execParallel(
 	ctx context.Context,
 	db *sql.DB,
 	c *sqlconf.Config,
 ) error {
-	var (
-		wg  sync.WaitGroup
-		mu  sync.Mutex
-		err error
-	)
+	payloads := make(chan *sqlconf.Payload, len(c.Payloads))
 	for _, payload := range c.Payloads {
-		wg.Add(1)
-		go func(payload *sqlconf.Payload) {
-			_, err2 := db.ExecContext(ctx, payload.Exec)
-			if err2 != nil {
-				mu.Lock()
-				err = multierr.Append(err, err2)
-				mu.Unlock()
-			}
-			wg.Done()
-		}(payload)
+		payloads <- payload
 	}
-	wg.Wait()
+	close(payloads)
+	pool := executor.NewPool(len(c.Payloads))
+	return pool.Run(ctx, "exec", len(c.Payloads), func(ctx context.Context, _ []byte) (int64, error) {
+		_, err := db.ExecContext(ctx, (<-payloads).Exec)
+		return 0, executor.Failed(classifyError(err))
+	})
+}
 
+// classifyError classifies Postgres errors by their SQL state and MySQL
+// errors by their number.
+func classifyError(err error) error {
+	var (
+		pqErr    *pq.Error
+		mysqlErr *mysql.MySQLError
+	)
+	switch {
+	case errors.As(err, &pqErr):
+		// Class 28 is invalid authorization specification.
+		if strings.HasPrefix(string(pqErr.Code), "28") {
+			return stats.Classify(stats.ClassAuth, string(pqErr.Code), err)
+		}
+		return stats.Classify(stats.ClassProtocol, string(pqErr.Code), err)
+	case errors.As(err, &mysqlErr):
+		code := strconv.Itoa(int(mysqlErr.Number))
+		// 1044 and 1045 deny access to a database or user.
+		if mysqlErr.Number == 1044 || mysqlErr.Number == 1045 {
+			return stats.Classify(stats.ClassAuth, code, err)
+		}
+		return stats.Classify(stats.ClassProtocol, code, err)
+	}
 	return err
 }
 
@@ -2,32 +2,60 @@ package ssh
 
 import (
 	"context"
+	"strings"
 
 	sshconf "github.com/hodgesds/dlg/config/ssh"
 	"github.com/hodgesds/dlg/executor"
+	"golang.org/x/crypto/ssh"
 )
 
-type sshExecutor struct{}
+// clientKey contains the config fields used to dial a client.
+type clientKey struct {
+	addr              string
+	user              string
+	password          string
+	keyFile           string
+	clientVersion     string
+	hostKeyAlgorithms string
+}
+
+type sshExecutor struct {
+	*executor.ClientCache[clientKey, *ssh.Client]
+}
 
 // New returns a new SSH.
 func New() executor.SSH {
-	return &sshExecutor{}
+	return &sshExecutor{
+		ClientCache: executor.NewClientCache[clientKey](
+			func(c *ssh.Client) error { return c.Close() },
+		),
+	}
 }
 
 // Execute implements the SSH interface.
 func (e *sshExecutor) Execute(ctx context.Context, config *sshconf.Config) error {
-	client, err := config.SSHClient(ctx)
+	key := clientKey{
+		addr:              config.Addr,
+		user:              config.User,
+		password:          config.Password,
+		keyFile:           config.KeyFile,
+		clientVersion:     config.ClientVersion,
+		hostKeyAlgorithms: strings.Join(config.HostKeyAlgorithms, ","),
+	}
+	client, release, err := e.Get(ctx, key, config.SSHClient)
 	if err != nil {
 		return err
 	}
-	if config.Cmd != nil {
+	defer release()
+	if config.Cmd == nil {
+		return nil
+	}
+	return executor.Run(ctx, "cmd", 1, func(context.Context, []byte) (int64, error) {
 		s, err := client.NewSession()
 		if err != nil {
-			return err
+			return 0, executor.Failed(err)
 		}
-		if err := s.Run(*config.Cmd); err != nil {
-			return err
-		}
-	}
-	return nil
+		defer s.Close()
+		return 0, executor.Failed(s.Run(*config.Cmd))
+	})
 }
@@ -6,6 +6,13 @@ import (
 
 // metrics contains metrics.
 type metrics struct {
+	OperationsTotal            *prometheus.CounterVec
+	OperationDuration          *prometheus.HistogramVec
+	OperationErrorsTotal       *prometheus.CounterVec
+	WarmupOperationsTotal      *prometheus.CounterVec
+	WarmupOperationDuration    *prometheus.HistogramVec
+	WarmupOperationErrorsTotal *prometheus.CounterVec
+
 	ErrorsTotal        *prometheus.CounterVec
 	ArangoDBTotal      *prometheus.CounterVec
 	CassandraTotal     *prometheus.CounterVec
@@ -52,6 +59,42 @@ func newMetrics(reg *prometheus.Registry) (*metrics, error) {
 			Name:      "errors_total",
 			Help:      "The total number and type of errors that occurred while advertising.",
 		}, []string{"stage"}),
+		OperationsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "executor",
+			Subsystem: "stage",
+			Name:      "operations_total",
+			Help:      "The total number of operations, excluding warm-up.",
+		}, []string{"stage"}),
+		OperationDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "executor",
+			Subsystem: "stage",
+			Name:      "operation_duration_seconds",
+			Help:      "The duration of operations, excluding warm-up.",
+		}, []string{"stage"}),
+		OperationErrorsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "executor",
+			Subsystem: "stage",
+			Name:      "operation_errors_total",
+			Help:      "The total number of failed operations by error class, excluding warm-up.",
+		}, []string{"stage", "class"}),
+		WarmupOperationsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "executor",
+			Subsystem: "stage",
+			Name:      "warmup_operations_total",
+			Help:      "The total number of operations during warm-up.",
+		}, []string{"stage"}),
+		WarmupOperationDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "executor",
+			Subsystem: "stage",
+			Name:      "warmup_operation_duration_seconds",
+			Help:      "The duration of operations during warm-up.",
+		}, []string{"stage"}),
+		WarmupOperationErrorsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "executor",
+			Subsystem: "stage",
+			Name:      "warmup_operation_errors_total",
+			Help:      "The total number of failed operations by error class during warm-up.",
+		}, []string{"stage", "class"}),
 		DHCP4Total: prometheus.NewCounterVec(prometheus.CounterOpts{
 			Namespace: "executor",
 			Subsystem: "stage",
@@ -265,6 +308,12 @@ func newMetrics(reg *prometheus.Registry) (*metrics, error) {
 	}
 	reg.MustRegister(
 		m.ErrorsTotal,
+		m.OperationsTotal,
+		m.OperationDuration,
+		m.OperationErrorsTotal,
+		m.WarmupOperationsTotal,
+		m.WarmupOperationDuration,
+		m.WarmupOperationErrorsTotal,
 		m.ArangoDBTotal,
 		m.CassandraTotal,
 		m.ClickHouseTotal,
@@ -0,0 +1,112 @@
+package stage
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hodgesds/dlg/config"
+	"github.com/hodgesds/dlg/stats"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// stageRecorder records the results of a stage. Results are tagged with the
+// stage name and marked as warmup while the stage, or any of its parents, is
+// in its warm-up period.
+type stageRecorder struct {
+	stage   *config.Stage
+	start   time.Time
+	ops     int64
+	parent  *stageRecorder
+	next    stats.Recorder
+	metrics *metrics
+}
+
+type recordersKey struct{}
+
+// stageRecorders are the recorders of the stages of an execution.
+type stageRecorders struct {
+	mu        sync.Mutex
+	recorders map[*config.Stage]*stageRecorder
+}
+
+// recorder returns the recorder of a stage. A stage executed again during
+// the same execution, such as the child of a repeated stage, keeps its
+// recorder so that its warm-up period is not restarted.
+func (e *stageExecutor) recorder(ctx context.Context, s *config.Stage) (context.Context, *stageRecorder) {
+	recs, ok := ctx.Value(recordersKey{}).(*stageRecorders)
+	if !ok {
+		recs = &stageRecorders{recorders: map[*config.Stage]*stageRecorder{}}
+		ctx = context.WithValue(ctx, recordersKey{}, recs)
+	}
+	recs.mu.Lock()
+	defer recs.mu.Unlock()
+	r, ok := recs.recorders[s]
+	if !ok {
+		r = e.newRecorder(ctx, s)
+		recs.recorders[s] = r
+	}
+	return ctx, r
+}
+
+func (e *stageExecutor) newRecorder(ctx context.Context, s *config.Stage) *stageRecorder {
+	r := &stageRecorder{
+		stage:   s,
+		start:   time.Now(),
+		next:    stats.FromContext(ctx),
+		metrics: e.metrics,
+	}
+	if parent, ok := r.next.(*stageRecorder); ok {
+		r.parent = parent
+		r.next = parent.next
+	}
+	return r
+}
+
+// warmup counts an operation and returns if it happened during a warm-up
+// period.
+func (r *stageRecorder) warmup(t time.Time) bool {
+	warmup := r.parent != nil && r.parent.warmup(t)
+	if ops := atomic.AddInt64(&r.ops, 1); ops <= int64(r.stage.WarmupOps) {
+		warmup = true
+	}
+	if r.stage.Warmup != nil && t.Sub(r.start) < *r.stage.Warmup {
+		warmup = true
+	}
+	return warmup
+}
+
+// Record implements the stats.Recorder interface.
+func (r *stageRecorder) Record(res stats.Result) {
+	if res.Time.IsZero() {
+		res.Time = time.Now()
+	}
+	res.Stage = r.stage.Name
+	if res.Protocol == "" {
+		res.Protocol = r.stage.Protocol()
+	}
+	if r.warmup(res.Time) {
+		res.Warmup = true
+	}
+
+	labels := prometheus.Labels{"stage": res.Stage}
+	if res.Warmup {
+		r.metrics.WarmupOperationsTotal.With(labels).Inc()
+		r.metrics.WarmupOperationDuration.With(labels).Observe(res.Latency.Seconds())
+		if res.Err != nil {
+			r.metrics.WarmupOperationErrorsTotal.With(errorLabels(res)).Inc()
+		}
+	} else {
+		r.metrics.OperationsTotal.With(labels).Inc()
+		r.metrics.OperationDuration.With(labels).Observe(res.Latency.Seconds())
+		if res.Err != nil {
+			r.metrics.OperationErrorsTotal.With(errorLabels(res)).Inc()
+		}
+	}
+	r.next.Record(res)
+}
+
+func errorLabels(res stats.Result) prometheus.Labels {
+	return prometheus.Labels{"stage": res.Stage, "class": stats.ErrorClass(res.Err)}
+}
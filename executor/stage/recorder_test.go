@@ -0,0 +1,138 @@
+package stage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hodgesds/dlg/config"
+	httpconf "github.com/hodgesds/dlg/config/http"
+	"github.com/hodgesds/dlg/executor"
+	"github.com/hodgesds/dlg/stats"
+	"github.com/hodgesds/dlg/util"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeHTTP struct{}
+
+func (*fakeHTTP) Execute(ctx context.Context, _ *httpconf.Config) error {
+	return executor.Run(ctx, "GET", 1, func(context.Context, []byte) (int64, error) {
+		return 0, nil
+	})
+}
+
+func newTestExecutor(t *testing.T) *stageExecutor {
+	e, err := New(Params{
+		Registry: prometheus.NewPedanticRegistry(),
+		HTTP:     &fakeHTTP{},
+	})
+	require.NoError(t, err)
+	return e.(*stageExecutor)
+}
+
+// TestWarmupOps tests the first operations of a stage are recorded as
+// warm-up.
+func TestWarmupOps(t *testing.T) {
+	c := stats.NewCollector()
+	ctx := stats.NewContext(context.Background(), c)
+	s := &config.Stage{
+		Name:      "http",
+		Repeat:    4,
+		WarmupOps: 2,
+		HTTP:      &httpconf.Config{},
+	}
+	require.NoError(t, newTestExecutor(t).Execute(ctx, s))
+
+	stages := c.Stages()
+	require.Len(t, stages, 1)
+	assert.Equal(t, "http", stages[0].Name)
+	assert.Equal(t, "http", stages[0].Protocol)
+	assert.Equal(t, int64(2), stages[0].Warmup.Ops)
+	assert.Equal(t, int64(3), stages[0].Main.Ops)
+}
+
+// TestWarmupParent tests a parent warm-up applies to its children.
+func TestWarmupParent(t *testing.T) {
+	c := stats.NewCollector()
+	ctx := stats.NewContext(context.Background(), c)
+	s := &config.Stage{
+		Name:   "parent",
+		Warmup: util.DurPtr(time.Hour),
+		Children: []*config.Stage{
+			{
+				Name:   "child",
+				Repeat: 1,
+				HTTP:   &httpconf.Config{},
+			},
+		},
+	}
+	require.NoError(t, newTestExecutor(t).Execute(ctx, s))
+
+	stages := c.Stages()
+	require.Len(t, stages, 1)
+	assert.Equal(t, "child", stages[0].Name)
+	assert.Equal(t, int64(2), stages[0].Warmup.Ops)
+	assert.Equal(t, int64(0), stages[0].Main.Ops)
+}
+
+// TestWarmupRepeatedParent tests the warm-up of a child is not restarted
+// when its parent repeats it.
+func TestWarmupRepeatedParent(t *testing.T) {
+	c := stats.NewCollector()
+	ctx := stats.NewContext(context.Background(), c)
+	s := &config.Stage{
+		Name:   "parent",
+		Repeat: 9,
+		Children: []*config.Stage{
+			{
+				Name:      "child",
+				WarmupOps: 2,
+				HTTP:      &httpconf.Config{},
+			},
+		},
+	}
+	require.NoError(t, newTestExecutor(t).Execute(ctx, s))
+
+	stages := c.Stages()
+	require.Len(t, stages, 1)
+	assert.Equal(t, int64(2), stages[0].Warmup.Ops)
+	assert.Equal(t, int64(8), stages[0].Main.Ops)
+}
+
+// TestWarmupDuration tests results after the warm-up period are measured.
+func TestWarmupDuration(t *testing.T) {
+	c := stats.NewCollector()
+	s := &config.Stage{
+		Name:   "http",
+		Warmup: util.DurPtr(time.Minute),
+	}
+	e := newTestExecutor(t)
+	r := e.newRecorder(stats.NewContext(context.Background(), c), s)
+	r.Record(stats.Result{Time: r.start})
+	r.Record(stats.Result{Time: r.start.Add(2 * time.Minute)})
+
+	stages := c.Stages()
+	require.Len(t, stages, 1)
+	assert.Equal(t, int64(1), stages[0].Warmup.Ops)
+	assert.Equal(t, int64(1), stages[0].Main.Ops)
+}
+
+// TestExecuteRecorder tests a stage executed with a recorder of another type
+// in its context records its results to it.
+func TestExecuteRecorder(t *testing.T) {
+	c := stats.NewCollector()
+	ctx := stats.NewContext(context.Background(), c)
+	s := &config.Stage{
+		Name:   "http",
+		Repeat: 1,
+		HTTP:   &httpconf.Config{},
+	}
+	require.NoError(t, newTestExecutor(t).execute(ctx, s))
+
+	stages := c.Stages()
+	require.Len(t, stages, 1)
+	assert.Equal(t, "http", stages[0].Name)
+	assert.Equal(t, int64(2), stages[0].Main.Ops)
+}
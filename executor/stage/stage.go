@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/hodgesds/dlg/config"
 	"github.com/hodgesds/dlg/executor"
@@ -42,6 +44,8 @@ import (
 	"github.com/hodgesds/dlg/executor/tftp"
 	"github.com/hodgesds/dlg/executor/udp"
 	"github.com/hodgesds/dlg/executor/websocket"
+	"github.com/hodgesds/dlg/stats"
+	"github.com/hodgesds/dlg/tracing"
 	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/multierr"
 )
@@ -225,11 +229,108 @@ func Default(reg *prometheus.Registry) (executor.Stage, error) {
 	}, nil
 }
 
+// executors returns all the protocol executors.
+func (e *stageExecutor) executors() []interface{} {
+	return []interface{}{
+		e.arangodb,
+		e.cassandra,
+		e.clickhouse,
+		e.couchdb,
+		e.dhcp4,
+		e.dns,
+		e.elasticsearch,
+		e.etcd,
+		e.ftp,
+		e.graphql,
+		e.grpc,
+		e.http,
+		e.icmp,
+		e.influxdb,
+		e.kafka,
+		e.ldap,
+		e.memcache,
+		e.mongodb,
+		e.mqtt,
+		e.nats,
+		e.neo4j,
+		e.ntp,
+		e.pulsar,
+		e.rabbitmq,
+		e.redis,
+		e.scylladb,
+		e.sql,
+		e.snmp,
+		e.ssh,
+		e.syslog,
+		e.tcp,
+		e.telnet,
+		e.tftp,
+		e.udp,
+		e.websocket,
+	}
+}
+
+// Init implements the executor.Lifecycle interface.
+func (e *stageExecutor) Init(ctx context.Context) error {
+	for _, ex := range e.executors() {
+		if l, ok := ex.(executor.Lifecycle); ok {
+			if err := l.Init(ctx); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Close implements the executor.Lifecycle interface, it closes any clients
+// held by the protocol executors.
+func (e *stageExecutor) Close() error {
+	var err error
+	for _, ex := range e.executors() {
+		if l, ok := ex.(executor.Lifecycle); ok {
+			err = multierr.Append(err, l.Close())
+		}
+	}
+	return err
+}
+
 // Execute implements the Stage interface.
 func (e *stageExecutor) Execute(ctx context.Context, s *config.Stage) error {
+	ctx, rec := e.recorder(ctx, s)
+	ctx = stats.NewContext(ctx, rec)
+	if tracing.FromContext(ctx) != nil {
+		ctx = tracing.WithAttributes(ctx,
+			tracing.String("dlg.stage", s.Name),
+			tracing.String("dlg.protocol", s.Protocol()),
+		)
+	}
+	if s.Churn {
+		ctx = executor.WithChurn(ctx)
+	}
+	if s.Concurrency > 0 {
+		ctx = executor.WithPool(ctx, executor.NewPool(s.Concurrency))
+	}
+	if s.Rate > 0 {
+		ctx = executor.WithRate(ctx, s.Rate)
+	}
+	status := s.Status()
+	ctx = executor.WithStatus(ctx, status)
+	status.SetState(config.Running)
+	defer status.SetState(config.Complete)
+	return e.execute(ctx, s)
+}
+
+func (e *stageExecutor) execute(ctx context.Context, s *config.Stage) error {
 	if err := s.Validate(); err != nil {
 		return err
 	}
+	// Execute sets up the recorder of the stage, a stage executed with any
+	// other recorder in its context gets its own.
+	rec, ok := stats.FromContext(ctx).(*stageRecorder)
+	if !ok {
+		ctx, rec = e.recorder(ctx, s)
+		ctx = stats.NewContext(ctx, rec)
+	}
 
 	var (
 		// exCtx is the context for this execution, since a stage can
@@ -245,12 +346,59 @@ func (e *stageExecutor) Execute(ctx context.Context, s *config.Stage) error {
 	}
 	defer cancel()
 
+	if s.Protocol() != "" {
+		ops := atomic.LoadInt64(&rec.ops)
+		start := time.Now()
+		if err := e.execProtocol(exCtx, s); err != nil {
+			// Executors record each operation, an executor that
+			// failed before running any, for example because it
+			// could not connect, is recorded as a failed operation.
+			if atomic.LoadInt64(&rec.ops) == ops {
+				rec.Record(stats.Result{
+					Time:    start,
+					Latency: time.Since(start),
+					Err:     err,
+				})
+			}
+			return err
+		}
+	}
+
+	// Execute any children.
+	if len(s.Children) > 1 && s.Concurrent > 0 {
+		if err := e.execParallel(exCtx, s.Concurrent, s.Children); err != nil {
+			return err
+		}
+		s.Status().AddIteration()
+		if s.Repeat > 0 {
+			s.Repeat--
+			return e.execute(ctx, s)
+		}
+		return e.execDuration(ctx, s)
+	}
+
+	for _, child := range s.Children {
+		if err := e.Execute(exCtx, child); err != nil {
+			e.metrics.ErrorsTotal.With(prometheus.Labels{"stage": child.Name}).Add(1)
+			return err
+		}
+	}
+	s.Status().AddIteration()
+	if s.Repeat > 0 {
+		s.Repeat--
+		return e.execute(ctx, s)
+	}
+	return e.execDuration(ctx, s)
+}
+
+// execProtocol executes the protocol configured for a stage.
+func (e *stageExecutor) execProtocol(ctx context.Context, s *config.Stage) error {
 	if s.DHCP4 != nil {
 		if e.dhcp4 == nil {
 			return ErrNoStageExecutor
 		}
 		e.metrics.DHCP4Total.With(prometheus.Labels{"stage": s.Name}).Add(1)
-		if err := e.dhcp4.Execute(exCtx, s.DHCP4); err != nil {
+		if err := e.dhcp4.Execute(ctx, s.DHCP4); err != nil {
 			return err
 		}
 	}
@@ -259,7 +407,7 @@ func (e *stageExecutor) Execute(ctx context.Context, s *config.Stage) error {
 			return ErrNoStageExecutor
 		}
 		e.metrics.DNSTotal.With(prometheus.Labels{"stage": s.Name}).Add(1)
-		if err := e.dns.Execute(exCtx, s.DNS); err != nil {
+		if err := e.dns.Execute(ctx, s.DNS); err != nil {
 			return err
 		}
 	}
@@ -268,7 +416,7 @@ func (e *stageExecutor) Execute(ctx context.Context, s *config.Stage) error {
 			return ErrNoStageExecutor
 		}
 		e.metrics.ETCDTotal.With(prometheus.Labels{"stage": s.Name}).Add(1)
-		if err := e.etcd.Execute(exCtx, s.ETCD); err != nil {
+		if err := e.etcd.Execute(ctx, s.ETCD); err != nil {
 			return err
 		}
 	}
@@ -277,7 +425,7 @@ func (e *stageExecutor) Execute(ctx context.Context, s *config.Stage) error {
 			return ErrNoStageExecutor
 		}
 		e.metrics.HTTPTotal.With(prometheus.Labels{"stage": s.Name}).Add(1)
-		if err := e.http.Execute(exCtx, s.HTTP); err != nil {
+		if err := e.http.Execute(ctx, s.HTTP); err != nil {
 			return err
 		}
 	}
@@ -286,7 +434,7 @@ func (e *stageExecutor) Execute(ctx context.Context, s *config.Stage) error {
 			return ErrNoStageExecutor
 		}
 		e.metrics.LDAPTotal.With(prometheus.Labels{"stage": s.Name}).Add(1)
-		if err := e.ldap.Execute(exCtx, s.LDAP); err != nil {
+		if err := e.ldap.Execute(ctx, s.LDAP); err != nil {
 			return err
 		}
 	}
@@ -295,7 +443,7 @@ func (e *stageExecutor) Execute(ctx context.Context, s *config.Stage) error {
 			return ErrNoStageExecutor
 		}
 		e.metrics.MemcacheTotal.With(prometheus.Labels{"stage": s.Name}).Add(1)
-		if err := e.memcache.Execute(exCtx, s.Memcache); err != nil {
+		if err := e.memcache.Execute(ctx, s.Memcache); err != nil {
 			return err
 		}
 	}
@@ -304,7 +452,7 @@ func (e *stageExecutor) Execute(ctx context.Context, s *config.Stage) error {
 			return ErrNoStageExecutor
 		}
 		e.metrics.RedisTotal.With(prometheus.Labels{"stage": s.Name}).Add(1)
-		if err := e.redis.Execute(exCtx, s.Redis); err != nil {
+		if err := e.redis.Execute(ctx, s.Redis); err != nil {
 			return err
 		}
 	}
@@ -313,7 +461,7 @@ func (e *stageExecutor) Execute(ctx context.Context, s *config.Stage) error {
 			return ErrNoStageExecutor
 		}
 		e.metrics.SNMPTotal.With(prometheus.Labels{"stage": s.Name}).Add(1)
-		if err := e.snmp.Execute(exCtx, s.SNMP); err != nil {
+		if err := e.snmp.Execute(ctx, s.SNMP); err != nil {
 			return err
 		}
 	}
@@ -322,7 +470,7 @@ func (e *stageExecutor) Execute(ctx context.Context, s *config.Stage) error {
 			return ErrNoStageExecutor
 		}
 		e.metrics.SSHTotal.With(prometheus.Labels{"stage": s.Name}).Add(1)
-		if err := e.ssh.Execute(exCtx, s.SSH); err != nil {
+		if err := e.ssh.Execute(ctx, s.SSH); err != nil {
 			return err
 		}
 	}
@@ -331,7 +479,7 @@ func (e *stageExecutor) Execute(ctx context.Context, s *config.Stage) error {
 			return ErrNoStageExecutor
 		}
 		e.metrics.SQLTotal.With(prometheus.Labels{"stage": s.Name}).Add(1)
-		if err := e.sql.Execute(exCtx, s.SQL); err != nil {
+		if err := e.sql.Execute(ctx, s.SQL); err != nil {
 			return err
 		}
 	}
@@ -340,7 +488,7 @@ func (e *stageExecutor) Execute(ctx context.Context, s *config.Stage) error {
 			return ErrNoStageExecutor
 		}
 		e.metrics.UDPTotal.With(prometheus.Labels{"stage": s.Name}).Add(1)
-		if err := e.udp.Execute(exCtx, s.UDP); err != nil {
+		if err := e.udp.Execute(ctx, s.UDP); err != nil {
 			return err
 		}
 	}
@@ -349,7 +497,7 @@ func (e *stageExecutor) Execute(ctx context.Context, s *config.Stage) error {
 			return ErrNoStageExecutor
 		}
 		e.metrics.WebsocketTotal.With(prometheus.Labels{"stage": s.Name}).Add(1)
-		if err := e.websocket.Execute(exCtx, s.Websocket); err != nil {
+		if err := e.websocket.Execute(ctx, s.Websocket); err != nil {
 			return err
 		}
 	}
@@ -358,7 +506,7 @@ func (e *stageExecutor) Execute(ctx context.Context, s *config.Stage) error {
 			return ErrNoStageExecutor
 		}
 		e.metrics.GraphQLTotal.With(prometheus.Labels{"stage": s.Name}).Add(1)
-		if err := e.graphql.Execute(exCtx, s.GraphQL); err != nil {
+		if err := e.graphql.Execute(ctx, s.GraphQL); err != nil {
 			return err
 		}
 	}
@@ -367,7 +515,7 @@ func (e *stageExecutor) Execute(ctx context.Context, s *config.Stage) error {
 			return ErrNoStageExecutor
 		}
 		e.metrics.GRPCTotal.With(prometheus.Labels{"stage": s.Name}).Add(1)
-		if err := e.grpc.Execute(exCtx, s.GRPC); err != nil {
+		if err := e.grpc.Execute(ctx, s.GRPC); err != nil {
 			return err
 		}
 	}
@@ -376,7 +524,7 @@ func (e *stageExecutor) Execute(ctx context.Context, s *config.Stage) error {
 			return ErrNoStageExecutor
 		}
 		e.metrics.MongoDBTotal.With(prometheus.Labels{"stage": s.Name}).Add(1)
-		if err := e.mongodb.Execute(exCtx, s.MongoDB); err != nil {
+		if err := e.mongodb.Execute(ctx, s.MongoDB); err != nil {
 			return err
 		}
 	}
@@ -385,7 +533,7 @@ func (e *stageExecutor) Execute(ctx context.Context, s *config.Stage) error {
 			return ErrNoStageExecutor
 		}
 		e.metrics.MQTTTotal.With(prometheus.Labels{"stage": s.Name}).Add(1)
-		if err := e.mqtt.Execute(exCtx, s.MQTT); err != nil {
+		if err := e.mqtt.Execute(ctx, s.MQTT); err != nil {
 			return err
 		}
 	}
@@ -394,7 +542,7 @@ func (e *stageExecutor) Execute(ctx context.Context, s *config.Stage) error {
 			return ErrNoStageExecutor
 		}
 		e.metrics.CassandraTotal.With(prometheus.Labels{"stage": s.Name}).Add(1)
-		if err := e.cassandra.Execute(exCtx, s.Cassandra); err != nil {
+		if err := e.cassandra.Execute(ctx, s.Cassandra); err != nil {
 			return err
 		}
 	}
@@ -403,7 +551,7 @@ func (e *stageExecutor) Execute(ctx context.Context, s *config.Stage) error {
 			return ErrNoStageExecutor
 		}
 		e.metrics.ClickHouseTotal.With(prometheus.Labels{"stage": s.Name}).Add(1)
-		if err := e.clickhouse.Execute(exCtx, s.ClickHouse); err != nil {
+		if err := e.clickhouse.Execute(ctx, s.ClickHouse); err != nil {
 			return err
 		}
 	}
@@ -412,7 +560,7 @@ func (e *stageExecutor) Execute(ctx context.Context, s *config.Stage) error {
 			return ErrNoStageExecutor
 		}
 		e.metrics.ElasticsearchTotal.With(prometheus.Labels{"stage": s.Name}).Add(1)
-		if err := e.elasticsearch.Execute(exCtx, s.Elasticsearch); err != nil {
+		if err := e.elasticsearch.Execute(ctx, s.Elasticsearch); err != nil {
 			return err
 		}
 	}
@@ -421,7 +569,7 @@ func (e *stageExecutor) Execute(ctx context.Context, s *config.Stage) error {
 			return ErrNoStageExecutor
 		}
 		e.metrics.InfluxDBTotal.With(prometheus.Labels{"stage": s.Name}).Add(1)
-		if err := e.influxdb.Execute(exCtx, s.InfluxDB); err != nil {
+		if err := e.influxdb.Execute(ctx, s.InfluxDB); err != nil {
 			return err
 		}
 	}
@@ -430,7 +578,7 @@ func (e *stageExecutor) Execute(ctx context.Context, s *config.Stage) error {
 			return ErrNoStageExecutor
 		}
 		e.metrics.KafkaTotal.With(prometheus.Labels{"stage": s.Name}).Add(1)
-		if err := e.kafka.Execute(exCtx, s.Kafka); err != nil {
+		if err := e.kafka.Execute(ctx, s.Kafka); err != nil {
 			return err
 		}
 	}
@@ -439,7 +587,7 @@ func (e *stageExecutor) Execute(ctx context.Context, s *config.Stage) error {
 			return ErrNoStageExecutor
 		}
 		e.metrics.RabbitMQTotal.With(prometheus.Labels{"stage": s.Name}).Add(1)
-		if err := e.rabbitmq.Execute(exCtx, s.RabbitMQ); err != nil {
+		if err := e.rabbitmq.Execute(ctx, s.RabbitMQ); err != nil {
 			return err
 		}
 	}
@@ -448,7 +596,7 @@ func (e *stageExecutor) Execute(ctx context.Context, s *config.Stage) error {
 			return ErrNoStageExecutor
 		}
 		e.metrics.NATSTotal.With(prometheus.Labels{"stage": s.Name}).Add(1)
-		if err := e.nats.Execute(exCtx, s.NATS); err != nil {
+		if err := e.nats.Execute(ctx, s.NATS); err != nil {
 			return err
 		}
 	}
@@ -457,7 +605,7 @@ func (e *stageExecutor) Execute(ctx context.Context, s *config.Stage) error {
 			return ErrNoStageExecutor
 		}
 		e.metrics.PulsarTotal.With(prometheus.Labels{"stage": s.Name}).Add(1)
-		if err := e.pulsar.Execute(exCtx, s.Pulsar); err != nil {
+		if err := e.pulsar.Execute(ctx, s.Pulsar); err != nil {
 			return err
 		}
 	}
@@ -466,7 +614,7 @@ func (e *stageExecutor) Execute(ctx context.Context, s *config.Stage) error {
 			return ErrNoStageExecutor
 		}
 		e.metrics.ScyllaDBTotal.With(prometheus.Labels{"stage": s.Name}).Add(1)
-		if err := e.scylladb.Execute(exCtx, s.ScyllaDB); err != nil {
+		if err := e.scylladb.Execute(ctx, s.ScyllaDB); err != nil {
 			return err
 		}
 	}
@@ -475,7 +623,7 @@ func (e *stageExecutor) Execute(ctx context.Context, s *config.Stage) error {
 			return ErrNoStageExecutor
 		}
 		e.metrics.CouchDBTotal.With(prometheus.Labels{"stage": s.Name}).Add(1)
-		if err := e.couchdb.Execute(exCtx, s.CouchDB); err != nil {
+		if err := e.couchdb.Execute(ctx, s.CouchDB); err != nil {
 			return err
 		}
 	}
@@ -484,7 +632,7 @@ func (e *stageExecutor) Execute(ctx context.Context, s *config.Stage) error {
 			return ErrNoStageExecutor
 		}
 		e.metrics.Neo4jTotal.With(prometheus.Labels{"stage": s.Name}).Add(1)
-		if err := e.neo4j.Execute(exCtx, s.Neo4j); err != nil {
+		if err := e.neo4j.Execute(ctx, s.Neo4j); err != nil {
 			return err
 		}
 	}
@@ -493,7 +641,7 @@ func (e *stageExecutor) Execute(ctx context.Context, s *config.Stage) error {
 			return ErrNoStageExecutor
 		}
 		e.metrics.ArangoDBTotal.With(prometheus.Labels{"stage": s.Name}).Add(1)
-		if err := e.arangodb.Execute(exCtx, s.ArangoDB); err != nil {
+		if err := e.arangodb.Execute(ctx, s.ArangoDB); err != nil {
 			return err
 		}
 	}
@@ -502,7 +650,7 @@ func (e *stageExecutor) Execute(ctx context.Context, s *config.Stage) error {
 			return ErrNoStageExecutor
 		}
 		e.metrics.FTPTotal.With(prometheus.Labels{"stage": s.Name}).Add(1)
-		if err := e.ftp.Execute(exCtx, s.FTP); err != nil {
+		if err := e.ftp.Execute(ctx, s.FTP); err != nil {
 			return err
 		}
 	}
@@ -511,7 +659,7 @@ func (e *stageExecutor) Execute(ctx context.Context, s *config.Stage) error {
 			return ErrNoStageExecutor
 		}
 		e.metrics.TCPTotal.With(prometheus.Labels{"stage": s.Name}).Add(1)
-		if err := e.tcp.Execute(exCtx, s.TCP); err != nil {
+		if err := e.tcp.Execute(ctx, s.TCP); err != nil {
 			return err
 		}
 	}
@@ -520,7 +668,7 @@ func (e *stageExecutor) Execute(ctx context.Context, s *config.Stage) error {
 			return ErrNoStageExecutor
 		}
 		e.metrics.ICMPTotal.With(prometheus.Labels{"stage": s.Name}).Add(1)
-		if err := e.icmp.Execute(exCtx, s.ICMP); err != nil {
+		if err := e.icmp.Execute(ctx, s.ICMP); err != nil {
 			return err
 		}
 	}
@@ -529,7 +677,7 @@ func (e *stageExecutor) Execute(ctx context.Context, s *config.Stage) error {
 			return ErrNoStageExecutor
 		}
 		e.metrics.NTPTotal.With(prometheus.Labels{"stage": s.Name}).Add(1)
-		if err := e.ntp.Execute(exCtx, s.NTP); err != nil {
+		if err := e.ntp.Execute(ctx, s.NTP); err != nil {
 			return err
 		}
 	}
@@ -538,7 +686,7 @@ func (e *stageExecutor) Execute(ctx context.Context, s *config.Stage) error {
 			return ErrNoStageExecutor
 		}
 		e.metrics.TFTPTotal.With(prometheus.Labels{"stage": s.Name}).Add(1)
-		if err := e.tftp.Execute(exCtx, s.TFTP); err != nil {
+		if err := e.tftp.Execute(ctx, s.TFTP); err != nil {
 			return err
 		}
 	}
@@ -547,7 +695,7 @@ func (e *stageExecutor) Execute(ctx context.Context, s *config.Stage) error {
 			return ErrNoStageExecutor
 		}
 		e.metrics.TelnetTotal.With(prometheus.Labels{"stage": s.Name}).Add(1)
-		if err := e.telnet.Execute(exCtx, s.Telnet); err != nil {
+		if err := e.telnet.Execute(ctx, s.Telnet); err != nil {
 			return err
 		}
 	}
@@ -556,34 +704,12 @@ func (e *stageExecutor) Execute(ctx context.Context, s *config.Stage) error {
 			return ErrNoStageExecutor
 		}
 		e.metrics.SyslogTotal.With(prometheus.Labels{"stage": s.Name}).Add(1)
-		if err := e.syslog.Execute(exCtx, s.Syslog); err != nil {
-			return err
-		}
-	}
-
-	// Execute any children.
-	if len(s.Children) > 1 && s.Concurrent > 0 {
-		if err := e.execParallel(exCtx, s.Concurrent, s.Children); err != nil {
+		if err := e.syslog.Execute(ctx, s.Syslog); err != nil {
 			return err
 		}
-		if s.Repeat > 0 {
-			s.Repeat--
-			return e.Execute(ctx, s)
-		}
-		return e.execDuration(ctx, s)
 	}
 
-	for _, child := range s.Children {
-		if err := e.Execute(exCtx, child); err != nil {
-			e.metrics.ErrorsTotal.With(prometheus.Labels{"stage": child.Name}).Add(1)
-			return err
-		}
-	}
-	if s.Repeat > 0 {
-		s.Repeat--
-		return e.Execute(ctx, s)
-	}
-	return e.execDuration(ctx, s)
+	return nil
 }
 
 // execDuration is used to execute a stage until the context is complete, this
@@ -599,7 +725,7 @@ func (e *stageExecutor) execDuration(ctx context.Context, stage *config.Stage) e
 	case <-ctx.Done():
 		return nil
 	default:
-		return e.Execute(ctx, stage)
+		return e.execute(ctx, stage)
 	}
 }
 
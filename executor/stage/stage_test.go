@@ -0,0 +1,156 @@
+package stage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hodgesds/dlg/config"
+	httpconf "github.com/hodgesds/dlg/config/http"
+	"github.com/hodgesds/dlg/executor"
+	"github.com/hodgesds/dlg/stats"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type lifecycleHTTP struct {
+	init   int
+	closed int
+	churn  []bool
+}
+
+func (e *lifecycleHTTP) Init(context.Context) error {
+	e.init++
+	return nil
+}
+
+func (e *lifecycleHTTP) Close() error {
+	e.closed++
+	return nil
+}
+
+func (e *lifecycleHTTP) Execute(ctx context.Context, _ *httpconf.Config) error {
+	e.churn = append(e.churn, executor.Churn(ctx))
+	return nil
+}
+
+// TestLifecycle tests Init and Close are passed to protocol executors and
+// churn is set from the stage.
+func TestLifecycle(t *testing.T) {
+	h := &lifecycleHTTP{}
+	e, err := New(Params{
+		Registry: prometheus.NewPedanticRegistry(),
+		HTTP:     h,
+	})
+	require.NoError(t, err)
+	l, ok := e.(executor.Lifecycle)
+	require.True(t, ok)
+
+	ctx := context.Background()
+	require.NoError(t, l.Init(ctx))
+	require.NoError(t, e.Execute(ctx, &config.Stage{
+		Name: "reuse",
+		HTTP: &httpconf.Config{},
+	}))
+	require.NoError(t, e.Execute(ctx, &config.Stage{
+		Name:  "churn",
+		Churn: true,
+		Children: []*config.Stage{
+			{
+				Name: "child",
+				HTTP: &httpconf.Config{},
+			},
+		},
+	}))
+	require.NoError(t, l.Close())
+
+	assert.Equal(t, 1, h.init)
+	assert.Equal(t, 1, h.closed)
+	assert.Equal(t, []bool{false, true}, h.churn)
+}
+
+type poolHTTP struct {
+	concurrency []int
+	err         error
+}
+
+func (e *poolHTTP) Execute(ctx context.Context, _ *httpconf.Config) error {
+	e.concurrency = append(e.concurrency, executor.PoolFromContext(ctx).Concurrency())
+	return e.err
+}
+
+// TestConcurrency tests the stage concurrency is used by its children.
+func TestConcurrency(t *testing.T) {
+	h := &poolHTTP{}
+	e, err := New(Params{
+		Registry: prometheus.NewPedanticRegistry(),
+		HTTP:     h,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, e.Execute(context.Background(), &config.Stage{
+		Name:        "parent",
+		Concurrency: 5,
+		HTTP:        &httpconf.Config{},
+		Children: []*config.Stage{
+			{
+				Name: "child",
+				HTTP: &httpconf.Config{},
+			},
+			{
+				Name:        "override",
+				Concurrency: 2,
+				HTTP:        &httpconf.Config{},
+			},
+		},
+	}))
+	assert.Equal(t, []int{5, 5, 2}, h.concurrency)
+}
+
+// TestSetupError tests an executor failing before running any operation is
+// recorded as a failed operation.
+func TestSetupError(t *testing.T) {
+	h := &poolHTTP{err: errors.New("connection refused")}
+	e, err := New(Params{
+		Registry: prometheus.NewPedanticRegistry(),
+		HTTP:     h,
+	})
+	require.NoError(t, err)
+
+	c := stats.NewCollector()
+	ctx := stats.NewContext(context.Background(), c)
+	require.Error(t, e.Execute(ctx, &config.Stage{
+		Name: "http",
+		HTTP: &httpconf.Config{},
+	}))
+
+	stages := c.Stages()
+	require.Len(t, stages, 1)
+	assert.Equal(t, "http", stages[0].Protocol)
+	assert.Equal(t, int64(1), stages[0].Main.Ops)
+	assert.Equal(t, int64(1), stages[0].Main.Errors)
+}
+
+// TestStatus tests the status of a stage is complete and counts its
+// iterations after the stage is executed.
+func TestStatus(t *testing.T) {
+	e, err := New(Params{
+		Registry: prometheus.NewPedanticRegistry(),
+		HTTP:     &poolHTTP{},
+	})
+	require.NoError(t, err)
+
+	s := &config.Stage{
+		Name:   "repeat",
+		Repeat: 2,
+		Rate:   100,
+		HTTP:   &httpconf.Config{},
+	}
+	require.NoError(t, e.Execute(context.Background(), s))
+	assert.Equal(t, config.Complete, s.Status().State())
+	assert.Equal(t, int64(3), s.Status().Iterations())
+	assert.Equal(t, int64(0), s.Status().Workers())
+	assert.True(t, s.Status().Elapsed(time.Now()) > 0)
+}
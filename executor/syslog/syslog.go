@@ -9,6 +9,7 @@ import (
 	"time"
 
 	syslogconfig "github.com/hodgesds/dlg/config/syslog"
+	"github.com/hodgesds/dlg/executor"
 )
 
 type syslogExecutor struct{}
@@ -23,7 +24,12 @@ func (e *syslogExecutor) Execute(ctx context.Context, config *syslogconfig.Confi
 	if err := config.Validate(); err != nil {
 		return err
 	}
+	return executor.Run(ctx, "log", 1, func(ctx context.Context, _ []byte) (int64, error) {
+		return 0, e.exec(ctx, config)
+	})
+}
 
+func (e *syslogExecutor) exec(ctx context.Context, config *syslogconfig.Config) error {
 	if config.Format == "rfc5424" || config.Format == "rfc3164" {
 		return e.executeCustom(ctx, config)
 	}
@@ -34,30 +40,31 @@ func (e *syslogExecutor) Execute(ctx context.Context, config *syslogconfig.Confi
 
 	writer, err := syslog.Dial(network, addr, priority, config.Tag)
 	if err != nil {
-		return fmt.Errorf("failed to connect to syslog server: %w", err)
+		return executor.Failed(fmt.Errorf("failed to connect to syslog server: %w", err))
 	}
 	defer writer.Close()
 
 	switch config.Severity {
 	case 0:
-		return writer.Emerg(config.Message)
+		err = writer.Emerg(config.Message)
 	case 1:
-		return writer.Alert(config.Message)
+		err = writer.Alert(config.Message)
 	case 2:
-		return writer.Crit(config.Message)
+		err = writer.Crit(config.Message)
 	case 3:
-		return writer.Err(config.Message)
+		err = writer.Err(config.Message)
 	case 4:
-		return writer.Warning(config.Message)
+		err = writer.Warning(config.Message)
 	case 5:
-		return writer.Notice(config.Message)
+		err = writer.Notice(config.Message)
 	case 6:
-		return writer.Info(config.Message)
+		err = writer.Info(config.Message)
 	case 7:
-		return writer.Debug(config.Message)
+		err = writer.Debug(config.Message)
 	default:
-		return writer.Info(config.Message)
+		err = writer.Info(config.Message)
 	}
+	return executor.Failed(err)
 }
 
 func (e *syslogExecutor) executeCustom(ctx context.Context, config *syslogconfig.Config) error {
@@ -67,7 +74,7 @@ func (e *syslogExecutor) executeCustom(ctx context.Context, config *syslogconfig
 	dialer := &net.Dialer{Timeout: config.Timeout}
 	conn, err := dialer.DialContext(ctx, network, addr)
 	if err != nil {
-		return fmt.Errorf("failed to connect: %w", err)
+		return executor.Failed(fmt.Errorf("failed to connect: %w", err))
 	}
 	defer conn.Close()
 
@@ -91,5 +98,5 @@ func (e *syslogExecutor) executeCustom(ctx context.Context, config *syslogconfig
 
 	conn.SetWriteDeadline(time.Now().Add(config.Timeout))
 	_, err = conn.Write([]byte(message))
-	return err
+	return executor.Failed(err)
 }
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	tcpconfig "github.com/hodgesds/dlg/config/tcp"
+	"github.com/hodgesds/dlg/executor"
 )
 
 type tcpExecutor struct{}
@@ -21,7 +22,12 @@ func (e *tcpExecutor) Execute(ctx context.Context, config *tcpconfig.Config) err
 	if err := config.Validate(); err != nil {
 		return err
 	}
+	return executor.Run(ctx, config.Operation, 1, func(ctx context.Context, _ []byte) (int64, error) {
+		return 0, e.exec(ctx, config)
+	})
+}
 
+func (e *tcpExecutor) exec(ctx context.Context, config *tcpconfig.Config) error {
 	addr := fmt.Sprintf("%s:%d", config.Host, config.Port)
 	dialer := &net.Dialer{
 		Timeout:   config.Timeout,
@@ -33,7 +39,7 @@ func (e *tcpExecutor) Execute(ctx context.Context, config *tcpconfig.Config) err
 
 	conn, err := dialer.DialContext(ctx, "tcp", addr)
 	if err != nil {
-		return err
+		return executor.Failed(err)
 	}
 	defer conn.Close()
 
@@ -66,7 +72,7 @@ func (e *tcpExecutor) Execute(ctx context.Context, config *tcpconfig.Config) err
 		}
 		conn.SetWriteDeadline(time.Now().Add(config.WriteTimeout))
 		_, err = conn.Write(data)
-		return err
+		return executor.Failed(err)
 
 	case "send_receive":
 		var data []byte
@@ -84,14 +90,14 @@ func (e *tcpExecutor) Execute(ctx context.Context, config *tcpconfig.Config) err
 
 		conn.SetWriteDeadline(time.Now().Add(config.WriteTimeout))
 		if _, err := conn.Write(data); err != nil {
-			return err
+			return executor.Failed(err)
 		}
 
 		buf := make([]byte, 4096)
 		conn.SetReadDeadline(time.Now().Add(config.ReadTimeout))
 		_, err := conn.Read(buf)
 		if err != nil && err != io.EOF {
-			return err
+			return executor.Failed(err)
 		}
 		return nil
 
@@ -7,6 +7,7 @@ import (
 
 	"github.com/ziutek/telnet"
 	telnetconfig "github.com/hodgesds/dlg/config/telnet"
+	"github.com/hodgesds/dlg/executor"
 )
 
 type telnetExecutor struct{}
@@ -21,11 +22,16 @@ func (e *telnetExecutor) Execute(ctx context.Context, config *telnetconfig.Confi
 	if err := config.Validate(); err != nil {
 		return err
 	}
+	return executor.Run(ctx, "session", 1, func(ctx context.Context, _ []byte) (int64, error) {
+		return 0, e.exec(ctx, config)
+	})
+}
 
+func (e *telnetExecutor) exec(ctx context.Context, config *telnetconfig.Config) error {
 	addr := fmt.Sprintf("%s:%d", config.Host, config.Port)
 	conn, err := telnet.DialTimeout("tcp", addr, config.Timeout)
 	if err != nil {
-		return fmt.Errorf("failed to connect: %w", err)
+		return executor.Failed(fmt.Errorf("failed to connect: %w", err))
 	}
 	defer conn.Close()
 
@@ -51,7 +57,7 @@ func (e *telnetExecutor) Execute(ctx context.Context, config *telnetconfig.Confi
 		conn.SetWriteDeadline(time.Now().Add(config.Timeout))
 		_, err := conn.Write([]byte(cmd + "\n"))
 		if err != nil {
-			return fmt.Errorf("failed to send command '%s': %w", cmd, err)
+			return executor.Failed(fmt.Errorf("failed to send command '%s': %w", cmd, err))
 		}
 
 		conn.SetReadDeadline(time.Now().Add(config.Timeout))
@@ -9,6 +9,7 @@ import (
 
 	"github.com/pin/tftp/v3"
 	tftpconfig "github.com/hodgesds/dlg/config/tftp"
+	"github.com/hodgesds/dlg/executor"
 )
 
 type tftpExecutor struct{}
@@ -33,11 +34,17 @@ func (e *tftpExecutor) Execute(ctx context.Context, config *tftpconfig.Config) e
 	client.SetRetries(config.Retries)
 	client.SetBlockSize(config.BlockSize)
 
+	return executor.Run(ctx, config.Operation, 1, func(ctx context.Context, _ []byte) (int64, error) {
+		return 0, e.exec(config, client)
+	})
+}
+
+func (e *tftpExecutor) exec(config *tftpconfig.Config, client *tftp.Client) error {
 	switch config.Operation {
 	case "read":
 		reader, err := client.Receive(config.RemotePath, config.Mode)
 		if err != nil {
-			return fmt.Errorf("failed to receive file: %w", err)
+			return executor.Failed(fmt.Errorf("failed to receive file: %w", err))
 		}
 		defer reader.Close()
 
@@ -48,22 +55,22 @@ func (e *tftpExecutor) Execute(ctx context.Context, config *tftpconfig.Config) e
 			}
 			defer f.Close()
 			_, err = io.Copy(f, reader)
-			return err
+			return executor.Failed(err)
 		}
 
 		_, err = io.Copy(io.Discard, reader)
-		return err
+		return executor.Failed(err)
 
 	case "write":
 		writer, err := client.Send(config.RemotePath, config.Mode)
 		if err != nil {
-			return fmt.Errorf("failed to send file: %w", err)
+			return executor.Failed(fmt.Errorf("failed to send file: %w", err))
 		}
 		defer writer.Close()
 
 		if config.Data != "" {
 			_, err = io.Copy(writer, strings.NewReader(config.Data))
-			return err
+			return executor.Failed(err)
 		} else if config.LocalPath != "" {
 			f, err := os.Open(config.LocalPath)
 			if err != nil {
@@ -71,7 +78,7 @@ func (e *tftpExecutor) Execute(ctx context.Context, config *tftpconfig.Config) e
 			}
 			defer f.Close()
 			_, err = io.Copy(writer, f)
-			return err
+			return executor.Failed(err)
 		}
 		return fmt.Errorf("data or local_path required for write operation")
 
@@ -17,14 +17,20 @@ func New() executor.UDP {
 
 // Execute implements the UDP executor interface.
 func (e *udpExecutor) Execute(ctx context.Context, c *udpconf.Config) error {
+	return executor.Run(ctx, "write", 1, func(ctx context.Context, _ []byte) (int64, error) {
+		return 0, e.exec(ctx, c)
+	})
+}
+
+func (e *udpExecutor) exec(ctx context.Context, c *udpconf.Config) error {
 	conn, err := c.Conn()
 	if err != nil {
-		return err
+		return executor.Failed(err)
 	}
 	payload, err := c.GetPayload()
 	if err != nil {
 		return err
 	}
 	_, err = conn.Write(payload)
-	return err
+	return executor.Failed(err)
 }
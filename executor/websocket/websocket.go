@@ -2,42 +2,89 @@ package websocket
 
 import (
 	"context"
-	"io/ioutil"
+	"fmt"
+	"sync"
 
 	"github.com/gorilla/websocket"
 	websocketconf "github.com/hodgesds/dlg/config/websocket"
 	"github.com/hodgesds/dlg/executor"
 )
 
-type websocketExecutor struct{}
+// clientKey contains the config fields used to dial a connection.
+type clientKey struct {
+	url    string
+	header string
+}
+
+// wsConn is a websocket connection, connections support a single reader and
+// writer so ops on a shared connection are serialized.
+type wsConn struct {
+	mu sync.Mutex
+	*websocket.Conn
+}
+
+type websocketExecutor struct {
+	*executor.ClientCache[clientKey, *wsConn]
+}
 
 // New returns a new Websocket interface.
 func New() executor.Websocket {
-	return &websocketExecutor{}
+	return &websocketExecutor{
+		ClientCache: executor.NewClientCache[clientKey](
+			func(c *wsConn) error { return c.Close() },
+		),
+	}
 }
 
 // Execute implements the Websocket interface.
 func (e *websocketExecutor) Execute(ctx context.Context, config *websocketconf.Config) error {
-	conn, _, err := config.Conn(ctx)
+	key := clientKey{
+		url:    config.URL,
+		header: fmt.Sprint(config.Header),
+	}
+	conn, release, err := e.Get(ctx, key, func(ctx context.Context) (*wsConn, error) {
+		c, _, err := config.Conn(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return &wsConn{Conn: c}, nil
+	})
 	if err != nil {
 		return err
 	}
+	defer release()
+
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
 	for _, op := range config.Ops {
 		if op.Read {
-			_, r, err := conn.NextReader()
+			err := executor.Run(ctx, "read", 1, func(_ context.Context, buf []byte) (int64, error) {
+				_, r, err := conn.NextReader()
+				if err != nil {
+					return 0, executor.Failed(err)
+				}
+				n, err := executor.Drain(r, buf)
+				return n, executor.Failed(err)
+			})
 			if err != nil {
 				return err
 			}
-			ioutil.ReadAll(r)
 		}
 		if op.Write != "" {
-			w, err := conn.NextWriter(websocket.TextMessage)
+			err := executor.Run(ctx, "write", 1, func(context.Context, []byte) (int64, error) {
+				w, err := conn.NextWriter(websocket.TextMessage)
+				if err != nil {
+					return 0, executor.Failed(err)
+				}
+				n, err := w.Write([]byte(op.Write))
+				if err != nil {
+					return int64(n), executor.Failed(err)
+				}
+				return int64(n), executor.Failed(w.Close())
+			})
 			if err != nil {
 				return err
 			}
-			if _, err := w.Write([]byte(op.Write)); err != nil {
-				return err
-			}
 		}
 	}
 	return nil
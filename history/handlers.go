@@ -0,0 +1,126 @@
+package history
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// historyRouter is a Store HTTP Router.
+type historyRouter struct {
+	s *Store
+}
+
+// NewHistoryRouter adds the routes to list, get and delete stored runs to e.
+func NewHistoryRouter(e *gin.Engine, s *Store) {
+	r := &historyRouter{s: s}
+	e.GET("/history", r.List)
+	e.GET("/history/:id", r.Get)
+	e.DELETE("/history/:id", r.Delete)
+	e.GET("/history/:id/report", r.Report)
+	e.GET("/history/:id/results", r.Results)
+}
+
+// List returns the runs matching the plan, state, tag, since, until and
+// limit query parameters, most recent first. The reports of the runs are
+// omitted.
+func (r *historyRouter) List(c *gin.Context) {
+	f, err := parseFilter(c)
+	if err != nil {
+		c.JSON(400, gin.H{"msg": err.Error()})
+		return
+	}
+	runs, err := r.s.List(f)
+	if err != nil {
+		c.JSON(500, gin.H{"msg": err.Error()})
+		return
+	}
+	c.JSON(200, runs)
+}
+
+func parseFilter(c *gin.Context) (Filter, error) {
+	f := Filter{
+		Plan:  c.Query("plan"),
+		State: c.Query("state"),
+		Tag:   c.Query("tag"),
+	}
+	for _, t := range []struct {
+		param string
+		time  *time.Time
+	}{{"since", &f.Since}, {"until", &f.Until}} {
+		v := c.Query(t.param)
+		if v == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return f, fmt.Errorf("invalid %s: %w", t.param, err)
+		}
+		*t.time = parsed
+	}
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 0 {
+			return f, fmt.Errorf("invalid limit %q", v)
+		}
+		f.Limit = limit
+	}
+	return f, nil
+}
+
+// Get returns a run with its report.
+func (r *historyRouter) Get(c *gin.Context) {
+	run, err := r.s.Get(c.Param("id"))
+	if err != nil {
+		c.JSON(404, gin.H{"msg": err.Error()})
+		return
+	}
+	c.JSON(200, run)
+}
+
+// Delete removes a run.
+func (r *historyRouter) Delete(c *gin.Context) {
+	if err := r.s.Delete(c.Param("id")); err != nil {
+		c.JSON(404, gin.H{"msg": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"status": "ok"})
+}
+
+// Report returns the report of a run as JSON, or as HTML with format=html.
+func (r *historyRouter) Report(c *gin.Context) {
+	run, err := r.s.Get(c.Param("id"))
+	if err != nil {
+		c.JSON(404, gin.H{"msg": err.Error()})
+		return
+	}
+	if run.Report == nil {
+		c.JSON(404, gin.H{"msg": "run has no report"})
+		return
+	}
+	switch c.DefaultQuery("format", "json") {
+	case "json":
+		c.JSON(200, run.Report)
+	case "html":
+		c.Header("Content-Type", "text/html; charset=utf-8")
+		c.Status(200)
+		if err := run.Report.WriteHTML(c.Writer, []byte(run.PlanYAML)); err != nil {
+			c.Error(err)
+		}
+	default:
+		c.JSON(400, gin.H{"msg": "format must be json or html"})
+	}
+}
+
+// Results returns the gzip compressed result log of a run.
+func (r *historyRouter) Results(c *gin.Context) {
+	log, err := r.s.ResultLog(c.Param("id"))
+	if err != nil {
+		c.JSON(404, gin.H{"msg": err.Error()})
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", c.Param("id")+".jsonl.gz"))
+	c.Data(200, "application/gzip", log)
+}
@@ -0,0 +1,292 @@
+// Package history stores the history of plan runs in an embedded bbolt
+// database.
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/coreos/bbolt"
+	"github.com/hodgesds/dlg/report"
+)
+
+// Runs are stored in runsBucket, their summaries without the report in
+// summariesBucket, so that runs are listed and pruned without reading their
+// reports, and their result logs in logsBucket.
+var (
+	runsBucket      = []byte("runs")
+	summariesBucket = []byte("summaries")
+	logsBucket      = []byte("logs")
+)
+
+// Run is a finished run of a plan.
+type Run struct {
+	ID    string    `json:"id"`
+	Plan  string    `json:"plan"`
+	Tags  []string  `json:"tags,omitempty"`
+	Seed  int64     `json:"seed,omitempty"`
+	State string    `json:"state"`
+	Error string    `json:"error,omitempty"`
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	// PlanYAML is the plan as it was run, including its parameters.
+	PlanYAML string         `json:"planYaml"`
+	Report   *report.Report `json:"report,omitempty"`
+	// ResultLog is set if the result log of the run is stored.
+	ResultLog bool `json:"resultLog"`
+}
+
+// Retention limits the runs kept in a Store, a zero limit keeps all runs.
+type Retention struct {
+	// MaxAge is the age after which runs are removed.
+	MaxAge time.Duration
+	// MaxRuns is the number of most recent runs kept.
+	MaxRuns int
+}
+
+// Filter selects runs, zero fields match every run.
+type Filter struct {
+	Plan  string
+	State string
+	Tag   string
+	// Since and Until bound the start of the run.
+	Since time.Time
+	Until time.Time
+	// Limit is the maximum number of runs returned.
+	Limit int
+}
+
+func (f Filter) match(r *Run) bool {
+	switch {
+	case f.Plan != "" && r.Plan != f.Plan:
+		return false
+	case f.State != "" && r.State != f.State:
+		return false
+	case !f.Since.IsZero() && r.Start.Before(f.Since):
+		return false
+	case !f.Until.IsZero() && !r.Start.Before(f.Until):
+		return false
+	case f.Tag == "":
+		return true
+	}
+	for _, tag := range r.Tags {
+		if tag == f.Tag {
+			return true
+		}
+	}
+	return false
+}
+
+// Store is a run history.
+type Store struct {
+	db        *bbolt.DB
+	retention Retention
+	now       func() time.Time
+}
+
+// Open opens or creates the run history at path.
+func Open(path string, retention Retention) (*Store, error) {
+	db, err := bbolt.Open(path, 0600, &bbolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bbolt.Tx) error {
+		index := tx.Bucket(summariesBucket) == nil
+		for _, b := range [][]byte{runsBucket, summariesBucket, logsBucket} {
+			if _, err := tx.CreateBucketIfNotExists(b); err != nil {
+				return err
+			}
+		}
+		if !index {
+			return nil
+		}
+		// The runs of a history without summaries are summarized once.
+		return tx.Bucket(runsBucket).ForEach(func(id, b []byte) error {
+			var r Run
+			if err := json.Unmarshal(b, &r); err != nil {
+				return err
+			}
+			return putSummary(tx, &r)
+		})
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	s := &Store{db: db, retention: retention, now: time.Now}
+	if _, err := s.Prune(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Close closes the Store.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Put stores a run and its compressed result log, which may be nil, then
+// removes the runs beyond the retention limits.
+func (s *Store) Put(r *Run, resultLog []byte) error {
+	r.ResultLog = resultLog != nil
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	err = s.db.Update(func(tx *bbolt.Tx) error {
+		if err := tx.Bucket(runsBucket).Put([]byte(r.ID), b); err != nil {
+			return err
+		}
+		if err := putSummary(tx, r); err != nil {
+			return err
+		}
+		if resultLog == nil {
+			return nil
+		}
+		return tx.Bucket(logsBucket).Put([]byte(r.ID), resultLog)
+	})
+	if err != nil {
+		return err
+	}
+	_, err = s.Prune()
+	return err
+}
+
+// putSummary stores the summary of a run.
+func putSummary(tx *bbolt.Tx, r *Run) error {
+	summary := *r
+	summary.Report = nil
+	b, err := json.Marshal(&summary)
+	if err != nil {
+		return err
+	}
+	return tx.Bucket(summariesBucket).Put([]byte(r.ID), b)
+}
+
+// Get returns a run by ID with its report.
+func (s *Store) Get(id string) (*Run, error) {
+	var r Run
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(runsBucket).Get([]byte(id))
+		if b == nil {
+			return fmt.Errorf("no such run: %q", id)
+		}
+		return json.Unmarshal(b, &r)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// ResultLog returns the compressed result log of a run.
+func (s *Store) ResultLog(id string) ([]byte, error) {
+	var log []byte
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(logsBucket).Get([]byte(id))
+		if b == nil {
+			return fmt.Errorf("no result log for run: %q", id)
+		}
+		log = append([]byte(nil), b...)
+		return nil
+	})
+	return log, err
+}
+
+// List returns the runs matching the filter without their reports, most
+// recent first.
+func (s *Store) List(f Filter) ([]*Run, error) {
+	runs, err := s.runs()
+	if err != nil {
+		return nil, err
+	}
+	matched := []*Run{}
+	for _, r := range runs {
+		if !f.match(r) {
+			continue
+		}
+		if f.Limit > 0 && len(matched) == f.Limit {
+			break
+		}
+		matched = append(matched, r)
+	}
+	return matched, nil
+}
+
+// runs returns the summaries of all runs, most recent first.
+func (s *Store) runs() ([]*Run, error) {
+	var runs []*Run
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(summariesBucket).ForEach(func(_, b []byte) error {
+			var r Run
+			if err := json.Unmarshal(b, &r); err != nil {
+				return err
+			}
+			runs = append(runs, &r)
+			return nil
+		})
+	})
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[i].Start.After(runs[j].Start)
+	})
+	return runs, err
+}
+
+// Delete removes a run and its result log.
+func (s *Store) Delete(id string) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		runs := tx.Bucket(runsBucket)
+		if runs.Get([]byte(id)) == nil {
+			return fmt.Errorf("no such run: %q", id)
+		}
+		return deleteRun(tx, id)
+	})
+}
+
+// Prune removes the runs beyond the retention limits and returns the number
+// of removed runs.
+func (s *Store) Prune() (int, error) {
+	if s.retention.MaxAge <= 0 && s.retention.MaxRuns <= 0 {
+		return 0, nil
+	}
+	runs, err := s.runs()
+	if err != nil {
+		return 0, err
+	}
+	var expired []string
+	oldest := s.now().Add(-s.retention.MaxAge)
+	for i, r := range runs {
+		if (s.retention.MaxRuns > 0 && i >= s.retention.MaxRuns) ||
+			(s.retention.MaxAge > 0 && r.Start.Before(oldest)) {
+			expired = append(expired, r.ID)
+		}
+	}
+	if len(expired) == 0 {
+		return 0, nil
+	}
+	err = s.db.Update(func(tx *bbolt.Tx) error {
+		for _, id := range expired {
+			if err := deleteRun(tx, id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(expired), nil
+}
+
+// deleteRun removes a run with its summary and result log.
+func deleteRun(tx *bbolt.Tx, id string) error {
+	for _, b := range [][]byte{runsBucket, summariesBucket, logsBucket} {
+		if err := tx.Bucket(b).Delete([]byte(id)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+package history
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/coreos/bbolt"
+	"github.com/gin-gonic/gin"
+	"github.com/hodgesds/dlg/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testStore(t *testing.T, retention Retention) *Store {
+	s, err := Open(filepath.Join(t.TempDir(), "history.db"), retention)
+	require.NoError(t, err)
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func testRun(id, plan, state string, start time.Time, tags ...string) *Run {
+	return &Run{
+		ID:       id,
+		Plan:     plan,
+		Tags:     tags,
+		State:    state,
+		Start:    start,
+		End:      start.Add(time.Minute),
+		PlanYAML: "name: " + plan + "\n",
+		Report:   &report.Report{Plan: plan, Passed: state == "passed"},
+	}
+}
+
+// TestStore tests storing, listing and deleting runs.
+func TestStore(t *testing.T) {
+	s := testStore(t, Retention{})
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, s.Put(testRun("a", "api", "passed", start, "nightly"), nil))
+	require.NoError(t, s.Put(testRun("b", "api", "failed", start.Add(time.Hour)), []byte("log")))
+	require.NoError(t, s.Put(testRun("c", "db", "passed", start.Add(2*time.Hour)), nil))
+
+	r, err := s.Get("b")
+	require.NoError(t, err)
+	assert.Equal(t, "failed", r.State)
+	assert.True(t, r.ResultLog)
+	assert.True(t, start.Add(time.Hour).Equal(r.Start))
+	require.NotNil(t, r.Report)
+	assert.Equal(t, "api", r.Report.Plan)
+	log, err := s.ResultLog("b")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("log"), log)
+	_, err = s.ResultLog("a")
+	require.Error(t, err)
+
+	ids := func(f Filter) []string {
+		runs, err := s.List(f)
+		require.NoError(t, err)
+		ids := []string{}
+		for _, r := range runs {
+			ids = append(ids, r.ID)
+		}
+		return ids
+	}
+	assert.Equal(t, []string{"c", "b", "a"}, ids(Filter{}))
+	assert.Equal(t, []string{"b", "a"}, ids(Filter{Plan: "api"}))
+	assert.Equal(t, []string{"c", "a"}, ids(Filter{State: "passed"}))
+	assert.Equal(t, []string{"a"}, ids(Filter{Tag: "nightly"}))
+	assert.Equal(t, []string{"b"}, ids(Filter{Since: start.Add(time.Minute), Until: start.Add(2 * time.Hour)}))
+	assert.Equal(t, []string{"c"}, ids(Filter{Limit: 1}))
+	runs, err := s.List(Filter{Plan: "db"})
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Nil(t, runs[0].Report)
+	assert.Equal(t, "name: db\n", runs[0].PlanYAML)
+
+	require.NoError(t, s.Delete("b"))
+	_, err = s.Get("b")
+	require.EqualError(t, err, `no such run: "b"`)
+	_, err = s.ResultLog("b")
+	require.Error(t, err)
+	require.Error(t, s.Delete("b"))
+}
+
+// TestStoreRetention tests runs beyond the retention limits are removed.
+func TestStoreRetention(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.db")
+	s, err := Open(path, Retention{MaxRuns: 2})
+	require.NoError(t, err)
+	now := time.Now()
+	for i, id := range []string{"a", "b", "c"} {
+		require.NoError(t, s.Put(testRun(id, "api", "passed", now.Add(time.Duration(i-3)*time.Hour)), []byte(id)))
+	}
+	runs, err := s.List(Filter{})
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	_, err = s.ResultLog("a")
+	require.Error(t, err)
+	require.NoError(t, s.Close())
+
+	// Runs are pruned by age when the history is opened.
+	s, err = Open(path, Retention{MaxAge: 90 * time.Minute})
+	require.NoError(t, err)
+	defer s.Close()
+	runs, err = s.List(Filter{})
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Equal(t, "c", runs[0].ID)
+
+	s.now = func() time.Time { return now.Add(time.Hour) }
+	n, err := s.Prune()
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+}
+
+// TestStoreSummaries tests runs are listed and pruned from their summaries,
+// which are created for a history stored without them.
+func TestStoreSummaries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.db")
+	db, err := bbolt.Open(path, 0600, nil)
+	require.NoError(t, err)
+	start := time.Now()
+	err = db.Update(func(tx *bbolt.Tx) error {
+		runs, err := tx.CreateBucket(runsBucket)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.CreateBucket(logsBucket); err != nil {
+			return err
+		}
+		for i, id := range []string{"a", "b"} {
+			b, err := json.Marshal(testRun(id, "api", "passed", start.Add(time.Duration(i)*time.Hour)))
+			if err != nil {
+				return err
+			}
+			if err := runs.Put([]byte(id), b); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	s, err := Open(path, Retention{MaxRuns: 2})
+	require.NoError(t, err)
+	defer s.Close()
+	runs, err := s.List(Filter{})
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	assert.Equal(t, "b", runs[0].ID)
+	assert.Nil(t, runs[0].Report)
+
+	// Listing and pruning do not read the stored runs.
+	err = s.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(runsBucket).Put([]byte("b"), []byte("{"))
+	})
+	require.NoError(t, err)
+	require.NoError(t, s.Put(testRun("c", "api", "passed", start.Add(2*time.Hour)), nil))
+	runs, err = s.List(Filter{})
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	assert.Equal(t, "c", runs[0].ID)
+	assert.Equal(t, "b", runs[1].ID)
+	_, err = s.Get("a")
+	require.Error(t, err)
+	_, err = s.Get("b")
+	require.Error(t, err)
+	r, err := s.Get("c")
+	require.NoError(t, err)
+	require.NotNil(t, r.Report)
+}
+
+// TestHistoryRouter tests the HTTP API of the history.
+func TestHistoryRouter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := testStore(t, Retention{})
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, s.Put(testRun("a", "api", "passed", start), []byte("log")))
+	require.NoError(t, s.Put(testRun("b", "db", "failed", start.Add(time.Hour)), nil))
+	e := gin.New()
+	NewHistoryRouter(e, s)
+	do := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+
+	w := do(http.MethodGet, "/history?plan=api&since=2020-01-01T00:00:00Z")
+	require.Equal(t, 200, w.Code)
+	var runs []*Run
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &runs))
+	require.Len(t, runs, 1)
+	assert.Equal(t, "a", runs[0].ID)
+	assert.Nil(t, runs[0].Report)
+	assert.Equal(t, 400, do(http.MethodGet, "/history?since=yesterday").Code)
+	assert.Equal(t, 400, do(http.MethodGet, "/history?limit=-1").Code)
+
+	w = do(http.MethodGet, "/history/a")
+	require.Equal(t, 200, w.Code)
+	var run Run
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &run))
+	require.NotNil(t, run.Report)
+
+	w = do(http.MethodGet, "/history/a/report?format=html")
+	require.Equal(t, 200, w.Code)
+	assert.Contains(t, w.Body.String(), "<h1>api</h1>")
+	w = do(http.MethodGet, "/history/a/results")
+	require.Equal(t, 200, w.Code)
+	assert.Equal(t, "application/gzip", w.Header().Get("Content-Type"))
+	assert.Equal(t, 404, do(http.MethodGet, "/history/b/results").Code)
+
+	assert.Equal(t, 200, do(http.MethodDelete, "/history/a").Code)
+	assert.Equal(t, 404, do(http.MethodGet, "/history/a").Code)
+}
@@ -0,0 +1,301 @@
+// Package dir is a Manager that keeps plans as YAML files in a directory.
+package dir
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hodgesds/dlg"
+	"github.com/hodgesds/dlg/config"
+	"github.com/hodgesds/dlg/executor"
+	"gopkg.in/yaml.v2"
+)
+
+// PollInterval is the interval the directory is scanned for changed plan
+// files at.
+const PollInterval = 2 * time.Second
+
+// planFile is a plan file as of its last scan.
+type planFile struct {
+	modTime time.Time
+	size    int64
+	plan    *config.Plan
+}
+
+type manager struct {
+	dir      string
+	planExec executor.Plan
+
+	// scanMu serializes scans and writes of plan files.
+	scanMu sync.Mutex
+	mu     sync.RWMutex
+	// files are the plan files by file name and plans are the file names
+	// of the plans by plan name.
+	files map[string]planFile
+	plans map[string]string
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewManager returns a new manager for the plan files with a .yaml or .yml
+// extension in dir. A plan is named by its file if it has no name. Files are
+// scanned every PollInterval, so plans in new, changed and deleted files are
+// picked up. A file that fails to parse is logged and its last valid plan is
+// kept.
+func NewManager(dir string, planExec executor.Plan) (dlg.Manager, error) {
+	return newManager(dir, planExec, PollInterval)
+}
+
+func newManager(dir string, planExec executor.Plan, interval time.Duration) (*manager, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	m := &manager{
+		dir:      dir,
+		planExec: planExec,
+		files:    map[string]planFile{},
+		plans:    map[string]string{},
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+	if err := m.scan(); err != nil {
+		cancel()
+		return nil, err
+	}
+	go m.poll(ctx, interval)
+	return m, nil
+}
+
+func (m *manager) poll(ctx context.Context, interval time.Duration) {
+	defer close(m.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+		if err := m.scan(); err != nil {
+			log.Printf("scan plans: %v", err)
+		}
+	}
+}
+
+func isPlanFile(name string) bool {
+	ext := filepath.Ext(name)
+	return !strings.HasPrefix(name, ".") && (ext == ".yaml" || ext == ".yml")
+}
+
+// scan reads the plan files that changed since the last scan.
+func (m *manager) scan() error {
+	m.scanMu.Lock()
+	defer m.scanMu.Unlock()
+	entries, err := os.ReadDir(m.dir)
+	if err != nil {
+		return err
+	}
+	m.mu.RLock()
+	prev := m.files
+	m.mu.RUnlock()
+	files := map[string]planFile{}
+	for _, e := range entries {
+		if e.IsDir() || !isPlanFile(e.Name()) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			// The file was removed since the directory was read.
+			continue
+		}
+		f, ok := prev[e.Name()]
+		if ok && f.modTime.Equal(info.ModTime()) && f.size == info.Size() {
+			files[e.Name()] = f
+			continue
+		}
+		plan, err := m.read(e.Name())
+		if err != nil {
+			log.Printf("plan file %s: %v", filepath.Join(m.dir, e.Name()), err)
+			if !ok {
+				continue
+			}
+			plan = f.plan
+		}
+		files[e.Name()] = planFile{modTime: info.ModTime(), size: info.Size(), plan: plan}
+	}
+	m.mu.Lock()
+	m.setFiles(files)
+	m.mu.Unlock()
+	return nil
+}
+
+// read reads a plan file.
+func (m *manager) read(name string) (*config.Plan, error) {
+	b, err := ioutil.ReadFile(filepath.Join(m.dir, name))
+	if err != nil {
+		return nil, err
+	}
+	var plan config.Plan
+	if err := yaml.Unmarshal(b, &plan); err != nil {
+		return nil, err
+	}
+	if plan.Name == "" {
+		plan.Name = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+	return &plan, nil
+}
+
+// setFiles sets the plan files and indexes their plans by name, if several
+// files have a plan with the same name the first file is used. It must be
+// called with mu held.
+func (m *manager) setFiles(files map[string]planFile) {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	plans := map[string]string{}
+	for _, name := range names {
+		plan := files[name].plan.Name
+		if other, ok := plans[plan]; ok {
+			// Duplicates are logged when the file is read.
+			if m.files[name].plan != files[name].plan {
+				log.Printf("plan %q of %s is already in %s", plan, name, other)
+			}
+			continue
+		}
+		plans[plan] = name
+	}
+	m.files = files
+	m.plans = plans
+}
+
+// Close stops scanning the directory.
+func (m *manager) Close() error {
+	m.cancel()
+	<-m.done
+	return nil
+}
+
+// Get implements the Manager interface.
+func (m *manager) Get(ctx context.Context, name string) (*config.Plan, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	file, ok := m.plans[name]
+	if !ok {
+		return nil, fmt.Errorf("no such plan: %q", name)
+	}
+	return m.files[file].plan, nil
+}
+
+// Add implements the Manager interface. The plan is written to the file it
+// was read from, or to a new file named by the plan.
+func (m *manager) Add(ctx context.Context, plan *config.Plan) error {
+	if plan.Name == "" || plan.Name != filepath.Base(plan.Name) || strings.HasPrefix(plan.Name, ".") {
+		return fmt.Errorf("invalid plan name: %q", plan.Name)
+	}
+	b, err := yaml.Marshal(plan)
+	if err != nil {
+		return err
+	}
+	m.scanMu.Lock()
+	defer m.scanMu.Unlock()
+	m.mu.RLock()
+	file, ok := m.plans[plan.Name]
+	m.mu.RUnlock()
+	if !ok {
+		file = plan.Name + ".yaml"
+	}
+
+	// The plan is written to a temporary file which is renamed, so scans
+	// never read a partial file.
+	tmp, err := ioutil.TempFile(m.dir, "."+file)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	path := filepath.Join(m.dir, file)
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	files := make(map[string]planFile, len(m.files)+1)
+	for name, f := range m.files {
+		files[name] = f
+	}
+	files[file] = planFile{modTime: info.ModTime(), size: info.Size(), plan: plan}
+	m.setFiles(files)
+	return nil
+}
+
+// Delete implements the Manager interface, it removes the file of the plan.
+func (m *manager) Delete(ctx context.Context, name string) error {
+	m.scanMu.Lock()
+	defer m.scanMu.Unlock()
+	m.mu.RLock()
+	file, ok := m.plans[name]
+	m.mu.RUnlock()
+	if !ok {
+		return nil
+	}
+	if err := os.Remove(filepath.Join(m.dir, file)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	files := make(map[string]planFile, len(m.files))
+	for name, f := range m.files {
+		if name != file {
+			files[name] = f
+		}
+	}
+	m.setFiles(files)
+	return nil
+}
+
+// Plans implements the Manager interface, plans are ordered by name.
+func (m *manager) Plans(ctx context.Context) ([]*config.Plan, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	plans := make([]*config.Plan, 0, len(m.plans))
+	for _, file := range m.plans {
+		plans = append(plans, m.files[file].plan)
+	}
+	sort.Slice(plans, func(i, j int) bool {
+		return plans[i].Name < plans[j].Name
+	})
+	return plans, nil
+}
+
+// Execute implements the Executor interface.
+func (m *manager) Execute(ctx context.Context, plan *config.Plan) error {
+	return m.planExec.Execute(ctx, plan)
+}
@@ -0,0 +1,122 @@
+package dir
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hodgesds/dlg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testManager(t *testing.T) (*manager, string) {
+	dir := t.TempDir()
+	m, err := newManager(dir, nil, 10*time.Millisecond)
+	require.NoError(t, err)
+	t.Cleanup(func() { m.Close() })
+	return m, dir
+}
+
+func writeFile(t *testing.T, path, s string) {
+	require.NoError(t, ioutil.WriteFile(path, []byte(s), 0644))
+	// Scans detect changes by modification time and size.
+	later := time.Now().Add(time.Second)
+	require.NoError(t, os.Chtimes(path, later, later))
+}
+
+func planNames(t *testing.T, m *manager) []string {
+	plans, err := m.Plans(context.Background())
+	require.NoError(t, err)
+	names := []string{}
+	for _, p := range plans {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+// TestManagerFiles tests plans of new, changed and deleted files are picked
+// up.
+func TestManagerFiles(t *testing.T) {
+	m, dir := testManager(t)
+	ctx := context.Background()
+
+	writeFile(t, filepath.Join(dir, "api.yaml"), "name: api\ntags: [a]\n")
+	writeFile(t, filepath.Join(dir, "db.yml"), "tags: [b]\n")
+	writeFile(t, filepath.Join(dir, "README.md"), "plans\n")
+	require.Eventually(t, func() bool {
+		return len(planNames(t, m)) == 2
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, []string{"api", "db"}, planNames(t, m))
+
+	writeFile(t, filepath.Join(dir, "api.yaml"), "name: api\ntags: [c]\n")
+	require.Eventually(t, func() bool {
+		p, err := m.Get(ctx, "api")
+		return err == nil && p.Tags[0] == "c"
+	}, time.Second, time.Millisecond)
+
+	// An invalid file keeps its last plan.
+	writeFile(t, filepath.Join(dir, "api.yaml"), "name: [")
+	require.NoError(t, m.scan())
+	p, err := m.Get(ctx, "api")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c"}, p.Tags)
+
+	require.NoError(t, os.Remove(filepath.Join(dir, "db.yml")))
+	require.Eventually(t, func() bool {
+		_, err := m.Get(ctx, "db")
+		return err != nil
+	}, time.Second, time.Millisecond)
+}
+
+// TestManagerAddDelete tests adding and deleting plans writes and removes
+// their files.
+func TestManagerAddDelete(t *testing.T) {
+	m, dir := testManager(t)
+	ctx := context.Background()
+
+	require.NoError(t, m.Add(ctx, &config.Plan{Name: "api", Tags: []string{"a"}}))
+	p, err := m.Get(ctx, "api")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a"}, p.Tags)
+	b, err := ioutil.ReadFile(filepath.Join(dir, "api.yaml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(b), "name: api")
+
+	// Plans are written to the file they were read from.
+	writeFile(t, filepath.Join(dir, "db-plan.yml"), "name: db\n")
+	require.NoError(t, m.scan())
+	require.NoError(t, m.Add(ctx, &config.Plan{Name: "db", Tags: []string{"b"}}))
+	b, err = ioutil.ReadFile(filepath.Join(dir, "db-plan.yml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(b), "- b")
+	require.NoError(t, m.scan())
+	assert.Equal(t, []string{"api", "db"}, planNames(t, m))
+
+	require.NoError(t, m.Delete(ctx, "db"))
+	_, err = os.Stat(filepath.Join(dir, "db-plan.yml"))
+	assert.True(t, os.IsNotExist(err))
+	assert.Equal(t, []string{"api"}, planNames(t, m))
+
+	require.Error(t, m.Add(ctx, &config.Plan{Name: "../api"}))
+	require.Error(t, m.Add(ctx, &config.Plan{}))
+
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, "api.yaml", files[0].Name())
+}
+
+// TestManagerDuplicate tests the first file of a plan name is used.
+func TestManagerDuplicate(t *testing.T) {
+	m, dir := testManager(t)
+	writeFile(t, filepath.Join(dir, "a.yaml"), "name: api\ntags: [a]\n")
+	writeFile(t, filepath.Join(dir, "b.yaml"), "name: api\ntags: [b]\n")
+	require.NoError(t, m.scan())
+	p, err := m.Get(context.Background(), "api")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a"}, p.Tags)
+}
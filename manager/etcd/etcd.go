@@ -2,79 +2,129 @@ package etcd
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
-	"log"
-	"sync"
+	"time"
 
 	"github.com/hodgesds/dlg"
 	"github.com/hodgesds/dlg/config"
 	etcdconfig "github.com/hodgesds/dlg/config/etcd"
 	"github.com/hodgesds/dlg/executor"
+	"github.com/hodgesds/dlg/report"
 	"go.etcd.io/etcd/clientv3"
 	"gopkg.in/yaml.v2"
 )
 
+// Keys of a plan, a plan is stored as YAML under planPrefix. A run of a plan
+// is queued by writing its queue key, the claim key holds the worker running
+// the queued run and is bound to a lease of the worker. The revision key is
+// written with the final status of a run with the revision of the queue key
+// that was run, and the status key holds the RunStatus of the last run. A
+// queued run whose worker died before it completed has no revision key once
+// the lease of its claim expires, so it is claimed again.
+const (
+	planPrefix     = "plan-"
+	queuePrefix    = "queue-"
+	claimPrefix    = "claim-"
+	revisionPrefix = "revision-"
+	statusPrefix   = "status-"
+)
+
+// States of a RunStatus, the states of a finished run are those of
+// dlg.RunStatus.
+const (
+	StateRunning  = "running"
+	StatePassed   = "passed"
+	StateFailed   = "failed"
+	StateCanceled = "canceled"
+)
+
+// RunStatus is the status of a run of a plan written to etcd.
+type RunStatus struct {
+	Plan string `json:"plan"`
+	// Revision is the etcd revision of the queue key of the run.
+	Revision int64  `json:"revision"`
+	Worker   string `json:"worker"`
+	// Run is the ID of the run on the worker.
+	Run    string         `json:"run,omitempty"`
+	State  string         `json:"state"`
+	Start  time.Time      `json:"start"`
+	End    *time.Time     `json:"end,omitempty"`
+	Error  string         `json:"error,omitempty"`
+	Report *report.Report `json:"report,omitempty"`
+}
+
+// Manager is a dlg.Manager that keeps plans in etcd and queues their runs
+// on the workers.
+type Manager interface {
+	dlg.Manager
+
+	// Queue queues a run of a plan, it is run by one of the workers.
+	Queue(ctx context.Context, name string) error
+
+	// Status returns the status of the last run of a plan.
+	Status(ctx context.Context, name string) (*RunStatus, error)
+}
+
 type manager struct {
-	mu       sync.RWMutex
 	planExec executor.Plan
 	c        *clientv3.Client
-	stop     chan struct{}
-	stopped  bool
 }
 
-// NewManager returns a new manager.
-func NewManager(
-	config *etcdconfig.Config,
-	planExec executor.Plan,
-) (dlg.Manager, error) {
-	c, err := clientv3.New(clientv3.Config{
-		Endpoints:   config.Endpoints,
-		DialTimeout: config.DialTimeout,
-	})
+// NewManager returns a new Manager. Plans are executed on this server with
+// planExec, queued runs are executed by a Worker.
+func NewManager(config *etcdconfig.Config, planExec executor.Plan) (Manager, error) {
+	c, err := clientv3.New(config.ClientConfig())
 	if err != nil {
 		return nil, err
 	}
+	return &manager{c: c, planExec: planExec}, nil
+}
+
+func planKey(name string) string     { return planPrefix + name }
+func queueKey(name string) string    { return queuePrefix + name }
+func claimKey(name string) string    { return claimPrefix + name }
+func revisionKey(name string) string { return revisionPrefix + name }
+func statusKey(name string) string   { return statusPrefix + name }
 
-	m := &manager{
-		c:        c,
-		planExec: planExec,
-		stop:     make(chan struct{}),
+// Queue implements the Manager interface. A plan queued again before its
+// queued run was claimed is run once.
+func (m *manager) Queue(ctx context.Context, name string) error {
+	res, err := m.c.Get(ctx, planKey(name), clientv3.WithCountOnly())
+	if err != nil {
+		return err
+	}
+	if res.Count == 0 {
+		return fmt.Errorf("no plan with name: %q", name)
 	}
-	return m, m.start()
+	_, err = m.c.Put(ctx, queueKey(name), time.Now().Format(time.RFC3339Nano))
+	return err
 }
 
-func (m *manager) start() error {
-	go m.watch()
-	return nil
+// Close closes the etcd client.
+func (m *manager) Close() error {
+	return m.c.Close()
 }
 
-func (m *manager) watch() {
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
-	watch := m.c.Watch(ctx, "plan")
-	for {
-		select {
-		case res := <-watch:
-			if res.Canceled {
-				log.Println(res.Err().Error())
-			}
-			if !res.Created {
-				m.handleEvents(res.Events...)
-			}
-		case <-m.stop:
-			return
-		}
+// Status implements the Manager interface.
+func (m *manager) Status(ctx context.Context, name string) (*RunStatus, error) {
+	res, err := m.c.Get(ctx, statusKey(name))
+	if err != nil {
+		return nil, err
 	}
-}
-
-func (m *manager) handleEvents(events ...*clientv3.Event) error {
-	return nil
+	if len(res.Kvs) == 0 {
+		return nil, fmt.Errorf("no status for plan: %q", name)
+	}
+	var status RunStatus
+	if err := json.Unmarshal(res.Kvs[0].Value, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
 }
 
 // Get implements the Manager interface.
 func (m *manager) Get(ctx context.Context, name string) (*config.Plan, error) {
-	res, err := m.c.Get(ctx, fmt.Sprintf("plan-%s", name))
+	res, err := m.c.Get(ctx, planKey(name))
 	if err != nil {
 		return nil, err
 	}
@@ -94,19 +144,22 @@ func (m *manager) Add(ctx context.Context, plan *config.Plan) error {
 	if err != nil {
 		return err
 	}
-	_, err = m.c.Put(ctx, fmt.Sprintf("plan-%s", plan.Name), string(b))
+	_, err = m.c.Put(ctx, planKey(plan.Name), string(b))
 	return err
 }
 
 // Delete implments the Manager interface.
 func (m *manager) Delete(ctx context.Context, name string) error {
-	_, err := m.c.Delete(ctx, fmt.Sprintf("plan-%s", name), clientv3.WithPrevKV())
+	_, err := m.c.Txn(ctx).Then(
+		clientv3.OpDelete(planKey(name)),
+		clientv3.OpDelete(queueKey(name)),
+	).Commit()
 	return err
 }
 
 // Plans implements the Manager interface.
 func (m *manager) Plans(ctx context.Context) ([]*config.Plan, error) {
-	res, err := m.c.Get(ctx, "plan-")
+	res, err := m.c.Get(ctx, planPrefix, clientv3.WithPrefix())
 	if err != nil {
 		return nil, err
 	}
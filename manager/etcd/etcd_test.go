@@ -0,0 +1,289 @@
+package etcd
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hodgesds/dlg"
+	"github.com/hodgesds/dlg/config"
+	etcdconfig "github.com/hodgesds/dlg/config/etcd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/clientv3"
+)
+
+// testPlanExec records the plans it executes, plans named block run until
+// they are canceled.
+type testPlanExec struct {
+	mu    sync.Mutex
+	plans []string
+}
+
+func (e *testPlanExec) Execute(ctx context.Context, plan *config.Plan) error {
+	e.mu.Lock()
+	e.plans = append(e.plans, plan.Name)
+	e.mu.Unlock()
+	if strings.HasPrefix(plan.Name, "block") {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return nil
+}
+
+func (e *testPlanExec) runs(name string) int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	n := 0
+	for _, p := range e.plans {
+		if p == name {
+			n++
+		}
+	}
+	return n
+}
+
+// testManager returns a manager connected to the etcd of the
+// DLG_ETCD_ENDPOINTS environment variable.
+func testManager(t *testing.T) (*manager, *testPlanExec) {
+	endpoints := os.Getenv("DLG_ETCD_ENDPOINTS")
+	if endpoints == "" {
+		t.Skip("DLG_ETCD_ENDPOINTS is not set")
+	}
+	exec := &testPlanExec{}
+	m, err := NewManager(testConfig(), exec)
+	require.NoError(t, err)
+	t.Cleanup(func() { m.(*manager).Close() })
+	return m.(*manager), exec
+}
+
+func testConfig() *etcdconfig.Config {
+	return &etcdconfig.Config{
+		Endpoints:   strings.Split(os.Getenv("DLG_ETCD_ENDPOINTS"), ","),
+		DialTimeout: 5 * time.Second,
+	}
+}
+
+// testWorker returns a manager and a worker with labels that runs the
+// queued plans with at most maxRuns runs, 0 for no limit.
+func testWorker(t *testing.T, labels map[string]string, maxRuns int) (*manager, *Worker, *testPlanExec) {
+	m, exec := testManager(t)
+	runs := dlg.NewRuns(m, dlg.RunsConfig{MaxRuns: maxRuns})
+	w, err := NewWorker(testConfig(), runs, labels)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		w.Close()
+		runs.Close()
+	})
+	return m, w, exec
+}
+
+func waitStatus(t *testing.T, m *manager, name string, rev int64, state string) *RunStatus {
+	var status *RunStatus
+	require.Eventually(t, func() bool {
+		var err error
+		status, err = m.Status(context.Background(), name)
+		return err == nil && status.Revision >= rev && status.State == state
+	}, 10*time.Second, 10*time.Millisecond)
+	return status
+}
+
+// TestManagerPlans tests storing plans in etcd.
+func TestManagerPlans(t *testing.T) {
+	m, _ := testManager(t)
+	ctx := context.Background()
+	prefix := uuid.New().String()
+	for _, name := range []string{prefix + "-a", prefix + "-b"} {
+		require.NoError(t, m.Add(ctx, &config.Plan{Name: name}))
+		defer m.Delete(ctx, name)
+	}
+
+	plans, err := m.Plans(ctx)
+	require.NoError(t, err)
+	names := map[string]bool{}
+	for _, p := range plans {
+		names[p.Name] = true
+	}
+	assert.True(t, names[prefix+"-a"])
+	assert.True(t, names[prefix+"-b"])
+
+	p, err := m.Get(ctx, prefix+"-a")
+	require.NoError(t, err)
+	assert.Equal(t, prefix+"-a", p.Name)
+
+	require.NoError(t, m.Delete(ctx, prefix+"-a"))
+	_, err = m.Get(ctx, prefix+"-a")
+	require.Error(t, err)
+}
+
+// TestManagerQueue tests only existing plans are queued.
+func TestManagerQueue(t *testing.T) {
+	m, _ := testManager(t)
+	require.Error(t, m.Queue(context.Background(), uuid.New().String()))
+}
+
+// TestWorkerRun tests only queued runs are run, each by one worker, and their
+// status is written back.
+func TestWorkerRun(t *testing.T) {
+	m1, w1, exec1 := testWorker(t, nil, 0)
+	m2, w2, exec2 := testWorker(t, nil, 0)
+	ctx := context.Background()
+	name := uuid.New().String()
+	defer m1.Delete(ctx, name)
+
+	require.NoError(t, m1.Add(ctx, &config.Plan{Name: name}))
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, 0, exec1.runs(name)+exec2.runs(name))
+	_, err := m1.Status(ctx, name)
+	require.Error(t, err)
+
+	require.NoError(t, m1.Queue(ctx, name))
+	status := waitStatus(t, m1, name, 0, StatePassed)
+	assert.Contains(t, []string{w1.ID(), w2.ID()}, status.Worker)
+	assert.NotEmpty(t, status.Run)
+	require.NotNil(t, status.Report)
+	assert.True(t, status.Report.Passed)
+	assert.NotNil(t, status.End)
+
+	require.NoError(t, m2.Add(ctx, &config.Plan{Name: name, Tags: []string{"v2"}}))
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, 1, exec1.runs(name)+exec2.runs(name))
+
+	require.NoError(t, m2.Queue(ctx, name))
+	waitStatus(t, m2, name, status.Revision+1, StatePassed)
+	// Give the other worker time to run the plan a second time.
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, 2, exec1.runs(name)+exec2.runs(name))
+}
+
+// TestWorkerRunPending tests runs queued while no worker is watching are run
+// when a worker starts.
+func TestWorkerRunPending(t *testing.T) {
+	m1, _ := testManager(t)
+	ctx := context.Background()
+	name := uuid.New().String()
+	require.NoError(t, m1.Add(ctx, &config.Plan{Name: name}))
+	defer m1.Delete(ctx, name)
+	require.NoError(t, m1.Queue(ctx, name))
+
+	m2, _, exec := testWorker(t, nil, 0)
+	waitStatus(t, m2, name, 0, StatePassed)
+	assert.Equal(t, 1, exec.runs(name))
+}
+
+// TestWorkerClose tests closing a worker cancels its runs and the status of
+// the canceled run is written.
+func TestWorkerClose(t *testing.T) {
+	m1, w1, _ := testWorker(t, nil, 0)
+	m2, w2, _ := testWorker(t, nil, 0)
+	ctx := context.Background()
+	name := "block-" + uuid.New().String()
+	defer m1.Delete(ctx, name)
+
+	require.NoError(t, m1.Add(ctx, &config.Plan{Name: name}))
+	require.NoError(t, m1.Queue(ctx, name))
+	status := waitStatus(t, m2, name, 0, StateRunning)
+	running := w1
+	if status.Worker == w2.ID() {
+		running = w2
+	}
+	require.NoError(t, running.Close())
+	status = waitStatus(t, m2, name, 0, StateCanceled)
+	assert.Equal(t, running.ID(), status.Worker)
+	assert.Equal(t, "context canceled", status.Error)
+}
+
+// TestWorkerDelete tests deleting a plan cancels its run.
+func TestWorkerDelete(t *testing.T) {
+	m, _, exec := testWorker(t, nil, 0)
+	ctx := context.Background()
+	name := "block-" + uuid.New().String()
+
+	require.NoError(t, m.Add(ctx, &config.Plan{Name: name}))
+	require.NoError(t, m.Queue(ctx, name))
+	waitStatus(t, m, name, 0, StateRunning)
+	require.NoError(t, m.Delete(ctx, name))
+	status := waitStatus(t, m, name, 0, StateCanceled)
+	assert.Equal(t, "context canceled", status.Error)
+	assert.Equal(t, 1, exec.runs(name))
+}
+
+// TestWorkerLabels tests plans are only run by workers with the labels of
+// their workers.
+func TestWorkerLabels(t *testing.T) {
+	m1, w1, exec1 := testWorker(t, map[string]string{"region": "a", "zone": "1"}, 0)
+	m2, _, exec2 := testWorker(t, map[string]string{"region": "b"}, 0)
+	ctx := context.Background()
+	name := uuid.New().String()
+	defer m1.Delete(ctx, name)
+
+	plan := &config.Plan{
+		Name:        name,
+		Distributed: &config.Distributed{Workers: map[string]string{"region": "a"}},
+	}
+	require.NoError(t, m2.Add(ctx, plan))
+	require.NoError(t, m2.Queue(ctx, name))
+	status := waitStatus(t, m2, name, 0, StatePassed)
+	assert.Equal(t, w1.ID(), status.Worker)
+
+	require.NoError(t, m2.Queue(ctx, name))
+	status = waitStatus(t, m2, name, status.Revision+1, StatePassed)
+	assert.Equal(t, w1.ID(), status.Worker)
+	assert.Equal(t, 2, exec1.runs(name))
+	assert.Equal(t, 0, exec2.runs(name))
+}
+
+// TestWorkerOrphanedClaim tests a queued run is run again when the lease of
+// the claim of a worker that died expires.
+func TestWorkerOrphanedClaim(t *testing.T) {
+	m, w, exec := testWorker(t, nil, 0)
+	ctx := context.Background()
+	name := uuid.New().String()
+	defer m.Delete(ctx, name)
+
+	c, err := clientv3.New(clientv3.Config{Endpoints: m.c.Endpoints()})
+	require.NoError(t, err)
+	defer c.Close()
+	lease, err := c.Grant(ctx, leaseTTL)
+	require.NoError(t, err)
+	_, err = c.Put(ctx, claimKey(name), "dead", clientv3.WithLease(lease.ID))
+	require.NoError(t, err)
+	require.NoError(t, m.Add(ctx, &config.Plan{Name: name}))
+	require.NoError(t, m.Queue(ctx, name))
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, 0, exec.runs(name))
+
+	_, err = c.Revoke(ctx, lease.ID)
+	require.NoError(t, err)
+	status := waitStatus(t, m, name, 0, StatePassed)
+	assert.Equal(t, w.ID(), status.Worker)
+	assert.Equal(t, 1, exec.runs(name))
+}
+
+// TestWorkerMaxRuns tests a queued run is started once the maximum number of
+// runs of the worker allows it.
+func TestWorkerMaxRuns(t *testing.T) {
+	defer func(d time.Duration) { retryInterval = d }(retryInterval)
+	retryInterval = 10 * time.Millisecond
+	m, _, exec := testWorker(t, nil, 1)
+	ctx := context.Background()
+	block := "block-" + uuid.New().String()
+	name := uuid.New().String()
+	defer m.Delete(ctx, name)
+
+	require.NoError(t, m.Add(ctx, &config.Plan{Name: block}))
+	require.NoError(t, m.Add(ctx, &config.Plan{Name: name}))
+	require.NoError(t, m.Queue(ctx, block))
+	waitStatus(t, m, block, 0, StateRunning)
+	require.NoError(t, m.Queue(ctx, name))
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, 0, exec.runs(name))
+
+	require.NoError(t, m.Delete(ctx, block))
+	waitStatus(t, m, name, 0, StatePassed)
+	assert.Equal(t, 1, exec.runs(name))
+}
@@ -0,0 +1,37 @@
+package etcd
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// queueRouter is a Manager HTTP Router for queued runs.
+type queueRouter struct {
+	m Manager
+}
+
+// NewQueueRouter adds the routes to queue runs of plans on the workers and
+// get their status to e.
+func NewQueueRouter(e *gin.Engine, m Manager) {
+	r := &queueRouter{m: m}
+	e.POST("/plan/:name/queue", r.Queue)
+	e.GET("/plan/:name/status", r.Status)
+}
+
+// Queue queues a run of a plan.
+func (r *queueRouter) Queue(c *gin.Context) {
+	if err := r.m.Queue(c, c.Param("name")); err != nil {
+		c.JSON(400, gin.H{"msg": err.Error()})
+		return
+	}
+	c.JSON(202, gin.H{"status": "queued"})
+}
+
+// Status returns the status of the last queued run of a plan.
+func (r *queueRouter) Status(c *gin.Context) {
+	status, err := r.m.Status(c, c.Param("name"))
+	if err != nil {
+		c.JSON(404, gin.H{"msg": err.Error()})
+		return
+	}
+	c.JSON(200, status)
+}
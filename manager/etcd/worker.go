@@ -0,0 +1,397 @@
+package etcd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hodgesds/dlg"
+	"github.com/hodgesds/dlg/config"
+	etcdconfig "github.com/hodgesds/dlg/config/etcd"
+	"go.etcd.io/etcd/clientv3"
+	"gopkg.in/yaml.v2"
+)
+
+// leaseTTL is the TTL in seconds of the lease of a claim, it is kept alive
+// while the plan runs.
+const leaseTTL = 10
+
+// retryInterval is the time a worker waits to claim a queued run again when
+// it could not start the run because of its maximum number of runs.
+var retryInterval = time.Second
+
+// Worker claims queued runs of plans in etcd and runs them with Runs, so that
+// they are limited, recorded and controlled like other runs of the server.
+// The status and report of a run are written back to etcd.
+type Worker struct {
+	mu      sync.RWMutex
+	runs    *dlg.Runs
+	c       *clientv3.Client
+	id      string
+	labels  map[string]string
+	running map[string]context.CancelFunc
+	wg      sync.WaitGroup
+	ctx     context.Context
+	cancel  context.CancelFunc
+	stopped bool
+}
+
+// NewWorker returns a new Worker. Queued runs are claimed by one of the
+// watching workers whose labels match the workers of the plan.
+func NewWorker(
+	config *etcdconfig.Config,
+	runs *dlg.Runs,
+	labels map[string]string,
+) (*Worker, error) {
+	c, err := clientv3.New(config.ClientConfig())
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &Worker{
+		c:       c,
+		runs:    runs,
+		id:      workerID(),
+		labels:  labels,
+		running: map[string]context.CancelFunc{},
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+	if err := w.start(); err != nil {
+		cancel()
+		c.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// workerID returns an ID for the worker that is unique in the cluster.
+func workerID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "dlg"
+	}
+	return fmt.Sprintf("%s-%s", host, uuid.New().String()[:8])
+}
+
+// ID returns the ID of the worker.
+func (w *Worker) ID() string {
+	return w.id
+}
+
+// start claims the runs that were queued, or whose worker died, while no
+// worker was running and watches for queued runs, released claims and
+// deleted plans.
+func (w *Worker) start() error {
+	res, err := w.c.Get(w.ctx, queuePrefix, clientv3.WithPrefix())
+	if err != nil {
+		return err
+	}
+	for _, kv := range res.Kvs {
+		w.schedule(string(kv.Key[len(queuePrefix):]), kv.ModRevision)
+	}
+	w.wg.Add(3)
+	go w.watch(queuePrefix, res.Header.Revision+1)
+	go w.watch(claimPrefix, res.Header.Revision+1)
+	go w.watch(planPrefix, res.Header.Revision+1)
+	return nil
+}
+
+func (w *Worker) watch(prefix string, rev int64) {
+	defer w.wg.Done()
+	watch := w.c.Watch(
+		w.ctx,
+		prefix,
+		clientv3.WithPrefix(),
+		clientv3.WithRev(rev),
+	)
+	for res := range watch {
+		if res.Canceled {
+			log.Println(res.Err().Error())
+			continue
+		}
+		if err := w.handleEvents(res.Events...); err != nil {
+			log.Println(err.Error())
+		}
+	}
+}
+
+func (w *Worker) handleEvents(events ...*clientv3.Event) error {
+	for _, ev := range events {
+		key := string(ev.Kv.Key)
+		switch {
+		case strings.HasPrefix(key, queuePrefix):
+			if ev.Type == clientv3.EventTypePut {
+				w.schedule(key[len(queuePrefix):], ev.Kv.ModRevision)
+			}
+		case strings.HasPrefix(key, claimPrefix):
+			// A claim is released when its run completes or when the
+			// lease of a worker that died expires, the latest queued
+			// run is claimed again if it did not complete.
+			if ev.Type == clientv3.EventTypeDelete {
+				if err := w.reschedule(key[len(claimPrefix):]); err != nil {
+					return err
+				}
+			}
+		case ev.Type == clientv3.EventTypeDelete:
+			// Runs of deleted plans are canceled.
+			w.mu.RLock()
+			if cancel, ok := w.running[key[len(planPrefix):]]; ok {
+				cancel()
+			}
+			w.mu.RUnlock()
+		}
+	}
+	return nil
+}
+
+// reschedule schedules the latest queued run of a plan if it did not
+// complete.
+func (w *Worker) reschedule(name string) error {
+	rev, pending, err := w.pending(w.ctx, name)
+	if err != nil || !pending {
+		return err
+	}
+	w.schedule(name, rev)
+	return nil
+}
+
+// pending returns the revision of the queue key of a plan and if its run did
+// not complete.
+func (w *Worker) pending(ctx context.Context, name string) (int64, bool, error) {
+	res, err := w.c.Get(ctx, queueKey(name))
+	if err != nil || len(res.Kvs) == 0 {
+		return 0, false, err
+	}
+	rev := res.Kvs[0].ModRevision
+	last, _, err := w.completed(ctx, name)
+	if err != nil {
+		return 0, false, err
+	}
+	return rev, last < rev, nil
+}
+
+// completed returns the last revision of the queue key of a plan whose run
+// completed and the revision of its revision key, both are 0 if no run
+// completed.
+func (w *Worker) completed(ctx context.Context, name string) (int64, int64, error) {
+	res, err := w.c.Get(ctx, revisionKey(name))
+	if err != nil || len(res.Kvs) == 0 {
+		return 0, 0, err
+	}
+	last, err := strconv.ParseInt(string(res.Kvs[0].Value), 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	return last, res.Kvs[0].ModRevision, nil
+}
+
+// schedule runs a queued run of a plan if the worker claims it.
+func (w *Worker) schedule(name string, rev int64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.stopped {
+		return
+	}
+	if _, ok := w.running[name]; ok {
+		// A run queued during the current run is claimed after it.
+		return
+	}
+	ctx, cancel := context.WithCancel(w.ctx)
+	w.running[name] = cancel
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		err := w.run(ctx, name, rev)
+		cancel()
+		w.mu.Lock()
+		delete(w.running, name)
+		w.mu.Unlock()
+		if err != nil {
+			log.Printf("plan %q: %v", name, err)
+		}
+	}()
+}
+
+// run claims and executes a queued run of a plan, then claims the latest
+// queued run of the plan if it was queued again during the run.
+func (w *Worker) run(ctx context.Context, name string, rev int64) error {
+	for {
+		if ok, err := w.selects(ctx, name); err != nil || !ok {
+			return err
+		}
+		lease, claimed, err := w.claim(ctx, name, rev)
+		if err != nil || !claimed {
+			return err
+		}
+		err = w.execute(ctx, name, rev, lease)
+		if errors.Is(err, dlg.ErrMaxRuns) {
+			// The claim was released for other workers, the run is
+			// claimed again after a while if it is still pending.
+			t := time.NewTimer(retryInterval)
+			select {
+			case <-t.C:
+			case <-ctx.Done():
+				t.Stop()
+			}
+		} else if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+		var pending bool
+		rev, pending, err = w.pending(ctx, name)
+		if err != nil || !pending {
+			return err
+		}
+	}
+}
+
+// selects returns if the labels of the worker match the workers of a plan. An
+// invalid or missing plan is selected so that its error is written to its
+// status.
+func (w *Worker) selects(ctx context.Context, name string) (bool, error) {
+	res, err := w.c.Get(ctx, planKey(name))
+	if err != nil || len(res.Kvs) == 0 {
+		return err == nil, err
+	}
+	var plan config.Plan
+	if err := yaml.Unmarshal(res.Kvs[0].Value, &plan); err != nil || plan.Distributed == nil {
+		return true, nil
+	}
+	for k, v := range plan.Distributed.Workers {
+		if label, ok := w.labels[k]; !ok || label != v {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// claim claims a queued run of a plan with a lease of the worker. A run is
+// claimed only if it is the latest queued run of the plan, it did not
+// complete and no other run of the plan is active.
+func (w *Worker) claim(ctx context.Context, name string, rev int64) (clientv3.LeaseID, bool, error) {
+	last, lastRev, err := w.completed(ctx, name)
+	if err != nil || last >= rev {
+		return 0, false, err
+	}
+	lease, err := w.c.Grant(ctx, leaseTTL)
+	if err != nil {
+		return 0, false, err
+	}
+	res, err := w.c.Txn(ctx).If(
+		clientv3.Compare(clientv3.ModRevision(queueKey(name)), "=", rev),
+		clientv3.Compare(clientv3.CreateRevision(claimKey(name)), "=", 0),
+		clientv3.Compare(clientv3.ModRevision(revisionKey(name)), "=", lastRev),
+	).Then(
+		clientv3.OpPut(claimKey(name), w.id, clientv3.WithLease(lease.ID)),
+	).Commit()
+	if err == nil && res.Succeeded {
+		return lease.ID, true, nil
+	}
+	if _, err := w.c.Revoke(context.Background(), lease.ID); err != nil {
+		log.Printf("revoke lease: %v", err)
+	}
+	return 0, false, err
+}
+
+// execute starts a claimed run with Runs, waits for it to complete and
+// writes its status and revision. The run is canceled with ctx and the claim
+// is released by revoking its lease. dlg.ErrMaxRuns is returned without
+// writing the revision if the run could not be started.
+func (w *Worker) execute(ctx context.Context, name string, rev int64, lease clientv3.LeaseID) error {
+	defer func() {
+		if _, err := w.c.Revoke(context.Background(), lease); err != nil {
+			log.Printf("revoke lease: %v", err)
+		}
+	}()
+	keepAlive, err := w.c.KeepAlive(ctx, lease)
+	if err != nil {
+		return err
+	}
+	go func() {
+		for range keepAlive {
+		}
+	}()
+
+	status := &RunStatus{
+		Plan:     name,
+		Revision: rev,
+		Worker:   w.id,
+		State:    StateFailed,
+		Start:    time.Now(),
+	}
+	run, err := w.runs.Start(ctx, name)
+	if errors.Is(err, dlg.ErrMaxRuns) {
+		return err
+	}
+	if err == nil {
+		status.Run = run.ID
+		status.State = StateRunning
+		if err := w.putStatus(ctx, name, status); err != nil {
+			log.Printf("plan %q: status: %v", name, err)
+		}
+		select {
+		case <-run.Done():
+		case <-ctx.Done():
+			run.Cancel()
+			<-run.Done()
+		}
+		s := run.Status()
+		status.State = s.State
+		status.Start = s.Start
+		status.End = s.End
+		status.Error = s.Error
+		status.Report = run.Report()
+	} else {
+		end := time.Now()
+		status.End = &end
+		status.Error = err.Error()
+	}
+	b, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+	// The status is written even if the run was canceled, the revision is
+	// written before the claim is released so that it is not run again.
+	_, err = w.c.Txn(context.Background()).Then(
+		clientv3.OpPut(revisionKey(name), fmt.Sprint(rev)),
+		clientv3.OpPut(statusKey(name), string(b)),
+	).Commit()
+	return err
+}
+
+// putStatus writes the status of a run.
+func (w *Worker) putStatus(ctx context.Context, name string, status *RunStatus) error {
+	b, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+	_, err = w.c.Put(ctx, statusKey(name), string(b))
+	return err
+}
+
+// Close stops watching queued runs, cancels the active runs and closes the
+// etcd client.
+func (w *Worker) Close() error {
+	w.mu.Lock()
+	if w.stopped {
+		w.mu.Unlock()
+		return nil
+	}
+	w.stopped = true
+	w.mu.Unlock()
+	w.cancel()
+	w.wg.Wait()
+	return w.c.Close()
+}
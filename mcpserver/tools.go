@@ -93,6 +93,8 @@ import (
 	tftpexec "github.com/hodgesds/dlg/executor/tftp"
 	udpexec "github.com/hodgesds/dlg/executor/udp"
 	websocketexec "github.com/hodgesds/dlg/executor/websocket"
+	"github.com/hodgesds/dlg/report"
+	"github.com/hodgesds/dlg/stats"
 	"github.com/hodgesds/dlg/util"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/prometheus/client_golang/prometheus"
@@ -114,8 +116,9 @@ type HTTPLoadTestInput struct {
 
 // HTTPLoadTestOutput defines the output of HTTP load testing
 type HTTPLoadTestOutput struct {
-	Message string `json:"message" jsonschema:"description=Status message"`
-	Metrics string `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Message string         `json:"message" jsonschema:"description=Status message"`
+	Metrics string         `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Report  *report.Report `json:"report" jsonschema:"description=Report of the load test with per-stage results and threshold checks"`
 }
 
 // RedisLoadTestInput defines input parameters for Redis load testing
@@ -129,8 +132,9 @@ type RedisLoadTestInput struct {
 
 // RedisLoadTestOutput defines the output of Redis load testing
 type RedisLoadTestOutput struct {
-	Message string `json:"message" jsonschema:"description=Status message"`
-	Metrics string `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Message string         `json:"message" jsonschema:"description=Status message"`
+	Metrics string         `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Report  *report.Report `json:"report" jsonschema:"description=Report of the load test with per-stage results and threshold checks"`
 }
 
 // MongoDBLoadTestInput defines input parameters for MongoDB load testing
@@ -144,8 +148,9 @@ type MongoDBLoadTestInput struct {
 
 // MongoDBLoadTestOutput defines the output of MongoDB load testing
 type MongoDBLoadTestOutput struct {
-	Message string `json:"message" jsonschema:"description=Status message"`
-	Metrics string `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Message string         `json:"message" jsonschema:"description=Status message"`
+	Metrics string         `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Report  *report.Report `json:"report" jsonschema:"description=Report of the load test with per-stage results and threshold checks"`
 }
 
 // ClickHouseLoadTestInput defines input parameters for ClickHouse load testing
@@ -160,8 +165,9 @@ type ClickHouseLoadTestInput struct {
 
 // ClickHouseLoadTestOutput defines the output of ClickHouse load testing
 type ClickHouseLoadTestOutput struct {
-	Message string `json:"message" jsonschema:"description=Status message"`
-	Metrics string `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Message string         `json:"message" jsonschema:"description=Status message"`
+	Metrics string         `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Report  *report.Report `json:"report" jsonschema:"description=Report of the load test with per-stage results and threshold checks"`
 }
 
 // PostgresLoadTestInput defines input parameters for PostgreSQL load testing
@@ -177,8 +183,9 @@ type PostgresLoadTestInput struct {
 
 // PostgresLoadTestOutput defines the output of PostgreSQL load testing
 type PostgresLoadTestOutput struct {
-	Message string `json:"message" jsonschema:"description=Status message"`
-	Metrics string `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Message string         `json:"message" jsonschema:"description=Status message"`
+	Metrics string         `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Report  *report.Report `json:"report" jsonschema:"description=Report of the load test with per-stage results and threshold checks"`
 }
 
 // WebSocketLoadTestInput defines input parameters for WebSocket load testing
@@ -190,8 +197,9 @@ type WebSocketLoadTestInput struct {
 
 // WebSocketLoadTestOutput defines the output of WebSocket load testing
 type WebSocketLoadTestOutput struct {
-	Message string `json:"message" jsonschema:"description=Status message"`
-	Metrics string `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Message string         `json:"message" jsonschema:"description=Status message"`
+	Metrics string         `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Report  *report.Report `json:"report" jsonschema:"description=Report of the load test with per-stage results and threshold checks"`
 }
 
 // GRPCLoadTestInput defines input parameters for gRPC load testing
@@ -204,8 +212,9 @@ type GRPCLoadTestInput struct {
 
 // GRPCLoadTestOutput defines the output of gRPC load testing
 type GRPCLoadTestOutput struct {
-	Message string `json:"message" jsonschema:"description=Status message"`
-	Metrics string `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Message string         `json:"message" jsonschema:"description=Status message"`
+	Metrics string         `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Report  *report.Report `json:"report" jsonschema:"description=Report of the load test with per-stage results and threshold checks"`
 }
 
 // RunLoadPlanInput defines input parameters for running a YAML load plan
@@ -215,8 +224,9 @@ type RunLoadPlanInput struct {
 
 // RunLoadPlanOutput defines the output of running a load plan
 type RunLoadPlanOutput struct {
-	Message string `json:"message" jsonschema:"description=Status message"`
-	Metrics string `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Message string         `json:"message" jsonschema:"description=Status message"`
+	Metrics string         `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Report  *report.Report `json:"report" jsonschema:"description=Report of the load test with per-stage results and threshold checks"`
 }
 
 // ArangoDBLoadTestInput defines input parameters for ArangoDB load testing
@@ -228,8 +238,9 @@ type ArangoDBLoadTestInput struct {
 
 // ArangoDBLoadTestOutput defines the output of ArangoDB load testing
 type ArangoDBLoadTestOutput struct {
-	Message string `json:"message" jsonschema:"description=Status message"`
-	Metrics string `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Message string         `json:"message" jsonschema:"description=Status message"`
+	Metrics string         `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Report  *report.Report `json:"report" jsonschema:"description=Report of the load test with per-stage results and threshold checks"`
 }
 
 // CassandraLoadTestInput defines input parameters for Cassandra load testing
@@ -241,8 +252,9 @@ type CassandraLoadTestInput struct {
 
 // CassandraLoadTestOutput defines the output of Cassandra load testing
 type CassandraLoadTestOutput struct {
-	Message string `json:"message" jsonschema:"description=Status message"`
-	Metrics string `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Message string         `json:"message" jsonschema:"description=Status message"`
+	Metrics string         `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Report  *report.Report `json:"report" jsonschema:"description=Report of the load test with per-stage results and threshold checks"`
 }
 
 // CouchDBLoadTestInput defines input parameters for CouchDB load testing
@@ -254,8 +266,9 @@ type CouchDBLoadTestInput struct {
 
 // CouchDBLoadTestOutput defines the output of CouchDB load testing
 type CouchDBLoadTestOutput struct {
-	Message string `json:"message" jsonschema:"description=Status message"`
-	Metrics string `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Message string         `json:"message" jsonschema:"description=Status message"`
+	Metrics string         `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Report  *report.Report `json:"report" jsonschema:"description=Report of the load test with per-stage results and threshold checks"`
 }
 
 // DHCP4LoadTestInput defines input parameters for DHCP4 load testing
@@ -266,8 +279,9 @@ type DHCP4LoadTestInput struct {
 
 // DHCP4LoadTestOutput defines the output of DHCP4 load testing
 type DHCP4LoadTestOutput struct {
-	Message string `json:"message" jsonschema:"description=Status message"`
-	Metrics string `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Message string         `json:"message" jsonschema:"description=Status message"`
+	Metrics string         `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Report  *report.Report `json:"report" jsonschema:"description=Report of the load test with per-stage results and threshold checks"`
 }
 
 // DNSLoadTestInput defines input parameters for DNS load testing
@@ -279,8 +293,9 @@ type DNSLoadTestInput struct {
 
 // DNSLoadTestOutput defines the output of DNS load testing
 type DNSLoadTestOutput struct {
-	Message string `json:"message" jsonschema:"description=Status message"`
-	Metrics string `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Message string         `json:"message" jsonschema:"description=Status message"`
+	Metrics string         `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Report  *report.Report `json:"report" jsonschema:"description=Report of the load test with per-stage results and threshold checks"`
 }
 
 // ElasticsearchLoadTestInput defines input parameters for Elasticsearch load testing
@@ -292,8 +307,9 @@ type ElasticsearchLoadTestInput struct {
 
 // ElasticsearchLoadTestOutput defines the output of Elasticsearch load testing
 type ElasticsearchLoadTestOutput struct {
-	Message string `json:"message" jsonschema:"description=Status message"`
-	Metrics string `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Message string         `json:"message" jsonschema:"description=Status message"`
+	Metrics string         `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Report  *report.Report `json:"report" jsonschema:"description=Report of the load test with per-stage results and threshold checks"`
 }
 
 // ETCDLoadTestInput defines input parameters for ETCD load testing
@@ -304,8 +320,9 @@ type ETCDLoadTestInput struct {
 
 // ETCDLoadTestOutput defines the output of ETCD load testing
 type ETCDLoadTestOutput struct {
-	Message string `json:"message" jsonschema:"description=Status message"`
-	Metrics string `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Message string         `json:"message" jsonschema:"description=Status message"`
+	Metrics string         `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Report  *report.Report `json:"report" jsonschema:"description=Report of the load test with per-stage results and threshold checks"`
 }
 
 // FTPLoadTestInput defines input parameters for FTP load testing
@@ -317,8 +334,9 @@ type FTPLoadTestInput struct {
 
 // FTPLoadTestOutput defines the output of FTP load testing
 type FTPLoadTestOutput struct {
-	Message string `json:"message" jsonschema:"description=Status message"`
-	Metrics string `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Message string         `json:"message" jsonschema:"description=Status message"`
+	Metrics string         `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Report  *report.Report `json:"report" jsonschema:"description=Report of the load test with per-stage results and threshold checks"`
 }
 
 // GraphQLLoadTestInput defines input parameters for GraphQL load testing
@@ -330,8 +348,9 @@ type GraphQLLoadTestInput struct {
 
 // GraphQLLoadTestOutput defines the output of GraphQL load testing
 type GraphQLLoadTestOutput struct {
-	Message string `json:"message" jsonschema:"description=Status message"`
-	Metrics string `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Message string         `json:"message" jsonschema:"description=Status message"`
+	Metrics string         `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Report  *report.Report `json:"report" jsonschema:"description=Report of the load test with per-stage results and threshold checks"`
 }
 
 // ICMPLoadTestInput defines input parameters for ICMP/Ping load testing
@@ -342,8 +361,9 @@ type ICMPLoadTestInput struct {
 
 // ICMPLoadTestOutput defines the output of ICMP load testing
 type ICMPLoadTestOutput struct {
-	Message string `json:"message" jsonschema:"description=Status message"`
-	Metrics string `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Message string         `json:"message" jsonschema:"description=Status message"`
+	Metrics string         `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Report  *report.Report `json:"report" jsonschema:"description=Report of the load test with per-stage results and threshold checks"`
 }
 
 // InfluxDBLoadTestInput defines input parameters for InfluxDB load testing
@@ -355,8 +375,9 @@ type InfluxDBLoadTestInput struct {
 
 // InfluxDBLoadTestOutput defines the output of InfluxDB load testing
 type InfluxDBLoadTestOutput struct {
-	Message string `json:"message" jsonschema:"description=Status message"`
-	Metrics string `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Message string         `json:"message" jsonschema:"description=Status message"`
+	Metrics string         `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Report  *report.Report `json:"report" jsonschema:"description=Report of the load test with per-stage results and threshold checks"`
 }
 
 // KafkaLoadTestInput defines input parameters for Kafka load testing
@@ -368,8 +389,9 @@ type KafkaLoadTestInput struct {
 
 // KafkaLoadTestOutput defines the output of Kafka load testing
 type KafkaLoadTestOutput struct {
-	Message string `json:"message" jsonschema:"description=Status message"`
-	Metrics string `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Message string         `json:"message" jsonschema:"description=Status message"`
+	Metrics string         `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Report  *report.Report `json:"report" jsonschema:"description=Report of the load test with per-stage results and threshold checks"`
 }
 
 // LDAPLoadTestInput defines input parameters for LDAP load testing
@@ -381,8 +403,9 @@ type LDAPLoadTestInput struct {
 
 // LDAPLoadTestOutput defines the output of LDAP load testing
 type LDAPLoadTestOutput struct {
-	Message string `json:"message" jsonschema:"description=Status message"`
-	Metrics string `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Message string         `json:"message" jsonschema:"description=Status message"`
+	Metrics string         `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Report  *report.Report `json:"report" jsonschema:"description=Report of the load test with per-stage results and threshold checks"`
 }
 
 // MemcacheLoadTestInput defines input parameters for Memcache load testing
@@ -393,8 +416,9 @@ type MemcacheLoadTestInput struct {
 
 // MemcacheLoadTestOutput defines the output of Memcache load testing
 type MemcacheLoadTestOutput struct {
-	Message string `json:"message" jsonschema:"description=Status message"`
-	Metrics string `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Message string         `json:"message" jsonschema:"description=Status message"`
+	Metrics string         `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Report  *report.Report `json:"report" jsonschema:"description=Report of the load test with per-stage results and threshold checks"`
 }
 
 // MQTTLoadTestInput defines input parameters for MQTT load testing
@@ -406,8 +430,9 @@ type MQTTLoadTestInput struct {
 
 // MQTTLoadTestOutput defines the output of MQTT load testing
 type MQTTLoadTestOutput struct {
-	Message string `json:"message" jsonschema:"description=Status message"`
-	Metrics string `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Message string         `json:"message" jsonschema:"description=Status message"`
+	Metrics string         `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Report  *report.Report `json:"report" jsonschema:"description=Report of the load test with per-stage results and threshold checks"`
 }
 
 // NATSLoadTestInput defines input parameters for NATS load testing
@@ -419,8 +444,9 @@ type NATSLoadTestInput struct {
 
 // NATSLoadTestOutput defines the output of NATS load testing
 type NATSLoadTestOutput struct {
-	Message string `json:"message" jsonschema:"description=Status message"`
-	Metrics string `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Message string         `json:"message" jsonschema:"description=Status message"`
+	Metrics string         `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Report  *report.Report `json:"report" jsonschema:"description=Report of the load test with per-stage results and threshold checks"`
 }
 
 // Neo4jLoadTestInput defines input parameters for Neo4j load testing
@@ -432,8 +458,9 @@ type Neo4jLoadTestInput struct {
 
 // Neo4jLoadTestOutput defines the output of Neo4j load testing
 type Neo4jLoadTestOutput struct {
-	Message string `json:"message" jsonschema:"description=Status message"`
-	Metrics string `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Message string         `json:"message" jsonschema:"description=Status message"`
+	Metrics string         `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Report  *report.Report `json:"report" jsonschema:"description=Report of the load test with per-stage results and threshold checks"`
 }
 
 // NTPLoadTestInput defines input parameters for NTP load testing
@@ -444,8 +471,9 @@ type NTPLoadTestInput struct {
 
 // NTPLoadTestOutput defines the output of NTP load testing
 type NTPLoadTestOutput struct {
-	Message string `json:"message" jsonschema:"description=Status message"`
-	Metrics string `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Message string         `json:"message" jsonschema:"description=Status message"`
+	Metrics string         `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Report  *report.Report `json:"report" jsonschema:"description=Report of the load test with per-stage results and threshold checks"`
 }
 
 // PulsarLoadTestInput defines input parameters for Pulsar load testing
@@ -457,8 +485,9 @@ type PulsarLoadTestInput struct {
 
 // PulsarLoadTestOutput defines the output of Pulsar load testing
 type PulsarLoadTestOutput struct {
-	Message string `json:"message" jsonschema:"description=Status message"`
-	Metrics string `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Message string         `json:"message" jsonschema:"description=Status message"`
+	Metrics string         `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Report  *report.Report `json:"report" jsonschema:"description=Report of the load test with per-stage results and threshold checks"`
 }
 
 // RabbitMQLoadTestInput defines input parameters for RabbitMQ load testing
@@ -470,8 +499,9 @@ type RabbitMQLoadTestInput struct {
 
 // RabbitMQLoadTestOutput defines the output of RabbitMQ load testing
 type RabbitMQLoadTestOutput struct {
-	Message string `json:"message" jsonschema:"description=Status message"`
-	Metrics string `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Message string         `json:"message" jsonschema:"description=Status message"`
+	Metrics string         `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Report  *report.Report `json:"report" jsonschema:"description=Report of the load test with per-stage results and threshold checks"`
 }
 
 // ScyllaDBLoadTestInput defines input parameters for ScyllaDB load testing
@@ -483,8 +513,9 @@ type ScyllaDBLoadTestInput struct {
 
 // ScyllaDBLoadTestOutput defines the output of ScyllaDB load testing
 type ScyllaDBLoadTestOutput struct {
-	Message string `json:"message" jsonschema:"description=Status message"`
-	Metrics string `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Message string         `json:"message" jsonschema:"description=Status message"`
+	Metrics string         `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Report  *report.Report `json:"report" jsonschema:"description=Report of the load test with per-stage results and threshold checks"`
 }
 
 // SNMPLoadTestInput defines input parameters for SNMP load testing
@@ -496,8 +527,9 @@ type SNMPLoadTestInput struct {
 
 // SNMPLoadTestOutput defines the output of SNMP load testing
 type SNMPLoadTestOutput struct {
-	Message string `json:"message" jsonschema:"description=Status message"`
-	Metrics string `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Message string         `json:"message" jsonschema:"description=Status message"`
+	Metrics string         `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Report  *report.Report `json:"report" jsonschema:"description=Report of the load test with per-stage results and threshold checks"`
 }
 
 // SSHLoadTestInput defines input parameters for SSH load testing
@@ -510,8 +542,9 @@ type SSHLoadTestInput struct {
 
 // SSHLoadTestOutput defines the output of SSH load testing
 type SSHLoadTestOutput struct {
-	Message string `json:"message" jsonschema:"description=Status message"`
-	Metrics string `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Message string         `json:"message" jsonschema:"description=Status message"`
+	Metrics string         `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Report  *report.Report `json:"report" jsonschema:"description=Report of the load test with per-stage results and threshold checks"`
 }
 
 // SyslogLoadTestInput defines input parameters for Syslog load testing
@@ -522,8 +555,9 @@ type SyslogLoadTestInput struct {
 
 // SyslogLoadTestOutput defines the output of Syslog load testing
 type SyslogLoadTestOutput struct {
-	Message string `json:"message" jsonschema:"description=Status message"`
-	Metrics string `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Message string         `json:"message" jsonschema:"description=Status message"`
+	Metrics string         `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Report  *report.Report `json:"report" jsonschema:"description=Report of the load test with per-stage results and threshold checks"`
 }
 
 // TCPLoadTestInput defines input parameters for TCP load testing
@@ -535,8 +569,9 @@ type TCPLoadTestInput struct {
 
 // TCPLoadTestOutput defines the output of TCP load testing
 type TCPLoadTestOutput struct {
-	Message string `json:"message" jsonschema:"description=Status message"`
-	Metrics string `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Message string         `json:"message" jsonschema:"description=Status message"`
+	Metrics string         `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Report  *report.Report `json:"report" jsonschema:"description=Report of the load test with per-stage results and threshold checks"`
 }
 
 // TelnetLoadTestInput defines input parameters for Telnet load testing
@@ -548,8 +583,9 @@ type TelnetLoadTestInput struct {
 
 // TelnetLoadTestOutput defines the output of Telnet load testing
 type TelnetLoadTestOutput struct {
-	Message string `json:"message" jsonschema:"description=Status message"`
-	Metrics string `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Message string         `json:"message" jsonschema:"description=Status message"`
+	Metrics string         `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Report  *report.Report `json:"report" jsonschema:"description=Report of the load test with per-stage results and threshold checks"`
 }
 
 // TFTPLoadTestInput defines input parameters for TFTP load testing
@@ -560,8 +596,9 @@ type TFTPLoadTestInput struct {
 
 // TFTPLoadTestOutput defines the output of TFTP load testing
 type TFTPLoadTestOutput struct {
-	Message string `json:"message" jsonschema:"description=Status message"`
-	Metrics string `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Message string         `json:"message" jsonschema:"description=Status message"`
+	Metrics string         `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Report  *report.Report `json:"report" jsonschema:"description=Report of the load test with per-stage results and threshold checks"`
 }
 
 // UDPLoadTestInput defines input parameters for UDP load testing
@@ -573,8 +610,9 @@ type UDPLoadTestInput struct {
 
 // UDPLoadTestOutput defines the output of UDP load testing
 type UDPLoadTestOutput struct {
-	Message string `json:"message" jsonschema:"description=Status message"`
-	Metrics string `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Message string         `json:"message" jsonschema:"description=Status message"`
+	Metrics string         `json:"metrics" jsonschema:"description=Prometheus metrics from the load test"`
+	Report  *report.Report `json:"report" jsonschema:"description=Report of the load test with per-stage results and threshold checks"`
 }
 
 // handleHTTPLoadTest executes an HTTP load test
@@ -626,7 +664,7 @@ func handleHTTPLoadTest(ctx context.Context, req *mcp.CallToolRequest, input HTT
 	}
 
 	// Execute load test
-	metrics, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
+	metrics, rep, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
 		return stageexec.New(stageexec.Params{
 			Registry: reg,
 			HTTP:     httpexec.New(reg),
@@ -639,6 +677,7 @@ func handleHTTPLoadTest(ctx context.Context, req *mcp.CallToolRequest, input HTT
 	output := HTTPLoadTestOutput{
 		Message: fmt.Sprintf("Successfully executed HTTP load test: %d requests to %s", input.Count, input.URL),
 		Metrics: metrics,
+		Report:  rep,
 	}
 
 	return &mcp.CallToolResult{
@@ -690,7 +729,7 @@ func handleRedisLoadTest(ctx context.Context, req *mcp.CallToolRequest, input Re
 	}
 
 	// Execute load test
-	metrics, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
+	metrics, rep, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
 		return stageexec.New(stageexec.Params{
 			Registry: reg,
 			Redis:    redisexec.New(reg),
@@ -703,6 +742,7 @@ func handleRedisLoadTest(ctx context.Context, req *mcp.CallToolRequest, input Re
 	output := RedisLoadTestOutput{
 		Message: fmt.Sprintf("Successfully executed Redis load test: %d operations to %s", input.Count, input.Addr),
 		Metrics: metrics,
+		Report:  rep,
 	}
 
 	return &mcp.CallToolResult{
@@ -746,7 +786,7 @@ func handleMongoDBLoadTest(ctx context.Context, req *mcp.CallToolRequest, input
 	}
 
 	// Execute load test
-	metrics, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
+	metrics, rep, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
 		return stageexec.New(stageexec.Params{
 			Registry: reg,
 			MongoDB:  mongodbexec.New(reg),
@@ -760,6 +800,7 @@ func handleMongoDBLoadTest(ctx context.Context, req *mcp.CallToolRequest, input
 		Message: fmt.Sprintf("Successfully executed MongoDB load test: %d %s operations on %s.%s",
 			input.Count, input.Operation, input.Database, input.Collection),
 		Metrics: metrics,
+		Report:  rep,
 	}
 
 	return &mcp.CallToolResult{
@@ -804,7 +845,7 @@ func handleClickHouseLoadTest(ctx context.Context, req *mcp.CallToolRequest, inp
 	}
 
 	// Execute load test
-	metrics, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
+	metrics, rep, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
 		return stageexec.New(stageexec.Params{
 			Registry:   reg,
 			ClickHouse: clickhouseexec.New(),
@@ -818,6 +859,7 @@ func handleClickHouseLoadTest(ctx context.Context, req *mcp.CallToolRequest, inp
 		Message: fmt.Sprintf("Successfully executed ClickHouse load test: %d %s operations on %s.%s",
 			input.Count, input.Operation, input.Database, input.Table),
 		Metrics: metrics,
+		Report:  rep,
 	}
 
 	return &mcp.CallToolResult{
@@ -865,7 +907,7 @@ func handlePostgresLoadTest(ctx context.Context, req *mcp.CallToolRequest, input
 	}
 
 	// Execute load test
-	metrics, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
+	metrics, rep, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
 		return stageexec.New(stageexec.Params{
 			Registry: reg,
 			SQL:      sqlexec.New(reg),
@@ -878,6 +920,7 @@ func handlePostgresLoadTest(ctx context.Context, req *mcp.CallToolRequest, input
 	output := PostgresLoadTestOutput{
 		Message: fmt.Sprintf("Successfully executed PostgreSQL load test: %d queries", input.Count),
 		Metrics: metrics,
+		Report:  rep,
 	}
 
 	return &mcp.CallToolResult{
@@ -918,7 +961,7 @@ func handleWebSocketLoadTest(ctx context.Context, req *mcp.CallToolRequest, inpu
 	}
 
 	// Execute load test
-	metrics, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
+	metrics, rep, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
 		return stageexec.New(stageexec.Params{
 			Registry:  reg,
 			WebSocket: websocketexec.New(reg),
@@ -931,6 +974,7 @@ func handleWebSocketLoadTest(ctx context.Context, req *mcp.CallToolRequest, inpu
 	output := WebSocketLoadTestOutput{
 		Message: fmt.Sprintf("Successfully executed WebSocket load test: %d messages to %s", input.Count, input.URL),
 		Metrics: metrics,
+		Report:  rep,
 	}
 
 	return &mcp.CallToolResult{
@@ -972,7 +1016,7 @@ func handleGRPCLoadTest(ctx context.Context, req *mcp.CallToolRequest, input GRP
 	}
 
 	// Execute load test
-	metrics, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
+	metrics, rep, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
 		return stageexec.New(stageexec.Params{
 			Registry: reg,
 			GRPC:     grpcexec.New(reg),
@@ -985,6 +1029,7 @@ func handleGRPCLoadTest(ctx context.Context, req *mcp.CallToolRequest, input GRP
 	output := GRPCLoadTestOutput{
 		Message: fmt.Sprintf("Successfully executed gRPC load test: %d requests to %s", input.Count, input.Target),
 		Metrics: metrics,
+		Report:  rep,
 	}
 
 	return &mcp.CallToolResult{
@@ -1011,7 +1056,7 @@ func handleRunLoadPlan(ctx context.Context, req *mcp.CallToolRequest, input RunL
 	}
 
 	// Execute load test with all executors
-	metrics, err := executePlan(ctx, &plan, func(reg *prometheus.Registry) (executor.Stage, error) {
+	metrics, rep, err := executePlan(ctx, &plan, func(reg *prometheus.Registry) (executor.Stage, error) {
 		return stageexec.New(stageexec.Params{
 			Registry:      reg,
 			ArangoDB:      arangodbexec.New(),
@@ -1057,6 +1102,7 @@ func handleRunLoadPlan(ctx context.Context, req *mcp.CallToolRequest, input RunL
 	output := RunLoadPlanOutput{
 		Message: fmt.Sprintf("Successfully executed load plan: %s", plan.Name),
 		Metrics: metrics,
+		Report:  rep,
 	}
 
 	return &mcp.CallToolResult{
@@ -1091,7 +1137,7 @@ func handleArangoDBLoadTest(ctx context.Context, req *mcp.CallToolRequest, input
 		Stages: []*config.Stage{stage},
 	}
 
-	metrics, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
+	metrics, rep, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
 		return stageexec.New(stageexec.Params{
 			Registry: reg,
 			ArangoDB: arangodbexec.New(),
@@ -1104,6 +1150,7 @@ func handleArangoDBLoadTest(ctx context.Context, req *mcp.CallToolRequest, input
 	output := ArangoDBLoadTestOutput{
 		Message: fmt.Sprintf("Successfully executed ArangoDB load test: %d operations on %s", input.Count, input.Database),
 		Metrics: metrics,
+		Report:  rep,
 	}
 
 	return &mcp.CallToolResult{
@@ -1138,7 +1185,7 @@ func handleCassandraLoadTest(ctx context.Context, req *mcp.CallToolRequest, inpu
 		Stages: []*config.Stage{stage},
 	}
 
-	metrics, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
+	metrics, rep, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
 		return stageexec.New(stageexec.Params{
 			Registry:  reg,
 			Cassandra: cassandraexec.New(),
@@ -1151,6 +1198,7 @@ func handleCassandraLoadTest(ctx context.Context, req *mcp.CallToolRequest, inpu
 	output := CassandraLoadTestOutput{
 		Message: fmt.Sprintf("Successfully executed Cassandra load test: %d operations on keyspace %s", input.Count, input.Keyspace),
 		Metrics: metrics,
+		Report:  rep,
 	}
 
 	return &mcp.CallToolResult{
@@ -1185,7 +1233,7 @@ func handleCouchDBLoadTest(ctx context.Context, req *mcp.CallToolRequest, input
 		Stages: []*config.Stage{stage},
 	}
 
-	metrics, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
+	metrics, rep, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
 		return stageexec.New(stageexec.Params{
 			Registry: reg,
 			CouchDB:  couchdbexec.New(),
@@ -1198,6 +1246,7 @@ func handleCouchDBLoadTest(ctx context.Context, req *mcp.CallToolRequest, input
 	output := CouchDBLoadTestOutput{
 		Message: fmt.Sprintf("Successfully executed CouchDB load test: %d operations on %s", input.Count, input.Database),
 		Metrics: metrics,
+		Report:  rep,
 	}
 
 	return &mcp.CallToolResult{
@@ -1231,7 +1280,7 @@ func handleDHCP4LoadTest(ctx context.Context, req *mcp.CallToolRequest, input DH
 		Stages: []*config.Stage{stage},
 	}
 
-	metrics, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
+	metrics, rep, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
 		return stageexec.New(stageexec.Params{
 			Registry: reg,
 			DHCP4:    dhcp4exec.New(),
@@ -1244,6 +1293,7 @@ func handleDHCP4LoadTest(ctx context.Context, req *mcp.CallToolRequest, input DH
 	output := DHCP4LoadTestOutput{
 		Message: fmt.Sprintf("Successfully executed DHCP4 load test: %d requests to %s", input.Count, input.Server),
 		Metrics: metrics,
+		Report:  rep,
 	}
 
 	return &mcp.CallToolResult{
@@ -1278,7 +1328,7 @@ func handleDNSLoadTest(ctx context.Context, req *mcp.CallToolRequest, input DNSL
 		Stages: []*config.Stage{stage},
 	}
 
-	metrics, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
+	metrics, rep, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
 		return stageexec.New(stageexec.Params{
 			Registry: reg,
 			DNS:      dnsexec.New(),
@@ -1291,6 +1341,7 @@ func handleDNSLoadTest(ctx context.Context, req *mcp.CallToolRequest, input DNSL
 	output := DNSLoadTestOutput{
 		Message: fmt.Sprintf("Successfully executed DNS load test: %d queries for %s", input.Count, input.Domain),
 		Metrics: metrics,
+		Report:  rep,
 	}
 
 	return &mcp.CallToolResult{
@@ -1325,7 +1376,7 @@ func handleElasticsearchLoadTest(ctx context.Context, req *mcp.CallToolRequest,
 		Stages: []*config.Stage{stage},
 	}
 
-	metrics, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
+	metrics, rep, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
 		return stageexec.New(stageexec.Params{
 			Registry:      reg,
 			Elasticsearch: elasticsearchexec.New(),
@@ -1338,6 +1389,7 @@ func handleElasticsearchLoadTest(ctx context.Context, req *mcp.CallToolRequest,
 	output := ElasticsearchLoadTestOutput{
 		Message: fmt.Sprintf("Successfully executed Elasticsearch load test: %d operations on index %s", input.Count, input.Index),
 		Metrics: metrics,
+		Report:  rep,
 	}
 
 	return &mcp.CallToolResult{
@@ -1371,7 +1423,7 @@ func handleETCDLoadTest(ctx context.Context, req *mcp.CallToolRequest, input ETC
 		Stages: []*config.Stage{stage},
 	}
 
-	metrics, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
+	metrics, rep, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
 		return stageexec.New(stageexec.Params{
 			Registry: reg,
 			ETCD:     etcdexec.New(),
@@ -1384,6 +1436,7 @@ func handleETCDLoadTest(ctx context.Context, req *mcp.CallToolRequest, input ETC
 	output := ETCDLoadTestOutput{
 		Message: fmt.Sprintf("Successfully executed ETCD load test: %d operations", input.Count),
 		Metrics: metrics,
+		Report:  rep,
 	}
 
 	return &mcp.CallToolResult{
@@ -1421,7 +1474,7 @@ func handleFTPLoadTest(ctx context.Context, req *mcp.CallToolRequest, input FTPL
 		Stages: []*config.Stage{stage},
 	}
 
-	metrics, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
+	metrics, rep, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
 		return stageexec.New(stageexec.Params{
 			Registry: reg,
 			FTP:      ftpexec.New(),
@@ -1434,6 +1487,7 @@ func handleFTPLoadTest(ctx context.Context, req *mcp.CallToolRequest, input FTPL
 	output := FTPLoadTestOutput{
 		Message: fmt.Sprintf("Successfully executed FTP load test: %d operations", input.Count),
 		Metrics: metrics,
+		Report:  rep,
 	}
 
 	return &mcp.CallToolResult{
@@ -1468,7 +1522,7 @@ func handleGraphQLLoadTest(ctx context.Context, req *mcp.CallToolRequest, input
 		Stages: []*config.Stage{stage},
 	}
 
-	metrics, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
+	metrics, rep, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
 		return stageexec.New(stageexec.Params{
 			Registry: reg,
 			GraphQL:  graphqlexec.New(),
@@ -1481,6 +1535,7 @@ func handleGraphQLLoadTest(ctx context.Context, req *mcp.CallToolRequest, input
 	output := GraphQLLoadTestOutput{
 		Message: fmt.Sprintf("Successfully executed GraphQL load test: %d queries to %s", input.Count, input.URL),
 		Metrics: metrics,
+		Report:  rep,
 	}
 
 	return &mcp.CallToolResult{
@@ -1514,7 +1569,7 @@ func handleICMPLoadTest(ctx context.Context, req *mcp.CallToolRequest, input ICM
 		Stages: []*config.Stage{stage},
 	}
 
-	metrics, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
+	metrics, rep, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
 		return stageexec.New(stageexec.Params{
 			Registry: reg,
 			ICMP:     icmpexec.New(),
@@ -1527,6 +1582,7 @@ func handleICMPLoadTest(ctx context.Context, req *mcp.CallToolRequest, input ICM
 	output := ICMPLoadTestOutput{
 		Message: fmt.Sprintf("Successfully executed ICMP load test: %d pings to %s", input.Count, input.Host),
 		Metrics: metrics,
+		Report:  rep,
 	}
 
 	return &mcp.CallToolResult{
@@ -1561,7 +1617,7 @@ func handleInfluxDBLoadTest(ctx context.Context, req *mcp.CallToolRequest, input
 		Stages: []*config.Stage{stage},
 	}
 
-	metrics, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
+	metrics, rep, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
 		return stageexec.New(stageexec.Params{
 			Registry: reg,
 			InfluxDB: influxdbexec.New(),
@@ -1574,6 +1630,7 @@ func handleInfluxDBLoadTest(ctx context.Context, req *mcp.CallToolRequest, input
 	output := InfluxDBLoadTestOutput{
 		Message: fmt.Sprintf("Successfully executed InfluxDB load test: %d operations on bucket %s", input.Count, input.Bucket),
 		Metrics: metrics,
+		Report:  rep,
 	}
 
 	return &mcp.CallToolResult{
@@ -1608,7 +1665,7 @@ func handleKafkaLoadTest(ctx context.Context, req *mcp.CallToolRequest, input Ka
 		Stages: []*config.Stage{stage},
 	}
 
-	metrics, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
+	metrics, rep, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
 		return stageexec.New(stageexec.Params{
 			Registry: reg,
 			Kafka:    kafkaexec.New(),
@@ -1621,6 +1678,7 @@ func handleKafkaLoadTest(ctx context.Context, req *mcp.CallToolRequest, input Ka
 	output := KafkaLoadTestOutput{
 		Message: fmt.Sprintf("Successfully executed Kafka load test: %d messages to topic %s", input.Count, input.Topic),
 		Metrics: metrics,
+		Report:  rep,
 	}
 
 	return &mcp.CallToolResult{
@@ -1655,7 +1713,7 @@ func handleLDAPLoadTest(ctx context.Context, req *mcp.CallToolRequest, input LDA
 		Stages: []*config.Stage{stage},
 	}
 
-	metrics, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
+	metrics, rep, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
 		return stageexec.New(stageexec.Params{
 			Registry: reg,
 			LDAP:     ldapexec.New(),
@@ -1668,6 +1726,7 @@ func handleLDAPLoadTest(ctx context.Context, req *mcp.CallToolRequest, input LDA
 	output := LDAPLoadTestOutput{
 		Message: fmt.Sprintf("Successfully executed LDAP load test: %d operations", input.Count),
 		Metrics: metrics,
+		Report:  rep,
 	}
 
 	return &mcp.CallToolResult{
@@ -1701,7 +1760,7 @@ func handleMemcacheLoadTest(ctx context.Context, req *mcp.CallToolRequest, input
 		Stages: []*config.Stage{stage},
 	}
 
-	metrics, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
+	metrics, rep, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
 		return stageexec.New(stageexec.Params{
 			Registry: reg,
 			Memcache: memcacheexec.New(),
@@ -1714,6 +1773,7 @@ func handleMemcacheLoadTest(ctx context.Context, req *mcp.CallToolRequest, input
 	output := MemcacheLoadTestOutput{
 		Message: fmt.Sprintf("Successfully executed Memcache load test: %d operations", input.Count),
 		Metrics: metrics,
+		Report:  rep,
 	}
 
 	return &mcp.CallToolResult{
@@ -1748,7 +1808,7 @@ func handleMQTTLoadTest(ctx context.Context, req *mcp.CallToolRequest, input MQT
 		Stages: []*config.Stage{stage},
 	}
 
-	metrics, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
+	metrics, rep, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
 		return stageexec.New(stageexec.Params{
 			Registry: reg,
 			MQTT:     mqttexec.New(),
@@ -1761,6 +1821,7 @@ func handleMQTTLoadTest(ctx context.Context, req *mcp.CallToolRequest, input MQT
 	output := MQTTLoadTestOutput{
 		Message: fmt.Sprintf("Successfully executed MQTT load test: %d messages to topic %s", input.Count, input.Topic),
 		Metrics: metrics,
+		Report:  rep,
 	}
 
 	return &mcp.CallToolResult{
@@ -1795,7 +1856,7 @@ func handleNATSLoadTest(ctx context.Context, req *mcp.CallToolRequest, input NAT
 		Stages: []*config.Stage{stage},
 	}
 
-	metrics, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
+	metrics, rep, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
 		return stageexec.New(stageexec.Params{
 			Registry: reg,
 			NATS:     natsexec.New(),
@@ -1808,6 +1869,7 @@ func handleNATSLoadTest(ctx context.Context, req *mcp.CallToolRequest, input NAT
 	output := NATSLoadTestOutput{
 		Message: fmt.Sprintf("Successfully executed NATS load test: %d messages to subject %s", input.Count, input.Subject),
 		Metrics: metrics,
+		Report:  rep,
 	}
 
 	return &mcp.CallToolResult{
@@ -1842,7 +1904,7 @@ func handleNeo4jLoadTest(ctx context.Context, req *mcp.CallToolRequest, input Ne
 		Stages: []*config.Stage{stage},
 	}
 
-	metrics, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
+	metrics, rep, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
 		return stageexec.New(stageexec.Params{
 			Registry: reg,
 			Neo4j:    neo4jexec.New(),
@@ -1855,6 +1917,7 @@ func handleNeo4jLoadTest(ctx context.Context, req *mcp.CallToolRequest, input Ne
 	output := Neo4jLoadTestOutput{
 		Message: fmt.Sprintf("Successfully executed Neo4j load test: %d queries", input.Count),
 		Metrics: metrics,
+		Report:  rep,
 	}
 
 	return &mcp.CallToolResult{
@@ -1888,7 +1951,7 @@ func handleNTPLoadTest(ctx context.Context, req *mcp.CallToolRequest, input NTPL
 		Stages: []*config.Stage{stage},
 	}
 
-	metrics, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
+	metrics, rep, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
 		return stageexec.New(stageexec.Params{
 			Registry: reg,
 			NTP:      ntpexec.New(),
@@ -1901,6 +1964,7 @@ func handleNTPLoadTest(ctx context.Context, req *mcp.CallToolRequest, input NTPL
 	output := NTPLoadTestOutput{
 		Message: fmt.Sprintf("Successfully executed NTP load test: %d requests to %s", input.Count, input.Server),
 		Metrics: metrics,
+		Report:  rep,
 	}
 
 	return &mcp.CallToolResult{
@@ -1935,7 +1999,7 @@ func handlePulsarLoadTest(ctx context.Context, req *mcp.CallToolRequest, input P
 		Stages: []*config.Stage{stage},
 	}
 
-	metrics, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
+	metrics, rep, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
 		return stageexec.New(stageexec.Params{
 			Registry: reg,
 			Pulsar:   pulsarexec.New(),
@@ -1948,6 +2012,7 @@ func handlePulsarLoadTest(ctx context.Context, req *mcp.CallToolRequest, input P
 	output := PulsarLoadTestOutput{
 		Message: fmt.Sprintf("Successfully executed Pulsar load test: %d messages to topic %s", input.Count, input.Topic),
 		Metrics: metrics,
+		Report:  rep,
 	}
 
 	return &mcp.CallToolResult{
@@ -1982,7 +2047,7 @@ func handleRabbitMQLoadTest(ctx context.Context, req *mcp.CallToolRequest, input
 		Stages: []*config.Stage{stage},
 	}
 
-	metrics, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
+	metrics, rep, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
 		return stageexec.New(stageexec.Params{
 			Registry: reg,
 			RabbitMQ: rabbitmqexec.New(),
@@ -1995,6 +2060,7 @@ func handleRabbitMQLoadTest(ctx context.Context, req *mcp.CallToolRequest, input
 	output := RabbitMQLoadTestOutput{
 		Message: fmt.Sprintf("Successfully executed RabbitMQ load test: %d messages to queue %s", input.Count, input.Queue),
 		Metrics: metrics,
+		Report:  rep,
 	}
 
 	return &mcp.CallToolResult{
@@ -2029,7 +2095,7 @@ func handleScyllaDBLoadTest(ctx context.Context, req *mcp.CallToolRequest, input
 		Stages: []*config.Stage{stage},
 	}
 
-	metrics, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
+	metrics, rep, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
 		return stageexec.New(stageexec.Params{
 			Registry: reg,
 			ScyllaDB: scylladbexec.New(),
@@ -2042,6 +2108,7 @@ func handleScyllaDBLoadTest(ctx context.Context, req *mcp.CallToolRequest, input
 	output := ScyllaDBLoadTestOutput{
 		Message: fmt.Sprintf("Successfully executed ScyllaDB load test: %d operations on keyspace %s", input.Count, input.Keyspace),
 		Metrics: metrics,
+		Report:  rep,
 	}
 
 	return &mcp.CallToolResult{
@@ -2076,7 +2143,7 @@ func handleSNMPLoadTest(ctx context.Context, req *mcp.CallToolRequest, input SNM
 		Stages: []*config.Stage{stage},
 	}
 
-	metrics, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
+	metrics, rep, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
 		return stageexec.New(stageexec.Params{
 			Registry: reg,
 			SNMP:     snmpexec.New(),
@@ -2089,6 +2156,7 @@ func handleSNMPLoadTest(ctx context.Context, req *mcp.CallToolRequest, input SNM
 	output := SNMPLoadTestOutput{
 		Message: fmt.Sprintf("Successfully executed SNMP load test: %d requests to %s", input.Count, input.Target),
 		Metrics: metrics,
+		Report:  rep,
 	}
 
 	return &mcp.CallToolResult{
@@ -2127,7 +2195,7 @@ func handleSSHLoadTest(ctx context.Context, req *mcp.CallToolRequest, input SSHL
 		Stages: []*config.Stage{stage},
 	}
 
-	metrics, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
+	metrics, rep, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
 		return stageexec.New(stageexec.Params{
 			Registry: reg,
 			SSH:      sshexec.New(),
@@ -2140,6 +2208,7 @@ func handleSSHLoadTest(ctx context.Context, req *mcp.CallToolRequest, input SSHL
 	output := SSHLoadTestOutput{
 		Message: fmt.Sprintf("Successfully executed SSH load test: %d connections to %s", input.Count, input.Host),
 		Metrics: metrics,
+		Report:  rep,
 	}
 
 	return &mcp.CallToolResult{
@@ -2173,7 +2242,7 @@ func handleSyslogLoadTest(ctx context.Context, req *mcp.CallToolRequest, input S
 		Stages: []*config.Stage{stage},
 	}
 
-	metrics, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
+	metrics, rep, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
 		return stageexec.New(stageexec.Params{
 			Registry: reg,
 			Syslog:   syslogexec.New(),
@@ -2186,6 +2255,7 @@ func handleSyslogLoadTest(ctx context.Context, req *mcp.CallToolRequest, input S
 	output := SyslogLoadTestOutput{
 		Message: fmt.Sprintf("Successfully executed Syslog load test: %d messages to %s", input.Count, input.Server),
 		Metrics: metrics,
+		Report:  rep,
 	}
 
 	return &mcp.CallToolResult{
@@ -2220,7 +2290,7 @@ func handleTCPLoadTest(ctx context.Context, req *mcp.CallToolRequest, input TCPL
 		Stages: []*config.Stage{stage},
 	}
 
-	metrics, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
+	metrics, rep, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
 		return stageexec.New(stageexec.Params{
 			Registry: reg,
 			TCP:      tcpexec.New(),
@@ -2233,6 +2303,7 @@ func handleTCPLoadTest(ctx context.Context, req *mcp.CallToolRequest, input TCPL
 	output := TCPLoadTestOutput{
 		Message: fmt.Sprintf("Successfully executed TCP load test: %d connections to %s:%d", input.Count, input.Host, input.Port),
 		Metrics: metrics,
+		Report:  rep,
 	}
 
 	return &mcp.CallToolResult{
@@ -2270,7 +2341,7 @@ func handleTelnetLoadTest(ctx context.Context, req *mcp.CallToolRequest, input T
 		Stages: []*config.Stage{stage},
 	}
 
-	metrics, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
+	metrics, rep, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
 		return stageexec.New(stageexec.Params{
 			Registry: reg,
 			Telnet:   telnetexec.New(),
@@ -2283,6 +2354,7 @@ func handleTelnetLoadTest(ctx context.Context, req *mcp.CallToolRequest, input T
 	output := TelnetLoadTestOutput{
 		Message: fmt.Sprintf("Successfully executed Telnet load test: %d connections to %s:%d", input.Count, input.Host, input.Port),
 		Metrics: metrics,
+		Report:  rep,
 	}
 
 	return &mcp.CallToolResult{
@@ -2316,7 +2388,7 @@ func handleTFTPLoadTest(ctx context.Context, req *mcp.CallToolRequest, input TFT
 		Stages: []*config.Stage{stage},
 	}
 
-	metrics, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
+	metrics, rep, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
 		return stageexec.New(stageexec.Params{
 			Registry: reg,
 			TFTP:     tftpexec.New(),
@@ -2329,6 +2401,7 @@ func handleTFTPLoadTest(ctx context.Context, req *mcp.CallToolRequest, input TFT
 	output := TFTPLoadTestOutput{
 		Message: fmt.Sprintf("Successfully executed TFTP load test: %d operations", input.Count),
 		Metrics: metrics,
+		Report:  rep,
 	}
 
 	return &mcp.CallToolResult{
@@ -2363,7 +2436,7 @@ func handleUDPLoadTest(ctx context.Context, req *mcp.CallToolRequest, input UDPL
 		Stages: []*config.Stage{stage},
 	}
 
-	metrics, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
+	metrics, rep, err := executePlan(ctx, plan, func(reg *prometheus.Registry) (executor.Stage, error) {
 		return stageexec.New(stageexec.Params{
 			Registry: reg,
 			UDP:      udpexec.New(),
@@ -2376,6 +2449,7 @@ func handleUDPLoadTest(ctx context.Context, req *mcp.CallToolRequest, input UDPL
 	output := UDPLoadTestOutput{
 		Message: fmt.Sprintf("Successfully executed UDP load test: %d packets to %s:%d", input.Count, input.Host, input.Port),
 		Metrics: metrics,
+		Report:  rep,
 	}
 
 	return &mcp.CallToolResult{
@@ -2388,35 +2462,40 @@ func handleUDPLoadTest(ctx context.Context, req *mcp.CallToolRequest, input UDPL
 	}, output, nil
 }
 
-// executePlan is a helper function to execute a load test plan and capture metrics
-func executePlan(ctx context.Context, plan *config.Plan, stageFactory func(*prometheus.Registry) (executor.Stage, error)) (string, error) {
+// executePlan is a helper function to execute a load test plan and capture
+// metrics and a report of the results
+func executePlan(ctx context.Context, plan *config.Plan, stageFactory func(*prometheus.Registry) (executor.Stage, error)) (string, *report.Report, error) {
 	reg := prometheus.NewPedanticRegistry()
 
 	// Create stage executor
 	stageExec, err := stageFactory(reg)
 	if err != nil {
-		return "", fmt.Errorf("failed to create stage executor: %w", err)
+		return "", nil, fmt.Errorf("failed to create stage executor: %w", err)
 	}
 
 	// Create plan executor
+	collector := stats.NewCollector()
 	planExec, err := executor.NewPlan(
-		executor.Params{Registry: reg},
+		executor.Params{
+			Registry: reg,
+			Recorder: collector,
+		},
 		stageExec,
 	)
 	if err != nil {
-		return "", fmt.Errorf("failed to create plan executor: %w", err)
+		return "", nil, fmt.Errorf("failed to create plan executor: %w", err)
 	}
 
 	// Execute the plan
 	if err := planExec.Execute(ctx, plan); err != nil {
-		return "", fmt.Errorf("failed to execute plan: %w", err)
+		return "", nil, fmt.Errorf("failed to execute plan: %w", err)
 	}
 
 	// Gather metrics
 	var buf bytes.Buffer
 	if err := util.RegistryGather(reg, &buf); err != nil {
-		return "", fmt.Errorf("failed to gather metrics: %w", err)
+		return "", nil, fmt.Errorf("failed to gather metrics: %w", err)
 	}
 
-	return buf.String(), nil
+	return buf.String(), report.New(plan, collector.Stages(), nil), nil
 }
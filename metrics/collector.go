@@ -1,16 +1,161 @@
 package metrics
 
 import (
+	"time"
+
 	"github.com/hodgesds/dlg/config"
 	prom "github.com/prometheus/client_golang/prometheus"
 )
 
-// NewPlanCollector returns a prometheus collector for a execution plan.
+type statusDescs struct {
+	state      *prom.Desc
+	workers    *prom.Desc
+	targetRate *prom.Desc
+	rate       *prom.Desc
+	elapsed    *prom.Desc
+	remaining  *prom.Desc
+	iterations *prom.Desc
+}
+
+func newStatusDescs(subsystem string, labels prom.Labels) statusDescs {
+	desc := func(name, help string, variable ...string) *prom.Desc {
+		return prom.NewDesc(
+			prom.BuildFQName("dlg", subsystem, name),
+			help, variable, labels,
+		)
+	}
+	return statusDescs{
+		state:      desc("state", "Execution state, 1 for the current state.", "state"),
+		workers:    desc("active_workers", "Workers running an operation."),
+		targetRate: desc("target_rate", "Configured operations per second, 0 if unlimited."),
+		rate:       desc("achieved_rate", "Operations completed in the last second."),
+		elapsed:    desc("elapsed_seconds", "Time since the execution started."),
+		remaining:  desc("remaining_seconds", "Time until the configured duration ends."),
+		iterations: desc("iterations", "Completed iterations."),
+	}
+}
+
+func (d statusDescs) describe(ch chan<- *prom.Desc) {
+	ch <- d.state
+	ch <- d.workers
+	ch <- d.targetRate
+	ch <- d.rate
+	ch <- d.elapsed
+	ch <- d.remaining
+	ch <- d.iterations
+}
+
+type statusValues struct {
+	state      config.ExecutionState
+	workers    int64
+	targetRate float64
+	rate       float64
+	elapsed    time.Duration
+	duration   *time.Duration
+	iterations int64
+}
+
+func (d statusDescs) collect(ch chan<- prom.Metric, v statusValues) {
+	for _, state := range config.ExecutionStates {
+		value := 0.0
+		if state == v.state {
+			value = 1
+		}
+		ch <- prom.MustNewConstMetric(d.state, prom.GaugeValue, value, state.String())
+	}
+	ch <- prom.MustNewConstMetric(d.workers, prom.GaugeValue, float64(v.workers))
+	ch <- prom.MustNewConstMetric(d.targetRate, prom.GaugeValue, v.targetRate)
+	ch <- prom.MustNewConstMetric(d.rate, prom.GaugeValue, v.rate)
+	ch <- prom.MustNewConstMetric(d.elapsed, prom.GaugeValue, v.elapsed.Seconds())
+	// The remaining time is only known for executions with a duration.
+	if v.duration != nil {
+		remaining := *v.duration - v.elapsed
+		if remaining < 0 || v.state == config.Complete {
+			remaining = 0
+		}
+		ch <- prom.MustNewConstMetric(d.remaining, prom.GaugeValue, remaining.Seconds())
+	}
+	ch <- prom.MustNewConstMetric(d.iterations, prom.CounterValue, float64(v.iterations))
+}
+
+type planCollector struct {
+	plan  *config.Plan
+	descs statusDescs
+}
+
+// NewPlanCollector returns a prometheus collector for a execution plan. The
+// workers and rates of the plan are those of its stages, its iterations are
+// the completed iterations of its top level stages.
 func NewPlanCollector(p *config.Plan) prom.Collector {
-	return nil
+	return &planCollector{
+		plan:  p,
+		descs: newStatusDescs("plan", prom.Labels{"plan": p.Name}),
+	}
+}
+
+// Describe implements the prometheus.Collector interface.
+func (c *planCollector) Describe(ch chan<- *prom.Desc) {
+	c.descs.describe(ch)
+}
+
+// Collect implements the prometheus.Collector interface.
+func (c *planCollector) Collect(ch chan<- prom.Metric) {
+	now := time.Now()
+	status := c.plan.Status()
+	v := statusValues{
+		state:    status.State(),
+		elapsed:  status.Elapsed(now),
+		duration: c.plan.Duration,
+	}
+	var walk func(stages []*config.Stage, limited bool)
+	walk = func(stages []*config.Stage, limited bool) {
+		for _, s := range stages {
+			st := s.Status()
+			v.workers += st.Workers()
+			v.rate += st.Rate(now)
+			// The rate of a stage limits its children.
+			if s.Rate > 0 && !limited && st.State() == config.Running {
+				v.targetRate += s.Rate
+			}
+			walk(s.Children, limited || s.Rate > 0)
+		}
+	}
+	walk(c.plan.Stages, false)
+	for _, s := range c.plan.Stages {
+		v.iterations += s.Status().Iterations()
+	}
+	c.descs.collect(ch, v)
+}
+
+type stageCollector struct {
+	stage *config.Stage
+	descs statusDescs
 }
 
 // NewStageCollector returns a prometheus collector for a execution stage.
 func NewStageCollector(stage *config.Stage) prom.Collector {
-	return nil
+	return &stageCollector{
+		stage: stage,
+		descs: newStatusDescs("stage", prom.Labels{"stage": stage.Name}),
+	}
+}
+
+// Describe implements the prometheus.Collector interface.
+func (c *stageCollector) Describe(ch chan<- *prom.Desc) {
+	c.descs.describe(ch)
+}
+
+// Collect implements the prometheus.Collector interface.
+func (c *stageCollector) Collect(ch chan<- prom.Metric) {
+	now := time.Now()
+	status := c.stage.Status()
+	c.descs.collect(ch, statusValues{
+		state:      status.State(),
+		workers:    status.Workers(),
+		targetRate: c.stage.Rate,
+		rate:       status.Rate(now),
+		elapsed:    status.Elapsed(now),
+		duration:   c.stage.Duration,
+		iterations: status.Iterations(),
+	})
 }
@@ -0,0 +1,126 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hodgesds/dlg/config"
+	"github.com/hodgesds/dlg/util"
+	prom "github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gauge returns the value of the metric of a family with the labels.
+func gauge(t *testing.T, g prom.Gatherer, name string, labels map[string]string) float64 {
+	t.Helper()
+	families, err := g.Gather()
+	require.NoError(t, err)
+	for _, f := range families {
+		if f.GetName() != name {
+			continue
+		}
+	metrics:
+		for _, m := range f.GetMetric() {
+			for k, v := range labels {
+				if !hasLabel(m, k, v) {
+					continue metrics
+				}
+			}
+			if f.GetType() == dto.MetricType_COUNTER {
+				return m.GetCounter().GetValue()
+			}
+			return m.GetGauge().GetValue()
+		}
+	}
+	require.Failf(t, "metric not found", "%s %v", name, labels)
+	return 0
+}
+
+func hasLabel(m *dto.Metric, name, value string) bool {
+	for _, l := range m.GetLabel() {
+		if l.GetName() == name && l.GetValue() == value {
+			return true
+		}
+	}
+	return false
+}
+
+// TestRunScope tests the plan and stage collectors of a run scope.
+func TestRunScope(t *testing.T) {
+	child := &config.Stage{Name: "child", Rate: 5}
+	parent := &config.Stage{
+		Name:     "parent",
+		Rate:     10,
+		Duration: util.DurPtr(time.Hour),
+		Children: []*config.Stage{child},
+	}
+	other := &config.Stage{Name: "other", Rate: 20}
+	p := &config.Plan{Name: "test", Stages: []*config.Stage{parent, other}}
+
+	scope, err := NewRunScope(p, "abc")
+	require.NoError(t, err)
+
+	plan := map[string]string{"plan": "test", "run_id": "abc"}
+	assert.Equal(t, 1.0, gauge(t, scope, "dlg_plan_state", map[string]string{"state": "waiting"}))
+	assert.Equal(t, 0.0, gauge(t, scope, "dlg_plan_target_rate", plan))
+
+	p.Status().SetState(config.Running)
+	parent.Status().SetState(config.Running)
+	child.Status().SetState(config.Running)
+	parent.Status().AddIteration()
+	child.Status().AddWorkers(3)
+	child.Status().AddIteration()
+	child.Status().AddIteration()
+
+	assert.Equal(t, 1.0, gauge(t, scope, "dlg_plan_state", map[string]string{"state": "running"}))
+	assert.Equal(t, 0.0, gauge(t, scope, "dlg_plan_state", map[string]string{"state": "waiting"}))
+	assert.Equal(t, 3.0, gauge(t, scope, "dlg_plan_active_workers", plan))
+	// The child is limited by the rate of its parent and other is waiting.
+	assert.Equal(t, 10.0, gauge(t, scope, "dlg_plan_target_rate", plan))
+	assert.Equal(t, 1.0, gauge(t, scope, "dlg_plan_iterations", plan))
+
+	stage := map[string]string{"plan": "test", "run_id": "abc", "stage": "child"}
+	assert.Equal(t, 3.0, gauge(t, scope, "dlg_stage_active_workers", stage))
+	assert.Equal(t, 5.0, gauge(t, scope, "dlg_stage_target_rate", stage))
+	assert.Equal(t, 2.0, gauge(t, scope, "dlg_stage_iterations", stage))
+
+	remaining := gauge(t, scope, "dlg_stage_remaining_seconds", map[string]string{"stage": "parent"})
+	assert.InDelta(t, time.Hour.Seconds(), remaining, 5)
+	parent.Status().SetState(config.Complete)
+	assert.Equal(t, 0.0, gauge(t, scope, "dlg_stage_remaining_seconds", map[string]string{"stage": "parent"}))
+}
+
+// TestScopes tests the metrics of finished runs are kept.
+func TestScopes(t *testing.T) {
+	base := prom.NewPedanticRegistry()
+	base.MustRegister(prom.NewCounter(prom.CounterOpts{Name: "base_total", Help: "base"}))
+	scopes := NewScopes(base, 1)
+
+	runs := make([]Scope, 3)
+	for i, id := range []string{"a", "b", "c"} {
+		p := &config.Plan{Name: "test", Stages: []*config.Stage{{Name: "s"}}}
+		scope, err := NewRunScope(p, id)
+		require.NoError(t, err)
+		runs[i] = scope
+		scopes.Add(scope)
+	}
+	for _, id := range []string{"a", "b", "c"} {
+		gauge(t, scopes, "dlg_plan_elapsed_seconds", map[string]string{"run_id": id})
+	}
+
+	scopes.Finish(runs[0])
+	scopes.Finish(runs[1])
+	gauge(t, scopes, "base_total", nil)
+	gauge(t, scopes, "dlg_plan_elapsed_seconds", map[string]string{"run_id": "b"})
+	gauge(t, scopes, "dlg_plan_elapsed_seconds", map[string]string{"run_id": "c"})
+
+	families, err := scopes.Gather()
+	require.NoError(t, err)
+	for _, f := range families {
+		for _, m := range f.GetMetric() {
+			assert.False(t, hasLabel(m, "run_id", "a"), f.GetName())
+		}
+	}
+}
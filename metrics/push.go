@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hodgesds/dlg/config"
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+)
+
+// RunLabels returns the labels identifying a run of a plan. Tags of the form
+// key=value are added as labels, other tags are joined in a tags label.
+func RunLabels(p *config.Plan, runID string) map[string]string {
+	labels := map[string]string{
+		"plan":   p.Name,
+		"run_id": runID,
+	}
+	tags := []string{}
+	for _, tag := range p.Tags {
+		k, v, ok := strings.Cut(tag, "=")
+		if !ok || k == "" {
+			tags = append(tags, tag)
+			continue
+		}
+		k = labelName(k)
+		if _, ok := labels[k]; !ok {
+			labels[k] = v
+		}
+	}
+	if len(tags) > 0 {
+		labels["tags"] = strings.Join(tags, ",")
+	}
+	return labels
+}
+
+// labelName replaces characters that are invalid in label names.
+func labelName(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		switch {
+		case c == '_', c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
+
+// Push pushes the metrics of a Gatherer to a Pushgateway, labels are used as
+// the grouping key.
+func Push(
+	ctx context.Context,
+	url string,
+	gatherer prom.Gatherer,
+	labels map[string]string,
+) error {
+	pusher := push.New(url, "dlg").Gatherer(gatherer)
+	for k, v := range labels {
+		pusher = pusher.Grouping(k, v)
+	}
+	return pusher.PushContext(ctx)
+}
@@ -0,0 +1,45 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hodgesds/dlg/config"
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRunLabels tests creating labels for a run.
+func TestRunLabels(t *testing.T) {
+	labels := RunLabels(&config.Plan{
+		Name: "test",
+		Tags: []string{"env=prod", "nightly", "team-name=load", "ci", "plan=other"},
+	}, "abc")
+	assert.Equal(t, map[string]string{
+		"plan":      "test",
+		"run_id":    "abc",
+		"env":       "prod",
+		"team_name": "load",
+		"tags":      "nightly,ci",
+	}, labels)
+}
+
+// TestPush tests pushing metrics to a Pushgateway.
+func TestPush(t *testing.T) {
+	reg := prom.NewPedanticRegistry()
+	reg.MustRegister(prom.NewCounter(prom.CounterOpts{Name: "ops_total", Help: "ops"}))
+
+	var method, path string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path = r.Method, r.URL.Path
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	require.NoError(t, Push(context.Background(), srv.URL, reg, map[string]string{"plan": "test"}))
+	assert.Equal(t, http.MethodPut, method)
+	assert.Equal(t, "/metrics/job/dlg/plan/test", path)
+}
@@ -0,0 +1,197 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/golang/snappy"
+	prom "github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// RemoteWriter sends the metrics of a Gatherer to a Prometheus remote write
+// endpoint.
+type RemoteWriter struct {
+	url      string
+	gatherer prom.Gatherer
+	labels   map[string]string
+	client   *http.Client
+}
+
+// NewRemoteWriter returns a RemoteWriter, labels are added to every series.
+func NewRemoteWriter(
+	url string,
+	gatherer prom.Gatherer,
+	labels map[string]string,
+) *RemoteWriter {
+	return &RemoteWriter{
+		url:      url,
+		gatherer: gatherer,
+		labels:   labels,
+		client:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Run writes metrics every interval until the context is done, then writes
+// them a final time. Failed writes are logged and retried on the next
+// interval.
+func (w *RemoteWriter) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := w.Write(ctx); err != nil {
+				log.Printf("remote write: %v", err)
+			}
+		case <-ctx.Done():
+			final, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if err := w.Write(final); err != nil {
+				log.Printf("remote write: %v", err)
+			}
+			return
+		}
+	}
+}
+
+// Write gathers and writes the current metrics.
+func (w *RemoteWriter) Write(ctx context.Context) error {
+	families, err := w.gatherer.Gather()
+	if err != nil {
+		return err
+	}
+	body := snappy.Encode(nil, encodeWriteRequest(families, w.labels, time.Now()))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	res, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return fmt.Errorf("%s: %s", res.Status, bytes.TrimSpace(msg))
+	}
+	_, err = io.Copy(io.Discard, res.Body)
+	return err
+}
+
+type sample struct {
+	labels map[string]string
+	value  float64
+}
+
+// samples returns the samples of a metric family using the series names of
+// the Prometheus exposition format.
+func samples(f *dto.MetricFamily) []sample {
+	name := f.GetName()
+	out := []sample{}
+	add := func(m *dto.Metric, suffix string, value float64, extra ...string) {
+		labels := map[string]string{"__name__": name + suffix}
+		for _, l := range m.GetLabel() {
+			labels[l.GetName()] = l.GetValue()
+		}
+		for i := 0; i+1 < len(extra); i += 2 {
+			labels[extra[i]] = extra[i+1]
+		}
+		out = append(out, sample{labels: labels, value: value})
+	}
+	for _, m := range f.GetMetric() {
+		switch f.GetType() {
+		case dto.MetricType_COUNTER:
+			add(m, "", m.GetCounter().GetValue())
+		case dto.MetricType_GAUGE:
+			add(m, "", m.GetGauge().GetValue())
+		case dto.MetricType_UNTYPED:
+			add(m, "", m.GetUntyped().GetValue())
+		case dto.MetricType_SUMMARY:
+			s := m.GetSummary()
+			for _, q := range s.GetQuantile() {
+				add(m, "", q.GetValue(), "quantile", formatFloat(q.GetQuantile()))
+			}
+			add(m, "_sum", s.GetSampleSum())
+			add(m, "_count", float64(s.GetSampleCount()))
+		case dto.MetricType_HISTOGRAM, dto.MetricType_GAUGE_HISTOGRAM:
+			h := m.GetHistogram()
+			for _, b := range h.GetBucket() {
+				add(m, "_bucket", float64(b.GetCumulativeCount()), "le", formatFloat(b.GetUpperBound()))
+			}
+			add(m, "_bucket", float64(h.GetSampleCount()), "le", "+Inf")
+			add(m, "_sum", h.GetSampleSum())
+			add(m, "_count", float64(h.GetSampleCount()))
+		}
+	}
+	return out
+}
+
+func formatFloat(f float64) string {
+	if math.IsInf(f, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// encodeWriteRequest encodes a remote write WriteRequest protobuf.
+func encodeWriteRequest(
+	families []*dto.MetricFamily,
+	labels map[string]string,
+	now time.Time,
+) []byte {
+	ts := now.UnixMilli()
+	var req []byte
+	for _, f := range families {
+		for _, s := range samples(f) {
+			for k, v := range labels {
+				if _, ok := s.labels[k]; !ok {
+					s.labels[k] = v
+				}
+			}
+			req = protowire.AppendTag(req, 1, protowire.BytesType)
+			req = protowire.AppendBytes(req, encodeTimeSeries(s, ts))
+		}
+	}
+	return req
+}
+
+func encodeTimeSeries(s sample, ts int64) []byte {
+	// Labels must be sorted by name.
+	names := make([]string, 0, len(s.labels))
+	for name := range s.labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b []byte
+	for _, name := range names {
+		var l []byte
+		l = protowire.AppendTag(l, 1, protowire.BytesType)
+		l = protowire.AppendString(l, name)
+		l = protowire.AppendTag(l, 2, protowire.BytesType)
+		l = protowire.AppendString(l, s.labels[name])
+		b = protowire.AppendTag(b, 1, protowire.BytesType)
+		b = protowire.AppendBytes(b, l)
+	}
+	var smp []byte
+	smp = protowire.AppendTag(smp, 1, protowire.Fixed64Type)
+	smp = protowire.AppendFixed64(smp, math.Float64bits(s.value))
+	smp = protowire.AppendTag(smp, 2, protowire.VarintType)
+	smp = protowire.AppendVarint(smp, uint64(ts))
+	b = protowire.AppendTag(b, 2, protowire.BytesType)
+	b = protowire.AppendBytes(b, smp)
+	return b
+}
@@ -0,0 +1,128 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/snappy"
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+type testSeries struct {
+	labels map[string]string
+	value  float64
+	ts     int64
+}
+
+// decodeWriteRequest decodes the time series of a WriteRequest.
+func decodeWriteRequest(t *testing.T, b []byte) []testSeries {
+	series := []testSeries{}
+	fields(t, b, func(num protowire.Number, v []byte) {
+		require.Equal(t, protowire.Number(1), num)
+		s := testSeries{labels: map[string]string{}}
+		fields(t, v, func(num protowire.Number, v []byte) {
+			switch num {
+			case 1:
+				var name, value string
+				fields(t, v, func(num protowire.Number, v []byte) {
+					if num == 1 {
+						name = string(v)
+					} else {
+						value = string(v)
+					}
+				})
+				s.labels[name] = value
+			case 2:
+				bits, n := protowire.ConsumeFixed64(v[1:])
+				require.True(t, n > 0)
+				s.value = math.Float64frombits(bits)
+				ts, n := protowire.ConsumeVarint(v[1+n+1:])
+				require.True(t, n > 0)
+				s.ts = int64(ts)
+			}
+		})
+		series = append(series, s)
+	})
+	return series
+}
+
+func fields(t *testing.T, b []byte, fn func(protowire.Number, []byte)) {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		require.True(t, n > 0)
+		require.Equal(t, protowire.BytesType, typ)
+		b = b[n:]
+		v, n := protowire.ConsumeBytes(b)
+		require.True(t, n > 0)
+		fn(num, v)
+		b = b[n:]
+	}
+}
+
+// TestRemoteWriter tests writing metrics to a remote write receiver.
+func TestRemoteWriter(t *testing.T) {
+	reg := prom.NewPedanticRegistry()
+	counter := prom.NewCounterVec(prom.CounterOpts{
+		Name: "ops_total",
+		Help: "ops",
+	}, []string{"stage"})
+	counter.WithLabelValues("get").Add(3)
+	hist := prom.NewHistogram(prom.HistogramOpts{
+		Name:    "latency_seconds",
+		Help:    "latency",
+		Buckets: []float64{0.1, 1},
+	})
+	hist.Observe(0.5)
+	reg.MustRegister(counter, hist)
+
+	var got []testSeries
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "snappy", r.Header.Get("Content-Encoding"))
+		assert.Equal(t, "application/x-protobuf", r.Header.Get("Content-Type"))
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		b, err := snappy.Decode(nil, body)
+		require.NoError(t, err)
+		got = decodeWriteRequest(t, b)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	w := NewRemoteWriter(srv.URL, reg, map[string]string{"plan": "test", "run_id": "1"})
+	require.NoError(t, w.Write(context.Background()))
+
+	byName := map[string][]testSeries{}
+	for _, s := range got {
+		assert.Equal(t, "test", s.labels["plan"])
+		assert.Equal(t, "1", s.labels["run_id"])
+		assert.NotZero(t, s.ts)
+		byName[s.labels["__name__"]] = append(byName[s.labels["__name__"]], s)
+	}
+	require.Len(t, byName["ops_total"], 1)
+	assert.Equal(t, "get", byName["ops_total"][0].labels["stage"])
+	assert.Equal(t, 3.0, byName["ops_total"][0].value)
+	require.Len(t, byName["latency_seconds_bucket"], 3)
+	assert.Equal(t, "+Inf", byName["latency_seconds_bucket"][2].labels["le"])
+	assert.Equal(t, 1.0, byName["latency_seconds_count"][0].value)
+	assert.Equal(t, 0.5, byName["latency_seconds_sum"][0].value)
+}
+
+// TestRemoteWriterError tests failed writes return the response status.
+func TestRemoteWriterError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "out of order sample", http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	w := NewRemoteWriter(srv.URL, prom.NewPedanticRegistry(), nil)
+	err := w.Write(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "out of order sample")
+}
@@ -2,33 +2,151 @@ package metrics
 
 import (
 	"net/http"
+	"sync"
 
+	"github.com/hodgesds/dlg/config"
 	prom "github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
 )
 
 // Scope is a metrics scope.
 type Scope interface {
+	prom.Gatherer
 	Register(prom.Collector) error
+	Handler() http.Handler
 }
 
 type scope struct {
-	registry *prom.Registry
+	registry   *prom.Registry
+	registerer prom.Registerer
 }
 
 // NewScope returns a new scope.
 func NewScope() Scope {
+	reg := prom.NewRegistry()
 	return &scope{
-		registry: prom.NewRegistry(),
+		registry:   reg,
+		registerer: reg,
 	}
 }
 
 // Register implements the Scope interface.
 func (s *scope) Register(c prom.Collector) error {
-	return s.registry.Register(c)
+	return s.registerer.Register(c)
+}
+
+// Gather implements the prometheus.Gatherer interface.
+func (s *scope) Gather() ([]*dto.MetricFamily, error) {
+	return s.registry.Gather()
 }
 
 // Handler returns a http handler.
 func (s *scope) Handler() http.Handler {
-	return promhttp.InstrumentMetricHandler(s.registry, nil)
+	return promhttp.InstrumentMetricHandler(s.registry, promhttp.HandlerFor(s.registry, promhttp.HandlerOpts{}))
+}
+
+// NewRunScope returns a Scope with the collectors of a plan and all of its
+// stages, every metric has a run_id label including those of collectors
+// registered later.
+func NewRunScope(p *config.Plan, runID string) (Scope, error) {
+	s := &scope{registry: prom.NewRegistry()}
+	reg := prom.WrapRegistererWith(prom.Labels{"run_id": runID}, s.registry)
+	s.registerer = reg
+	if err := reg.Register(NewPlanCollector(p)); err != nil {
+		return nil, err
+	}
+	stageReg := prom.WrapRegistererWith(prom.Labels{"plan": p.Name}, reg)
+	var register func(stages []*config.Stage) error
+	register = func(stages []*config.Stage) error {
+		for _, stage := range stages {
+			if err := stageReg.Register(NewStageCollector(stage)); err != nil {
+				return err
+			}
+			if err := register(stage.Children); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := register(p.Stages); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Scopes gathers the metrics of a base Gatherer and the scopes of runs. The
+// final metrics of the most recently finished runs are kept so that they can
+// be scraped after the run.
+type Scopes struct {
+	base prom.Gatherer
+	keep int
+
+	mu       sync.Mutex
+	active   map[Scope]struct{}
+	finished []prom.Gatherer
+}
+
+// snapshot is a Gatherer of previously gathered metrics.
+type snapshot []*dto.MetricFamily
+
+// Gather implements the prometheus.Gatherer interface.
+func (s snapshot) Gather() ([]*dto.MetricFamily, error) {
+	return s, nil
+}
+
+// NewScopes returns Scopes that keep the scopes of up to keep finished runs.
+func NewScopes(base prom.Gatherer, keep int) *Scopes {
+	return &Scopes{
+		base:   base,
+		keep:   keep,
+		active: map[Scope]struct{}{},
+	}
+}
+
+// Add adds the scope of a running run.
+func (s *Scopes) Add(scope Scope) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.active[scope] = struct{}{}
+}
+
+// Finish marks the run of a scope finished and keeps its current metrics,
+// the metrics of the oldest run are removed once more than keep runs have
+// finished. Plans can be run again so the collectors of a finished run are
+// not gathered again.
+func (s *Scopes) Finish(scope Scope) {
+	families, _ := scope.Gather()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.active, scope)
+	if s.keep <= 0 {
+		return
+	}
+	s.finished = append(s.finished, snapshot(families))
+	if len(s.finished) > s.keep {
+		s.finished = s.finished[len(s.finished)-s.keep:]
+	}
+}
+
+// Gather implements the prometheus.Gatherer interface.
+func (s *Scopes) Gather() ([]*dto.MetricFamily, error) {
+	s.mu.Lock()
+	gatherers := make(prom.Gatherers, 0, 1+len(s.active)+len(s.finished))
+	if s.base != nil {
+		gatherers = append(gatherers, s.base)
+	}
+	for scope := range s.active {
+		gatherers = append(gatherers, scope)
+	}
+	for _, scope := range s.finished {
+		gatherers = append(gatherers, scope)
+	}
+	s.mu.Unlock()
+	return gatherers.Gather()
+}
+
+// Handler returns a http handler serving the gathered metrics.
+func (s *Scopes) Handler() http.Handler {
+	return promhttp.HandlerFor(s, promhttp.HandlerOpts{})
 }
@@ -0,0 +1,360 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hodgesds/dlg/config"
+	"github.com/hodgesds/dlg/stats"
+	"go.uber.org/multierr"
+)
+
+const (
+	// DefaultSinkInterval is the interval of sinks without an interval.
+	DefaultSinkInterval = 10 * time.Second
+
+	// maxPacketSize keeps UDP packets within a typical MTU.
+	maxPacketSize = 1432
+)
+
+// Sink is a stats.Recorder that aggregates the measured results of every
+// stage and writes the aggregates of each interval to a StatsD, DogStatsD or
+// InfluxDB endpoint. Warm-up results are not written.
+type Sink struct {
+	prefix   string
+	interval time.Duration
+	labels   map[string]string
+	encode   func(s *stats.Stage, interval time.Duration, now time.Time) []string
+	send     func(ctx context.Context, lines []string) error
+	conn     net.Conn
+
+	mu      sync.RWMutex
+	results *stats.Collector
+	start   time.Time
+}
+
+// NewSink returns a Sink, labels are added as tags to every metric.
+func NewSink(conf *config.Sink, labels map[string]string) (*Sink, error) {
+	if err := conf.Validate(); err != nil {
+		return nil, err
+	}
+	s := &Sink{
+		prefix:   conf.Prefix,
+		interval: DefaultSinkInterval,
+		labels:   labels,
+		results:  stats.NewCollector(),
+		start:    time.Now(),
+	}
+	if s.prefix == "" {
+		s.prefix = "dlg"
+	}
+	if conf.Interval != nil {
+		s.interval = *conf.Interval
+	}
+	switch conf.Type {
+	case "statsd":
+		s.encode = s.statsd
+	case "dogstatsd":
+		s.encode = s.dogstatsd
+	case "influx":
+		s.encode = s.influx
+	}
+
+	u, err := url.Parse(conf.Address)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "udp" {
+		s.conn, err = net.Dial("udp", u.Host)
+		if err != nil {
+			return nil, err
+		}
+		s.send = s.sendUDP
+		return s, nil
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+	s.send = func(ctx context.Context, lines []string) error {
+		return sendHTTP(ctx, client, conf.Address, lines)
+	}
+	return s, nil
+}
+
+// Record implements the stats.Recorder interface.
+func (s *Sink) Record(r stats.Result) {
+	if r.Warmup {
+		return
+	}
+	s.mu.RLock()
+	s.results.Record(r)
+	s.mu.RUnlock()
+}
+
+// Run flushes the results every interval until the context is done, then
+// flushes them a final time. Failed writes are logged.
+func (s *Sink) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.Flush(ctx); err != nil {
+				log.Printf("metrics sink: %v", err)
+			}
+		case <-ctx.Done():
+			final, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if err := s.Flush(final); err != nil {
+				log.Printf("metrics sink: %v", err)
+			}
+			return
+		}
+	}
+}
+
+// Flush writes the aggregated results since the last flush.
+func (s *Sink) Flush(ctx context.Context) error {
+	now := time.Now()
+	s.mu.Lock()
+	results, start := s.results, s.start
+	s.results, s.start = stats.NewCollector(), now
+	s.mu.Unlock()
+
+	lines := []string{}
+	for _, stage := range results.Stages() {
+		lines = append(lines, s.encode(stage, now.Sub(start), now)...)
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	return s.send(ctx, lines)
+}
+
+// Close closes the connection of a UDP sink.
+func (s *Sink) Close() error {
+	if s.conn != nil {
+		return s.conn.Close()
+	}
+	return nil
+}
+
+// sendUDP writes the lines in packets of at most maxPacketSize bytes.
+func (s *Sink) sendUDP(ctx context.Context, lines []string) error {
+	if deadline, ok := ctx.Deadline(); ok {
+		s.conn.SetWriteDeadline(deadline)
+	}
+	var packet bytes.Buffer
+	for _, line := range lines {
+		if packet.Len() > 0 && packet.Len()+len(line)+1 > maxPacketSize {
+			if _, err := s.conn.Write(packet.Bytes()); err != nil {
+				return err
+			}
+			packet.Reset()
+		}
+		if packet.Len() > 0 {
+			packet.WriteByte('\n')
+		}
+		packet.WriteString(line)
+	}
+	_, err := s.conn.Write(packet.Bytes())
+	return err
+}
+
+func sendHTTP(ctx context.Context, client *http.Client, url string, lines []string) error {
+	body := strings.NewReader(strings.Join(lines, "\n") + "\n")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return fmt.Errorf("%s: %s", res.Status, bytes.TrimSpace(msg))
+	}
+	_, err = io.Copy(io.Discard, res.Body)
+	return err
+}
+
+// aggregate is a named value of the aggregated results of a stage.
+type aggregate struct {
+	name    string
+	value   float64
+	counter bool
+}
+
+func aggregates(s *stats.Stage, interval time.Duration) []aggregate {
+	b := s.Main
+	throughput := 0.0
+	if interval > 0 {
+		throughput = float64(b.Ops) / interval.Seconds()
+	}
+	ms := func(d time.Duration) float64 {
+		return float64(d) / float64(time.Millisecond)
+	}
+	return []aggregate{
+		{"ops", float64(b.Ops), true},
+		{"errors", float64(b.Errors), true},
+		{"bytes", float64(b.Bytes), true},
+		{"throughput", throughput, false},
+		{"latency_mean_ms", ms(b.Latency.Mean()), false},
+		{"latency_p50_ms", ms(b.Latency.Quantile(0.5)), false},
+		{"latency_p90_ms", ms(b.Latency.Quantile(0.9)), false},
+		{"latency_p99_ms", ms(b.Latency.Quantile(0.99)), false},
+		{"latency_max_ms", ms(b.Latency.Max()), false},
+	}
+}
+
+// statsd encodes the aggregates with the stage in the metric names, since
+// StatsD has no tags.
+func (s *Sink) statsd(stage *stats.Stage, interval time.Duration, _ time.Time) []string {
+	lines := []string{}
+	for _, a := range aggregates(stage, interval) {
+		lines = append(lines, statsdLine(
+			s.prefix+"."+statsdName(stage.Name)+"."+a.name, a, "",
+		))
+	}
+	return lines
+}
+
+// dogstatsd encodes the aggregates with the labels, stage and protocol as
+// tags.
+func (s *Sink) dogstatsd(stage *stats.Stage, interval time.Duration, _ time.Time) []string {
+	tags := s.tags(stage)
+	names := sortedKeys(tags)
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = statsdName(name) + ":" + strings.NewReplacer(",", "_", "|", "_", "#", "_").Replace(tags[name])
+	}
+	suffix := "|#" + strings.Join(pairs, ",")
+	lines := []string{}
+	for _, a := range aggregates(stage, interval) {
+		lines = append(lines, statsdLine(s.prefix+"."+a.name, a, suffix))
+	}
+	return lines
+}
+
+func statsdLine(name string, a aggregate, suffix string) string {
+	typ := "g"
+	if a.counter {
+		typ = "c"
+	}
+	return name + ":" + strconv.FormatFloat(a.value, 'f', -1, 64) + "|" + typ + suffix
+}
+
+// statsdName replaces characters that are reserved in StatsD metric names.
+func statsdName(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if !(c == '_' || c == '-' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
+
+// influx encodes the aggregates as a single line protocol point with the
+// labels, stage and protocol as tags.
+func (s *Sink) influx(stage *stats.Stage, interval time.Duration, now time.Time) []string {
+	tags := s.tags(stage)
+	var b strings.Builder
+	b.WriteString(influxEscaper.Replace(s.prefix))
+	for _, name := range sortedKeys(tags) {
+		if tags[name] == "" {
+			continue
+		}
+		b.WriteString("," + influxEscaper.Replace(name) + "=" + influxEscaper.Replace(tags[name]))
+	}
+	for i, a := range aggregates(stage, interval) {
+		if i == 0 {
+			b.WriteString(" ")
+		} else {
+			b.WriteString(",")
+		}
+		b.WriteString(a.name + "=")
+		if a.counter {
+			b.WriteString(strconv.FormatInt(int64(a.value), 10) + "i")
+		} else {
+			b.WriteString(strconv.FormatFloat(a.value, 'f', -1, 64))
+		}
+	}
+	b.WriteString(" " + strconv.FormatInt(now.UnixNano(), 10))
+	return []string{b.String()}
+}
+
+var influxEscaper = strings.NewReplacer(",", `\,`, " ", `\ `, "=", `\=`)
+
+func (s *Sink) tags(stage *stats.Stage) map[string]string {
+	tags := make(map[string]string, len(s.labels)+2)
+	for k, v := range s.labels {
+		tags[k] = v
+	}
+	tags["stage"] = stage.Name
+	tags["protocol"] = stage.Protocol
+	return tags
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// StartSinks creates and runs the sinks, results recorded to the returned
+// Recorder are written to all of them. Calling stop flushes and closes the
+// sinks.
+func StartSinks(
+	ctx context.Context,
+	confs []*config.Sink,
+	labels map[string]string,
+) (stats.Recorder, func() error, error) {
+	sinks := make([]*Sink, 0, len(confs))
+	recorders := make([]stats.Recorder, 0, len(confs))
+	for _, conf := range confs {
+		s, err := NewSink(conf, labels)
+		if err != nil {
+			for _, s := range sinks {
+				s.Close()
+			}
+			return nil, nil, fmt.Errorf("%s sink: %w", conf.Type, err)
+		}
+		sinks = append(sinks, s)
+		recorders = append(recorders, s)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	for _, s := range sinks {
+		wg.Add(1)
+		go func(s *Sink) {
+			defer wg.Done()
+			s.Run(ctx)
+		}(s)
+	}
+	return stats.MultiRecorder(recorders...), func() error {
+		cancel()
+		wg.Wait()
+		var err error
+		for _, s := range sinks {
+			err = multierr.Append(err, s.Close())
+		}
+		return err
+	}, nil
+}
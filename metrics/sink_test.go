@@ -0,0 +1,124 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hodgesds/dlg/config"
+	"github.com/hodgesds/dlg/stats"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func recordSinkResults(s *Sink) {
+	now := time.Now()
+	s.Record(stats.Result{Time: now, Stage: "get users", Protocol: "http", Latency: 10 * time.Millisecond, Bytes: 100})
+	s.Record(stats.Result{Time: now, Stage: "get users", Protocol: "http", Latency: 20 * time.Millisecond, Err: errors.New("fail")})
+	s.Record(stats.Result{Time: now, Stage: "get users", Protocol: "http", Latency: time.Second, Warmup: true})
+}
+
+func listenUDP(t *testing.T) (net.PacketConn, string) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	return conn, "udp://" + conn.LocalAddr().String()
+}
+
+func readPacket(t *testing.T, conn net.PacketConn) []string {
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	buf := make([]byte, 64*1024)
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+	return strings.Split(string(buf[:n]), "\n")
+}
+
+// TestSinkStatsD tests writing StatsD metrics over UDP.
+func TestSinkStatsD(t *testing.T) {
+	conn, addr := listenUDP(t)
+	defer conn.Close()
+
+	s, err := NewSink(&config.Sink{Type: "statsd", Address: addr}, map[string]string{"plan": "test"})
+	require.NoError(t, err)
+	defer s.Close()
+	recordSinkResults(s)
+	require.NoError(t, s.Flush(context.Background()))
+
+	lines := readPacket(t, conn)
+	assert.Contains(t, lines, "dlg.get_users.ops:2|c")
+	assert.Contains(t, lines, "dlg.get_users.errors:1|c")
+	assert.Contains(t, lines, "dlg.get_users.bytes:100|c")
+	assert.Contains(t, lines, "dlg.get_users.latency_max_ms:20|g")
+
+	// Results are reset after a flush.
+	require.NoError(t, s.Flush(context.Background()))
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(50*time.Millisecond)))
+	_, _, err = conn.ReadFrom(make([]byte, 1024))
+	assert.Error(t, err)
+}
+
+// TestSinkDogStatsD tests DogStatsD metrics are tagged.
+func TestSinkDogStatsD(t *testing.T) {
+	conn, addr := listenUDP(t)
+	defer conn.Close()
+
+	s, err := NewSink(&config.Sink{Type: "dogstatsd", Address: addr, Prefix: "load"}, map[string]string{
+		"plan":   "test",
+		"run_id": "1",
+	})
+	require.NoError(t, err)
+	defer s.Close()
+	recordSinkResults(s)
+	require.NoError(t, s.Flush(context.Background()))
+
+	lines := readPacket(t, conn)
+	assert.Contains(t, lines, "load.ops:2|c|#plan:test,protocol:http,run_id:1,stage:get users")
+}
+
+// TestSinkInfluxHTTP tests writing InfluxDB line protocol over HTTP.
+func TestSinkInfluxHTTP(t *testing.T) {
+	var body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "dlg", r.URL.Query().Get("db"))
+		b, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		body = string(b)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	s, err := NewSink(&config.Sink{Type: "influx", Address: srv.URL + "/write?db=dlg"}, map[string]string{"plan": "test"})
+	require.NoError(t, err)
+	recordSinkResults(s)
+	require.NoError(t, s.Flush(context.Background()))
+
+	lines := strings.Split(strings.TrimSpace(body), "\n")
+	require.Len(t, lines, 1)
+	assert.True(t, strings.HasPrefix(lines[0], `dlg,plan=test,protocol=http,stage=get\ users ops=2i,errors=1i,bytes=100i,throughput=`), lines[0])
+	assert.Contains(t, lines[0], ",latency_max_ms=20 ")
+}
+
+// TestStartSinks tests results are flushed when the sinks are stopped.
+func TestStartSinks(t *testing.T) {
+	conn, addr := listenUDP(t)
+	defer conn.Close()
+
+	rec, stop, err := StartSinks(context.Background(), []*config.Sink{
+		{Type: "influx", Address: addr},
+	}, nil)
+	require.NoError(t, err)
+	rec.Record(stats.Result{Time: time.Now(), Stage: "a", Latency: time.Millisecond})
+	require.NoError(t, stop())
+
+	lines := readPacket(t, conn)
+	require.Len(t, lines, 1)
+	assert.True(t, strings.HasPrefix(lines[0], "dlg,stage=a ops=1i,"), lines[0])
+
+	_, _, err = StartSinks(context.Background(), []*config.Sink{{Type: "statsd", Address: "http://localhost"}}, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,395 @@
+// Package monitor samples the resource usage of the load generator during a
+// run, so that a saturated generator is not mistaken for a slow target.
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"runtime"
+	"runtime/metrics"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hodgesds/dlg/config"
+	"github.com/hodgesds/dlg/executor"
+	prom "github.com/prometheus/client_golang/prometheus"
+)
+
+// Interval is the interval the generator is sampled at.
+const Interval = time.Second
+
+// Limits above which the generator is considered saturated.
+const (
+	maxCPU          = 0.9
+	maxSchedLatency = 10 * time.Millisecond
+	maxGCPause      = 10 * time.Millisecond
+	maxFDs          = 0.9
+	maxPorts        = 0.8
+	// minRate is the ratio of the target rate a rate limited stage must
+	// achieve to be on schedule.
+	minRate = 0.9
+)
+
+const (
+	schedLatencies = "/sched/latencies:seconds"
+	gcPauses       = "/sched/pauses/total/gc:seconds"
+)
+
+// Sample is a measurement of the load generator. Values that cannot be
+// measured on the platform are -1.
+type Sample struct {
+	Time time.Time
+	// CPU is the CPU time used since the last sample as a ratio of the
+	// time of all CPUs.
+	CPU        float64
+	Goroutines int
+	// SchedLatency and GCPause are the 99th percentile of the time
+	// goroutines waited to run and of GC pauses since the last sample.
+	SchedLatency time.Duration
+	GCPause      time.Duration
+	OpenFDs      int
+	MaxFDs       int
+	// Ports are the TCP sockets using an ephemeral port and MaxPorts the
+	// size of the ephemeral port range.
+	Ports    int
+	MaxPorts int
+}
+
+// behind is the time a rate limited stage was behind its schedule.
+type behind struct {
+	samples  int
+	achieved float64
+	target   float64
+}
+
+// Monitor samples the load generator while a plan runs. It is a
+// prometheus.Collector of the last sample.
+type Monitor struct {
+	plan    *config.Plan
+	control *executor.Control
+	descs   descs
+
+	mu       sync.Mutex
+	last     Sample
+	prevCPU  time.Duration
+	prevTime time.Time
+	prevHist map[string]*metrics.Float64Histogram
+
+	highCPU   int
+	peakCPU   float64
+	peakSched time.Duration
+	peakGC    time.Duration
+	peakFDs   Sample
+	peakPorts Sample
+	behind    map[string]*behind
+}
+
+// New returns a Monitor for a run of a plan, the control of the run may be
+// nil.
+func New(plan *config.Plan, control *executor.Control) *Monitor {
+	m := &Monitor{
+		plan:     plan,
+		control:  control,
+		descs:    newDescs(),
+		prevHist: map[string]*metrics.Float64Histogram{},
+		behind:   map[string]*behind{},
+	}
+	m.prevCPU, _ = cpuTime()
+	m.prevTime = time.Now()
+	m.readHistograms()
+	return m
+}
+
+// Run samples the generator every Interval until the context is done.
+func (m *Monitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			m.sample(now)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Last returns the last sample.
+func (m *Monitor) Last() Sample {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.last
+}
+
+func (m *Monitor) sample(now time.Time) {
+	s := Sample{
+		Time:       now,
+		CPU:        -1,
+		Goroutines: runtime.NumGoroutine(),
+		OpenFDs:    -1,
+		MaxFDs:     -1,
+		Ports:      -1,
+		MaxPorts:   -1,
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if cpu, err := cpuTime(); err == nil {
+		if wall := now.Sub(m.prevTime); wall > 0 {
+			s.CPU = float64(cpu-m.prevCPU) / float64(wall) / float64(runtime.NumCPU())
+		}
+		m.prevCPU = cpu
+	}
+	m.prevTime = now
+	hists := m.readHistograms()
+	s.SchedLatency = hists[schedLatencies]
+	s.GCPause = hists[gcPauses]
+	if n, limit, err := openFDs(); err == nil {
+		s.OpenFDs, s.MaxFDs = n, limit
+	}
+	if n, size, err := ephemeralPorts(); err == nil {
+		s.Ports, s.MaxPorts = n, size
+	}
+	m.record(s)
+	m.checkRates(now)
+}
+
+// record adds a sample to the peaks used for warnings.
+func (m *Monitor) record(s Sample) {
+	m.last = s
+	if s.CPU >= maxCPU {
+		m.highCPU++
+	}
+	m.peakCPU = math.Max(m.peakCPU, s.CPU)
+	if s.SchedLatency > m.peakSched {
+		m.peakSched = s.SchedLatency
+	}
+	if s.GCPause > m.peakGC {
+		m.peakGC = s.GCPause
+	}
+	if s.MaxFDs > 0 && s.OpenFDs > m.peakFDs.OpenFDs {
+		m.peakFDs = s
+	}
+	if s.MaxPorts > 0 && s.Ports > m.peakPorts.Ports {
+		m.peakPorts = s
+	}
+}
+
+// readHistograms reads the runtime histograms and returns the 99th
+// percentile of the values recorded since the last read.
+func (m *Monitor) readHistograms() map[string]time.Duration {
+	samples := []metrics.Sample{{Name: schedLatencies}, {Name: gcPauses}}
+	metrics.Read(samples)
+	p99 := map[string]time.Duration{}
+	for _, s := range samples {
+		if s.Value.Kind() != metrics.KindFloat64Histogram {
+			continue
+		}
+		h := s.Value.Float64Histogram()
+		p99[s.Name] = quantile(h, m.prevHist[s.Name], 0.99)
+		m.prevHist[s.Name] = &metrics.Float64Histogram{
+			Counts:  append([]uint64(nil), h.Counts...),
+			Buckets: h.Buckets,
+		}
+	}
+	return p99
+}
+
+// quantile returns the quantile q of the values of h that are not in prev.
+func quantile(h, prev *metrics.Float64Histogram, q float64) time.Duration {
+	counts := make([]uint64, len(h.Counts))
+	var total uint64
+	for i, c := range h.Counts {
+		if prev != nil && i < len(prev.Counts) {
+			c -= prev.Counts[i]
+		}
+		counts[i] = c
+		total += c
+	}
+	if total == 0 {
+		return 0
+	}
+	rank := uint64(math.Ceil(q * float64(total)))
+	var n uint64
+	for i, c := range counts {
+		n += c
+		if n < rank {
+			continue
+		}
+		// Use the upper bound of the bucket unless it is unbounded.
+		v := h.Buckets[i+1]
+		if math.IsInf(v, 1) {
+			v = h.Buckets[i]
+		}
+		return time.Duration(v * float64(time.Second))
+	}
+	return 0
+}
+
+// checkRates counts the rate limited stages that did not achieve their
+// target rate in the last second.
+func (m *Monitor) checkRates(now time.Time) {
+	if m.control.Paused() {
+		return
+	}
+	scale := m.control.RateScale()
+	var walk func(stages []*config.Stage)
+	walk = func(stages []*config.Stage) {
+		for _, s := range stages {
+			walk(s.Children)
+			st := s.Status()
+			// The first second of a stage is not a full second.
+			if s.Rate <= 0 || st.State() != config.Running || st.Elapsed(now) < 2*time.Second {
+				continue
+			}
+			target := s.Rate * scale
+			achieved := limitedRate(s, now)
+			if achieved >= minRate*target {
+				continue
+			}
+			b, ok := m.behind[s.Name]
+			if !ok {
+				b = &behind{}
+				m.behind[s.Name] = b
+			}
+			b.samples++
+			b.achieved += achieved
+			b.target += target
+		}
+	}
+	walk(m.plan.Stages)
+}
+
+// limitedRate returns the operations per second of a stage and its children
+// that are limited by its rate.
+func limitedRate(s *config.Stage, now time.Time) float64 {
+	rate := s.Status().Rate(now)
+	for _, child := range s.Children {
+		if child.Rate <= 0 {
+			rate += limitedRate(child, now)
+		}
+	}
+	return rate
+}
+
+// Warnings returns a warning for every resource the generator was saturated
+// on during the run.
+func (m *Monitor) Warnings() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	warnings := []string{}
+	if m.highCPU > 0 {
+		warnings = append(warnings, fmt.Sprintf(
+			"generator CPU usage was above %.0f%% for %s, peaking at %.0f%%",
+			maxCPU*100, time.Duration(m.highCPU)*Interval, m.peakCPU*100,
+		))
+	}
+	if m.peakSched > maxSchedLatency {
+		warnings = append(warnings, fmt.Sprintf(
+			"goroutine scheduling latency p99 reached %s, operations were delayed by the generator",
+			m.peakSched,
+		))
+	}
+	if m.peakGC > maxGCPause {
+		warnings = append(warnings, fmt.Sprintf("GC pause p99 reached %s", m.peakGC))
+	}
+	if s := m.peakFDs; s.MaxFDs > 0 && float64(s.OpenFDs) >= maxFDs*float64(s.MaxFDs) {
+		warnings = append(warnings, fmt.Sprintf(
+			"open file descriptors reached %d of the limit of %d", s.OpenFDs, s.MaxFDs,
+		))
+	}
+	if s := m.peakPorts; s.MaxPorts > 0 && float64(s.Ports) >= maxPorts*float64(s.MaxPorts) {
+		warnings = append(warnings, fmt.Sprintf(
+			"%d of %d ephemeral ports were in use", s.Ports, s.MaxPorts,
+		))
+	}
+	names := make([]string, 0, len(m.behind))
+	for name := range m.behind {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		b := m.behind[name]
+		warnings = append(warnings, fmt.Sprintf(
+			"stage %s fell behind its rate for %s, achieving %.1f of %.1f ops/s",
+			name,
+			time.Duration(b.samples)*Interval,
+			b.achieved/float64(b.samples),
+			b.target/float64(b.samples),
+		))
+	}
+	return warnings
+}
+
+type descs struct {
+	cpu          *prom.Desc
+	goroutines   *prom.Desc
+	schedLatency *prom.Desc
+	gcPause      *prom.Desc
+	openFDs      *prom.Desc
+	maxFDs       *prom.Desc
+	ports        *prom.Desc
+	maxPorts     *prom.Desc
+	behind       *prom.Desc
+}
+
+func newDescs() descs {
+	desc := func(name, help string, variable ...string) *prom.Desc {
+		return prom.NewDesc(prom.BuildFQName("dlg", "generator", name), help, variable, nil)
+	}
+	return descs{
+		cpu:          desc("cpu_utilization", "CPU time used as a ratio of the time of all CPUs."),
+		goroutines:   desc("goroutines", "Number of goroutines."),
+		schedLatency: desc("sched_latency_p99_seconds", "99th percentile of the time goroutines waited to run."),
+		gcPause:      desc("gc_pause_p99_seconds", "99th percentile of GC pauses."),
+		openFDs:      desc("open_fds", "Open file descriptors."),
+		maxFDs:       desc("max_fds", "Limit of open file descriptors."),
+		ports:        desc("ephemeral_ports_used", "TCP sockets using an ephemeral port."),
+		maxPorts:     desc("ephemeral_ports", "Size of the ephemeral port range."),
+		behind:       desc("behind_schedule_seconds", "Time a rate limited stage did not achieve its rate.", "stage"),
+	}
+}
+
+// Describe implements the prometheus.Collector interface.
+func (m *Monitor) Describe(ch chan<- *prom.Desc) {
+	d := m.descs
+	for _, desc := range []*prom.Desc{
+		d.cpu, d.goroutines, d.schedLatency, d.gcPause, d.openFDs,
+		d.maxFDs, d.ports, d.maxPorts, d.behind,
+	} {
+		ch <- desc
+	}
+}
+
+// Collect implements the prometheus.Collector interface.
+func (m *Monitor) Collect(ch chan<- prom.Metric) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, d := m.last, m.descs
+	if s.Time.IsZero() {
+		return
+	}
+	gauge := func(desc *prom.Desc, v float64) {
+		ch <- prom.MustNewConstMetric(desc, prom.GaugeValue, v)
+	}
+	if s.CPU >= 0 {
+		gauge(d.cpu, s.CPU)
+	}
+	gauge(d.goroutines, float64(s.Goroutines))
+	gauge(d.schedLatency, s.SchedLatency.Seconds())
+	gauge(d.gcPause, s.GCPause.Seconds())
+	if s.MaxFDs >= 0 {
+		gauge(d.openFDs, float64(s.OpenFDs))
+		gauge(d.maxFDs, float64(s.MaxFDs))
+	}
+	if s.MaxPorts >= 0 {
+		gauge(d.ports, float64(s.Ports))
+		gauge(d.maxPorts, float64(s.MaxPorts))
+	}
+	for stage, b := range m.behind {
+		secs := (time.Duration(b.samples) * Interval).Seconds()
+		ch <- prom.MustNewConstMetric(d.behind, prom.CounterValue, secs, stage)
+	}
+}
@@ -0,0 +1,128 @@
+package monitor
+
+import (
+	"bufio"
+	"math"
+	"runtime"
+	"runtime/metrics"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hodgesds/dlg/config"
+	"github.com/hodgesds/dlg/executor"
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestQuantile tests quantiles of the values added to a runtime histogram
+// since a previous read.
+func TestQuantile(t *testing.T) {
+	buckets := []float64{0, 0.001, 0.01, 0.1, math.Inf(1)}
+	prev := &metrics.Float64Histogram{Counts: []uint64{10, 0, 0, 0}, Buckets: buckets}
+	h := &metrics.Float64Histogram{Counts: []uint64{10, 98, 1, 1}, Buckets: buckets}
+	// Values are the upper bound of their bucket.
+	assert.Equal(t, 10*time.Millisecond, quantile(h, prev, 0.5))
+	assert.Equal(t, 100*time.Millisecond, quantile(h, prev, 0.99))
+	// The last bucket is unbounded.
+	assert.Equal(t, 100*time.Millisecond, quantile(h, prev, 1))
+	assert.Equal(t, time.Duration(0), quantile(prev, prev, 0.99))
+}
+
+// TestParsePorts tests counting sockets using an ephemeral port.
+func TestParsePorts(t *testing.T) {
+	tcp := `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 1 1 0000000000000000 100 0 0 10 0
+   1: 0100007F:9C40 0100007F:1F90 01 00000000:00000000 00:00000000 00000000     0        0 2 1 0000000000000000 20 4 30 10 -1
+   2: 0100007F:9C41 0100007F:1F90 06 00000000:00000000 03:00001770 00000000     0        0 0 3 0000000000000000
+   3: 0100007F:1F90 0100007F:9C40 01 00000000:00000000 00:00000000 00000000     0        0 3 1 0000000000000000 20 4 30 10 -1
+`
+	n, err := parsePorts(bufio.NewScanner(strings.NewReader(tcp)), 32768, 60999)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+}
+
+// TestCheckRates tests rate limited stages that do not achieve their rate
+// are warned about.
+func TestCheckRates(t *testing.T) {
+	child := &config.Stage{Name: "child"}
+	stage := &config.Stage{Name: "api", Rate: 100, Children: []*config.Stage{child}}
+	control := executor.NewControl()
+	m := New(&config.Plan{Stages: []*config.Stage{stage}}, control)
+
+	now := time.Now()
+	stage.Status().SetState(config.Running)
+	child.Status().SetState(config.Running)
+	for i := 0; i < 95; i++ {
+		child.Status().AddOp(now.Add(2 * time.Second))
+	}
+	m.checkRates(now.Add(3 * time.Second))
+	assert.Empty(t, m.Warnings())
+
+	m.checkRates(now.Add(4 * time.Second))
+	control.Pause()
+	m.checkRates(now.Add(5 * time.Second))
+	control.Resume()
+	control.SetRateScale(2)
+	m.checkRates(now.Add(3 * time.Second))
+	assert.Equal(t, []string{
+		"stage api fell behind its rate for 2s, achieving 47.5 of 150.0 ops/s",
+	}, m.Warnings())
+
+	reg := prom.NewPedanticRegistry()
+	require.NoError(t, reg.Register(m))
+	m.record(Sample{Time: now, CPU: -1, MaxFDs: -1, MaxPorts: -1})
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	names := []string{}
+	for _, f := range families {
+		names = append(names, f.GetName())
+	}
+	assert.Contains(t, names, "dlg_generator_behind_schedule_seconds")
+	assert.NotContains(t, names, "dlg_generator_cpu_utilization")
+}
+
+// TestWarnings tests warnings for saturated resources.
+func TestWarnings(t *testing.T) {
+	m := New(&config.Plan{}, nil)
+	m.record(Sample{CPU: 0.5, OpenFDs: 10, MaxFDs: 1024, Ports: 10, MaxPorts: 100})
+	assert.Empty(t, m.Warnings())
+
+	m.record(Sample{
+		CPU:          0.95,
+		SchedLatency: 20 * time.Millisecond,
+		GCPause:      15 * time.Millisecond,
+		OpenFDs:      1000,
+		MaxFDs:       1024,
+		Ports:        90,
+		MaxPorts:     100,
+	})
+	m.record(Sample{CPU: 0.98})
+	assert.Equal(t, []string{
+		"generator CPU usage was above 90% for 2s, peaking at 98%",
+		"goroutine scheduling latency p99 reached 20ms, operations were delayed by the generator",
+		"GC pause p99 reached 15ms",
+		"open file descriptors reached 1000 of the limit of 1024",
+		"90 of 100 ephemeral ports were in use",
+	}, m.Warnings())
+}
+
+// TestSample tests sampling the generator.
+func TestSample(t *testing.T) {
+	m := New(&config.Plan{}, nil)
+	m.sample(time.Now().Add(Interval))
+	s := m.Last()
+	assert.True(t, s.Goroutines > 0)
+	if runtime.GOOS == "linux" {
+		assert.True(t, s.CPU >= 0)
+		assert.True(t, s.OpenFDs > 0)
+		assert.True(t, s.MaxFDs >= s.OpenFDs)
+	}
+
+	reg := prom.NewPedanticRegistry()
+	require.NoError(t, reg.Register(m))
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	assert.NotEmpty(t, families)
+}
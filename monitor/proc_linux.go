@@ -0,0 +1,95 @@
+package monitor
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// cpuTime returns the user and system CPU time of the process.
+func cpuTime() (time.Duration, error) {
+	var ru syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &ru); err != nil {
+		return 0, err
+	}
+	return time.Duration(ru.Utime.Nano() + ru.Stime.Nano()), nil
+}
+
+// openFDs returns the number of open file descriptors of the process and
+// their limit.
+func openFDs() (int, int, error) {
+	entries, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		return 0, 0, err
+	}
+	var rl syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &rl); err != nil {
+		return 0, 0, err
+	}
+	// Reading the directory opens a descriptor of its own.
+	return len(entries) - 1, int(rl.Cur), nil
+}
+
+// ephemeralPorts returns the number of TCP sockets of all processes using a
+// local port of the ephemeral port range and the size of the range.
+func ephemeralPorts() (int, int, error) {
+	b, err := os.ReadFile("/proc/sys/net/ipv4/ip_local_port_range")
+	if err != nil {
+		return 0, 0, err
+	}
+	var lo, hi int
+	if _, err := fmt.Sscan(string(b), &lo, &hi); err != nil {
+		return 0, 0, fmt.Errorf("invalid port range %q: %w", b, err)
+	}
+	used := 0
+	for _, path := range []string{"/proc/net/tcp", "/proc/net/tcp6"} {
+		n, err := countPorts(path, lo, hi)
+		if err != nil && !os.IsNotExist(err) {
+			return 0, 0, err
+		}
+		used += n
+	}
+	return used, hi - lo + 1, nil
+}
+
+// tcpListen is the state of listening sockets in /proc/net/tcp.
+const tcpListen = "0A"
+
+// countPorts counts the sockets of a /proc/net/tcp file with a local port
+// in [lo, hi] that are not listening.
+func countPorts(path string, lo, hi int) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	return parsePorts(bufio.NewScanner(f), lo, hi)
+}
+
+func parsePorts(s *bufio.Scanner, lo, hi int) (int, error) {
+	n := 0
+	// The first line is a header.
+	s.Scan()
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) < 4 || fields[3] == tcpListen {
+			continue
+		}
+		i := strings.LastIndexByte(fields[1], ':')
+		if i < 0 {
+			continue
+		}
+		port, err := strconv.ParseUint(fields[1][i+1:], 16, 16)
+		if err != nil {
+			continue
+		}
+		if int(port) >= lo && int(port) <= hi {
+			n++
+		}
+	}
+	return n, s.Err()
+}
@@ -0,0 +1,22 @@
+//go:build !linux
+
+package monitor
+
+import (
+	"errors"
+	"time"
+)
+
+var errUnsupported = errors.New("not supported on this platform")
+
+func cpuTime() (time.Duration, error) {
+	return 0, errUnsupported
+}
+
+func openFDs() (int, int, error) {
+	return 0, 0, errUnsupported
+}
+
+func ephemeralPorts() (int, int, error) {
+	return 0, 0, errUnsupported
+}
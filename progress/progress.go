@@ -0,0 +1,247 @@
+// Package progress shows the live progress of a running plan.
+package progress
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hodgesds/dlg/config"
+	"github.com/hodgesds/dlg/executor"
+	"github.com/hodgesds/dlg/stats"
+)
+
+// window is the number of seconds the rolling error rate and latencies are
+// computed over.
+const window = 10
+
+// stageResults are the results of a stage by second of completion in a ring
+// of window seconds.
+type stageResults struct {
+	ops    int64
+	errors int64
+	secs   [window]int64
+	ring   [window]stats.Bucket
+}
+
+// Progress is a stats.Recorder that keeps the recent results of every stage
+// of a plan, together with the status of the plan and its stages they show
+// the progress of a run.
+type Progress struct {
+	plan    *config.Plan
+	control *executor.Control
+
+	mu     sync.Mutex
+	stages map[string]*stageResults
+}
+
+// New returns a Progress for a plan, the control is used to pause the plan
+// and adjust its rate and may be nil.
+func New(plan *config.Plan, control *executor.Control) *Progress {
+	return &Progress{
+		plan:    plan,
+		control: control,
+		stages:  map[string]*stageResults{},
+	}
+}
+
+// Record implements the stats.Recorder interface.
+func (p *Progress) Record(r stats.Result) {
+	sec := r.Time.Add(r.Latency).Unix()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	s, ok := p.stages[r.Stage]
+	if !ok {
+		s = &stageResults{}
+		p.stages[r.Stage] = s
+	}
+	s.ops++
+	if r.Err != nil {
+		s.errors++
+	}
+	i := sec % window
+	switch {
+	case s.secs[i] == sec:
+	case s.secs[i] < sec:
+		s.secs[i] = sec
+		s.ring[i] = stats.Bucket{Latency: stats.NewHistogram()}
+	default:
+		// The second is no longer in the window.
+		return
+	}
+	b := &s.ring[i]
+	b.Ops++
+	if r.Err != nil {
+		b.Errors++
+	}
+	b.Latency.Record(r.Latency)
+}
+
+// Pause pauses the plan.
+func (p *Progress) Pause() {
+	if p.control == nil || p.control.Paused() {
+		return
+	}
+	p.control.Pause()
+	setState(p.plan, config.Running, config.Paused)
+}
+
+// Resume resumes a paused plan.
+func (p *Progress) Resume() {
+	if p.control == nil || !p.control.Paused() {
+		return
+	}
+	p.control.Resume()
+	setState(p.plan, config.Paused, config.Running)
+}
+
+// SetRateScale sets the factor the rate of rate limited stages is multiplied
+// with.
+func (p *Progress) SetRateScale(scale float64) {
+	if p.control != nil {
+		p.control.SetRateScale(scale)
+	}
+}
+
+// setState sets the state of the plan and its stages in state from to to.
+func setState(plan *config.Plan, from, to config.ExecutionState) {
+	if plan.Status().State() == from {
+		plan.Status().SetState(to)
+	}
+	var walk func(stages []*config.Stage)
+	walk = func(stages []*config.Stage) {
+		for _, s := range stages {
+			if s.Status().State() == from {
+				s.Status().SetState(to)
+			}
+			walk(s.Children)
+		}
+	}
+	walk(plan.Stages)
+}
+
+// Stage is the progress of a stage.
+type Stage struct {
+	Name  string                `json:"name"`
+	Depth int                   `json:"depth"`
+	State config.ExecutionState `json:"state"`
+	// Done is the completed fraction of the stage duration, or -1 if the
+	// stage is running without a duration.
+	Done   float64 `json:"done"`
+	Ops    int64   `json:"ops"`
+	Errors int64   `json:"errors"`
+	// Rate is the number of operations completed in the last second and
+	// TargetRate the configured rate.
+	Rate       float64 `json:"rate"`
+	TargetRate float64 `json:"targetRate"`
+	// ErrorRate, P50 and P99 are of the operations completed in the last
+	// seconds.
+	ErrorRate float64       `json:"errorRate"`
+	P50       time.Duration `json:"p50"`
+	P99       time.Duration `json:"p99"`
+	Active    int64         `json:"active"`
+	Elapsed   time.Duration `json:"elapsed"`
+	// Remaining is the time left of the stage duration, nil if the stage
+	// has no duration.
+	Remaining *time.Duration `json:"remaining,omitempty"`
+}
+
+// Snapshot is the progress of a plan at a point in time.
+type Snapshot struct {
+	Plan      string                `json:"plan"`
+	State     config.ExecutionState `json:"state"`
+	Elapsed   time.Duration         `json:"elapsed"`
+	Remaining *time.Duration        `json:"remaining,omitempty"`
+	RateScale float64               `json:"rateScale"`
+	Stages    []Stage               `json:"stages"`
+	// Total are the operations of all stages.
+	Total Stage `json:"total"`
+}
+
+// Snapshot returns the progress at now.
+func (p *Progress) Snapshot(now time.Time) Snapshot {
+	status := p.plan.Status()
+	snap := Snapshot{
+		Plan:      p.plan.Name,
+		State:     status.State(),
+		Elapsed:   status.Elapsed(now),
+		RateScale: p.control.RateScale(),
+		Total:     Stage{Name: "total", State: status.State()},
+	}
+	snap.Remaining = remaining(p.plan.Duration, snap.Elapsed, snap.State)
+	snap.Total.Elapsed = snap.Elapsed
+	snap.Total.Remaining = snap.Remaining
+	snap.Total.Done = done(p.plan.Duration, snap.Elapsed, snap.State)
+
+	total := stats.Bucket{Latency: stats.NewHistogram()}
+	p.mu.Lock()
+	var walk func(stages []*config.Stage, depth int)
+	walk = func(stages []*config.Stage, depth int) {
+		for _, s := range stages {
+			recent := stats.Bucket{Latency: stats.NewHistogram()}
+			st := s.Status()
+			stage := Stage{
+				Name:       s.Name,
+				Depth:      depth,
+				State:      st.State(),
+				Rate:       st.Rate(now),
+				TargetRate: s.Rate * snap.RateScale,
+				Active:     st.Workers(),
+				Elapsed:    st.Elapsed(now),
+			}
+			stage.Remaining = remaining(s.Duration, stage.Elapsed, stage.State)
+			stage.Done = done(s.Duration, stage.Elapsed, stage.State)
+			if res, ok := p.stages[s.Name]; ok {
+				stage.Ops, stage.Errors = res.ops, res.errors
+				for i, sec := range res.secs {
+					if sec > now.Unix()-window {
+						recent.Merge(res.ring[i])
+					}
+				}
+			}
+			stage.ErrorRate = recent.ErrorRate()
+			stage.P50 = recent.Latency.Quantile(0.5)
+			stage.P99 = recent.Latency.Quantile(0.99)
+			total.Merge(recent)
+
+			snap.Total.Ops += stage.Ops
+			snap.Total.Errors += stage.Errors
+			snap.Total.Rate += stage.Rate
+			snap.Total.Active += stage.Active
+			snap.Stages = append(snap.Stages, stage)
+			walk(s.Children, depth+1)
+		}
+	}
+	walk(p.plan.Stages, 0)
+	p.mu.Unlock()
+
+	snap.Total.ErrorRate = total.ErrorRate()
+	snap.Total.P50 = total.Latency.Quantile(0.5)
+	snap.Total.P99 = total.Latency.Quantile(0.99)
+	return snap
+}
+
+func remaining(d *time.Duration, elapsed time.Duration, state config.ExecutionState) *time.Duration {
+	if d == nil {
+		return nil
+	}
+	r := *d - elapsed
+	if r < 0 || state == config.Complete {
+		r = 0
+	}
+	return &r
+}
+
+func done(d *time.Duration, elapsed time.Duration, state config.ExecutionState) float64 {
+	switch {
+	case state == config.Complete:
+		return 1
+	case state == config.Waiting:
+		return 0
+	case d == nil || *d <= 0:
+		return -1
+	case elapsed >= *d:
+		return 1
+	default:
+		return float64(elapsed) / float64(*d)
+	}
+}
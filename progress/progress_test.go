@@ -0,0 +1,212 @@
+package progress
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hodgesds/dlg/config"
+	"github.com/hodgesds/dlg/executor"
+	"github.com/hodgesds/dlg/stats"
+	"github.com/hodgesds/dlg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testPlan() *config.Plan {
+	return &config.Plan{
+		Name: "test",
+		Stages: []*config.Stage{{
+			Name:     "parent",
+			Duration: util.DurPtr(10 * time.Second),
+			Children: []*config.Stage{{Name: "child", Rate: 50}},
+		}},
+	}
+}
+
+// TestSnapshot tests the progress of stages from their status and recent
+// results.
+func TestSnapshot(t *testing.T) {
+	plan := testPlan()
+	p := New(plan, executor.NewControl())
+	plan.Status().SetState(config.Running)
+	plan.Stages[0].Status().SetState(config.Running)
+	child := plan.Stages[0].Children[0]
+	child.Status().SetState(config.Running)
+	child.Status().AddWorkers(2)
+
+	now := time.Now()
+	for i := 0; i < 10; i++ {
+		r := stats.Result{
+			Time:    now.Add(-time.Second),
+			Stage:   "child",
+			Latency: time.Duration(i+1) * time.Millisecond,
+		}
+		if i == 0 {
+			r.Err = errors.New("failed")
+		}
+		p.Record(r)
+	}
+	// Results older than the window are only counted.
+	p.Record(stats.Result{Time: now.Add(-time.Minute), Stage: "child", Latency: time.Second})
+
+	s := p.Snapshot(now)
+	assert.Equal(t, "test", s.Plan)
+	assert.Equal(t, config.Running, s.State)
+	assert.Nil(t, s.Remaining)
+	require.Len(t, s.Stages, 2)
+
+	parent := s.Stages[0]
+	assert.Equal(t, 0, parent.Depth)
+	require.NotNil(t, parent.Remaining)
+	assert.True(t, parent.Done >= 0 && parent.Done < 1)
+
+	c := s.Stages[1]
+	assert.Equal(t, "child", c.Name)
+	assert.Equal(t, 1, c.Depth)
+	assert.Equal(t, -1.0, c.Done)
+	assert.Equal(t, int64(11), c.Ops)
+	assert.Equal(t, int64(1), c.Errors)
+	assert.Equal(t, 0.1, c.ErrorRate)
+	assert.Equal(t, 50.0, c.TargetRate)
+	assert.Equal(t, int64(2), c.Active)
+	assert.True(t, c.P99 < time.Second)
+	assert.True(t, c.P50 >= 4*time.Millisecond && c.P50 <= 6*time.Millisecond, c.P50)
+
+	assert.Equal(t, int64(11), s.Total.Ops)
+	assert.Equal(t, int64(2), s.Total.Active)
+	assert.Equal(t, 0.1, s.Total.ErrorRate)
+}
+
+// TestPause tests pausing and resuming a plan sets the state of the plan and
+// its running stages.
+func TestPause(t *testing.T) {
+	plan := testPlan()
+	control := executor.NewControl()
+	p := New(plan, control)
+	plan.Status().SetState(config.Running)
+	plan.Stages[0].Status().SetState(config.Running)
+
+	p.Pause()
+	assert.True(t, control.Paused())
+	assert.Equal(t, config.Paused, plan.Status().State())
+	assert.Equal(t, config.Paused, plan.Stages[0].Status().State())
+	assert.Equal(t, config.Waiting, plan.Stages[0].Children[0].Status().State())
+
+	p.Resume()
+	assert.False(t, control.Paused())
+	assert.Equal(t, config.Running, plan.Status().State())
+	assert.Equal(t, config.Running, plan.Stages[0].Status().State())
+}
+
+// TestHandleKey tests the keys of the dashboard.
+func TestHandleKey(t *testing.T) {
+	control := executor.NewControl()
+	p := New(testPlan(), control)
+
+	require.True(t, handleKey(p, 'p'))
+	assert.True(t, control.Paused())
+	require.True(t, handleKey(p, ' '))
+	assert.False(t, control.Paused())
+
+	require.True(t, handleKey(p, '+'))
+	require.True(t, handleKey(p, '+'))
+	assert.Equal(t, 1.2, control.RateScale())
+	for i := 0; i < 20; i++ {
+		require.True(t, handleKey(p, '-'))
+	}
+	assert.Equal(t, 0.1, control.RateScale())
+	require.True(t, handleKey(p, '0'))
+	assert.Equal(t, 1.0, control.RateScale())
+
+	assert.False(t, handleKey(p, 'q'))
+	assert.False(t, handleKey(p, 3))
+}
+
+// TestWriteLine tests writing a progress line.
+func TestWriteLine(t *testing.T) {
+	remaining := 30 * time.Second
+	s := Snapshot{
+		State:     config.Running,
+		Elapsed:   90 * time.Second,
+		Remaining: &remaining,
+		Total: Stage{
+			Ops:       1234,
+			Rate:      102.5,
+			ErrorRate: 0.01,
+			P50:       1200 * time.Microsecond,
+			P99:       5 * time.Millisecond,
+			Active:    10,
+		},
+	}
+	var buf bytes.Buffer
+	require.NoError(t, s.WriteLine(&buf))
+	assert.Equal(
+		t,
+		"elapsed=1m30s state=running ops=1234 ops/s=102.50 errors=1.00% p50=1.2ms p99=5ms active=10 remaining=30s\n",
+		buf.String(),
+	)
+}
+
+// TestWriteDashboard tests writing the dashboard of a snapshot.
+func TestWriteDashboard(t *testing.T) {
+	p := New(testPlan(), executor.NewControl())
+	p.SetRateScale(2)
+	var buf bytes.Buffer
+	require.NoError(t, p.Snapshot(time.Now()).WriteDashboard(&buf, 0))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 5)
+	assert.Equal(t, "dlg: test  waiting  elapsed 0s  rate x2.0", lines[0])
+	assert.True(t, strings.HasPrefix(lines[1], "STAGE"))
+	assert.Contains(t, lines[2], "parent")
+	assert.Contains(t, lines[2], "[....................]   0%")
+	assert.True(t, strings.HasPrefix(lines[3], "  child"))
+	assert.Contains(t, lines[3], "100.00")
+	assert.True(t, strings.HasPrefix(lines[4], "total"))
+
+	buf.Reset()
+	require.NoError(t, p.Snapshot(time.Now()).WriteDashboard(&buf, 20))
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		assert.LessOrEqual(t, len(line), 20)
+	}
+}
+
+// TestBar tests progress bars.
+func TestBar(t *testing.T) {
+	assert.Equal(t, "[##########..........]  50%", bar(0.5))
+	assert.Equal(t, "[####################] 100%", bar(1))
+	assert.Equal(t, "[~~~~~~~~~~~~~~~~~~~~]   ?", bar(-1))
+}
+
+// TestReadKeys tests keys are no longer read once reading is stopped.
+func TestReadKeys(t *testing.T) {
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	defer r.Close()
+	defer w.Close()
+	keys := make(chan byte)
+	stop := readKeys(r, keys)
+	_, err = w.Write([]byte("p"))
+	require.NoError(t, err)
+	assert.Equal(t, byte('p'), <-keys)
+
+	stopped := make(chan struct{})
+	go func() {
+		stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("keys are still read")
+	}
+	_, err = w.Write([]byte("q"))
+	require.NoError(t, err)
+	b := make([]byte, 1)
+	_, err = r.Read(b)
+	require.NoError(t, err)
+	assert.Equal(t, byte('q'), b[0])
+}
@@ -0,0 +1,129 @@
+package progress
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// barWidth is the number of characters of a progress bar.
+const barWidth = 20
+
+// WriteLine writes the progress of all stages as a single line of key=value
+// pairs.
+func (s Snapshot) WriteLine(w io.Writer) error {
+	t := s.Total
+	line := fmt.Sprintf(
+		"elapsed=%s state=%s ops=%d ops/s=%.2f errors=%s p50=%s p99=%s active=%d",
+		formatElapsed(s.Elapsed),
+		s.State,
+		t.Ops,
+		t.Rate,
+		formatPercent(t.ErrorRate),
+		formatDuration(t.P50),
+		formatDuration(t.P99),
+		t.Active,
+	)
+	if s.Remaining != nil {
+		line += " remaining=" + formatElapsed(*s.Remaining)
+	}
+	_, err := fmt.Fprintln(w, line)
+	return err
+}
+
+// WriteDashboard writes a table of the progress of every stage, lines are
+// cut to width characters if width is positive.
+func (s Snapshot) WriteDashboard(w io.Writer, width int) error {
+	var buf bytes.Buffer
+	header := fmt.Sprintf("dlg: %s  %s  elapsed %s", s.Plan, s.State, formatElapsed(s.Elapsed))
+	if s.Remaining != nil {
+		header += "  remaining " + formatElapsed(*s.Remaining)
+	}
+	if s.RateScale != 1 {
+		header += fmt.Sprintf("  rate x%.1f", s.RateScale)
+	}
+	fmt.Fprintln(&buf, header)
+
+	tw := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "STAGE\tSTATE\tPROGRESS\tOPS\tOPS/S\tTARGET\tERROR %\tP50\tP99\tACTIVE\tELAPSED\tREMAINING")
+	for _, stage := range append(s.Stages, s.Total) {
+		target, rem := "-", "-"
+		if stage.TargetRate > 0 {
+			target = fmt.Sprintf("%.2f", stage.TargetRate)
+		}
+		if stage.Remaining != nil {
+			rem = formatElapsed(*stage.Remaining)
+		}
+		fmt.Fprintf(
+			tw,
+			"%s%s\t%s\t%s\t%d\t%.2f\t%s\t%s\t%s\t%s\t%d\t%s\t%s\n",
+			strings.Repeat("  ", stage.Depth),
+			stage.Name,
+			stage.State,
+			bar(stage.Done),
+			stage.Ops,
+			stage.Rate,
+			target,
+			formatPercent(stage.ErrorRate),
+			formatDuration(stage.P50),
+			formatDuration(stage.P99),
+			stage.Active,
+			formatElapsed(stage.Elapsed),
+			rem,
+		)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	for _, line := range strings.SplitAfter(buf.String(), "\n") {
+		if line == "" {
+			continue
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if width > 0 && len(line) > width {
+			line = line[:width]
+		}
+		if _, err := io.WriteString(w, line+"\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bar returns a progress bar for the completed fraction done, a negative
+// fraction is unknown.
+func bar(done float64) string {
+	if done < 0 {
+		return "[" + strings.Repeat("~", barWidth) + "]   ?"
+	}
+	n := int(done * barWidth)
+	return fmt.Sprintf(
+		"[%s%s] %3d%%",
+		strings.Repeat("#", n),
+		strings.Repeat(".", barWidth-n),
+		int(done*100),
+	)
+}
+
+func formatElapsed(d time.Duration) string {
+	return d.Round(time.Second).String()
+}
+
+func formatPercent(f float64) string {
+	return fmt.Sprintf("%.2f%%", f*100)
+}
+
+func formatDuration(d time.Duration) string {
+	switch {
+	case d >= time.Second:
+		return d.Round(time.Millisecond).String()
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond).String()
+	default:
+		return d.Round(time.Microsecond).String()
+	}
+}
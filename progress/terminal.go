@@ -0,0 +1,177 @@
+package progress
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// refresh is the interval the dashboard is redrawn at.
+const refresh = time.Second
+
+// IsTerminal returns if f is a terminal.
+func IsTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
+
+// RunLines writes a progress line to w every interval until the context is
+// done, then writes a final line.
+func RunLines(ctx context.Context, p *Progress, w io.Writer, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			p.Snapshot(time.Now()).WriteLine(w)
+		case <-ctx.Done():
+			p.Snapshot(time.Now()).WriteLine(w)
+			return
+		}
+	}
+}
+
+// RunDashboard redraws the progress on the terminal out every second until
+// the context is done. If in is a terminal keys control the run: p or space
+// pauses and resumes it, + and - adjust the rate by 10%, 0 resets the rate
+// and q or ctrl-c call cancel.
+func RunDashboard(ctx context.Context, p *Progress, out, in *os.File, cancel func()) {
+	d := &dashboard{out: out, newline: "\n"}
+	var keys chan byte
+	if p.control != nil && IsTerminal(in) {
+		if state, err := term.MakeRaw(int(in.Fd())); err == nil {
+			defer term.Restore(int(in.Fd()), state)
+			// Raw mode disables the translation of newlines.
+			d.newline, d.keys = "\r\n", true
+			keys = make(chan byte)
+			// Keys are no longer read once the dashboard returns, before
+			// the terminal is restored.
+			defer readKeys(in, keys)()
+		}
+	}
+
+	io.WriteString(out, "\x1b[?25l")
+	defer io.WriteString(out, "\x1b[?25h")
+	ticker := time.NewTicker(refresh)
+	defer ticker.Stop()
+	d.draw(p)
+	for {
+		select {
+		case <-ticker.C:
+		case key := <-keys:
+			if !handleKey(p, key) {
+				cancel()
+			}
+		case <-ctx.Done():
+			d.keys = false
+			d.draw(p)
+			io.WriteString(out, d.newline)
+			return
+		}
+		d.draw(p)
+	}
+}
+
+// handleKey handles a key press, it returns false if the run should be
+// canceled.
+func handleKey(p *Progress, key byte) bool {
+	scale := p.control.RateScale()
+	switch key {
+	case 'p', 'P', ' ':
+		if p.control.Paused() {
+			p.Resume()
+		} else {
+			p.Pause()
+		}
+	case '+', '=':
+		p.SetRateScale(math.Round(scale*10+1) / 10)
+	case '-', '_':
+		if scale > 0.15 {
+			p.SetRateScale(math.Round(scale*10-1) / 10)
+		}
+	case '0':
+		p.SetRateScale(1)
+	case 'q', 'Q', 3:
+		// ctrl-c does not interrupt in raw mode.
+		return false
+	}
+	return true
+}
+
+// readKeys sends the keys read from in to keys until the returned stop
+// function is called, which returns once in is no longer read if its reads
+// can be interrupted.
+func readKeys(in *os.File, keys chan<- byte) (stop func()) {
+	r, interrupt := interruptible(in)
+	done := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		buf := make([]byte, 16)
+		for {
+			n, err := r.Read(buf)
+			for _, b := range buf[:n] {
+				select {
+				case keys <- b:
+				case <-done:
+					return
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		if interrupt() {
+			<-exited
+		}
+	}
+}
+
+// dashboard redraws a snapshot over the previous one.
+type dashboard struct {
+	out     io.Writer
+	newline string
+	keys    bool
+	lines   int
+}
+
+func (d *dashboard) draw(p *Progress) {
+	width := 0
+	if f, ok := d.out.(*os.File); ok {
+		width, _, _ = term.GetSize(int(f.Fd()))
+	}
+	var buf bytes.Buffer
+	p.Snapshot(time.Now()).WriteDashboard(&buf, width)
+	if d.keys {
+		buf.WriteString("p pause/resume  +/- rate  0 reset rate  q quit\n")
+	}
+	frame := strings.TrimSuffix(buf.String(), "\n")
+
+	var b strings.Builder
+	if d.lines > 1 {
+		// Move to the first line of the previous frame.
+		b.WriteString("\r\x1b[" + strconv.Itoa(d.lines-1) + "A")
+	} else {
+		b.WriteString("\r")
+	}
+	lines := strings.Split(frame, "\n")
+	for i, line := range lines {
+		if i > 0 {
+			b.WriteString(d.newline)
+		}
+		b.WriteString(line + "\x1b[K")
+	}
+	// Clear the lines of a longer previous frame.
+	b.WriteString("\x1b[J")
+	d.lines = len(lines)
+	io.WriteString(d.out, b.String())
+}
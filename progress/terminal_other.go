@@ -0,0 +1,14 @@
+//go:build !unix
+
+package progress
+
+import (
+	"io"
+	"os"
+)
+
+// interruptible returns in, reads of it can not be interrupted on this
+// platform so a pending read returns with the next key.
+func interruptible(in *os.File) (r io.Reader, interrupt func() bool) {
+	return in, func() bool { return false }
+}
@@ -0,0 +1,33 @@
+//go:build unix
+
+package progress
+
+import (
+	"io"
+	"os"
+	"syscall"
+)
+
+// interruptible returns a reader of in whose pending and later reads fail
+// once interrupt is called. The reader uses a non-blocking duplicate of in,
+// interrupt puts in back into blocking mode. interrupt returns false if
+// reads of in can not be interrupted.
+func interruptible(in *os.File) (r io.Reader, interrupt func() bool) {
+	fd := int(in.Fd())
+	dup, err := syscall.Dup(fd)
+	if err != nil {
+		return in, func() bool { return false }
+	}
+	// Making the duplicate, and so in, non-blocking makes it pollable so
+	// that closing it interrupts a read.
+	if err := syscall.SetNonblock(dup, true); err != nil {
+		syscall.Close(dup)
+		return in, func() bool { return false }
+	}
+	f := os.NewFile(uintptr(dup), in.Name())
+	return f, func() bool {
+		f.Close()
+		syscall.SetNonblock(fd, false)
+		return true
+	}
+}
@@ -0,0 +1,129 @@
+package report
+
+import (
+	"fmt"
+	"html/template"
+	"math"
+	"strings"
+)
+
+const (
+	chartWidth   = 640
+	chartHeight  = 220
+	chartLeft    = 64
+	chartRight   = 16
+	chartTop     = 12
+	chartBottom  = 32
+	chartGridN   = 4
+	chartMaxXTix = 8
+)
+
+var chartColors = []string{"#2563eb", "#d97706", "#dc2626", "#059669", "#7c3aed", "#0891b2", "#db2777", "#4b5563"}
+
+// series is a line of a chart.
+type series struct {
+	Name   string
+	Values []float64
+}
+
+// chart is a line chart rendered as inline SVG.
+type chart struct {
+	Title  string
+	Labels []string // x axis labels of each value
+	Series []series
+	Format func(float64) string // y axis value format
+}
+
+// SVG renders the chart, points have a title so values are shown on hover
+// without scripts.
+func (c chart) SVG() template.HTML {
+	n := len(c.Labels)
+	if n == 0 {
+		return ""
+	}
+	max := 0.0
+	for _, s := range c.Series {
+		for _, v := range s.Values {
+			max = math.Max(max, v)
+		}
+	}
+	if max == 0 {
+		max = 1
+	}
+	max *= 1.1
+
+	plotW := float64(chartWidth - chartLeft - chartRight)
+	plotH := float64(chartHeight - chartTop - chartBottom)
+	x := func(i int) float64 {
+		if n == 1 {
+			return chartLeft + plotW/2
+		}
+		return chartLeft + plotW*float64(i)/float64(n-1)
+	}
+	y := func(v float64) float64 {
+		return chartTop + plotH - plotH*v/max
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(
+		&b,
+		`<svg class="chart" viewBox="0 0 %d %d" role="img" aria-label="%s">`,
+		chartWidth, chartHeight, template.HTMLEscapeString(c.Title),
+	)
+	for i := 0; i <= chartGridN; i++ {
+		v := max * float64(i) / chartGridN
+		fmt.Fprintf(
+			&b,
+			`<line class="grid" x1="%d" x2="%d" y1="%.1f" y2="%.1f"/>`+
+				`<text class="axis" x="%d" y="%.1f" text-anchor="end">%s</text>`,
+			chartLeft, chartWidth-chartRight, y(v), y(v),
+			chartLeft-6, y(v)+4, template.HTMLEscapeString(c.Format(v)),
+		)
+	}
+	step := (n + chartMaxXTix - 1) / chartMaxXTix
+	for i := 0; i < n; i += step {
+		fmt.Fprintf(
+			&b,
+			`<text class="axis" x="%.1f" y="%d" text-anchor="middle">%s</text>`,
+			x(i), chartHeight-chartBottom+18, template.HTMLEscapeString(c.Labels[i]),
+		)
+	}
+	for si, s := range c.Series {
+		color := chartColors[si%len(chartColors)]
+		var path strings.Builder
+		for i, v := range s.Values {
+			cmd := "L"
+			if i == 0 {
+				cmd = "M"
+			}
+			fmt.Fprintf(&path, "%s%.1f %.1f ", cmd, x(i), y(v))
+		}
+		fmt.Fprintf(
+			&b,
+			`<path d="%s" fill="none" stroke="%s" stroke-width="2"/>`,
+			strings.TrimSpace(path.String()), color,
+		)
+		for i, v := range s.Values {
+			fmt.Fprintf(
+				&b,
+				`<circle cx="%.1f" cy="%.1f" r="3" fill="%s"><title>%s %s: %s</title></circle>`,
+				x(i), y(v), color,
+				template.HTMLEscapeString(s.Name),
+				template.HTMLEscapeString(c.Labels[i]),
+				template.HTMLEscapeString(c.Format(v)),
+			)
+		}
+	}
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String())
+}
+
+// Legend returns the name and color of each series.
+func (c chart) Legend() []struct{ Name, Color string } {
+	legend := make([]struct{ Name, Color string }, len(c.Series))
+	for i, s := range c.Series {
+		legend[i].Name = s.Name
+		legend[i].Color = chartColors[i%len(chartColors)]
+	}
+	return legend
+}
@@ -0,0 +1,117 @@
+// Package report renders the results of a plan execution.
+package report
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hodgesds/dlg/config"
+	"github.com/hodgesds/dlg/stats"
+)
+
+// Check is the result of evaluating a stage threshold.
+type Check struct {
+	Stage     string `json:"stage"`
+	Threshold string `json:"threshold"`
+	Limit     string `json:"limit"`
+	Value     string `json:"value"`
+	Pass      bool   `json:"pass"`
+}
+
+func (c Check) result() string {
+	if c.Pass {
+		return "PASS"
+	}
+	return "FAIL"
+}
+
+// Checks evaluates the thresholds of the plan stages. Thresholds of a stage
+// are checked against the measured results of the stage and its children.
+func Checks(plan *config.Plan, results []*stats.Stage) []Check {
+	byName := make(map[string]*stats.Stage, len(results))
+	for _, r := range results {
+		byName[r.Name] = r
+	}
+	checks := []Check{}
+	for _, stage := range plan.Stages {
+		checks = appendChecks(checks, stage, byName)
+	}
+	return checks
+}
+
+func appendChecks(
+	checks []Check,
+	stage *config.Stage,
+	results map[string]*stats.Stage,
+) []Check {
+	if t := stage.Thresholds; t != nil {
+		b := merged(stage, results)
+		if t.MaxErrorRate != nil {
+			checks = append(checks, Check{
+				Stage:     stage.Name,
+				Threshold: "error rate",
+				Limit:     "<= " + formatPercent(*t.MaxErrorRate),
+				Value:     formatPercent(b.ErrorRate()),
+				Pass:      b.Ops > 0 && b.ErrorRate() <= *t.MaxErrorRate,
+			})
+		}
+		if t.MinThroughput != nil {
+			checks = append(checks, Check{
+				Stage:     stage.Name,
+				Threshold: "throughput",
+				Limit:     fmt.Sprintf(">= %.2f/s", *t.MinThroughput),
+				Value:     fmt.Sprintf("%.2f/s", b.Throughput()),
+				Pass:      b.Ops > 0 && b.Throughput() >= *t.MinThroughput,
+			})
+		}
+		latencies := []struct {
+			name  string
+			limit *time.Duration
+			value func() time.Duration
+		}{
+			{"p50", t.P50, func() time.Duration { return b.Latency.Quantile(0.5) }},
+			{"p90", t.P90, func() time.Duration { return b.Latency.Quantile(0.9) }},
+			{"p99", t.P99, func() time.Duration { return b.Latency.Quantile(0.99) }},
+			{"max", t.Max, b.Latency.Max},
+		}
+		for _, l := range latencies {
+			if l.limit == nil {
+				continue
+			}
+			v := l.value()
+			checks = append(checks, Check{
+				Stage:     stage.Name,
+				Threshold: l.name,
+				Limit:     "<= " + formatDuration(*l.limit),
+				Value:     formatDuration(v),
+				Pass:      b.Ops > 0 && v <= *l.limit,
+			})
+		}
+	}
+	for _, child := range stage.Children {
+		checks = appendChecks(checks, child, results)
+	}
+	return checks
+}
+
+// merged returns the measured results of a stage and its children.
+func merged(stage *config.Stage, results map[string]*stats.Stage) stats.Bucket {
+	b := stats.Bucket{Latency: stats.NewHistogram()}
+	if r, ok := results[stage.Name]; ok {
+		b.Merge(r.Main)
+	}
+	for _, child := range stage.Children {
+		b.Merge(merged(child, results))
+	}
+	return b
+}
+
+// Passed returns if all checks passed.
+func Passed(checks []Check) bool {
+	for _, c := range checks {
+		if !c.Pass {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,91 @@
+package report
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hodgesds/dlg/config"
+	"github.com/hodgesds/dlg/stats"
+	"github.com/hodgesds/dlg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testResults() []*stats.Stage {
+	c := stats.NewCollector()
+	start := time.Unix(1000, int64(500*time.Millisecond))
+	for i := 0; i < 100; i++ {
+		var err error
+		if i < 2 {
+			err = errors.New("fail")
+		}
+		c.Record(stats.Result{
+			Time:     start.Add(time.Duration(i) * 10 * time.Millisecond),
+			Stage:    "get",
+			Protocol: "http",
+			Latency:  time.Duration(i+1) * time.Millisecond,
+			Bytes:    1024,
+			Err:      err,
+		})
+	}
+	c.Record(stats.Result{
+		Time:     start,
+		Stage:    "get",
+		Protocol: "http",
+		Latency:  time.Second,
+		Warmup:   true,
+	})
+	return c.Stages()
+}
+
+// TestChecks tests thresholds are evaluated against stage results.
+func TestChecks(t *testing.T) {
+	plan := &config.Plan{
+		Stages: []*config.Stage{
+			{
+				Name: "parent",
+				Thresholds: &config.Thresholds{
+					MaxErrorRate: util.Float64Ptr(0.01),
+				},
+				Children: []*config.Stage{
+					{
+						Name: "get",
+						Thresholds: &config.Thresholds{
+							MaxErrorRate: util.Float64Ptr(0.05),
+							P99:          util.DurPtr(200 * time.Millisecond),
+							Max:          util.DurPtr(50 * time.Millisecond),
+						},
+					},
+				},
+			},
+			{
+				Name: "missing",
+				Thresholds: &config.Thresholds{
+					P50: util.DurPtr(time.Second),
+				},
+			},
+		},
+	}
+
+	checks := Checks(plan, testResults())
+	require.Len(t, checks, 5)
+	assert.Equal(t, Check{
+		Stage:     "parent",
+		Threshold: "error rate",
+		Limit:     "<= 1.00%",
+		Value:     "2.00%",
+		Pass:      false,
+	}, checks[0])
+	assert.Equal(t, "get", checks[1].Stage)
+	assert.True(t, checks[1].Pass)
+	assert.Equal(t, "p99", checks[2].Threshold)
+	assert.True(t, checks[2].Pass)
+	assert.Equal(t, "max", checks[3].Threshold)
+	assert.False(t, checks[3].Pass)
+	// Stages without results fail their thresholds.
+	assert.Equal(t, "missing", checks[4].Stage)
+	assert.False(t, checks[4].Pass)
+	assert.False(t, Passed(checks))
+	assert.True(t, Passed(checks[1:3]))
+}
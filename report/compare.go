@@ -0,0 +1,227 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Tolerances are the changes allowed between a baseline and a candidate
+// before a change is a regression. Throughput and Latency are relative
+// changes, ErrorRate is an absolute change of the error rate.
+type Tolerances struct {
+	Throughput float64
+	Latency    float64
+	ErrorRate  float64
+}
+
+// DefaultTolerances are the default Tolerances.
+var DefaultTolerances = Tolerances{
+	Throughput: 0.05,
+	Latency:    0.10,
+	ErrorRate:  0.01,
+}
+
+// Delta is the change of a metric of a stage between a baseline and a
+// candidate.
+type Delta struct {
+	Stage      string
+	Metric     string
+	Baseline   float64
+	Candidate  float64
+	Regression bool
+
+	format func(float64) string
+}
+
+// Change returns the relative change of the metric.
+func (d Delta) Change() float64 {
+	if d.Baseline == 0 {
+		if d.Candidate == 0 {
+			return 0
+		}
+		return math.Inf(1)
+	}
+	return (d.Candidate - d.Baseline) / d.Baseline
+}
+
+func (d Delta) change() string {
+	if d.Metric == "error rate" {
+		return fmt.Sprintf("%+.2fpp", (d.Candidate-d.Baseline)*100)
+	}
+	c := d.Change()
+	if math.IsInf(c, 0) {
+		return "n/a"
+	}
+	return fmt.Sprintf("%+.2f%%", c*100)
+}
+
+// Comparison is the result of comparing a candidate Report to a baseline.
+type Comparison struct {
+	Baseline  string
+	Candidate string
+	Deltas    []Delta
+	// Missing are stages of the baseline the candidate has no results for,
+	// Added are stages only the candidate has results for.
+	Missing []string
+	Added   []string
+}
+
+// Compare compares the stages of a candidate Report to a baseline.
+func Compare(baseline, candidate *Report, t Tolerances) *Comparison {
+	c := &Comparison{
+		Baseline:  baseline.Plan,
+		Candidate: candidate.Plan,
+	}
+	stages := make(map[string]Stage, len(candidate.Stages))
+	for _, s := range candidate.Stages {
+		stages[s.Name] = s
+	}
+	seen := map[string]bool{}
+	for _, b := range baseline.Stages {
+		seen[b.Name] = true
+		s, ok := stages[b.Name]
+		if !ok {
+			c.Missing = append(c.Missing, b.Name)
+			continue
+		}
+		c.Deltas = append(c.Deltas, Delta{
+			Stage:      b.Name,
+			Metric:     "throughput",
+			Baseline:   b.Throughput,
+			Candidate:  s.Throughput,
+			Regression: s.Throughput < b.Throughput*(1-t.Throughput),
+			format:     func(v float64) string { return fmt.Sprintf("%.2f/s", v) },
+		})
+		c.Deltas = append(c.Deltas, Delta{
+			Stage:      b.Name,
+			Metric:     "error rate",
+			Baseline:   b.ErrorRate,
+			Candidate:  s.ErrorRate,
+			Regression: s.ErrorRate-b.ErrorRate > t.ErrorRate,
+			format:     formatPercent,
+		})
+		latencies := []struct {
+			name string
+			b, c time.Duration
+		}{
+			{"p50", b.Latency.P50, s.Latency.P50},
+			{"p90", b.Latency.P90, s.Latency.P90},
+			{"p95", b.Latency.P95, s.Latency.P95},
+			{"p99", b.Latency.P99, s.Latency.P99},
+		}
+		for _, l := range latencies {
+			c.Deltas = append(c.Deltas, Delta{
+				Stage:      b.Name,
+				Metric:     l.name,
+				Baseline:   float64(l.b),
+				Candidate:  float64(l.c),
+				Regression: float64(l.c) > float64(l.b)*(1+t.Latency),
+				format: func(v float64) string {
+					return formatDuration(time.Duration(v))
+				},
+			})
+		}
+	}
+	for _, s := range candidate.Stages {
+		if !seen[s.Name] {
+			c.Added = append(c.Added, s.Name)
+		}
+	}
+	return c
+}
+
+// Regressed returns if any metric regressed or a stage is missing from the
+// candidate.
+func (c *Comparison) Regressed() bool {
+	if len(c.Missing) > 0 {
+		return true
+	}
+	for _, d := range c.Deltas {
+		if d.Regression {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Comparison) rows() [][]string {
+	rows := make([][]string, 0, len(c.Deltas))
+	for _, d := range c.Deltas {
+		result := "ok"
+		if d.Regression {
+			result = "REGRESSION"
+		}
+		rows = append(rows, []string{
+			d.Stage,
+			d.Metric,
+			d.format(d.Baseline),
+			d.format(d.Candidate),
+			d.change(),
+			result,
+		})
+	}
+	return rows
+}
+
+func (c *Comparison) notes() []string {
+	notes := []string{}
+	for _, s := range c.Missing {
+		notes = append(notes, fmt.Sprintf("stage %s has no results in the candidate", s))
+	}
+	for _, s := range c.Added {
+		notes = append(notes, fmt.Sprintf("stage %s is not in the baseline", s))
+	}
+	return notes
+}
+
+var compareHeader = []string{"STAGE", "METRIC", "BASELINE", "CANDIDATE", "CHANGE", "RESULT"}
+
+// WriteText writes the Comparison as a table.
+func (c *Comparison) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(compareHeader, "\t"))
+	for _, row := range c.rows() {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	for _, note := range c.notes() {
+		if _, err := fmt.Fprintln(w, note); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteMarkdown writes the Comparison as a markdown table.
+func (c *Comparison) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+	status := "No regressions"
+	if c.Regressed() {
+		status = "**Regressions found**"
+	}
+	fmt.Fprintf(&b, "### %s vs %s\n\n%s\n\n", c.Baseline, c.Candidate, status)
+	fmt.Fprintf(&b, "| Stage | Metric | Baseline | Candidate | Change | Result |\n")
+	fmt.Fprintf(&b, "|---|---|---:|---:|---:|---|\n")
+	for _, row := range c.rows() {
+		if row[5] != "ok" {
+			row[5] = ":x: regression"
+		} else {
+			row[5] = ":white_check_mark:"
+		}
+		fmt.Fprintf(&b, "| %s |\n", strings.Join(row, " | "))
+	}
+	if notes := c.notes(); len(notes) > 0 {
+		b.WriteString("\n")
+		for _, note := range notes {
+			fmt.Fprintf(&b, "- %s\n", note)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,88 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func compareReport(name string, throughput, errorRate float64, p99 time.Duration, stages ...string) *Report {
+	r := &Report{Plan: name}
+	for _, s := range stages {
+		r.Stages = append(r.Stages, Stage{
+			Name:       s,
+			Throughput: throughput,
+			ErrorRate:  errorRate,
+			Latency: Latency{
+				P50: p99 / 4,
+				P90: p99 / 2,
+				P95: p99 / 2,
+				P99: p99,
+			},
+		})
+	}
+	return r
+}
+
+// TestCompare tests comparing a candidate to a baseline.
+func TestCompare(t *testing.T) {
+	baseline := compareReport("base", 100, 0.01, 100*time.Millisecond, "a", "b")
+
+	c := Compare(baseline, compareReport("cand", 97, 0.015, 105*time.Millisecond, "a", "b"), DefaultTolerances)
+	assert.False(t, c.Regressed())
+	require.Len(t, c.Deltas, 12)
+	assert.Equal(t, "throughput", c.Deltas[0].Metric)
+	assert.InDelta(t, -0.03, c.Deltas[0].Change(), 0.0001)
+
+	c = Compare(baseline, compareReport("cand", 90, 0.01, 100*time.Millisecond, "a", "b"), DefaultTolerances)
+	assert.True(t, c.Regressed())
+	assert.True(t, c.Deltas[0].Regression)
+
+	c = Compare(baseline, compareReport("cand", 100, 0.05, 100*time.Millisecond, "a", "b"), DefaultTolerances)
+	assert.True(t, c.Regressed())
+	assert.True(t, c.Deltas[1].Regression)
+
+	c = Compare(baseline, compareReport("cand", 100, 0.01, 150*time.Millisecond, "a", "b"), DefaultTolerances)
+	assert.True(t, c.Regressed())
+	assert.True(t, c.Deltas[5].Regression)
+	assert.Equal(t, "p99", c.Deltas[5].Metric)
+
+	c = Compare(baseline, compareReport("cand", 100, 0.01, 100*time.Millisecond, "a", "c"), DefaultTolerances)
+	assert.True(t, c.Regressed())
+	assert.Equal(t, []string{"b"}, c.Missing)
+	assert.Equal(t, []string{"c"}, c.Added)
+
+	// Faster latencies and a higher throughput are not regressions.
+	c = Compare(baseline, compareReport("cand", 200, 0, time.Millisecond, "a", "b"), DefaultTolerances)
+	assert.False(t, c.Regressed())
+}
+
+// TestComparisonWrite tests writing a Comparison as text and markdown.
+func TestComparisonWrite(t *testing.T) {
+	c := Compare(
+		compareReport("base", 100, 0.01, 100*time.Millisecond, "a", "b"),
+		compareReport("cand", 100, 0.01, 150*time.Millisecond, "a"),
+		DefaultTolerances,
+	)
+
+	var buf bytes.Buffer
+	require.NoError(t, c.WriteText(&buf))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 8)
+	assert.Equal(t, compareHeader, strings.Fields(lines[0]))
+	assert.Equal(t, []string{"a", "p99", "100ms", "150ms", "+50.00%", "REGRESSION"}, strings.Fields(lines[6]))
+	assert.Equal(t, "stage b has no results in the candidate", lines[7])
+
+	buf.Reset()
+	require.NoError(t, c.WriteMarkdown(&buf))
+	md := buf.String()
+	assert.Contains(t, md, "### base vs cand")
+	assert.Contains(t, md, "**Regressions found**")
+	assert.Contains(t, md, "| a | p99 | 100ms | 150ms | +50.00% | :x: regression |")
+	assert.Contains(t, md, "| a | error rate | 1.00% | 1.00% | +0.00pp | :white_check_mark: |")
+	assert.Contains(t, md, "- stage b has no results in the candidate")
+}
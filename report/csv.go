@@ -0,0 +1,86 @@
+package report
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var csvHeader = []string{
+	"plan",
+	"tags",
+	"seed",
+	"stage",
+	"protocol",
+	"ops",
+	"errors",
+	"error_rate",
+	"throughput",
+	"bytes",
+	"duration_seconds",
+	"min_ms",
+	"mean_ms",
+	"p50_ms",
+	"p90_ms",
+	"p95_ms",
+	"p99_ms",
+	"max_ms",
+	"warmup_ops",
+	"thresholds",
+}
+
+// WriteCSV writes a row for every stage of the Report. The thresholds column
+// is PASS or FAIL for stages with thresholds and empty otherwise.
+func (r *Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	tags := strings.Join(r.Tags, ";")
+	seed := strconv.FormatInt(r.Seed, 10)
+	for _, s := range r.Stages {
+		thresholds := ""
+		if checks := r.stageChecks(s.Name); len(checks) > 0 {
+			thresholds = "PASS"
+			if !Passed(checks) {
+				thresholds = "FAIL"
+			}
+		}
+		if err := cw.Write([]string{
+			r.Plan,
+			tags,
+			seed,
+			s.Name,
+			s.Protocol,
+			strconv.FormatInt(s.Ops, 10),
+			strconv.FormatInt(s.Errors, 10),
+			formatFloat(s.ErrorRate),
+			formatFloat(s.Throughput),
+			strconv.FormatInt(s.Bytes, 10),
+			formatFloat(s.Duration.Seconds()),
+			formatMillis(s.Latency.Min),
+			formatMillis(s.Latency.Mean),
+			formatMillis(s.Latency.P50),
+			formatMillis(s.Latency.P90),
+			formatMillis(s.Latency.P95),
+			formatMillis(s.Latency.P99),
+			formatMillis(s.Latency.Max),
+			strconv.FormatInt(s.WarmupOps, 10),
+			thresholds,
+		}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func formatMillis(d time.Duration) string {
+	return formatFloat(float64(d) / float64(time.Millisecond))
+}
@@ -0,0 +1,157 @@
+package report
+
+import (
+	_ "embed" // for the HTML template
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+//go:embed report.html
+var htmlReport string
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"duration": formatDuration,
+	"percent":  formatPercent,
+	"bytes":    formatBytes,
+	"time": func(t time.Time) string {
+		return t.Format(time.RFC3339)
+	},
+}).Parse(htmlReport))
+
+type htmlStage struct {
+	Stage
+	Charts []chart
+	Traces []htmlTrace
+}
+
+type htmlTrace struct {
+	Trace
+	URL string
+}
+
+// WriteHTML writes the Report as a self-contained HTML page with charts of
+// the results of each stage and planYAML, the YAML of the plan that was run.
+// Executing a plan updates its stages, so the plan must be marshaled before
+// it is executed.
+func (r *Report) WriteHTML(w io.Writer, planYAML []byte) error {
+	stages := make([]htmlStage, len(r.Stages))
+	for i, s := range r.Stages {
+		stages[i] = htmlStage{Stage: s, Charts: stageCharts(s)}
+		for _, t := range s.Traces {
+			ht := htmlTrace{Trace: t}
+			if r.TraceURL != "" {
+				ht.URL = strings.ReplaceAll(r.TraceURL, "{traceId}", t.TraceID)
+			}
+			stages[i].Traces = append(stages[i].Traces, ht)
+		}
+	}
+	return htmlTemplate.Execute(w, struct {
+		*Report
+		Stages []htmlStage
+		YAML   string
+	}{
+		Report: r,
+		Stages: stages,
+		YAML:   string(planYAML),
+	})
+}
+
+func stageCharts(s Stage) []chart {
+	var (
+		labels    = make([]string, len(s.Timeline))
+		ops       = make([]float64, len(s.Timeline))
+		errorRate = make([]float64, len(s.Timeline))
+		p50       = make([]float64, len(s.Timeline))
+		p90       = make([]float64, len(s.Timeline))
+		p99       = make([]float64, len(s.Timeline))
+	)
+	for i, p := range s.Timeline {
+		labels[i] = formatDuration(p.Time.Sub(s.Timeline[0].Time))
+		ops[i] = float64(p.Ops)
+		if p.Ops > 0 {
+			errorRate[i] = float64(p.Errors) / float64(p.Ops)
+		}
+		p50[i] = float64(p.P50)
+		p90[i] = float64(p.P90)
+		p99[i] = float64(p.P99)
+	}
+	latency := func(v float64) string {
+		return formatDuration(time.Duration(v))
+	}
+	dist := chart{
+		Title:  "Latency distribution",
+		Labels: make([]string, len(s.Distribution)),
+		Format: latency,
+	}
+	values := make([]float64, len(s.Distribution))
+	for i, q := range s.Distribution {
+		dist.Labels[i] = fmt.Sprintf("p%g", q.Quantile*100)
+		values[i] = float64(q.Latency)
+	}
+	dist.Series = []series{{Name: "latency", Values: values}}
+
+	errorSeries := []series{{Name: "errors", Values: errorRate}}
+	if classes := errorClasses(s.Timeline); len(classes) > 0 {
+		errorSeries = make([]series, len(classes))
+		for i, class := range classes {
+			values := make([]float64, len(s.Timeline))
+			for j, p := range s.Timeline {
+				if p.Ops > 0 {
+					values[j] = float64(p.ErrorClasses[class]) / float64(p.Ops)
+				}
+			}
+			errorSeries[i] = series{Name: class, Values: values}
+		}
+	}
+
+	return []chart{
+		{
+			Title:  "Throughput",
+			Labels: labels,
+			Series: []series{{Name: "ops/s", Values: ops}},
+			Format: func(v float64) string { return fmt.Sprintf("%.0f/s", v) },
+		},
+		{
+			Title:  "Error rate",
+			Labels: labels,
+			Series: errorSeries,
+			Format: formatPercent,
+		},
+		{
+			Title:  "Latency",
+			Labels: labels,
+			Series: []series{
+				{Name: "p50", Values: p50},
+				{Name: "p90", Values: p90},
+				{Name: "p99", Values: p99},
+			},
+			Format: latency,
+		},
+		dist,
+	}
+}
+
+// errorClasses returns the error classes of a timeline, most frequent first.
+func errorClasses(timeline []Point) []string {
+	counts := map[string]int64{}
+	for _, p := range timeline {
+		for class, n := range p.ErrorClasses {
+			counts[class] += n
+		}
+	}
+	classes := make([]string, 0, len(counts))
+	for class := range counts {
+		classes = append(classes, class)
+	}
+	sort.Slice(classes, func(i, j int) bool {
+		if counts[classes[i]] != counts[classes[j]] {
+			return counts[classes[i]] > counts[classes[j]]
+		}
+		return classes[i] < classes[j]
+	})
+	return classes
+}
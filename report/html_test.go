@@ -0,0 +1,78 @@
+package report
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testPlanYAML is testPlan as it was run.
+var testPlanYAML = []byte("name: test\nstages:\n- name: get\n  repeat: 3\n  thresholds:\n    p99: 1ms\n")
+
+// TestWriteHTML tests the HTML report contains the charts and plan.
+func TestWriteHTML(t *testing.T) {
+	plan := testPlan()
+	r := New(plan, testResults(), nil)
+	var buf bytes.Buffer
+	require.NoError(t, r.WriteHTML(&buf, testPlanYAML))
+
+	html := buf.String()
+	assert.Contains(t, html, "<title>dlg report: test</title>")
+	assert.Contains(t, html, "Seed: 42.")
+	assert.Contains(t, html, "repeat: 3")
+	assert.Contains(t, html, `<span class="fail">FAILED</span>`)
+	for _, title := range []string{"Throughput", "Error rate", "Latency", "Latency distribution"} {
+		assert.Contains(t, html, "<figcaption>"+title+"</figcaption>")
+	}
+	assert.Equal(t, 4, bytes.Count(buf.Bytes(), []byte("<svg ")))
+	assert.Contains(t, html, "p99: 1ms")
+	assert.Contains(t, html, "<h4>Errors</h4>")
+	assert.Contains(t, html, "<td>error</td><td></td><td>2</td><td><code>fail</code></td>")
+	// The report must not load any external resources.
+	assert.NotContains(t, html, "src=")
+	assert.NotContains(t, html, "href=")
+}
+
+// TestChartSVG tests rendering a chart.
+func TestChartSVG(t *testing.T) {
+	c := chart{
+		Title:  "ops",
+		Labels: []string{"0s", "1s", "2s"},
+		Series: []series{{Name: "ops/s", Values: []float64{1, 2, 3}}},
+		Format: formatFloat,
+	}
+	svg := string(c.SVG())
+	assert.Contains(t, svg, `<path d="M64.0 `)
+	assert.Equal(t, 3, bytes.Count([]byte(svg), []byte("<circle")))
+	assert.Contains(t, svg, "<title>ops/s 2s: 3</title>")
+	assert.Empty(t, chart{Format: formatFloat}.SVG())
+}
+
+// TestWriteHTMLTraces tests the slowest traced operations are linked.
+func TestWriteHTMLTraces(t *testing.T) {
+	plan := testPlan()
+	r := New(plan, testResults(), nil)
+	r.Stages[0].Traces = []Trace{{TraceID: "abc", Op: "GET", Latency: time.Second}}
+	r.TraceURL = "http://jaeger:16686/trace/{traceId}"
+	var buf bytes.Buffer
+	require.NoError(t, r.WriteHTML(&buf, testPlanYAML))
+	assert.Contains(t, buf.String(), `<a href="http://jaeger:16686/trace/abc">abc</a>`)
+
+	r.TraceURL = ""
+	buf.Reset()
+	require.NoError(t, r.WriteHTML(&buf, testPlanYAML))
+	assert.Contains(t, buf.String(), "<code>abc</code>")
+}
+
+// TestWriteHTMLWarnings tests generator warnings are listed.
+func TestWriteHTMLWarnings(t *testing.T) {
+	plan := testPlan()
+	r := New(plan, testResults(), nil)
+	r.Warnings = []string{"stage get fell behind its rate for 3s, achieving 80.0 of 100.0 ops/s"}
+	var buf bytes.Buffer
+	require.NoError(t, r.WriteHTML(&buf, testPlanYAML))
+	assert.Contains(t, buf.String(), "<li>stage get fell behind its rate for 3s, achieving 80.0 of 100.0 ops/s</li>")
+}
@@ -0,0 +1,125 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type junitSuites struct {
+	XMLName xml.Name     `xml:"testsuites"`
+	Suites  []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Time       float64         `xml:"time,attr"`
+	Timestamp  string          `xml:"timestamp,attr,omitempty"`
+	Properties []junitProperty `xml:"properties>property,omitempty"`
+	Cases      []junitCase     `xml:"testcase"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      float64       `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes the Report as JUnit XML. Every stage is a testcase with
+// its results as output and every threshold is a testcase that fails when
+// the threshold is not met. A failed execution adds a failing testcase for
+// the plan.
+func (r *Report) WriteJUnit(w io.Writer) error {
+	suite := junitSuite{
+		Name: r.Plan,
+		Time: r.Duration().Seconds(),
+		Properties: []junitProperty{
+			{Name: "seed", Value: fmt.Sprint(r.Seed)},
+		},
+	}
+	if !r.Start.IsZero() {
+		suite.Timestamp = r.Start.UTC().Format("2006-01-02T15:04:05")
+	}
+	if len(r.Tags) > 0 {
+		suite.Properties = append(suite.Properties, junitProperty{
+			Name:  "tags",
+			Value: strings.Join(r.Tags, ","),
+		})
+	}
+	if r.Error != "" {
+		suite.Cases = append(suite.Cases, junitCase{
+			Name:      "execute",
+			Classname: r.Plan,
+			Failure: &junitFailure{
+				Message: r.Error,
+				Type:    "error",
+			},
+		})
+	}
+	for _, s := range r.Stages {
+		suite.Cases = append(suite.Cases, junitCase{
+			Name:      s.Name,
+			Classname: r.Plan,
+			Time:      s.Duration.Seconds(),
+			SystemOut: fmt.Sprintf(
+				"protocol=%s ops=%d errors=%d error_rate=%s throughput=%.2f/s p50=%s p90=%s p99=%s max=%s bytes=%d",
+				s.Protocol,
+				s.Ops,
+				s.Errors,
+				formatPercent(s.ErrorRate),
+				s.Throughput,
+				formatDuration(s.Latency.P50),
+				formatDuration(s.Latency.P90),
+				formatDuration(s.Latency.P99),
+				formatDuration(s.Latency.Max),
+				s.Bytes,
+			),
+		})
+	}
+	for _, c := range r.Checks {
+		tc := junitCase{
+			Name:      fmt.Sprintf("%s %s %s", c.Stage, c.Threshold, c.Limit),
+			Classname: r.Plan + "." + c.Stage,
+		}
+		if !c.Pass {
+			tc.Failure = &junitFailure{
+				Message: fmt.Sprintf("%s is %s, want %s", c.Threshold, c.Value, c.Limit),
+				Type:    "threshold",
+			}
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+	suite.Tests = len(suite.Cases)
+	for _, tc := range suite.Cases {
+		if tc.Failure != nil {
+			suite.Failures++
+		}
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(junitSuites{Suites: []junitSuite{suite}}); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,246 @@
+package report
+
+import (
+	"time"
+
+	"github.com/hodgesds/dlg/config"
+	"github.com/hodgesds/dlg/stats"
+)
+
+// Report is the result of a plan execution.
+type Report struct {
+	Plan   string    `json:"plan"`
+	Tags   []string  `json:"tags,omitempty"`
+	Seed   int64     `json:"seed,omitempty"`
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+	Error  string    `json:"error,omitempty"`
+	Passed bool      `json:"passed"`
+	Stages []Stage   `json:"stages"`
+	Checks []Check   `json:"checks"`
+	// TraceURL links to a trace in a tracing UI, {traceId} is replaced with
+	// the trace ID. It is used by the HTML report.
+	TraceURL string `json:"traceUrl,omitempty"`
+	// Warnings describe resources the load generator was saturated on,
+	// results of a saturated generator may understate the target.
+	Warnings []string `json:"warnings,omitempty"`
+	// Agents are the results of each agent of a distributed plan, the
+	// stages of the report are the merged results of the agents.
+	Agents []Agent `json:"agents,omitempty"`
+}
+
+// Agent contains the results of the part of a plan run by an agent.
+type Agent struct {
+	Name   string  `json:"name"`
+	Stages []Stage `json:"stages"`
+}
+
+// Stage contains the measured results of a stage.
+type Stage struct {
+	Name       string        `json:"name"`
+	Protocol   string        `json:"protocol"`
+	Ops        int64         `json:"ops"`
+	Errors     int64         `json:"errors"`
+	ErrorRate  float64       `json:"errorRate"`
+	Throughput float64       `json:"throughput"` // operations per second
+	Bytes      int64         `json:"bytes"`
+	Duration   time.Duration `json:"duration"`
+	Latency    Latency       `json:"latency"`
+	WarmupOps  int64         `json:"warmupOps,omitempty"`
+
+	// Timeline contains the results by second and Distribution the
+	// latency at increasing quantiles.
+	Timeline     []Point    `json:"timeline,omitempty"`
+	Distribution []Quantile `json:"distribution,omitempty"`
+	// Traces are the traced operations with the highest latency.
+	Traces []Trace `json:"traces,omitempty"`
+	// ErrorClasses are the failed operations by operation and error class,
+	// most frequent first.
+	ErrorClasses []ErrorCount `json:"errorClasses,omitempty"`
+}
+
+// ErrorCount is the number of failed operations of an operation with the same
+// error class and code.
+type ErrorCount struct {
+	Op      string `json:"op,omitempty"`
+	Class   string `json:"class"`
+	Code    string `json:"code,omitempty"`
+	Count   int64  `json:"count"`
+	Example string `json:"example"`
+}
+
+// Trace is a traced operation.
+type Trace struct {
+	TraceID string        `json:"traceId"`
+	Op      string        `json:"op,omitempty"`
+	Time    time.Time     `json:"time"`
+	Latency time.Duration `json:"latency"`
+	Error   string        `json:"error,omitempty"`
+}
+
+// Point contains the results of the operations started within a second.
+type Point struct {
+	Time         time.Time        `json:"time"`
+	Ops          int64            `json:"ops"`
+	Errors       int64            `json:"errors"`
+	ErrorClasses map[string]int64 `json:"errorClasses,omitempty"`
+	P50          time.Duration    `json:"p50"`
+	P90          time.Duration    `json:"p90"`
+	P99          time.Duration    `json:"p99"`
+}
+
+// Quantile is the latency at a quantile.
+type Quantile struct {
+	Quantile float64       `json:"quantile"`
+	Latency  time.Duration `json:"latency"`
+}
+
+// distribution are the quantiles of the latency distribution.
+var distribution = []float64{
+	0, 0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0.7, 0.75, 0.8, 0.85, 0.9,
+	0.95, 0.99, 0.995, 0.999, 0.9999, 1,
+}
+
+// Latency is a summary of operation latencies.
+type Latency struct {
+	Min  time.Duration `json:"min"`
+	Mean time.Duration `json:"mean"`
+	P50  time.Duration `json:"p50"`
+	P90  time.Duration `json:"p90"`
+	P95  time.Duration `json:"p95"`
+	P99  time.Duration `json:"p99"`
+	Max  time.Duration `json:"max"`
+}
+
+// New returns a Report for the results of a plan, err is the error returned
+// by the plan execution.
+func New(plan *config.Plan, results []*stats.Stage, err error) *Report {
+	r := &Report{
+		Plan:   plan.Name,
+		Tags:   plan.Tags,
+		Seed:   plan.Seed,
+		Stages: make([]Stage, 0, len(results)),
+		Checks: Checks(plan, results),
+	}
+	if err != nil {
+		r.Error = err.Error()
+	}
+	r.Passed = err == nil && Passed(r.Checks)
+	for _, res := range results {
+		for _, b := range []stats.Bucket{res.Warmup, res.Main} {
+			if b.Ops == 0 {
+				continue
+			}
+			if r.Start.IsZero() || b.First.Before(r.Start) {
+				r.Start = b.First
+			}
+			if b.Last.After(r.End) {
+				r.End = b.Last
+			}
+		}
+		r.Stages = append(r.Stages, newStage(res))
+	}
+	return r
+}
+
+func newStage(res *stats.Stage) Stage {
+	b := res.Main
+	s := Stage{
+		Name:       res.Name,
+		Protocol:   res.Protocol,
+		Ops:        b.Ops,
+		Errors:     b.Errors,
+		ErrorRate:  b.ErrorRate(),
+		Throughput: b.Throughput(),
+		Bytes:      b.Bytes,
+		Duration:   b.Duration(),
+		WarmupOps:  res.Warmup.Ops,
+	}
+	if h := b.Latency; h != nil {
+		s.Latency = Latency{
+			Min:  h.Min(),
+			Mean: h.Mean(),
+			P50:  h.Quantile(0.5),
+			P90:  h.Quantile(0.9),
+			P95:  h.Quantile(0.95),
+			P99:  h.Quantile(0.99),
+			Max:  h.Max(),
+		}
+		if h.Count() > 0 {
+			for _, q := range distribution {
+				s.Distribution = append(s.Distribution, Quantile{
+					Quantile: q,
+					Latency:  h.Quantile(q),
+				})
+			}
+		}
+	}
+	for _, i := range res.Intervals {
+		s.Timeline = append(s.Timeline, Point{
+			Time:         i.Time,
+			Ops:          i.Bucket.Ops,
+			Errors:       i.Bucket.Errors,
+			ErrorClasses: i.ErrorClasses,
+			P50:          i.Bucket.Latency.Quantile(0.5),
+			P90:          i.Bucket.Latency.Quantile(0.9),
+			P99:          i.Bucket.Latency.Quantile(0.99),
+		})
+	}
+	for _, e := range res.Errors {
+		s.ErrorClasses = append(s.ErrorClasses, ErrorCount(e))
+	}
+	for _, r := range res.Slowest {
+		t := Trace{
+			TraceID: r.TraceID,
+			Op:      r.Op,
+			Time:    r.Time,
+			Latency: r.Latency,
+		}
+		if r.Err != nil {
+			t.Error = r.Err.Error()
+		}
+		s.Traces = append(s.Traces, t)
+	}
+	return s
+}
+
+// Agents returns the results of the agents merged into a collector. The
+// stages of an agent only contain the aggregated results.
+func Agents(c *stats.Collector) []Agent {
+	var agents []Agent
+	for _, name := range c.Sources() {
+		agents = append(agents, Agent{
+			Name:   name,
+			Stages: Summary(c.SourceStages(name)),
+		})
+	}
+	return agents
+}
+
+// Summary returns the aggregated results of each stage without their
+// timeline, latency distribution and traces.
+func Summary(results []*stats.Stage) []Stage {
+	stages := make([]Stage, 0, len(results))
+	for _, res := range results {
+		s := newStage(res)
+		s.Timeline, s.Distribution, s.Traces = nil, nil, nil
+		stages = append(stages, s)
+	}
+	return stages
+}
+
+// Duration returns the time between the first and last result of the run.
+func (r *Report) Duration() time.Duration {
+	return r.End.Sub(r.Start)
+}
+
+// stageChecks returns the checks of a stage.
+func (r *Report) stageChecks(stage string) []Check {
+	checks := []Check{}
+	for _, c := range r.Checks {
+		if c.Stage == stage {
+			checks = append(checks, c)
+		}
+	}
+	return checks
+}
@@ -0,0 +1,160 @@
+package report
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hodgesds/dlg/config"
+	"github.com/hodgesds/dlg/stats"
+	"github.com/hodgesds/dlg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testPlan() *config.Plan {
+	return &config.Plan{
+		Name: "test",
+		Tags: []string{"ci", "nightly"},
+		Seed: 42,
+		Stages: []*config.Stage{
+			{
+				Name: "get",
+				Thresholds: &config.Thresholds{
+					MaxErrorRate: util.Float64Ptr(0.05),
+					P99:          util.DurPtr(time.Millisecond),
+				},
+			},
+		},
+	}
+}
+
+// TestNew tests creating a Report from stage results.
+func TestNew(t *testing.T) {
+	r := New(testPlan(), testResults(), nil)
+	assert.Equal(t, "test", r.Plan)
+	assert.Equal(t, []string{"ci", "nightly"}, r.Tags)
+	assert.Equal(t, int64(42), r.Seed)
+	assert.Empty(t, r.Error)
+	assert.False(t, r.Passed)
+	assert.Equal(t, 1090*time.Millisecond, r.Duration())
+	require.Len(t, r.Stages, 1)
+	s := r.Stages[0]
+	assert.Equal(t, "get", s.Name)
+	assert.Equal(t, int64(100), s.Ops)
+	assert.Equal(t, int64(2), s.Errors)
+	assert.Equal(t, int64(1), s.WarmupOps)
+	assert.Equal(t, time.Millisecond, s.Latency.Min)
+	assert.Equal(t, 100*time.Millisecond, s.Latency.Max)
+	require.Len(t, r.Checks, 2)
+	assert.True(t, r.Checks[0].Pass)
+	assert.False(t, r.Checks[1].Pass)
+
+	r = New(&config.Plan{Name: "test"}, nil, errors.New("canceled"))
+	assert.Equal(t, "canceled", r.Error)
+	assert.False(t, r.Passed)
+	assert.Empty(t, r.Stages)
+}
+
+// TestWriteJSON tests the JSON encoding of a Report.
+func TestWriteJSON(t *testing.T) {
+	r := New(testPlan(), testResults(), nil)
+	var buf bytes.Buffer
+	require.NoError(t, r.WriteJSON(&buf))
+
+	var got Report
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+	assert.Equal(t, r.Plan, got.Plan)
+	assert.Equal(t, r.Checks, got.Checks)
+	assert.True(t, r.Start.Equal(got.Start))
+	require.Len(t, got.Stages, 1)
+	assert.Equal(t, r.Stages[0].Latency, got.Stages[0].Latency)
+	assert.Len(t, got.Stages[0].Timeline, 2)
+	assert.Len(t, got.Stages[0].Distribution, len(distribution))
+	assert.Equal(t, []ErrorCount{
+		{Class: "error", Count: 2, Example: "fail"},
+	}, got.Stages[0].ErrorClasses)
+
+	var again bytes.Buffer
+	require.NoError(t, got.WriteJSON(&again))
+	assert.JSONEq(t, buf.String(), again.String())
+}
+
+// TestWriteCSV tests the CSV encoding of a Report.
+func TestWriteCSV(t *testing.T) {
+	r := New(testPlan(), testResults(), nil)
+	var buf bytes.Buffer
+	require.NoError(t, r.WriteCSV(&buf))
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, csvHeader, records[0])
+	row := map[string]string{}
+	for i, col := range records[0] {
+		row[col] = records[1][i]
+	}
+	assert.Equal(t, "test", row["plan"])
+	assert.Equal(t, "ci;nightly", row["tags"])
+	assert.Equal(t, "42", row["seed"])
+	assert.Equal(t, "get", row["stage"])
+	assert.Equal(t, "100", row["ops"])
+	assert.Equal(t, "0.02", row["error_rate"])
+	assert.Equal(t, "100", row["max_ms"])
+	assert.Equal(t, "FAIL", row["thresholds"])
+}
+
+// TestWriteJUnit tests the JUnit encoding of a Report.
+func TestWriteJUnit(t *testing.T) {
+	r := New(testPlan(), testResults(), errors.New("canceled"))
+	var buf bytes.Buffer
+	require.NoError(t, r.WriteJUnit(&buf))
+
+	var got junitSuites
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &got))
+	require.Len(t, got.Suites, 1)
+	suite := got.Suites[0]
+	assert.Equal(t, "test", suite.Name)
+	assert.Equal(t, 4, suite.Tests)
+	assert.Equal(t, 2, suite.Failures)
+	require.Len(t, suite.Cases, 4)
+	assert.Equal(t, "execute", suite.Cases[0].Name)
+	assert.Equal(t, "canceled", suite.Cases[0].Failure.Message)
+	assert.Equal(t, "get", suite.Cases[1].Name)
+	assert.Nil(t, suite.Cases[1].Failure)
+	assert.Equal(t, "get error rate <= 5.00%", suite.Cases[2].Name)
+	assert.Nil(t, suite.Cases[2].Failure)
+	assert.Equal(t, "get p99 <= 1ms", suite.Cases[3].Name)
+	require.NotNil(t, suite.Cases[3].Failure)
+	assert.Equal(t, "threshold", suite.Cases[3].Failure.Type)
+}
+
+// TestAgents tests the per-agent results of a distributed plan.
+func TestAgents(t *testing.T) {
+	c := stats.NewCollector()
+	c.MergeSource("a", testResults())
+	c.MergeSource("b", testResults())
+	r := New(testPlan(), c.Stages(), nil)
+	r.Agents = Agents(c)
+	assert.Equal(t, int64(200), r.Stages[0].Ops)
+	require.Len(t, r.Agents, 2)
+	assert.Equal(t, "a", r.Agents[0].Name)
+	require.Len(t, r.Agents[0].Stages, 1)
+	s := r.Agents[0].Stages[0]
+	assert.Equal(t, int64(100), s.Ops)
+	assert.Equal(t, 100*time.Millisecond, s.Latency.Max)
+	assert.Empty(t, s.Timeline)
+
+	var buf bytes.Buffer
+	require.NoError(t, r.WriteSummary(&buf))
+	assert.Contains(t, buf.String(), "AGENT")
+	buf.Reset()
+	require.NoError(t, r.WriteHTML(&buf, testPlanYAML))
+	assert.Contains(t, buf.String(), "<h2>Agents</h2>")
+
+	assert.Nil(t, Agents(stats.NewCollector()))
+}
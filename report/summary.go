@@ -0,0 +1,204 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/hodgesds/dlg/stats"
+)
+
+// WriteSummary writes a table of the results of each stage followed by the
+// threshold checks.
+func (r *Report) WriteSummary(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "STAGE\tPROTOCOL\tOPS\tOPS/S\tERRORS\tERROR %\tP50\tP90\tP99\tMAX\tBYTES\tDURATION")
+	for _, s := range r.Stages {
+		fmt.Fprintf(
+			tw,
+			"%s\t%s\t%d\t%.2f\t%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			s.Name,
+			s.Protocol,
+			s.Ops,
+			s.Throughput,
+			s.Errors,
+			formatPercent(s.ErrorRate),
+			formatDuration(s.Latency.P50),
+			formatDuration(s.Latency.P90),
+			formatDuration(s.Latency.P99),
+			formatDuration(s.Latency.Max),
+			formatBytes(s.Bytes),
+			formatDuration(s.Duration),
+		)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	for _, s := range r.Stages {
+		if s.WarmupOps == 0 {
+			continue
+		}
+		if _, err := fmt.Fprintf(
+			w, "%s: %d warm-up operations excluded\n", s.Name, s.WarmupOps,
+		); err != nil {
+			return err
+		}
+	}
+	if err := r.writeAgents(w); err != nil {
+		return err
+	}
+	if err := r.writeErrors(w); err != nil {
+		return err
+	}
+	for _, warning := range r.Warnings {
+		if _, err := fmt.Fprintf(w, "warning: %s\n", warning); err != nil {
+			return err
+		}
+	}
+	if len(r.Checks) == 0 {
+		return nil
+	}
+
+	if _, err := fmt.Fprintln(w); err != nil {
+		return err
+	}
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "STAGE\tTHRESHOLD\tLIMIT\tVALUE\tRESULT")
+	for _, c := range r.Checks {
+		fmt.Fprintf(
+			tw,
+			"%s\t%s\t%s\t%s\t%s\n",
+			c.Stage,
+			c.Threshold,
+			c.Limit,
+			c.Value,
+			c.result(),
+		)
+	}
+	return tw.Flush()
+}
+
+// writeAgents writes a table of the results of each agent by stage.
+func (r *Report) writeAgents(w io.Writer) error {
+	if len(r.Agents) == 0 {
+		return nil
+	}
+	if _, err := fmt.Fprintln(w); err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "AGENT\tSTAGE\tOPS\tOPS/S\tERRORS\tERROR %\tP50\tP99\tMAX")
+	for _, a := range r.Agents {
+		for _, s := range a.Stages {
+			fmt.Fprintf(
+				tw,
+				"%s\t%s\t%d\t%.2f\t%d\t%s\t%s\t%s\t%s\n",
+				a.Name,
+				s.Name,
+				s.Ops,
+				s.Throughput,
+				s.Errors,
+				formatPercent(s.ErrorRate),
+				formatDuration(s.Latency.P50),
+				formatDuration(s.Latency.P99),
+				formatDuration(s.Latency.Max),
+			)
+		}
+	}
+	return tw.Flush()
+}
+
+// maxExample is the length error examples are cut to in the summary.
+const maxExample = 80
+
+// writeErrors writes a table of the failed operations of each stage by
+// operation and error class.
+func (r *Report) writeErrors(w io.Writer) error {
+	hasErrors := false
+	for _, s := range r.Stages {
+		hasErrors = hasErrors || len(s.ErrorClasses) > 0
+	}
+	if !hasErrors {
+		return nil
+	}
+	if _, err := fmt.Fprintln(w); err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "STAGE\tOP\tERROR CLASS\tCODE\tCOUNT\tEXAMPLE")
+	for _, s := range r.Stages {
+		for _, e := range s.ErrorClasses {
+			example := strings.Join(strings.Fields(e.Example), " ")
+			if len(example) > maxExample {
+				example = example[:maxExample-3] + "..."
+			}
+			fmt.Fprintf(
+				tw,
+				"%s\t%s\t%s\t%s\t%d\t%s\n",
+				s.Name,
+				orDash(e.Op),
+				e.Class,
+				orDash(e.Code),
+				e.Count,
+				example,
+			)
+		}
+	}
+	return tw.Flush()
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func formatPercent(f float64) string {
+	return fmt.Sprintf("%.2f%%", f*100)
+}
+
+func formatDuration(d time.Duration) string {
+	switch {
+	case d >= time.Second:
+		return d.Round(time.Millisecond).String()
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond).String()
+	default:
+		return d.Round(time.Microsecond).String()
+	}
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// WritePercentiles writes a table of the latency of each stage at the given
+// quantiles, where a quantile is in the range [0, 1].
+func WritePercentiles(w io.Writer, results []*stats.Stage, quantiles []float64) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprint(tw, "STAGE")
+	for _, q := range quantiles {
+		fmt.Fprintf(tw, "\tP%g", q*100)
+	}
+	fmt.Fprintln(tw)
+	for _, r := range results {
+		fmt.Fprint(tw, r.Name)
+		for _, q := range quantiles {
+			fmt.Fprintf(tw, "\t%s", formatDuration(r.Main.Latency.Quantile(q)))
+		}
+		fmt.Fprintln(tw)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,56 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestWriteSummary tests writing the summary table.
+func TestWriteSummary(t *testing.T) {
+	var buf bytes.Buffer
+	r := New(testPlan(), testResults(), nil)
+	require.NoError(t, r.WriteSummary(&buf))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 10)
+	assert.Equal(t, []string{
+		"STAGE", "PROTOCOL", "OPS", "OPS/S", "ERRORS", "ERROR", "%",
+		"P50", "P90", "P99", "MAX", "BYTES", "DURATION",
+	}, strings.Fields(lines[0]))
+	assert.Equal(t, []string{
+		"get", "http", "100", "91.74", "2", "2.00%",
+		"50.07ms", "89.65ms", "99.09ms", "100ms", "100.0KiB", "1.09s",
+	}, strings.Fields(lines[1]))
+	assert.Equal(t, "get: 1 warm-up operations excluded", lines[2])
+	assert.Equal(t, "", lines[3])
+	assert.Equal(t, []string{"STAGE", "OP", "ERROR", "CLASS", "CODE", "COUNT", "EXAMPLE"}, strings.Fields(lines[4]))
+	assert.Equal(t, []string{"get", "-", "error", "-", "2", "fail"}, strings.Fields(lines[5]))
+	assert.Equal(t, "", lines[6])
+	assert.Equal(t, []string{"get", "p99", "<=", "1ms", "99.09ms", "FAIL"}, strings.Fields(lines[9]))
+}
+
+// TestWritePercentiles tests writing a table of custom percentiles.
+func TestWritePercentiles(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WritePercentiles(&buf, testResults(), []float64{0.75, 0.999}))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.Equal(t, []string{"STAGE", "P75", "P99.9"}, strings.Fields(lines[0]))
+	assert.Equal(t, "get", strings.Fields(lines[1])[0])
+}
+
+// TestWriteSummaryWarnings tests generator warnings follow the summary
+// table.
+func TestWriteSummaryWarnings(t *testing.T) {
+	var buf bytes.Buffer
+	plan := testPlan()
+	plan.Stages[0].Thresholds = nil
+	r := New(plan, testResults(), nil)
+	r.Warnings = []string{"generator CPU usage was above 90% for 5s, peaking at 98%"}
+	require.NoError(t, r.WriteSummary(&buf))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal(t, "warning: generator CPU usage was above 90% for 5s, peaking at 98%", lines[len(lines)-1])
+}
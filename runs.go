@@ -0,0 +1,433 @@
+package dlg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hodgesds/dlg/config"
+	"github.com/hodgesds/dlg/executor"
+	"github.com/hodgesds/dlg/history"
+	"github.com/hodgesds/dlg/metrics"
+	"github.com/hodgesds/dlg/monitor"
+	"github.com/hodgesds/dlg/progress"
+	"github.com/hodgesds/dlg/report"
+	"github.com/hodgesds/dlg/stats"
+	"gopkg.in/yaml.v2"
+)
+
+// States of a run.
+const (
+	RunRunning  = "running"
+	RunPaused   = "paused"
+	RunPassed   = "passed"
+	RunFailed   = "failed"
+	RunCanceled = "canceled"
+)
+
+// keepRuns is the number of finished runs kept by Runs.
+const keepRuns = 100
+
+// ErrMaxRuns is returned when a run is started while the maximum number of
+// runs are active.
+var ErrMaxRuns = errors.New("too many active runs")
+
+// RunStatus is the state and the live results of a run.
+type RunStatus struct {
+	ID     string         `json:"id"`
+	Plan   string         `json:"plan"`
+	State  string         `json:"state"`
+	Start  time.Time      `json:"start"`
+	End    *time.Time     `json:"end,omitempty"`
+	Error  string         `json:"error,omitempty"`
+	Stages []report.Stage `json:"stages"`
+}
+
+// Run is a run of a plan.
+type Run struct {
+	ID string
+	// Plan is a copy of the plan taken when the run started, PlanYAML is
+	// the plan as YAML before its execution changed it.
+	Plan     *config.Plan
+	PlanYAML []byte
+	Start    time.Time
+
+	control   *executor.Control
+	collector *stats.Collector
+	progress  *progress.Progress
+	cancel    context.CancelFunc
+	done      chan struct{}
+
+	mu       sync.Mutex
+	end      time.Time
+	canceled bool
+	report   *report.Report
+	// subs are the event channels of subscribers, nil once the run
+	// completed.
+	subs map[chan Event]struct{}
+}
+
+// Done returns a channel that is closed when the run completes.
+func (run *Run) Done() <-chan struct{} {
+	return run.done
+}
+
+func (run *Run) finished() bool {
+	select {
+	case <-run.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Status returns the status of the run.
+func (run *Run) Status() RunStatus {
+	s := RunStatus{
+		ID:     run.ID,
+		Plan:   run.Plan.Name,
+		State:  RunRunning,
+		Start:  run.Start,
+		Stages: report.Summary(run.collector.Stages()),
+	}
+	if run.control.Paused() {
+		s.State = RunPaused
+	}
+	if !run.finished() {
+		return s
+	}
+	run.mu.Lock()
+	defer run.mu.Unlock()
+	end := run.end
+	s.End = &end
+	s.Error = run.report.Error
+	switch {
+	case run.canceled:
+		s.State = RunCanceled
+	case run.report.Passed:
+		s.State = RunPassed
+	default:
+		s.State = RunFailed
+	}
+	return s
+}
+
+// Report returns the report of the run, the report of an active run has
+// the results so far.
+func (run *Run) Report() *report.Report {
+	if run.finished() {
+		run.mu.Lock()
+		defer run.mu.Unlock()
+		return run.report
+	}
+	rep := report.New(run.Plan, run.collector.Stages(), nil)
+	rep.Agents = report.Agents(run.collector)
+	return rep
+}
+
+// Pause pauses the operations of an active run.
+func (run *Run) Pause() error {
+	if run.finished() {
+		return fmt.Errorf("run %s is not active", run.ID)
+	}
+	run.progress.Pause()
+	return nil
+}
+
+// Resume resumes the operations of a paused run.
+func (run *Run) Resume() error {
+	if run.finished() {
+		return fmt.Errorf("run %s is not active", run.ID)
+	}
+	run.progress.Resume()
+	return nil
+}
+
+// Cancel cancels an active run.
+func (run *Run) Cancel() error {
+	if run.finished() {
+		return fmt.Errorf("run %s is not active", run.ID)
+	}
+	run.mu.Lock()
+	run.canceled = true
+	run.mu.Unlock()
+	run.cancel()
+	return nil
+}
+
+func (run *Run) execute(
+	ctx context.Context,
+	m Manager,
+	scopes *metrics.Scopes,
+	recorders ...stats.Recorder,
+) {
+	watched := make(chan struct{})
+	go func() {
+		defer close(watched)
+		run.watch()
+	}()
+	defer func() { <-watched }()
+	defer close(run.done)
+	defer run.cancel()
+	rep, err := execute(
+		executor.WithControl(ctx, run.control),
+		m, scopes, run.Plan, run.ID, run.collector, append(recorders, run.progress)...,
+	)
+	if err != nil {
+		rep = report.New(run.Plan, nil, err)
+	}
+	run.mu.Lock()
+	defer run.mu.Unlock()
+	run.end = time.Now()
+	run.report = rep
+}
+
+// historyRun returns the history record of a finished run.
+func (run *Run) historyRun() *history.Run {
+	status := run.Status()
+	return &history.Run{
+		ID:       run.ID,
+		Plan:     run.Plan.Name,
+		Tags:     run.Plan.Tags,
+		Seed:     run.Plan.Seed,
+		State:    status.State,
+		Error:    status.Error,
+		Start:    status.Start,
+		End:      *status.End,
+		PlanYAML: string(run.PlanYAML),
+		Report:   run.Report(),
+	}
+}
+
+// RunsConfig configures Runs.
+type RunsConfig struct {
+	// MaxRuns is the maximum number of concurrent runs, 0 for no limit.
+	MaxRuns int
+	// Scopes are the metrics scopes runs are added to, it may be nil.
+	Scopes *metrics.Scopes
+	// History stores finished runs if it is not nil, ResultSample is the
+	// fraction of the results of a run stored in its result log.
+	History      *history.Store
+	ResultSample float64
+}
+
+// Runs executes the plans of a Manager in the background and keeps the
+// active and the last finished runs.
+type Runs struct {
+	m      Manager
+	conf   RunsConfig
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu     sync.Mutex
+	runs   map[string]*Run
+	order  []string
+	active int
+}
+
+// NewRuns returns a new Runs.
+func NewRuns(m Manager, conf RunsConfig) *Runs {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Runs{
+		m:      m,
+		conf:   conf,
+		ctx:    ctx,
+		cancel: cancel,
+		runs:   map[string]*Run{},
+	}
+}
+
+// Start starts a run of a plan.
+func (r *Runs) Start(ctx context.Context, name string) (*Run, error) {
+	plan, err := r.m.Get(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if plan, err = plan.Copy(); err != nil {
+		return nil, err
+	}
+	planYAML, err := yaml.Marshal(plan)
+	if err != nil {
+		return nil, err
+	}
+	runCtx, cancel := context.WithCancel(r.ctx)
+	control := executor.NewControl()
+	run := &Run{
+		ID:        uuid.New().String(),
+		Plan:      plan,
+		PlanYAML:  planYAML,
+		Start:     time.Now(),
+		control:   control,
+		collector: stats.NewCollector(),
+		progress:  progress.New(plan, control),
+		cancel:    cancel,
+		done:      make(chan struct{}),
+		subs:      map[chan Event]struct{}{},
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.conf.MaxRuns > 0 && r.active >= r.conf.MaxRuns {
+		cancel()
+		return nil, ErrMaxRuns
+	}
+	r.runs[run.ID] = run
+	r.order = append(r.order, run.ID)
+	r.active++
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		r.run(runCtx, run)
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.active--
+		r.prune()
+	}()
+	return run, nil
+}
+
+// run executes a run and stores it in the history.
+func (r *Runs) run(ctx context.Context, run *Run) {
+	if r.conf.History == nil {
+		run.execute(ctx, r.m, r.conf.Scopes)
+		return
+	}
+	var (
+		results   *os.File
+		resultLog *stats.LogWriter
+		recorders []stats.Recorder
+	)
+	if r.conf.ResultSample > 0 {
+		f, err := os.CreateTemp("", "dlg-results-*.jsonl.gz")
+		if err != nil {
+			log.Printf("run %s: result log: %v", run.ID, err)
+		} else {
+			defer os.Remove(f.Name())
+			defer f.Close()
+			results = f
+			resultLog = stats.NewLogWriter(f, r.conf.ResultSample, true)
+			recorders = append(recorders, resultLog)
+		}
+	}
+	run.execute(ctx, r.m, r.conf.Scopes, recorders...)
+
+	var logData []byte
+	if resultLog != nil {
+		err := resultLog.Close()
+		if err == nil {
+			logData, err = os.ReadFile(results.Name())
+		}
+		if err != nil {
+			log.Printf("run %s: result log: %v", run.ID, err)
+			logData = nil
+		}
+	}
+	if err := r.conf.History.Put(run.historyRun(), logData); err != nil {
+		log.Printf("run %s: history: %v", run.ID, err)
+	}
+}
+
+// prune removes the oldest finished runs beyond keepRuns.
+func (r *Runs) prune() {
+	remove := len(r.order) - r.active - keepRuns
+	if remove <= 0 {
+		return
+	}
+	order := r.order[:0]
+	for _, id := range r.order {
+		if remove > 0 && r.runs[id].finished() {
+			delete(r.runs, id)
+			remove--
+			continue
+		}
+		order = append(order, id)
+	}
+	r.order = order
+}
+
+// Get returns a run by ID.
+func (r *Runs) Get(id string) (*Run, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	run, ok := r.runs[id]
+	if !ok {
+		return nil, fmt.Errorf("no such run: %q", id)
+	}
+	return run, nil
+}
+
+// List returns the runs ordered by start time.
+func (r *Runs) List() []*Run {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	runs := make([]*Run, 0, len(r.order))
+	for _, id := range r.order {
+		runs = append(runs, r.runs[id])
+	}
+	return runs
+}
+
+// Close cancels the active runs and waits for them to complete.
+func (r *Runs) Close() error {
+	r.cancel()
+	r.wg.Wait()
+	return nil
+}
+
+// execute executes a plan with a monitor, a metrics scope and the metrics
+// sinks of the plan and returns its report. The results are recorded to
+// collector and recorders, errors are returned if the run could not be set
+// up.
+func execute(
+	ctx context.Context,
+	m Manager,
+	scopes *metrics.Scopes,
+	plan *config.Plan,
+	runID string,
+	collector *stats.Collector,
+	recorders ...stats.Recorder,
+) (*report.Report, error) {
+	mon := monitor.New(plan, executor.ControlFromContext(ctx))
+	if scopes != nil {
+		scope, err := metrics.NewRunScope(plan, runID)
+		if err == nil {
+			err = scope.Register(mon)
+		}
+		if err != nil {
+			return nil, err
+		}
+		scopes.Add(scope)
+		defer scopes.Finish(scope)
+	}
+	rec := stats.MultiRecorder(append([]stats.Recorder{collector}, recorders...)...)
+	var stopSinks func() error
+	if plan.Metrics != nil && len(plan.Metrics.Sinks) > 0 {
+		labels := metrics.RunLabels(plan, runID)
+		sinks, stop, err := metrics.StartSinks(ctx, plan.Metrics.Sinks, labels)
+		if err != nil {
+			return nil, err
+		}
+		rec = stats.MultiRecorder(rec, sinks)
+		stopSinks = stop
+	}
+	ctx, cancel := context.WithCancel(stats.NewContext(ctx, rec))
+	defer cancel()
+	go mon.Run(ctx)
+	err := m.Execute(ctx, plan)
+	cancel()
+	if stopSinks != nil {
+		if err := stopSinks(); err != nil {
+			log.Printf("metrics sinks: %v", err)
+		}
+	}
+	rep := report.New(plan, collector.Stages(), err)
+	rep.Warnings = mon.Warnings()
+	rep.Agents = report.Agents(collector)
+	return rep, nil
+}
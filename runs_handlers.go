@@ -0,0 +1,218 @@
+package dlg
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// upgrader upgrades event streams to WebSockets, it only accepts connections
+// from pages of the same origin.
+var upgrader = websocket.Upgrader{}
+
+// runsRouter is a Runs HTTP Router.
+type runsRouter struct {
+	runs *Runs
+}
+
+// NewRunsRouter adds the routes to start runs of plans and follow and
+// control them to e.
+func NewRunsRouter(e *gin.Engine, runs *Runs) {
+	r := &runsRouter{runs: runs}
+	e.POST("/plan/:name/runs", r.Start)
+	e.POST("/plan/:name/execute", r.Execute)
+	e.GET("/runs", r.List)
+	e.GET("/runs/:id", r.Get)
+	e.POST("/runs/:id/pause", r.Pause)
+	e.POST("/runs/:id/resume", r.Resume)
+	e.POST("/runs/:id/cancel", r.Cancel)
+	e.GET("/runs/:id/report", r.Report)
+	e.GET("/runs/:id/events", r.Events)
+	e.GET("/runs/:id/ws", r.WebSocket)
+}
+
+// Start starts a run of a plan and returns its status.
+func (r *runsRouter) Start(c *gin.Context) {
+	run, ok := r.start(c)
+	if !ok {
+		return
+	}
+	c.JSON(202, run.Status())
+}
+
+// Execute starts a run of a plan, waits for it to complete and returns its
+// report. The run is canceled if the request is.
+func (r *runsRouter) Execute(c *gin.Context) {
+	run, ok := r.start(c)
+	if !ok {
+		return
+	}
+	select {
+	case <-run.Done():
+	case <-c.Request.Context().Done():
+		run.Cancel()
+		<-run.Done()
+	}
+	c.JSON(200, run.Report())
+}
+
+func (r *runsRouter) start(c *gin.Context) (*Run, bool) {
+	run, err := r.runs.Start(c, c.Param("name"))
+	if errors.Is(err, ErrMaxRuns) {
+		c.JSON(429, gin.H{"msg": err.Error()})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(400, gin.H{"msg": err.Error()})
+		return nil, false
+	}
+	return run, true
+}
+
+// List returns the status of the runs.
+func (r *runsRouter) List(c *gin.Context) {
+	runs := r.runs.List()
+	statuses := make([]RunStatus, len(runs))
+	for i, run := range runs {
+		statuses[i] = run.Status()
+	}
+	c.JSON(200, statuses)
+}
+
+// Get returns the status of a run.
+func (r *runsRouter) Get(c *gin.Context) {
+	run, err := r.runs.Get(c.Param("id"))
+	if err != nil {
+		c.JSON(404, gin.H{"msg": err.Error()})
+		return
+	}
+	c.JSON(200, run.Status())
+}
+
+// Pause pauses a run.
+func (r *runsRouter) Pause(c *gin.Context) {
+	r.control(c, (*Run).Pause)
+}
+
+// Resume resumes a paused run.
+func (r *runsRouter) Resume(c *gin.Context) {
+	r.control(c, (*Run).Resume)
+}
+
+// Cancel cancels a run.
+func (r *runsRouter) Cancel(c *gin.Context) {
+	r.control(c, (*Run).Cancel)
+}
+
+func (r *runsRouter) control(c *gin.Context, f func(*Run) error) {
+	run, err := r.runs.Get(c.Param("id"))
+	if err != nil {
+		c.JSON(404, gin.H{"msg": err.Error()})
+		return
+	}
+	if err := f(run); err != nil {
+		c.JSON(409, gin.H{"msg": err.Error()})
+		return
+	}
+	c.JSON(200, run.Status())
+}
+
+// Report returns the report of a run as JSON, or as HTML with format=html.
+func (r *runsRouter) Report(c *gin.Context) {
+	run, err := r.runs.Get(c.Param("id"))
+	if err != nil {
+		c.JSON(404, gin.H{"msg": err.Error()})
+		return
+	}
+	rep := run.Report()
+	switch c.DefaultQuery("format", "json") {
+	case "json":
+		c.JSON(200, rep)
+	case "html":
+		c.Header("Content-Type", "text/html; charset=utf-8")
+		c.Status(200)
+		if err := rep.WriteHTML(c.Writer, run.PlanYAML); err != nil {
+			c.Error(err)
+		}
+	default:
+		c.JSON(400, gin.H{"msg": "format must be json or html"})
+	}
+}
+
+// Events streams the events of a run as Server-Sent Events, starting with a
+// snapshot of the run.
+func (r *runsRouter) Events(c *gin.Context) {
+	run, err := r.runs.Get(c.Param("id"))
+	if err != nil {
+		c.JSON(404, gin.H{"msg": err.Error()})
+		return
+	}
+	snap, events, cancel := run.Subscribe()
+	defer cancel()
+	c.Header("Cache-Control", "no-cache")
+	c.SSEvent(snap.Type, snap)
+	c.Writer.Flush()
+	for {
+		select {
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+			c.SSEvent(e.Type, e)
+			c.Writer.Flush()
+		case <-c.Request.Context().Done():
+			return
+		}
+	}
+}
+
+// WebSocket streams the events of a run as JSON messages over a WebSocket,
+// starting with a snapshot of the run. The connection is closed after the
+// done event.
+func (r *runsRouter) WebSocket(c *gin.Context) {
+	run, err := r.runs.Get(c.Param("id"))
+	if err != nil {
+		c.JSON(404, gin.H{"msg": err.Error()})
+		return
+	}
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	snap, events, cancel := run.Subscribe()
+	defer cancel()
+
+	// Messages from the client are discarded, reading detects when it
+	// closes the connection.
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+	if err := conn.WriteJSON(snap); err != nil {
+		return
+	}
+	for {
+		select {
+		case e, ok := <-events:
+			if !ok {
+				conn.WriteMessage(
+					websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+				)
+				return
+			}
+			if err := conn.WriteJSON(e); err != nil {
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
@@ -0,0 +1,359 @@
+package dlg
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hodgesds/dlg/config"
+	httpconf "github.com/hodgesds/dlg/config/http"
+	"github.com/hodgesds/dlg/executor"
+	"github.com/hodgesds/dlg/history"
+	"github.com/hodgesds/dlg/stats"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testRunExec records an operation, plans named block then run until they
+// are canceled.
+type testRunExec struct{}
+
+func (testRunExec) Execute(ctx context.Context, p *config.Plan) error {
+	if executor.ControlFromContext(ctx) == nil {
+		return errors.New("run without control")
+	}
+	stats.FromContext(ctx).Record(stats.Result{
+		Time:     time.Now(),
+		Stage:    "get",
+		Protocol: "http",
+		Latency:  time.Millisecond,
+	})
+	if p.Name == "block" {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return nil
+}
+
+func testRuns(t *testing.T, max int) *Runs {
+	m := NewManager(testRunExec{})
+	for _, name := range []string{"test", "block"} {
+		require.NoError(t, m.Add(context.Background(), &config.Plan{Name: name}))
+	}
+	runs := NewRuns(m, RunsConfig{MaxRuns: max})
+	t.Cleanup(func() { runs.Close() })
+	return runs
+}
+
+func waitRun(t *testing.T, run *Run) {
+	select {
+	case <-run.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("run did not complete")
+	}
+}
+
+// TestRunsStart tests runs execute in the background.
+func TestRunsStart(t *testing.T) {
+	runs := testRuns(t, 0)
+	_, err := runs.Start(context.Background(), "missing")
+	require.Error(t, err)
+
+	run, err := runs.Start(context.Background(), "test")
+	require.NoError(t, err)
+	waitRun(t, run)
+	status := run.Status()
+	assert.Equal(t, RunPassed, status.State)
+	assert.Equal(t, "test", status.Plan)
+	require.NotNil(t, status.End)
+	require.Len(t, status.Stages, 1)
+	assert.Equal(t, int64(1), status.Stages[0].Ops)
+	assert.True(t, run.Report().Passed)
+
+	got, err := runs.Get(run.ID)
+	require.NoError(t, err)
+	assert.Equal(t, run, got)
+	assert.Equal(t, []*Run{run}, runs.List())
+	_, err = runs.Get("missing")
+	require.EqualError(t, err, `no such run: "missing"`)
+}
+
+// TestRunsControl tests pausing, resuming and canceling a run.
+func TestRunsControl(t *testing.T) {
+	runs := testRuns(t, 0)
+	run, err := runs.Start(context.Background(), "block")
+	require.NoError(t, err)
+	require.NoError(t, run.Pause())
+	assert.Equal(t, RunPaused, run.Status().State)
+	assert.True(t, run.control.Paused())
+	require.NoError(t, run.Resume())
+	assert.Equal(t, RunRunning, run.Status().State)
+	require.Eventually(t, func() bool {
+		return len(run.Report().Stages) == 1
+	}, time.Second, time.Millisecond)
+
+	require.NoError(t, run.Cancel())
+	waitRun(t, run)
+	status := run.Status()
+	assert.Equal(t, RunCanceled, status.State)
+	assert.Equal(t, "context canceled", status.Error)
+	assert.Error(t, run.Pause())
+	assert.Error(t, run.Cancel())
+}
+
+// TestRunsMax tests the limit of concurrent runs.
+func TestRunsMax(t *testing.T) {
+	runs := testRuns(t, 1)
+	run, err := runs.Start(context.Background(), "block")
+	require.NoError(t, err)
+	_, err = runs.Start(context.Background(), "test")
+	require.Equal(t, ErrMaxRuns, err)
+
+	require.NoError(t, run.Cancel())
+	waitRun(t, run)
+	require.Eventually(t, func() bool {
+		_, err := runs.Start(context.Background(), "test")
+		return err == nil
+	}, time.Second, time.Millisecond)
+}
+
+// clientStage is a stage executor with a client cache, stages named long
+// wait until released before they check their client is still open.
+type clientStage struct {
+	*executor.ClientCache[string, *atomic.Bool]
+	started chan struct{}
+	release chan struct{}
+}
+
+func (s *clientStage) Execute(ctx context.Context, stage *config.Stage) error {
+	closed, release, err := s.Get(ctx, "a", func(context.Context) (*atomic.Bool, error) {
+		return &atomic.Bool{}, nil
+	})
+	if err != nil {
+		return err
+	}
+	defer release()
+	if stage.Name == "long" {
+		close(s.started)
+		<-s.release
+	}
+	if closed.Load() {
+		return errors.New("client is closed")
+	}
+	return nil
+}
+
+// TestRunsConcurrentClients tests a run that completes does not close the
+// clients of another active run of the same plan executor.
+func TestRunsConcurrentClients(t *testing.T) {
+	stage := &clientStage{
+		ClientCache: executor.NewClientCache[string](func(closed *atomic.Bool) error {
+			closed.Store(true)
+			return nil
+		}),
+		started: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+	planExec, err := executor.NewPlan(executor.Params{}, stage)
+	require.NoError(t, err)
+	m := NewManager(planExec)
+	for _, name := range []string{"long", "short"} {
+		require.NoError(t, m.Add(context.Background(), &config.Plan{
+			Name:   name,
+			Stages: []*config.Stage{{Name: name, HTTP: &httpconf.Config{}}},
+		}))
+	}
+	runs := NewRuns(m, RunsConfig{MaxRuns: 2})
+	defer runs.Close()
+
+	long, err := runs.Start(context.Background(), "long")
+	require.NoError(t, err)
+	<-stage.started
+	short, err := runs.Start(context.Background(), "short")
+	require.NoError(t, err)
+	waitRun(t, short)
+	assert.Equal(t, RunPassed, short.Status().State)
+	close(stage.release)
+	waitRun(t, long)
+	assert.Equal(t, RunPassed, long.Status().State, long.Status().Error)
+}
+
+// TestRunsPrune tests only the last finished runs are kept.
+func TestRunsPrune(t *testing.T) {
+	runs := testRuns(t, 0)
+	first, err := runs.Start(context.Background(), "test")
+	require.NoError(t, err)
+	waitRun(t, first)
+	for i := 0; i < keepRuns; i++ {
+		run, err := runs.Start(context.Background(), "test")
+		require.NoError(t, err)
+		waitRun(t, run)
+	}
+	require.Eventually(t, func() bool {
+		return len(runs.List()) == keepRuns
+	}, time.Second, time.Millisecond)
+	_, err = runs.Get(first.ID)
+	require.Error(t, err)
+}
+
+// TestRunsRouter tests the HTTP API of runs.
+func TestRunsRouter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	runs := testRuns(t, 0)
+	e := gin.New()
+	NewRunsRouter(e, runs)
+	do := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+
+	w := do(http.MethodPost, "/plan/block/runs")
+	require.Equal(t, 202, w.Code)
+	var status RunStatus
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &status))
+	assert.Equal(t, "block", status.Plan)
+	assert.Equal(t, 400, do(http.MethodPost, "/plan/missing/runs").Code)
+
+	w = do(http.MethodPost, "/runs/"+status.ID+"/pause")
+	require.Equal(t, 200, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &status))
+	assert.Equal(t, RunPaused, status.State)
+
+	w = do(http.MethodGet, "/runs")
+	require.Equal(t, 200, w.Code)
+	var statuses []RunStatus
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &statuses))
+	require.Len(t, statuses, 1)
+	assert.Equal(t, status.ID, statuses[0].ID)
+
+	require.Equal(t, 200, do(http.MethodPost, "/runs/"+status.ID+"/cancel").Code)
+	run, err := runs.Get(status.ID)
+	require.NoError(t, err)
+	waitRun(t, run)
+	assert.Equal(t, 409, do(http.MethodPost, "/runs/"+status.ID+"/resume").Code)
+	w = do(http.MethodGet, "/runs/"+status.ID)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &status))
+	assert.Equal(t, RunCanceled, status.State)
+
+	w = do(http.MethodGet, "/runs/"+status.ID+"/report")
+	require.Equal(t, 200, w.Code)
+	assert.Contains(t, w.Body.String(), `"plan":"block"`)
+	w = do(http.MethodGet, "/runs/"+status.ID+"/report?format=html")
+	require.Equal(t, 200, w.Code)
+	assert.True(t, strings.HasPrefix(w.Header().Get("Content-Type"), "text/html"))
+	assert.Equal(t, 400, do(http.MethodGet, "/runs/"+status.ID+"/report?format=xml").Code)
+	assert.Equal(t, 404, do(http.MethodGet, "/runs/missing").Code)
+}
+
+// repeatExec uses up the repeats of the stages of a plan like the stage
+// executor.
+type repeatExec struct{}
+
+func (repeatExec) Execute(ctx context.Context, p *config.Plan) error {
+	for _, s := range p.Stages {
+		s.Repeat = 0
+	}
+	return nil
+}
+
+// TestRunsRouterExecute tests executing a plan runs it to completion, leaves
+// the stored plan unchanged and reports the plan as it was run.
+func TestRunsRouterExecute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	m := NewManager(repeatExec{})
+	require.NoError(t, m.Add(context.Background(), &config.Plan{
+		Name:   "test",
+		Stages: []*config.Stage{{Name: "get", Repeat: 3}},
+	}))
+	runs := NewRuns(m, RunsConfig{})
+	defer runs.Close()
+	e := gin.New()
+	NewRunsRouter(e, runs)
+
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/plan/test/execute", nil))
+		require.Equal(t, 200, w.Code)
+		assert.Contains(t, w.Body.String(), `"passed":true`)
+	}
+	plan, err := m.Get(context.Background(), "test")
+	require.NoError(t, err)
+	assert.Equal(t, 3, plan.Stages[0].Repeat)
+
+	run := runs.List()[0]
+	assert.Equal(t, RunPassed, run.Status().State)
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/runs/"+run.ID+"/report?format=html", nil))
+	require.Equal(t, 200, w.Code)
+	assert.Contains(t, w.Body.String(), "repeat: 3")
+}
+
+// TestRunsHistory tests finished runs are stored in the history with their
+// result log.
+func TestRunsHistory(t *testing.T) {
+	store, err := history.Open(filepath.Join(t.TempDir(), "history.db"), history.Retention{})
+	require.NoError(t, err)
+	defer store.Close()
+	m := NewManager(testRunExec{})
+	require.NoError(t, m.Add(context.Background(), &config.Plan{Name: "test", Seed: 42}))
+	runs := NewRuns(m, RunsConfig{History: store, ResultSample: 1})
+	defer runs.Close()
+
+	run, err := runs.Start(context.Background(), "test")
+	require.NoError(t, err)
+	waitRun(t, run)
+	var h *history.Run
+	require.Eventually(t, func() bool {
+		h, err = store.Get(run.ID)
+		return err == nil
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, RunPassed, h.State)
+	assert.Equal(t, int64(42), h.Seed)
+	assert.Contains(t, h.PlanYAML, "name: test")
+	require.NotNil(t, h.Report)
+	assert.True(t, h.Report.Passed)
+	require.True(t, h.ResultLog)
+
+	resultLog, err := store.ResultLog(run.ID)
+	require.NoError(t, err)
+	collector := stats.NewCollector()
+	_, err = stats.ReadLog(bytes.NewReader(resultLog), collector)
+	require.NoError(t, err)
+	require.Len(t, collector.Stages(), 1)
+	assert.Equal(t, int64(1), collector.Stages()[0].Main.Ops)
+}
+
+// TestRunsHistoryPlan tests the history stores the plan as it was run,
+// before its execution changed it.
+func TestRunsHistoryPlan(t *testing.T) {
+	store, err := history.Open(filepath.Join(t.TempDir(), "history.db"), history.Retention{})
+	require.NoError(t, err)
+	defer store.Close()
+	m := NewManager(repeatExec{})
+	require.NoError(t, m.Add(context.Background(), &config.Plan{
+		Name:   "test",
+		Stages: []*config.Stage{{Name: "get", Repeat: 3}},
+	}))
+	runs := NewRuns(m, RunsConfig{History: store})
+	defer runs.Close()
+
+	run, err := runs.Start(context.Background(), "test")
+	require.NoError(t, err)
+	waitRun(t, run)
+	var h *history.Run
+	require.Eventually(t, func() bool {
+		h, err = store.Get(run.ID)
+		return err == nil
+	}, time.Second, time.Millisecond)
+	assert.Contains(t, h.PlanYAML, "repeat: 3")
+}
@@ -0,0 +1,221 @@
+package dlg
+
+import (
+	"context"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hodgesds/dlg/config"
+)
+
+// ScheduleInterval is the interval the scheduler checks for plans with due
+// runs at.
+const ScheduleInterval = time.Second
+
+// ScheduledPlan is the schedule of a plan and its next run.
+type ScheduledPlan struct {
+	Plan     string    `json:"plan"`
+	Cron     string    `json:"cron,omitempty"`
+	Every    string    `json:"every,omitempty"`
+	TimeZone string    `json:"timeZone,omitempty"`
+	Overlap  string    `json:"overlap"`
+	Next     time.Time `json:"next"`
+	LastRun  string    `json:"lastRun,omitempty"`
+	Queued   bool      `json:"queued"`
+}
+
+// scheduled is the state of a scheduled plan.
+type scheduled struct {
+	schedule config.Schedule
+	next     time.Time
+	// last is the last run started by the scheduler and queued is set if
+	// a run is due once it completes, or once it stopped if it was
+	// canceled.
+	last   *Run
+	queued bool
+}
+
+// Scheduler starts runs of the plans of a Manager that have a schedule.
+type Scheduler struct {
+	runs   *Runs
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu      sync.Mutex
+	entries map[string]*scheduled
+}
+
+// NewScheduler returns a scheduler that starts runs of the scheduled plans
+// of the manager of runs. The plans are checked every ScheduleInterval, so
+// added, changed and deleted schedules are picked up. Runs that were due
+// while the scheduler was not running are not started.
+func NewScheduler(runs *Runs) *Scheduler {
+	return newScheduler(runs, ScheduleInterval)
+}
+
+func newScheduler(runs *Runs, interval time.Duration) *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Scheduler{
+		runs:    runs,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+		entries: map[string]*scheduled{},
+	}
+	go s.loop(ctx, interval)
+	return s
+}
+
+func (s *Scheduler) loop(ctx context.Context, interval time.Duration) {
+	defer close(s.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		s.tick(ctx, time.Now())
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// tick syncs the schedules with the plans and starts the runs due at now.
+func (s *Scheduler) tick(ctx context.Context, now time.Time) {
+	plans, err := s.runs.m.Plans(ctx)
+	if err != nil {
+		log.Printf("scheduler: %v", err)
+		return
+	}
+	// Runs are started after the lock is released, starting a run gets
+	// the plan from the manager which may be remote.
+	for _, name := range s.due(plans, now) {
+		run, err := s.runs.Start(ctx, name)
+		if err != nil {
+			log.Printf("scheduler: plan %s: %v", name, err)
+			continue
+		}
+		s.mu.Lock()
+		if e, ok := s.entries[name]; ok {
+			e.last = run
+		}
+		s.mu.Unlock()
+	}
+}
+
+// due syncs the schedules with the plans and returns the names of the
+// plans with a run to start at now.
+func (s *Scheduler) due(plans []*config.Plan, now time.Time) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	names := map[string]struct{}{}
+	for _, plan := range plans {
+		if plan.Schedule == nil {
+			continue
+		}
+		names[plan.Name] = struct{}{}
+		e, ok := s.entries[plan.Name]
+		if ok && sameSchedule(&e.schedule, plan.Schedule) {
+			continue
+		}
+		next, err := plan.Schedule.Next(now)
+		if err != nil {
+			log.Printf("scheduler: plan %s: %v", plan.Name, err)
+			continue
+		}
+		if !ok {
+			e = &scheduled{}
+			s.entries[plan.Name] = e
+		}
+		e.schedule = *plan.Schedule
+		e.next = next
+		e.queued = false
+	}
+	for name := range s.entries {
+		if _, ok := names[name]; !ok {
+			delete(s.entries, name)
+		}
+	}
+
+	var due []string
+	for name, e := range s.entries {
+		active := e.last != nil && !e.last.finished()
+		start := e.queued && !active
+		if start {
+			e.queued = false
+		}
+		if !e.next.IsZero() && !now.Before(e.next) {
+			if next, err := e.schedule.Next(now); err == nil {
+				e.next = next
+			}
+			switch {
+			case !active && !start:
+				start = true
+			case e.schedule.Overlap == config.OverlapQueue:
+				e.queued = true
+			case start:
+				// The queued run that starts now is the due run.
+			case e.schedule.Overlap == config.OverlapCancelPrevious:
+				// The due run starts on a later tick once the canceled
+				// run stopped, the lock is not held while it stops.
+				e.last.Cancel()
+				e.queued = true
+			default:
+				log.Printf("scheduler: plan %s: skipped run, run %s is active", name, e.last.ID)
+			}
+		}
+		if start {
+			due = append(due, name)
+		}
+	}
+	return due
+}
+
+func sameSchedule(a, b *config.Schedule) bool {
+	if (a.Every == nil) != (b.Every == nil) || (a.Every != nil && *a.Every != *b.Every) {
+		return false
+	}
+	return a.Cron == b.Cron && a.TimeZone == b.TimeZone && a.Overlap == b.Overlap
+}
+
+// Plans returns the scheduled plans ordered by their next run.
+func (s *Scheduler) Plans() []ScheduledPlan {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	plans := make([]ScheduledPlan, 0, len(s.entries))
+	for name, e := range s.entries {
+		p := ScheduledPlan{
+			Plan:     name,
+			Cron:     e.schedule.Cron,
+			TimeZone: e.schedule.TimeZone,
+			Overlap:  e.schedule.Overlap,
+			Next:     e.next,
+			Queued:   e.queued,
+		}
+		if p.Overlap == "" {
+			p.Overlap = config.OverlapSkip
+		}
+		if e.schedule.Every != nil {
+			p.Every = e.schedule.Every.String()
+		}
+		if e.last != nil {
+			p.LastRun = e.last.ID
+		}
+		plans = append(plans, p)
+	}
+	sort.Slice(plans, func(i, j int) bool {
+		if plans[i].Next.Equal(plans[j].Next) {
+			return plans[i].Plan < plans[j].Plan
+		}
+		return plans[i].Next.Before(plans[j].Next)
+	})
+	return plans
+}
+
+// Close stops the scheduler, active runs are not canceled.
+func (s *Scheduler) Close() error {
+	s.cancel()
+	<-s.done
+	return nil
+}
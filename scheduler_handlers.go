@@ -0,0 +1,21 @@
+package dlg
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// schedulerRouter is a Scheduler HTTP Router.
+type schedulerRouter struct {
+	s *Scheduler
+}
+
+// NewSchedulerRouter adds the route to list the scheduled plans to e.
+func NewSchedulerRouter(e *gin.Engine, s *Scheduler) {
+	r := &schedulerRouter{s: s}
+	e.GET("/schedules", r.List)
+}
+
+// List returns the scheduled plans ordered by their next run.
+func (r *schedulerRouter) List(c *gin.Context) {
+	c.JSON(200, r.s.Plans())
+}
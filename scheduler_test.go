@@ -0,0 +1,189 @@
+package dlg
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hodgesds/dlg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testScheduler returns a scheduler of a plan named block that is due
+// every 20ms.
+func testScheduler(t *testing.T, overlap string) (*Scheduler, *Runs) {
+	every := 20 * time.Millisecond
+	m := NewManager(testRunExec{})
+	require.NoError(t, m.Add(context.Background(), &config.Plan{
+		Name:     "block",
+		Schedule: &config.Schedule{Every: &every, Overlap: overlap},
+	}))
+	runs := NewRuns(m, RunsConfig{})
+	t.Cleanup(func() { runs.Close() })
+	s := newScheduler(runs, 5*time.Millisecond)
+	t.Cleanup(func() { s.Close() })
+	return s, runs
+}
+
+// firstRun waits for the first run started by the scheduler.
+func firstRun(t *testing.T, runs *Runs) *Run {
+	require.Eventually(t, func() bool {
+		return len(runs.List()) > 0
+	}, 5*time.Second, time.Millisecond)
+	return runs.List()[0]
+}
+
+// TestSchedulerSkip tests runs that are due while the previous run is
+// active are skipped.
+func TestSchedulerSkip(t *testing.T) {
+	s, runs := testScheduler(t, config.OverlapSkip)
+	run := firstRun(t, runs)
+	time.Sleep(100 * time.Millisecond)
+	require.Len(t, runs.List(), 1)
+	plans := s.Plans()
+	require.Len(t, plans, 1)
+	assert.Equal(t, run.ID, plans[0].LastRun)
+	assert.False(t, plans[0].Queued)
+
+	require.NoError(t, run.Cancel())
+	require.Eventually(t, func() bool {
+		return len(runs.List()) == 2
+	}, 5*time.Second, time.Millisecond)
+}
+
+// TestSchedulerQueue tests a run that is due while the previous run is
+// active starts once it completes.
+func TestSchedulerQueue(t *testing.T) {
+	s, runs := testScheduler(t, config.OverlapQueue)
+	run := firstRun(t, runs)
+	require.Eventually(t, func() bool {
+		return s.Plans()[0].Queued
+	}, 5*time.Second, time.Millisecond)
+	require.Len(t, runs.List(), 1)
+
+	require.NoError(t, run.Cancel())
+	require.Eventually(t, func() bool {
+		return len(runs.List()) == 2
+	}, 5*time.Second, time.Millisecond)
+	assert.NotEqual(t, run.ID, s.Plans()[0].LastRun)
+}
+
+// TestSchedulerCancelPrevious tests the previous run is canceled when a run
+// is due and the due run starts once it stopped.
+func TestSchedulerCancelPrevious(t *testing.T) {
+	s, runs := testScheduler(t, config.OverlapCancelPrevious)
+	run := firstRun(t, runs)
+	waitRun(t, run)
+	assert.Equal(t, RunCanceled, run.Status().State)
+	require.Eventually(t, func() bool {
+		return len(runs.List()) >= 2
+	}, 5*time.Second, time.Millisecond)
+	assert.NotEqual(t, run.ID, s.Plans()[0].LastRun)
+}
+
+// TestSchedulerPlans tests schedules follow the plans of the manager.
+func TestSchedulerPlans(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	m := NewManager(testRunExec{})
+	ctx := context.Background()
+	require.NoError(t, m.Add(ctx, &config.Plan{
+		Name:     "yearly",
+		Schedule: &config.Schedule{Cron: "@yearly", TimeZone: "Europe/Berlin"},
+	}))
+	require.NoError(t, m.Add(ctx, &config.Plan{Name: "test"}))
+	runs := NewRuns(m, RunsConfig{})
+	defer runs.Close()
+	s := newScheduler(runs, 5*time.Millisecond)
+	defer s.Close()
+	e := gin.New()
+	NewSchedulerRouter(e, s)
+
+	require.Eventually(t, func() bool {
+		return len(s.Plans()) == 1
+	}, 5*time.Second, time.Millisecond)
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/schedules", nil))
+	require.Equal(t, 200, w.Code)
+	var plans []ScheduledPlan
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &plans))
+	require.Len(t, plans, 1)
+	assert.Equal(t, "yearly", plans[0].Plan)
+	assert.Equal(t, config.OverlapSkip, plans[0].Overlap)
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	next := plans[0].Next.In(berlin)
+	assert.True(t, next.After(time.Now()))
+	assert.Equal(t, 0, next.Hour())
+	assert.Equal(t, 1, next.YearDay())
+
+	every := time.Hour
+	require.NoError(t, m.Add(ctx, &config.Plan{
+		Name:     "test",
+		Schedule: &config.Schedule{Every: &every},
+	}))
+	require.Eventually(t, func() bool {
+		plans := s.Plans()
+		return len(plans) == 2 && plans[0].Plan == "test" && plans[0].Every == "1h0m0s"
+	}, 5*time.Second, time.Millisecond)
+	require.NoError(t, m.Delete(ctx, "yearly"))
+	require.Eventually(t, func() bool {
+		return len(s.Plans()) == 1
+	}, 5*time.Second, time.Millisecond)
+	assert.Empty(t, runs.List())
+}
+
+// blockingManager blocks getting plans until get is closed.
+type blockingManager struct {
+	Manager
+	get     chan struct{}
+	getting chan struct{}
+}
+
+func (m *blockingManager) Get(ctx context.Context, name string) (*config.Plan, error) {
+	select {
+	case m.getting <- struct{}{}:
+	default:
+	}
+	<-m.get
+	return m.Manager.Get(ctx, name)
+}
+
+// TestSchedulerStartUnlocked tests the schedules can be listed while a due
+// run is starting.
+func TestSchedulerStartUnlocked(t *testing.T) {
+	every := 20 * time.Millisecond
+	m := &blockingManager{
+		Manager: NewManager(testRunExec{}),
+		get:     make(chan struct{}),
+		getting: make(chan struct{}, 1),
+	}
+	require.NoError(t, m.Add(context.Background(), &config.Plan{
+		Name:     "test",
+		Schedule: &config.Schedule{Every: &every},
+	}))
+	runs := NewRuns(m, RunsConfig{})
+	t.Cleanup(func() { runs.Close() })
+	s := newScheduler(runs, 5*time.Millisecond)
+	t.Cleanup(func() { s.Close() })
+	t.Cleanup(func() { close(m.get) })
+
+	select {
+	case <-m.getting:
+	case <-time.After(5 * time.Second):
+		t.Fatal("run was not started")
+	}
+	plans := make(chan []ScheduledPlan)
+	go func() { plans <- s.Plans() }()
+	select {
+	case p := <-plans:
+		require.Len(t, p, 1)
+		assert.Equal(t, "test", p[0].Plan)
+	case <-time.After(5 * time.Second):
+		t.Fatal("schedules are locked while a run starts")
+	}
+}
@@ -0,0 +1,467 @@
+package stats
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Bucket contains aggregated results.
+type Bucket struct {
+	Ops     int64
+	Errors  int64
+	Bytes   int64
+	First   time.Time
+	Last    time.Time
+	Latency *Histogram
+}
+
+func newBucket() Bucket {
+	return Bucket{Latency: NewHistogram()}
+}
+
+func (b *Bucket) add(r Result) {
+	end := r.Time.Add(r.Latency)
+	if b.First.IsZero() || r.Time.Before(b.First) {
+		b.First = r.Time
+	}
+	if end.After(b.Last) {
+		b.Last = end
+	}
+	b.Ops++
+	b.Bytes += r.Bytes
+	if r.Err != nil {
+		b.Errors++
+	}
+	b.Latency.Record(r.Latency)
+}
+
+// Merge adds the results of o to b.
+func (b *Bucket) Merge(o Bucket) {
+	if o.Ops == 0 {
+		return
+	}
+	if b.First.IsZero() || o.First.Before(b.First) {
+		b.First = o.First
+	}
+	if o.Last.After(b.Last) {
+		b.Last = o.Last
+	}
+	b.Ops += o.Ops
+	b.Errors += o.Errors
+	b.Bytes += o.Bytes
+	if b.Latency == nil {
+		b.Latency = NewHistogram()
+	}
+	b.Latency.Merge(o.Latency)
+}
+
+func (b Bucket) copy() Bucket {
+	if b.Latency != nil {
+		b.Latency = b.Latency.Copy()
+	}
+	return b
+}
+
+func (b *Bucket) scale(f float64) {
+	b.Ops = scaleCount(b.Ops, f)
+	b.Errors = scaleCount(b.Errors, f)
+	b.Bytes = scaleCount(b.Bytes, f)
+}
+
+func scaleCount(n int64, f float64) int64 {
+	return int64(math.Round(float64(n) * f))
+}
+
+// Duration returns the time between the first and last result.
+func (b Bucket) Duration() time.Duration {
+	return b.Last.Sub(b.First)
+}
+
+// Throughput returns the number of operations per second.
+func (b Bucket) Throughput() float64 {
+	d := b.Duration()
+	if d <= 0 {
+		return 0
+	}
+	return float64(b.Ops) / d.Seconds()
+}
+
+// ErrorRate returns the ratio of failed operations.
+func (b Bucket) ErrorRate() float64 {
+	if b.Ops == 0 {
+		return 0
+	}
+	return float64(b.Errors) / float64(b.Ops)
+}
+
+// Interval contains the measured results of the operations started within
+// a second, ErrorClasses are the failed operations by class.
+type Interval struct {
+	Time         time.Time
+	Bucket       Bucket
+	ErrorClasses map[string]int64
+}
+
+// ErrorCount is the number of failed operations of an operation with the same
+// error class and code, Example is the first error.
+type ErrorCount struct {
+	Op      string
+	Class   string
+	Code    string
+	Count   int64
+	Example string
+}
+
+// Stage contains the aggregated results of a stage. Results recorded during
+// the stage warm-up are kept in a separate Bucket. Intervals contains the
+// measured results by second in order, Slowest the traced results with the
+// highest latency, slowest first, and Errors the measured failed operations,
+// most frequent first.
+type Stage struct {
+	Name      string
+	Protocol  string
+	Main      Bucket
+	Warmup    Bucket
+	Intervals []Interval
+	Slowest   []Result
+	Errors    []ErrorCount
+}
+
+// slowest is the number of slowest traced results kept for each stage.
+const slowest = 10
+
+type stageResults struct {
+	stage          Stage
+	intervals      map[int64]*Bucket
+	intervalErrors map[int64]map[string]int64
+	errors         map[errorKey]*ErrorCount
+}
+
+type errorKey struct {
+	op, class, code string
+}
+
+// sourceResults are the stages merged from a source.
+type sourceResults struct {
+	stages    map[string]*Stage
+	intervals map[string]map[int64]Interval
+	order     []string
+}
+
+// Collector is a Recorder that aggregates results by stage. It is also a
+// Merger, the stages of every source are combined with the recorded results.
+type Collector struct {
+	mu          sync.Mutex
+	stages      map[string]*stageResults
+	order       []string
+	sources     map[string]*sourceResults
+	sourceOrder []string
+}
+
+// NewCollector returns a new Collector.
+func NewCollector() *Collector {
+	return &Collector{
+		stages:  map[string]*stageResults{},
+		sources: map[string]*sourceResults{},
+	}
+}
+
+// Record implements the Recorder interface.
+func (c *Collector) Record(r Result) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.stages[r.Stage]
+	if !ok {
+		s = &stageResults{
+			stage: Stage{
+				Name:     r.Stage,
+				Protocol: r.Protocol,
+				Main:     newBucket(),
+				Warmup:   newBucket(),
+			},
+			intervals:      map[int64]*Bucket{},
+			intervalErrors: map[int64]map[string]int64{},
+			errors:         map[errorKey]*ErrorCount{},
+		}
+		c.stages[r.Stage] = s
+		c.order = append(c.order, r.Stage)
+	}
+	if r.Warmup {
+		s.stage.Warmup.add(r)
+		return
+	}
+	s.stage.Main.add(r)
+	sec := r.Time.Unix()
+	b, ok := s.intervals[sec]
+	if !ok {
+		nb := newBucket()
+		b = &nb
+		s.intervals[sec] = b
+	}
+	b.add(r)
+	if r.TraceID != "" {
+		s.stage.Slowest = addSlowest(s.stage.Slowest, r)
+	}
+	if r.Err != nil {
+		s.addError(sec, r)
+	}
+}
+
+func (s *stageResults) addError(sec int64, r Result) {
+	class := ErrorClass(r.Err)
+	classes, ok := s.intervalErrors[sec]
+	if !ok {
+		classes = map[string]int64{}
+		s.intervalErrors[sec] = classes
+	}
+	classes[class]++
+
+	key := errorKey{op: r.Op, class: class, code: ErrorCode(r.Err)}
+	e, ok := s.errors[key]
+	if !ok {
+		e = &ErrorCount{
+			Op:      key.op,
+			Class:   key.class,
+			Code:    key.code,
+			Example: r.Err.Error(),
+		}
+		s.errors[key] = e
+	}
+	e.Count++
+}
+
+// addSlowest adds r to the results ordered by decreasing latency if it is
+// one of the slowest.
+func addSlowest(results []Result, r Result) []Result {
+	i := sort.Search(len(results), func(i int) bool {
+		return results[i].Latency < r.Latency
+	})
+	if i == slowest {
+		return results
+	}
+	if len(results) < slowest {
+		results = append(results, Result{})
+	}
+	copy(results[i+1:], results[i:])
+	results[i] = r
+	return results
+}
+
+// Stages returns a copy of the aggregated results of each stage in the
+// order the stages were first seen, including the stages of all sources.
+func (c *Collector) Stages() []*Stage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stages := make([]*Stage, 0, len(c.order))
+	byName := map[string]*Stage{}
+	for _, name := range c.order {
+		s := c.stages[name].copy()
+		stages = append(stages, s)
+		byName[name] = s
+	}
+	for _, source := range c.sourceOrder {
+		for _, s := range c.sources[source].copy() {
+			if dst, ok := byName[s.Name]; ok {
+				dst.merge(s)
+				continue
+			}
+			stages = append(stages, s)
+			byName[s.Name] = s
+		}
+	}
+	return stages
+}
+
+func (res *stageResults) copy() *Stage {
+	s := res.stage
+	s.Main = s.Main.copy()
+	s.Warmup = s.Warmup.copy()
+	s.Slowest = append([]Result(nil), s.Slowest...)
+	s.Intervals = make([]Interval, 0, len(res.intervals))
+	for sec, b := range res.intervals {
+		i := Interval{
+			Time:   time.Unix(sec, 0),
+			Bucket: b.copy(),
+		}
+		if classes, ok := res.intervalErrors[sec]; ok {
+			i.ErrorClasses = copyClasses(classes)
+		}
+		s.Intervals = append(s.Intervals, i)
+	}
+	sortIntervals(s.Intervals)
+	s.Errors = make([]ErrorCount, 0, len(res.errors))
+	for _, e := range res.errors {
+		s.Errors = append(s.Errors, *e)
+	}
+	sortErrors(s.Errors)
+	return &s
+}
+
+func copyClasses(classes map[string]int64) map[string]int64 {
+	if classes == nil {
+		return nil
+	}
+	c := make(map[string]int64, len(classes))
+	for class, n := range classes {
+		c[class] = n
+	}
+	return c
+}
+
+func sortIntervals(intervals []Interval) {
+	sort.Slice(intervals, func(i, j int) bool {
+		return intervals[i].Time.Before(intervals[j].Time)
+	})
+}
+
+func sortErrors(errs []ErrorCount) {
+	sort.Slice(errs, func(i, j int) bool {
+		a, b := errs[i], errs[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if a.Op != b.Op {
+			return a.Op < b.Op
+		}
+		if a.Class != b.Class {
+			return a.Class < b.Class
+		}
+		return a.Code < b.Code
+	})
+}
+
+// merge adds the results of o to s.
+func (s *Stage) merge(o *Stage) {
+	if s.Protocol == "" {
+		s.Protocol = o.Protocol
+	}
+	s.Main.Merge(o.Main)
+	s.Warmup.Merge(o.Warmup)
+
+	byTime := make(map[int64]int, len(s.Intervals))
+	for i, interval := range s.Intervals {
+		byTime[interval.Time.Unix()] = i
+	}
+	for _, interval := range o.Intervals {
+		i, ok := byTime[interval.Time.Unix()]
+		if !ok {
+			interval.Bucket = interval.Bucket.copy()
+			interval.ErrorClasses = copyClasses(interval.ErrorClasses)
+			byTime[interval.Time.Unix()] = len(s.Intervals)
+			s.Intervals = append(s.Intervals, interval)
+			continue
+		}
+		dst := &s.Intervals[i]
+		dst.Bucket.Merge(interval.Bucket)
+		for class, n := range interval.ErrorClasses {
+			if dst.ErrorClasses == nil {
+				dst.ErrorClasses = map[string]int64{}
+			}
+			dst.ErrorClasses[class] += n
+		}
+	}
+	sortIntervals(s.Intervals)
+
+	byKey := make(map[errorKey]int, len(s.Errors))
+	for i, e := range s.Errors {
+		byKey[errorKey{op: e.Op, class: e.Class, code: e.Code}] = i
+	}
+	for _, e := range o.Errors {
+		if i, ok := byKey[errorKey{op: e.Op, class: e.Class, code: e.Code}]; ok {
+			s.Errors[i].Count += e.Count
+			continue
+		}
+		s.Errors = append(s.Errors, e)
+	}
+	sortErrors(s.Errors)
+
+	for _, r := range o.Slowest {
+		s.Slowest = addSlowest(s.Slowest, r)
+	}
+}
+
+// Scale multiplies the operation, error and byte counts of s by f, it is
+// used to estimate the results of a run from a sample of its results.
+// Latency distributions are unchanged.
+func (s *Stage) Scale(f float64) {
+	s.Main.scale(f)
+	s.Warmup.scale(f)
+	for i := range s.Intervals {
+		interval := &s.Intervals[i]
+		interval.Bucket.scale(f)
+		for class, n := range interval.ErrorClasses {
+			interval.ErrorClasses[class] = scaleCount(n, f)
+		}
+	}
+	for i := range s.Errors {
+		s.Errors[i].Count = scaleCount(s.Errors[i].Count, f)
+	}
+}
+
+// MergeSource implements the Merger interface.
+func (c *Collector) MergeSource(source string, stages []*Stage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	src, ok := c.sources[source]
+	if !ok {
+		src = &sourceResults{
+			stages:    map[string]*Stage{},
+			intervals: map[string]map[int64]Interval{},
+		}
+		c.sources[source] = src
+		c.sourceOrder = append(c.sourceOrder, source)
+		sort.Strings(c.sourceOrder)
+	}
+	for _, s := range stages {
+		if _, ok := src.stages[s.Name]; !ok {
+			src.order = append(src.order, s.Name)
+			src.intervals[s.Name] = map[int64]Interval{}
+		}
+		stage := *s
+		stage.Intervals = nil
+		src.stages[s.Name] = &stage
+		for _, i := range s.Intervals {
+			src.intervals[s.Name][i.Time.Unix()] = i
+		}
+	}
+}
+
+// Sources returns the sources merged into the Collector ordered by name.
+func (c *Collector) Sources() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.sourceOrder...)
+}
+
+// SourceStages returns a copy of the stages of a source.
+func (c *Collector) SourceStages(source string) []*Stage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	src, ok := c.sources[source]
+	if !ok {
+		return nil
+	}
+	return src.copy()
+}
+
+func (src *sourceResults) copy() []*Stage {
+	stages := make([]*Stage, 0, len(src.order))
+	for _, name := range src.order {
+		s := *src.stages[name]
+		s.Main = s.Main.copy()
+		s.Warmup = s.Warmup.copy()
+		s.Slowest = append([]Result(nil), s.Slowest...)
+		s.Errors = append([]ErrorCount(nil), s.Errors...)
+		s.Intervals = make([]Interval, 0, len(src.intervals[name]))
+		for _, i := range src.intervals[name] {
+			i.Bucket = i.Bucket.copy()
+			i.ErrorClasses = copyClasses(i.ErrorClasses)
+			s.Intervals = append(s.Intervals, i)
+		}
+		sortIntervals(s.Intervals)
+		stages = append(stages, &s)
+	}
+	return stages
+}
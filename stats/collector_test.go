@@ -0,0 +1,234 @@
+package stats
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCollector tests results are aggregated by stage.
+func TestCollector(t *testing.T) {
+	c := NewCollector()
+	start := time.Now()
+	for i := 0; i < 10; i++ {
+		c.Record(Result{
+			Time:     start.Add(time.Duration(i) * 100 * time.Millisecond),
+			Stage:    "a",
+			Protocol: "http",
+			Latency:  100 * time.Millisecond,
+			Bytes:    10,
+		})
+	}
+	c.Record(Result{Time: start, Stage: "b", Latency: time.Millisecond, Err: errors.New("fail")})
+
+	stages := c.Stages()
+	require.Len(t, stages, 2)
+	a := stages[0]
+	assert.Equal(t, "a", a.Name)
+	assert.Equal(t, "http", a.Protocol)
+	assert.Equal(t, int64(10), a.Main.Ops)
+	assert.Equal(t, int64(100), a.Main.Bytes)
+	assert.Equal(t, time.Second, a.Main.Duration())
+	assert.InEpsilon(t, 10.0, a.Main.Throughput(), 0.001)
+	assert.Equal(t, int64(0), a.Warmup.Ops)
+
+	b := stages[1]
+	assert.Equal(t, int64(1), b.Main.Errors)
+	assert.Equal(t, 1.0, b.Main.ErrorRate())
+}
+
+// TestCollectorWarmup tests warm-up results are kept separate.
+func TestCollectorWarmup(t *testing.T) {
+	c := NewCollector()
+	c.Record(Result{Time: time.Now(), Stage: "a", Latency: time.Second, Warmup: true})
+	c.Record(Result{Time: time.Now(), Stage: "a", Latency: time.Millisecond})
+
+	stages := c.Stages()
+	require.Len(t, stages, 1)
+	assert.Equal(t, int64(1), stages[0].Main.Ops)
+	assert.Equal(t, time.Millisecond, stages[0].Main.Latency.Max())
+	assert.Equal(t, int64(1), stages[0].Warmup.Ops)
+	assert.Equal(t, time.Second, stages[0].Warmup.Latency.Max())
+}
+
+// TestCollectorStagesCopy tests returned stages are not modified by later
+// results.
+func TestCollectorStagesCopy(t *testing.T) {
+	c := NewCollector()
+	c.Record(Result{Stage: "a", Latency: time.Millisecond})
+	stages := c.Stages()
+	c.Record(Result{Stage: "a", Latency: time.Second})
+	assert.Equal(t, int64(1), stages[0].Main.Ops)
+	assert.Equal(t, uint64(1), stages[0].Main.Latency.Count())
+}
+
+// TestRecorderContext tests passing a Recorder through a context.
+func TestRecorderContext(t *testing.T) {
+	// A context without a recorder discards results.
+	FromContext(context.Background()).Record(Result{})
+
+	var got []Result
+	rec := RecorderFunc(func(r Result) { got = append(got, r) })
+	c := NewCollector()
+	ctx := NewContext(context.Background(), MultiRecorder(rec, nil, c))
+	FromContext(ctx).Record(Result{Stage: "a"})
+	assert.Len(t, got, 1)
+	assert.Len(t, c.Stages(), 1)
+}
+
+// TestBucketMerge tests merging the results of buckets.
+func TestBucketMerge(t *testing.T) {
+	c := NewCollector()
+	start := time.Now()
+	c.Record(Result{Time: start, Stage: "a", Latency: time.Millisecond, Bytes: 1})
+	c.Record(Result{Time: start.Add(time.Second), Stage: "b", Latency: time.Second, Err: errors.New("fail")})
+
+	var b Bucket
+	for _, s := range c.Stages() {
+		b.Merge(s.Main)
+	}
+	b.Merge(Bucket{})
+	assert.Equal(t, int64(2), b.Ops)
+	assert.Equal(t, int64(1), b.Errors)
+	assert.Equal(t, int64(1), b.Bytes)
+	assert.Equal(t, 2*time.Second, b.Duration())
+	assert.Equal(t, time.Second, b.Latency.Max())
+	assert.Equal(t, time.Millisecond, b.Latency.Min())
+}
+
+// TestCollectorIntervals tests measured results are aggregated by second.
+func TestCollectorIntervals(t *testing.T) {
+	c := NewCollector()
+	start := time.Unix(1000, 0)
+	c.Record(Result{Time: start.Add(1500 * time.Millisecond), Stage: "a", Latency: time.Second})
+	c.Record(Result{Time: start, Stage: "a", Latency: time.Millisecond})
+	c.Record(Result{Time: start.Add(100 * time.Millisecond), Stage: "a", Latency: 2 * time.Millisecond, Err: errors.New("fail")})
+	c.Record(Result{Time: start, Stage: "a", Latency: time.Minute, Warmup: true})
+
+	stages := c.Stages()
+	require.Len(t, stages, 1)
+	intervals := stages[0].Intervals
+	require.Len(t, intervals, 2)
+	assert.Equal(t, start, intervals[0].Time)
+	assert.Equal(t, int64(2), intervals[0].Bucket.Ops)
+	assert.Equal(t, int64(1), intervals[0].Bucket.Errors)
+	assert.Equal(t, 2*time.Millisecond, intervals[0].Bucket.Latency.Max())
+	assert.Equal(t, start.Add(time.Second), intervals[1].Time)
+	assert.Equal(t, int64(1), intervals[1].Bucket.Ops)
+}
+
+// TestCollectorSlowest tests the slowest traced results are kept.
+func TestCollectorSlowest(t *testing.T) {
+	c := NewCollector()
+	for i := 1; i <= 20; i++ {
+		c.Record(Result{Stage: "a", Latency: time.Duration(i%7*100+i) * time.Millisecond, TraceID: "t"})
+	}
+	c.Record(Result{Stage: "a", Latency: time.Hour})
+	c.Record(Result{Stage: "a", Latency: time.Hour, Warmup: true, TraceID: "w"})
+
+	slow := c.Stages()[0].Slowest
+	require.Len(t, slow, slowest)
+	assert.Equal(t, 620*time.Millisecond, slow[0].Latency)
+	for i := 1; i < len(slow); i++ {
+		assert.GreaterOrEqual(t, slow[i-1].Latency, slow[i].Latency)
+		assert.Equal(t, "t", slow[i].TraceID)
+	}
+}
+
+// TestCollectorErrors tests errors are counted by operation, class and code.
+func TestCollectorErrors(t *testing.T) {
+	c := NewCollector()
+	start := time.Unix(1000, 0)
+	refused := errors.New("connect: connection refused")
+	c.Record(Result{Time: start, Stage: "a", Op: "get", Err: refused})
+	c.Record(Result{Time: start, Stage: "a", Op: "get", Err: Classify(ClassProtocol, "503", errors.New("503"))})
+	c.Record(Result{Time: start.Add(time.Second), Stage: "a", Op: "get", Err: refused})
+	c.Record(Result{Time: start, Stage: "a", Op: "set", Err: refused})
+	c.Record(Result{Time: start, Stage: "a", Op: "get"})
+	c.Record(Result{Time: start, Stage: "a", Op: "get", Err: refused, Warmup: true})
+
+	s := c.Stages()[0]
+	assert.Equal(t, []ErrorCount{
+		{Op: "get", Class: ClassRefused, Count: 2, Example: refused.Error()},
+		{Op: "get", Class: ClassProtocol, Code: "503", Count: 1, Example: "503"},
+		{Op: "set", Class: ClassRefused, Count: 1, Example: refused.Error()},
+	}, s.Errors)
+	require.Len(t, s.Intervals, 2)
+	assert.Equal(t, map[string]int64{ClassRefused: 2, ClassProtocol: 1}, s.Intervals[0].ErrorClasses)
+	assert.Equal(t, map[string]int64{ClassRefused: 1}, s.Intervals[1].ErrorClasses)
+}
+
+// TestCollectorMergeSource tests the stages of sources are merged with the
+// recorded results.
+func TestCollectorMergeSource(t *testing.T) {
+	start := time.Unix(1000, 0)
+	agent := NewCollector()
+	agent.Record(Result{Time: start, Stage: "a", Protocol: "http", Op: "get", Latency: time.Second, TraceID: "t"})
+	agent.Record(Result{Time: start.Add(time.Second), Stage: "a", Op: "get", Latency: time.Millisecond, Err: errors.New("fail")})
+	agent.Record(Result{Time: start, Stage: "b", Latency: time.Millisecond})
+
+	// Stages are sent as JSON by agents.
+	b, err := json.Marshal(agent.Stages())
+	require.NoError(t, err)
+	var stages []*Stage
+	require.NoError(t, json.Unmarshal(b, &stages))
+
+	c := NewCollector()
+	c.Record(Result{Time: start, Stage: "a", Op: "get", Latency: 2 * time.Millisecond, Err: errors.New("fail")})
+	c.MergeSource("agent-1", stages[:1])
+	// Intervals replace those of the same second.
+	c.MergeSource("agent-1", stages)
+	c.MergeSource("agent-2", stages)
+	assert.Equal(t, []string{"agent-1", "agent-2"}, c.Sources())
+
+	merged := c.Stages()
+	require.Len(t, merged, 2)
+	a := merged[0]
+	assert.Equal(t, "http", a.Protocol)
+	assert.Equal(t, int64(5), a.Main.Ops)
+	assert.Equal(t, int64(3), a.Main.Errors)
+	assert.Equal(t, uint64(5), a.Main.Latency.Count())
+	assert.Equal(t, time.Second, a.Main.Latency.Max())
+	require.Len(t, a.Intervals, 2)
+	assert.Equal(t, int64(3), a.Intervals[0].Bucket.Ops)
+	assert.Equal(t, int64(2), a.Intervals[1].Bucket.Ops)
+	assert.Equal(t, map[string]int64{ClassError: 2}, a.Intervals[1].ErrorClasses)
+	assert.Equal(t, []ErrorCount{{Op: "get", Class: ClassError, Count: 3, Example: "fail"}}, a.Errors)
+	require.Len(t, a.Slowest, 2)
+	assert.Equal(t, "t", a.Slowest[0].TraceID)
+	assert.Equal(t, int64(2), merged[1].Main.Ops)
+
+	source := c.SourceStages("agent-1")
+	require.Len(t, source, 2)
+	assert.Equal(t, int64(2), source[0].Main.Ops)
+	assert.Nil(t, c.SourceStages("agent-3"))
+
+	// Merging does not change the results of the sources.
+	c.Stages()
+	assert.Equal(t, int64(2), c.SourceStages("agent-2")[0].Main.Ops)
+}
+
+// TestStageScale tests scaling the counts of a stage.
+func TestStageScale(t *testing.T) {
+	c := NewCollector()
+	start := time.Unix(1000, 0)
+	c.Record(Result{Time: start, Stage: "a", Latency: time.Millisecond, Bytes: 10})
+	c.Record(Result{Time: start, Stage: "a", Latency: time.Second, Err: Classify(ClassTimeout, "", errors.New("fail"))})
+
+	s := c.Stages()[0]
+	s.Scale(4)
+	assert.Equal(t, int64(8), s.Main.Ops)
+	assert.Equal(t, int64(4), s.Main.Errors)
+	assert.Equal(t, int64(40), s.Main.Bytes)
+	assert.Equal(t, time.Second, s.Main.Latency.Max())
+	require.Len(t, s.Intervals, 1)
+	assert.Equal(t, int64(8), s.Intervals[0].Bucket.Ops)
+	assert.Equal(t, int64(4), s.Intervals[0].ErrorClasses[ClassTimeout])
+	require.Len(t, s.Errors, 1)
+	assert.Equal(t, int64(4), s.Errors[0].Count)
+}
@@ -0,0 +1,150 @@
+package stats
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+)
+
+// Classes of failed operations.
+const (
+	ClassTimeout  = "timeout"
+	ClassCanceled = "canceled"
+	ClassRefused  = "connection_refused"
+	ClassReset    = "connection_reset"
+	ClassDNS      = "dns"
+	ClassTLS      = "tls"
+	ClassAuth     = "auth"
+	// ClassProtocol is an error response of the target, such as a HTTP
+	// 5xx status, a Redis error reply or a SQL error.
+	ClassProtocol = "protocol"
+	// ClassCheck is a response that did not pass a check.
+	ClassCheck = "check"
+	ClassError = "error"
+)
+
+// ClassifiedError is an error of a known class. Executors return it for
+// errors whose class cannot be told from their type, such as the error
+// responses of a protocol.
+type ClassifiedError struct {
+	Class string
+	// Code is the protocol specific code of the error, such as a HTTP
+	// status or a SQL state.
+	Code string
+	Err  error
+}
+
+// Classify returns err as an error of class with a protocol specific code.
+func Classify(class, code string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &ClassifiedError{Class: class, Code: code, Err: err}
+}
+
+// Error implements the error interface.
+func (e *ClassifiedError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the classified error.
+func (e *ClassifiedError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorCode returns the protocol specific code of a classified error.
+func ErrorCode(err error) string {
+	var classified *ClassifiedError
+	if errors.As(err, &classified) {
+		return classified.Code
+	}
+	return ""
+}
+
+// ErrorClass returns the class of an error. Errors are classified by their
+// type where possible and otherwise by common messages, since many clients
+// only return formatted errors.
+func ErrorClass(err error) string {
+	var (
+		classified *ClassifiedError
+		dnsErr     *net.DNSError
+		netErr     net.Error
+	)
+	switch {
+	case err == nil:
+		return ""
+	case errors.As(err, &classified):
+		return classified.Class
+	case errors.Is(err, context.DeadlineExceeded):
+		return ClassTimeout
+	case errors.Is(err, context.Canceled):
+		return ClassCanceled
+	case errors.As(err, &dnsErr):
+		return ClassDNS
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return ClassRefused
+	case errors.Is(err, syscall.ECONNRESET),
+		errors.Is(err, syscall.ECONNABORTED),
+		errors.Is(err, syscall.EPIPE),
+		errors.Is(err, io.ErrUnexpectedEOF),
+		errors.Is(err, io.EOF):
+		return ClassReset
+	case isTLS(err):
+		return ClassTLS
+	case errors.Is(err, os.ErrDeadlineExceeded),
+		errors.As(err, &netErr) && netErr.Timeout():
+		return ClassTimeout
+	}
+	return classifyMessage(strings.ToLower(err.Error()))
+}
+
+func isTLS(err error) bool {
+	var (
+		recordErr    tls.RecordHeaderError
+		verifyErr    *tls.CertificateVerificationError
+		alertErr     tls.AlertError
+		authorityErr x509.UnknownAuthorityError
+		invalidErr   x509.CertificateInvalidError
+		hostnameErr  x509.HostnameError
+	)
+	return errors.As(err, &recordErr) ||
+		errors.As(err, &verifyErr) ||
+		errors.As(err, &alertErr) ||
+		errors.As(err, &authorityErr) ||
+		errors.As(err, &invalidErr) ||
+		errors.As(err, &hostnameErr)
+}
+
+// messageClasses are classes of error messages in order of precedence.
+var messageClasses = []struct {
+	class    string
+	messages []string
+}{
+	{ClassRefused, []string{"connection refused"}},
+	{ClassReset, []string{"connection reset", "broken pipe", "connection aborted"}},
+	{ClassDNS, []string{"no such host", "server misbehaving"}},
+	{ClassTLS, []string{"x509:", "tls:", "certificate"}},
+	{ClassTimeout, []string{"timeout", "timed out", "deadline exceeded"}},
+	{ClassAuth, []string{
+		"unauthorized", "unauthenticated", "authentication", "unable to authenticate",
+		"access denied", "permission denied", "not authorized", "invalid credentials",
+		"noauth", "wrongpass", "noperm",
+	}},
+}
+
+func classifyMessage(msg string) string {
+	for _, c := range messageClasses {
+		for _, m := range c.messages {
+			if strings.Contains(msg, m) {
+				return c.class
+			}
+		}
+	}
+	return ClassError
+}
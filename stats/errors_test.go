@@ -0,0 +1,72 @@
+package stats
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestErrorClass tests classifying errors.
+func TestErrorClass(t *testing.T) {
+	tests := []struct {
+		err   error
+		class string
+	}{
+		{nil, ""},
+		{context.DeadlineExceeded, ClassTimeout},
+		{fmt.Errorf("op: %w", context.Canceled), ClassCanceled},
+		{&net.DNSError{Err: "no such host", Name: "x"}, ClassDNS},
+		{&net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}, ClassRefused},
+		{&net.OpError{Op: "read", Err: os.NewSyscallError("read", syscall.ECONNRESET)}, ClassReset},
+		{fmt.Errorf("read: %w", io.ErrUnexpectedEOF), ClassReset},
+		{x509.UnknownAuthorityError{}, ClassTLS},
+		{fmt.Errorf("read: %w", os.ErrDeadlineExceeded), ClassTimeout},
+		{Classify(ClassProtocol, "500", errors.New("internal error")), ClassProtocol},
+		{errors.New("fail"), ClassError},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.class, ErrorClass(test.err), "%v", test.err)
+	}
+}
+
+// TestErrorClassMessage tests classifying errors by their message.
+func TestErrorClassMessage(t *testing.T) {
+	tests := []struct {
+		msg   string
+		class string
+	}{
+		{"dial tcp 127.0.0.1:1: connect: connection refused", ClassRefused},
+		{"write: broken pipe", ClassReset},
+		{"lookup db: server misbehaving", ClassDNS},
+		{"tls: handshake failure", ClassTLS},
+		{"i/o timeout", ClassTimeout},
+		{"NOAUTH Authentication required.", ClassAuth},
+		{"pq: password authentication failed for user", ClassAuth},
+		{"unexpected response", ClassError},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.class, ErrorClass(errors.New(test.msg)), test.msg)
+	}
+}
+
+// TestClassify tests classified errors keep their class and code when
+// wrapped.
+func TestClassify(t *testing.T) {
+	assert.Nil(t, Classify(ClassAuth, "401", nil))
+	assert.Equal(t, "", ErrorCode(errors.New("fail")))
+
+	cause := errors.New("GET /: 503 Service Unavailable")
+	err := fmt.Errorf("request: %w", Classify(ClassProtocol, "503", cause))
+	assert.Equal(t, ClassProtocol, ErrorClass(err))
+	assert.Equal(t, "503", ErrorCode(err))
+	assert.ErrorIs(t, err, cause)
+	assert.Equal(t, "request: GET /: 503 Service Unavailable", err.Error())
+}
@@ -0,0 +1,204 @@
+package stats
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/bits"
+	"time"
+)
+
+const (
+	// subBucketBits controls the precision of a Histogram, values are
+	// tracked with a relative error of roughly 1/2^subBucketBits.
+	subBucketBits  = 7
+	subBucketCount = 1 << subBucketBits
+	subBucketHalf  = subBucketCount / 2
+)
+
+// Histogram is a log-linear latency histogram. Histograms with the same
+// precision can be merged without loss, which makes them suitable for
+// aggregating results from multiple workers. A Histogram is not safe for
+// concurrent use.
+type Histogram struct {
+	counts []uint64
+	count  uint64
+	sum    int64
+	min    int64
+	max    int64
+}
+
+// NewHistogram returns a new Histogram.
+func NewHistogram() *Histogram {
+	return &Histogram{}
+}
+
+func bucketIndex(v int64) int {
+	if v < subBucketCount {
+		return int(v)
+	}
+	shift := bits.Len64(uint64(v)) - subBucketBits
+	m := int(v >> uint(shift))
+	return subBucketCount + (shift-1)*subBucketHalf + (m - subBucketHalf)
+}
+
+func bucketBounds(i int) (int64, int64) {
+	if i < subBucketCount {
+		return int64(i), int64(i)
+	}
+	i -= subBucketCount
+	shift := uint(i/subBucketHalf + 1)
+	m := int64(i%subBucketHalf + subBucketHalf)
+	return m << shift, ((m + 1) << shift) - 1
+}
+
+// Record records a single duration.
+func (h *Histogram) Record(d time.Duration) {
+	v := int64(d)
+	if v < 0 {
+		v = 0
+	}
+	i := bucketIndex(v)
+	if i >= len(h.counts) {
+		counts := make([]uint64, i+1)
+		copy(counts, h.counts)
+		h.counts = counts
+	}
+	h.counts[i]++
+	if h.count == 0 || v < h.min {
+		h.min = v
+	}
+	if v > h.max {
+		h.max = v
+	}
+	h.count++
+	h.sum += v
+}
+
+// Merge adds all values recorded by o to h.
+func (h *Histogram) Merge(o *Histogram) {
+	if o == nil || o.count == 0 {
+		return
+	}
+	if len(o.counts) > len(h.counts) {
+		counts := make([]uint64, len(o.counts))
+		copy(counts, h.counts)
+		h.counts = counts
+	}
+	for i, c := range o.counts {
+		h.counts[i] += c
+	}
+	if h.count == 0 || o.min < h.min {
+		h.min = o.min
+	}
+	if o.max > h.max {
+		h.max = o.max
+	}
+	h.count += o.count
+	h.sum += o.sum
+}
+
+// Copy returns a deep copy of the Histogram.
+func (h *Histogram) Copy() *Histogram {
+	c := *h
+	c.counts = append([]uint64(nil), h.counts...)
+	return &c
+}
+
+// Count returns the number of recorded values.
+func (h *Histogram) Count() uint64 {
+	return h.count
+}
+
+// Min returns the smallest recorded value.
+func (h *Histogram) Min() time.Duration {
+	return time.Duration(h.min)
+}
+
+// Max returns the largest recorded value.
+func (h *Histogram) Max() time.Duration {
+	return time.Duration(h.max)
+}
+
+// Mean returns the mean of all recorded values.
+func (h *Histogram) Mean() time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+	return time.Duration(h.sum / int64(h.count))
+}
+
+// Quantile returns the value at quantile q, where q is in the range [0, 1].
+func (h *Histogram) Quantile(q float64) time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+	if q <= 0 {
+		return h.Min()
+	}
+	if q >= 1 {
+		return h.Max()
+	}
+	rank := uint64(math.Ceil(q * float64(h.count)))
+	var seen uint64
+	for i, c := range h.counts {
+		seen += c
+		if seen < rank {
+			continue
+		}
+		lo, hi := bucketBounds(i)
+		v := lo + (hi-lo)/2
+		if v < h.min {
+			v = h.min
+		}
+		if v > h.max {
+			v = h.max
+		}
+		return time.Duration(v)
+	}
+	return h.Max()
+}
+
+// histogramJSON is the JSON encoding of a Histogram, Counts holds the index
+// and count of every non-empty bucket.
+type histogramJSON struct {
+	Count  uint64      `json:"count"`
+	Sum    int64       `json:"sum"`
+	Min    int64       `json:"min"`
+	Max    int64       `json:"max"`
+	Counts [][2]uint64 `json:"counts,omitempty"`
+}
+
+// MarshalJSON implements the json.Marshaler interface, the encoding is
+// lossless so that histograms of other processes can be merged.
+func (h *Histogram) MarshalJSON() ([]byte, error) {
+	j := histogramJSON{Count: h.count, Sum: h.sum, Min: h.min, Max: h.max}
+	for i, c := range h.counts {
+		if c > 0 {
+			j.Counts = append(j.Counts, [2]uint64{uint64(i), c})
+		}
+	}
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (h *Histogram) UnmarshalJSON(b []byte) error {
+	var j histogramJSON
+	if err := json.Unmarshal(b, &j); err != nil {
+		return err
+	}
+	*h = Histogram{count: j.Count, sum: j.Sum, min: j.Min, max: j.Max}
+	for _, c := range j.Counts {
+		i := int(c[0])
+		if i > bucketIndex(math.MaxInt64) {
+			return fmt.Errorf("invalid histogram bucket %d", i)
+		}
+		if i >= len(h.counts) {
+			counts := make([]uint64, i+1)
+			copy(counts, h.counts)
+			h.counts = counts
+		}
+		h.counts[i] += c[1]
+	}
+	return nil
+}
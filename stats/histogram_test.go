@@ -0,0 +1,91 @@
+package stats
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHistogramEmpty tests an empty histogram.
+func TestHistogramEmpty(t *testing.T) {
+	h := NewHistogram()
+	assert.Equal(t, uint64(0), h.Count())
+	assert.Equal(t, time.Duration(0), h.Mean())
+	assert.Equal(t, time.Duration(0), h.Quantile(0.99))
+}
+
+// TestHistogramQuantile tests quantiles are within the histogram precision.
+func TestHistogramQuantile(t *testing.T) {
+	h := NewHistogram()
+	for i := 1; i <= 1000; i++ {
+		h.Record(time.Duration(i) * time.Millisecond)
+	}
+	require.Equal(t, uint64(1000), h.Count())
+	assert.Equal(t, time.Millisecond, h.Min())
+	assert.Equal(t, time.Second, h.Max())
+	assert.InEpsilon(t, float64(500*time.Millisecond), float64(h.Quantile(0.5)), 0.01)
+	assert.InEpsilon(t, float64(990*time.Millisecond), float64(h.Quantile(0.99)), 0.01)
+	assert.InEpsilon(t, float64(500500*time.Microsecond), float64(h.Mean()), 0.001)
+}
+
+// TestHistogramSmallValues tests small values are recorded exactly.
+func TestHistogramSmallValues(t *testing.T) {
+	h := NewHistogram()
+	for i := 0; i < 100; i++ {
+		h.Record(time.Duration(i))
+	}
+	assert.Equal(t, time.Duration(49), h.Quantile(0.5))
+	assert.Equal(t, time.Duration(99), h.Quantile(1))
+}
+
+// TestHistogramMerge tests merging histograms.
+func TestHistogramMerge(t *testing.T) {
+	a := NewHistogram()
+	b := NewHistogram()
+	all := NewHistogram()
+	for i := 1; i <= 100; i++ {
+		a.Record(time.Duration(i) * time.Microsecond)
+		b.Record(time.Duration(i) * time.Second)
+		all.Record(time.Duration(i) * time.Microsecond)
+		all.Record(time.Duration(i) * time.Second)
+	}
+	a.Merge(b)
+	assert.Equal(t, all.Count(), a.Count())
+	assert.Equal(t, all.Min(), a.Min())
+	assert.Equal(t, all.Max(), a.Max())
+	for _, q := range []float64{0.1, 0.5, 0.9, 0.99} {
+		assert.Equal(t, all.Quantile(q), a.Quantile(q))
+	}
+}
+
+// TestHistogramCopy tests copies are independent.
+func TestHistogramCopy(t *testing.T) {
+	h := NewHistogram()
+	h.Record(time.Millisecond)
+	c := h.Copy()
+	h.Record(time.Second)
+	assert.Equal(t, uint64(1), c.Count())
+	assert.Equal(t, time.Millisecond, c.Max())
+}
+
+// TestHistogramJSON tests the JSON encoding of a histogram is lossless.
+func TestHistogramJSON(t *testing.T) {
+	h := NewHistogram()
+	for i := 1; i <= 1000; i++ {
+		h.Record(time.Duration(i*i) * time.Microsecond)
+	}
+	b, err := json.Marshal(h)
+	require.NoError(t, err)
+
+	var got Histogram
+	require.NoError(t, json.Unmarshal(b, &got))
+	assert.Equal(t, h, &got)
+	for _, q := range []float64{0, 0.5, 0.99, 1} {
+		assert.Equal(t, h.Quantile(q), got.Quantile(q))
+	}
+
+	require.Error(t, json.Unmarshal([]byte(`{"counts":[[100000,1]]}`), &got))
+}
@@ -0,0 +1,189 @@
+package stats
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Record is the log entry of a Result, latencies are in nanoseconds.
+type Record struct {
+	Time       time.Time     `json:"time"`
+	Stage      string        `json:"stage"`
+	Protocol   string        `json:"protocol"`
+	Op         string        `json:"op,omitempty"`
+	Latency    time.Duration `json:"latency"`
+	Status     string        `json:"status"`
+	Bytes      int64         `json:"bytes"`
+	ErrorClass string        `json:"errorClass,omitempty"`
+	ErrorCode  string        `json:"errorCode,omitempty"`
+	Error      string        `json:"error,omitempty"`
+	Warmup     bool          `json:"warmup,omitempty"`
+	TraceID    string        `json:"traceId,omitempty"`
+	// Sample is the fraction of results written to the log, it is omitted
+	// if every result was written.
+	Sample float64 `json:"sample,omitempty"`
+}
+
+const (
+	// StatusOK is the status of a successful operation.
+	StatusOK = "ok"
+	// StatusError is the status of a failed operation.
+	StatusError = "error"
+)
+
+// NewRecord returns the log entry of a Result.
+func NewRecord(r Result) Record {
+	rec := Record{
+		Time:     r.Time,
+		Stage:    r.Stage,
+		Protocol: r.Protocol,
+		Op:       r.Op,
+		Latency:  r.Latency,
+		Status:   StatusOK,
+		Bytes:    r.Bytes,
+		Warmup:   r.Warmup,
+		TraceID:  r.TraceID,
+	}
+	if r.Err != nil {
+		rec.Status = StatusError
+		rec.ErrorClass = ErrorClass(r.Err)
+		rec.ErrorCode = ErrorCode(r.Err)
+		rec.Error = r.Err.Error()
+	}
+	return rec
+}
+
+// Result returns the Result of a log entry.
+func (r Record) Result() Result {
+	res := Result{
+		Time:     r.Time,
+		Stage:    r.Stage,
+		Protocol: r.Protocol,
+		Op:       r.Op,
+		Latency:  r.Latency,
+		Bytes:    r.Bytes,
+		Warmup:   r.Warmup,
+		TraceID:  r.TraceID,
+	}
+	if r.Status == StatusError {
+		msg := r.Error
+		if msg == "" {
+			msg = r.ErrorClass
+		}
+		res.Err = errors.New(msg)
+		if r.ErrorClass != "" {
+			res.Err = Classify(r.ErrorClass, r.ErrorCode, res.Err)
+		}
+	}
+	return res
+}
+
+// MarshalJSON implements the json.Marshaler interface, a Result is encoded
+// as its Record.
+func (r Result) MarshalJSON() ([]byte, error) {
+	return json.Marshal(NewRecord(r))
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (r *Result) UnmarshalJSON(b []byte) error {
+	var rec Record
+	if err := json.Unmarshal(b, &rec); err != nil {
+		return err
+	}
+	*r = rec.Result()
+	return nil
+}
+
+// LogWriter is a Recorder that writes a Record for every Result as JSON
+// lines.
+type LogWriter struct {
+	mu     sync.Mutex
+	buf    *bufio.Writer
+	gz     *gzip.Writer
+	enc    *json.Encoder
+	sample float64
+	err    error
+}
+
+// NewLogWriter returns a LogWriter, sample is the fraction of results that
+// are written. If compress is set the log is gzip compressed.
+func NewLogWriter(w io.Writer, sample float64, compress bool) *LogWriter {
+	l := &LogWriter{
+		buf:    bufio.NewWriter(w),
+		sample: sample,
+	}
+	if compress {
+		l.gz = gzip.NewWriter(l.buf)
+		l.enc = json.NewEncoder(l.gz)
+	} else {
+		l.enc = json.NewEncoder(l.buf)
+	}
+	return l
+}
+
+// Record implements the Recorder interface.
+func (l *LogWriter) Record(r Result) {
+	if l.sample < 1 && rand.Float64() >= l.sample {
+		return
+	}
+	rec := NewRecord(r)
+	if l.sample < 1 {
+		rec.Sample = l.sample
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.err != nil {
+		return
+	}
+	l.err = l.enc.Encode(rec)
+}
+
+// Close flushes the log and returns the first write error. The underlying
+// writer is not closed.
+func (l *LogWriter) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.gz != nil && l.err == nil {
+		l.err = l.gz.Close()
+	}
+	if l.err == nil {
+		l.err = l.buf.Flush()
+	}
+	return l.err
+}
+
+// ReadLog reads a log written by a LogWriter and records every Result to
+// rec, compressed logs are detected. It returns the fraction of results that
+// were written to the log, the counts of a sampled log can be scaled by its
+// inverse with Stage.Scale.
+func ReadLog(r io.Reader, rec Recorder) (float64, error) {
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return 0, err
+		}
+		defer gz.Close()
+		br = bufio.NewReader(gz)
+	}
+	dec := json.NewDecoder(br)
+	sample := 1.0
+	for {
+		var record Record
+		if err := dec.Decode(&record); err == io.EOF {
+			return sample, nil
+		} else if err != nil {
+			return 0, err
+		}
+		if record.Sample > 0 {
+			sample = record.Sample
+		}
+		rec.Record(record.Result())
+	}
+}
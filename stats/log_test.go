@@ -0,0 +1,87 @@
+package stats
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLog tests writing and reading a result log.
+func TestLog(t *testing.T) {
+	for _, compress := range []bool{false, true} {
+		t.Run(fmt.Sprint(compress), func(t *testing.T) {
+			var buf bytes.Buffer
+			l := NewLogWriter(&buf, 1, compress)
+			start := time.Unix(1000, 0).UTC()
+			l.Record(Result{
+				Time:     start,
+				Stage:    "a",
+				Protocol: "http",
+				Op:       "GET",
+				Latency:  time.Millisecond,
+				Bytes:    10,
+			})
+			l.Record(Result{
+				Time:    start.Add(time.Second),
+				Stage:   "a",
+				Latency: time.Second,
+				Err:     Classify(ClassProtocol, "503", fmt.Errorf("read: %w", context.DeadlineExceeded)),
+				Warmup:  true,
+			})
+			require.NoError(t, l.Close())
+			if !compress {
+				assert.Contains(t, buf.String(), `"status":"ok"`)
+				assert.Contains(t, buf.String(), `"errorClass":"protocol","errorCode":"503"`)
+			}
+
+			var got []Result
+			sample, err := ReadLog(&buf, RecorderFunc(func(r Result) {
+				got = append(got, r)
+			}))
+			require.NoError(t, err)
+			assert.Equal(t, 1.0, sample)
+			require.Len(t, got, 2)
+			assert.True(t, start.Equal(got[0].Time))
+			assert.Equal(t, "GET", got[0].Op)
+			assert.Equal(t, int64(10), got[0].Bytes)
+			assert.NoError(t, got[0].Err)
+			assert.EqualError(t, got[1].Err, "read: context deadline exceeded")
+			assert.Equal(t, ClassProtocol, ErrorClass(got[1].Err))
+			assert.Equal(t, "503", ErrorCode(got[1].Err))
+			assert.True(t, got[1].Warmup)
+		})
+	}
+}
+
+// TestLogSample tests sampling results.
+func TestLogSample(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLogWriter(&buf, 0, false)
+	for i := 0; i < 100; i++ {
+		l.Record(Result{Stage: "a"})
+	}
+	require.NoError(t, l.Close())
+	assert.Zero(t, buf.Len())
+
+	l = NewLogWriter(&buf, 0.5, false)
+	for i := 0; i < 1000; i++ {
+		l.Record(Result{Stage: "a"})
+	}
+	require.NoError(t, l.Close())
+	assert.Contains(t, buf.String(), `"sample":0.5`)
+	c := NewCollector()
+	sample, err := ReadLog(&buf, c)
+	require.NoError(t, err)
+	assert.Equal(t, 0.5, sample)
+	stages := c.Stages()
+	require.Len(t, stages, 1)
+	ops := stages[0].Main.Ops
+	assert.InDelta(t, 500, ops, 100)
+	stages[0].Scale(1 / sample)
+	assert.Equal(t, 2*ops, stages[0].Main.Ops)
+}
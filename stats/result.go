@@ -0,0 +1,95 @@
+package stats
+
+import (
+	"context"
+	"time"
+)
+
+// Result is the outcome of a single operation.
+type Result struct {
+	Time     time.Time
+	Stage    string
+	Protocol string
+	Op       string
+	Latency  time.Duration
+	Bytes    int64
+	Err      error
+	// Warmup is set for results recorded during a stage warm-up period,
+	// they are kept separate from the measured results.
+	Warmup bool
+	// TraceID is the ID of the exported trace of the operation.
+	TraceID string
+}
+
+// Recorder is used for recording operation results.
+type Recorder interface {
+	Record(Result)
+}
+
+// RecorderFunc is an adapter to allow the use of ordinary functions as a
+// Recorder.
+type RecorderFunc func(Result)
+
+// Record implements the Recorder interface.
+func (f RecorderFunc) Record(r Result) {
+	f(r)
+}
+
+// Merger is implemented by Recorders that aggregate the results of other
+// load generators, such as the agents of a distributed run.
+type Merger interface {
+	// MergeSource replaces the results of a source with stages, except for
+	// intervals which replace only the intervals of the same second.
+	MergeSource(source string, stages []*Stage)
+}
+
+type nopRecorder struct{}
+
+func (nopRecorder) Record(Result) {}
+
+type multiRecorder []Recorder
+
+// MultiRecorder returns a Recorder that records to all of the given
+// recorders, nil recorders are ignored.
+func MultiRecorder(recorders ...Recorder) Recorder {
+	m := multiRecorder{}
+	for _, r := range recorders {
+		if r != nil {
+			m = append(m, r)
+		}
+	}
+	return m
+}
+
+// Record implements the Recorder interface.
+func (m multiRecorder) Record(r Result) {
+	for _, rec := range m {
+		rec.Record(r)
+	}
+}
+
+// MergeSource implements the Merger interface for the recorders that are
+// Mergers.
+func (m multiRecorder) MergeSource(source string, stages []*Stage) {
+	for _, rec := range m {
+		if merger, ok := rec.(Merger); ok {
+			merger.MergeSource(source, stages)
+		}
+	}
+}
+
+type recorderKey struct{}
+
+// NewContext returns a context that carries a Recorder.
+func NewContext(ctx context.Context, r Recorder) context.Context {
+	return context.WithValue(ctx, recorderKey{}, r)
+}
+
+// FromContext returns the Recorder of a context, if the context has no
+// Recorder results are discarded.
+func FromContext(ctx context.Context) Recorder {
+	if r, ok := ctx.Value(recorderKey{}).(Recorder); ok && r != nil {
+		return r
+	}
+	return nopRecorder{}
+}
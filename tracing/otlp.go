@@ -0,0 +1,153 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// OTLPExporter exports spans to an OpenTelemetry collector with OTLP over
+// HTTP.
+type OTLPExporter struct {
+	url      string
+	resource []Attribute
+	client   *http.Client
+}
+
+// NewOTLPExporter returns an OTLPExporter for a collector endpoint such as
+// http://localhost:4318, the /v1/traces path is added when the endpoint has
+// no path. The resource attributes describe the run, service.name defaults
+// to dlg.
+func NewOTLPExporter(endpoint string, resource ...Attribute) (*OTLPExporter, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid OTLP endpoint %q", endpoint)
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = "/v1/traces"
+	}
+	hasService := false
+	for _, a := range resource {
+		if a.Key == "service.name" {
+			hasService = true
+		}
+	}
+	if !hasService {
+		resource = append([]Attribute{String("service.name", "dlg")}, resource...)
+	}
+	return &OTLPExporter{
+		url:      u.String(),
+		resource: resource,
+		client:   &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// Export implements the Exporter interface.
+func (e *OTLPExporter) Export(ctx context.Context, spans []*Span) error {
+	body := encodeExportRequest(e.resource, spans)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	res, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return fmt.Errorf("%s: %s", res.Status, bytes.TrimSpace(msg))
+	}
+	_, err = io.Copy(io.Discard, res.Body)
+	return err
+}
+
+// OTLP span kinds and status codes.
+const (
+	spanKindClient  = 3
+	statusCodeOK    = 1
+	statusCodeError = 2
+)
+
+// encodeExportRequest encodes an OTLP ExportTraceServiceRequest protobuf
+// with a single resource and instrumentation scope.
+func encodeExportRequest(resource []Attribute, spans []*Span) []byte {
+	var res []byte
+	for _, a := range resource {
+		res = appendMessage(res, 1, encodeAttribute(a))
+	}
+
+	var scope []byte
+	scope = protowire.AppendTag(scope, 1, protowire.BytesType)
+	scope = protowire.AppendString(scope, "github.com/hodgesds/dlg")
+
+	var scopeSpans []byte
+	scopeSpans = appendMessage(scopeSpans, 1, scope)
+	for _, s := range spans {
+		scopeSpans = appendMessage(scopeSpans, 2, encodeSpan(s))
+	}
+
+	var resourceSpans []byte
+	resourceSpans = appendMessage(resourceSpans, 1, res)
+	resourceSpans = appendMessage(resourceSpans, 2, scopeSpans)
+
+	return appendMessage(nil, 1, resourceSpans)
+}
+
+func encodeSpan(s *Span) []byte {
+	var b []byte
+	b = protowire.AppendTag(b, 1, protowire.BytesType)
+	b = protowire.AppendBytes(b, s.TraceID[:])
+	b = protowire.AppendTag(b, 2, protowire.BytesType)
+	b = protowire.AppendBytes(b, s.SpanID[:])
+	b = protowire.AppendTag(b, 5, protowire.BytesType)
+	b = protowire.AppendString(b, s.Name)
+	b = protowire.AppendTag(b, 6, protowire.VarintType)
+	b = protowire.AppendVarint(b, spanKindClient)
+	b = protowire.AppendTag(b, 7, protowire.Fixed64Type)
+	b = protowire.AppendFixed64(b, uint64(s.Start.UnixNano()))
+	b = protowire.AppendTag(b, 8, protowire.Fixed64Type)
+	b = protowire.AppendFixed64(b, uint64(s.End.UnixNano()))
+	for _, a := range s.Attributes {
+		b = appendMessage(b, 9, encodeAttribute(a))
+	}
+
+	var status []byte
+	if s.Err != nil {
+		status = protowire.AppendTag(status, 2, protowire.BytesType)
+		status = protowire.AppendString(status, s.Err.Error())
+		status = protowire.AppendTag(status, 3, protowire.VarintType)
+		status = protowire.AppendVarint(status, statusCodeError)
+	} else {
+		status = protowire.AppendTag(status, 3, protowire.VarintType)
+		status = protowire.AppendVarint(status, statusCodeOK)
+	}
+	return appendMessage(b, 15, status)
+}
+
+// encodeAttribute encodes a KeyValue with a string AnyValue.
+func encodeAttribute(a Attribute) []byte {
+	var value []byte
+	value = protowire.AppendTag(value, 1, protowire.BytesType)
+	value = protowire.AppendString(value, a.Value)
+
+	var kv []byte
+	kv = protowire.AppendTag(kv, 1, protowire.BytesType)
+	kv = protowire.AppendString(kv, a.Key)
+	return appendMessage(kv, 2, value)
+}
+
+func appendMessage(b []byte, num protowire.Number, msg []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, msg)
+}
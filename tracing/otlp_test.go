@@ -0,0 +1,106 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// message decodes the fields of a protobuf message by number, varint and
+// fixed64 values are returned as their encoding.
+func message(t *testing.T, b []byte) map[protowire.Number][][]byte {
+	fields := map[protowire.Number][][]byte{}
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		require.True(t, n > 0)
+		b = b[n:]
+		n = protowire.ConsumeFieldValue(num, typ, b)
+		require.True(t, n > 0)
+		v := b[:n]
+		if typ == protowire.BytesType {
+			v, _ = protowire.ConsumeBytes(v)
+		}
+		fields[num] = append(fields[num], v)
+		b = b[n:]
+	}
+	return fields
+}
+
+func attributes(t *testing.T, kvs [][]byte) map[string]string {
+	attrs := map[string]string{}
+	for _, kv := range kvs {
+		f := message(t, kv)
+		attrs[string(f[1][0])] = string(message(t, f[2][0])[1][0])
+	}
+	return attrs
+}
+
+// TestOTLPExporter tests exporting spans to a collector.
+func TestOTLPExporter(t *testing.T) {
+	var (
+		path string
+		body []byte
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/x-protobuf", r.Header.Get("Content-Type"))
+		path = r.URL.Path
+		var err error
+		body, err = io.ReadAll(r.Body)
+		require.NoError(t, err)
+	}))
+	defer srv.Close()
+
+	e, err := NewOTLPExporter(srv.URL, String("dlg.run_id", "1"))
+	require.NoError(t, err)
+	start := time.Unix(1000, 0)
+	span := &Span{
+		Name:       "GET",
+		TraceID:    TraceID{1, 2, 3},
+		SpanID:     SpanID{4, 5, 6},
+		Start:      start,
+		End:        start.Add(time.Second),
+		Attributes: []Attribute{String("dlg.stage", "a")},
+		Err:        errors.New("fail"),
+	}
+	require.NoError(t, e.Export(context.Background(), []*Span{span}))
+	assert.Equal(t, "/v1/traces", path)
+
+	resourceSpans := message(t, message(t, body)[1][0])
+	resource := message(t, resourceSpans[1][0])
+	assert.Equal(t, map[string]string{
+		"service.name": "dlg",
+		"dlg.run_id":   "1",
+	}, attributes(t, resource[1]))
+
+	spans := message(t, resourceSpans[2][0])[2]
+	require.Len(t, spans, 1)
+	s := message(t, spans[0])
+	assert.Equal(t, span.TraceID[:], s[1][0])
+	assert.Equal(t, span.SpanID[:], s[2][0])
+	assert.Equal(t, "GET", string(s[5][0]))
+	start64, _ := protowire.ConsumeFixed64(s[7][0])
+	end64, _ := protowire.ConsumeFixed64(s[8][0])
+	assert.Equal(t, uint64(time.Second), end64-start64)
+	assert.Equal(t, map[string]string{"dlg.stage": "a"}, attributes(t, s[9]))
+	status := message(t, s[15][0])
+	assert.Equal(t, "fail", string(status[2][0]))
+	code, _ := protowire.ConsumeVarint(status[3][0])
+	assert.Equal(t, uint64(statusCodeError), code)
+}
+
+// TestNewOTLPExporter tests collector endpoints.
+func TestNewOTLPExporter(t *testing.T) {
+	e, err := NewOTLPExporter("http://localhost:4318/custom/traces")
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:4318/custom/traces", e.url)
+	_, err = NewOTLPExporter("localhost:4318")
+	assert.Error(t, err)
+}
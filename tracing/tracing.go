@@ -0,0 +1,285 @@
+// Package tracing creates a span for every operation of a run and
+// propagates it to the system under test using W3C trace context, so that
+// server side traces can be matched to the operations that caused them.
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+const (
+	// TraceparentHeader is the W3C trace context header.
+	TraceparentHeader = "traceparent"
+
+	batchSize     = 512
+	queueSize     = 8192
+	flushInterval = 5 * time.Second
+)
+
+// TraceID is the ID of a trace.
+type TraceID [16]byte
+
+// String returns the ID as lower case hex.
+func (id TraceID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// SpanID is the ID of a span.
+type SpanID [8]byte
+
+// String returns the ID as lower case hex.
+func (id SpanID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// Attribute is a span attribute.
+type Attribute struct {
+	Key   string
+	Value string
+}
+
+// String returns an Attribute.
+func String(key, value string) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Span is an operation. Only sampled spans are exported, unsampled spans are
+// still propagated so that the system under test does not sample them either.
+type Span struct {
+	Name       string
+	TraceID    TraceID
+	SpanID     SpanID
+	Sampled    bool
+	Start      time.Time
+	End        time.Time
+	Attributes []Attribute
+	Err        error
+
+	tracer *Tracer
+}
+
+// Traceparent returns the W3C traceparent header value of the span.
+func (s *Span) Traceparent() string {
+	if s == nil {
+		return ""
+	}
+	flags := 0
+	if s.Sampled {
+		flags = 1
+	}
+	return fmt.Sprintf("00-%s-%s-%02x", s.TraceID, s.SpanID, flags)
+}
+
+// SampledTraceID returns the trace ID of a sampled span, otherwise an empty
+// string.
+func (s *Span) SampledTraceID() string {
+	if s == nil || !s.Sampled {
+		return ""
+	}
+	return s.TraceID.String()
+}
+
+// Finish ends the span, err is the result of the operation.
+func (s *Span) Finish(err error) {
+	if s == nil {
+		return
+	}
+	s.End = time.Now()
+	s.Err = err
+	if s.Sampled {
+		s.tracer.enqueue(s)
+	}
+}
+
+// Exporter exports finished spans.
+type Exporter interface {
+	Export(ctx context.Context, spans []*Span) error
+}
+
+// Tracer starts spans and exports them in batches in the background.
+type Tracer struct {
+	exporter Exporter
+	sample   float64
+
+	mu      sync.Mutex
+	queue   chan *Span
+	dropped int64
+	done    chan struct{}
+	closed  bool
+}
+
+// NewTracer returns a Tracer, sample is the fraction of spans that are
+// exported.
+func NewTracer(exporter Exporter, sample float64) *Tracer {
+	t := &Tracer{
+		exporter: exporter,
+		sample:   sample,
+		queue:    make(chan *Span, queueSize),
+		done:     make(chan struct{}),
+	}
+	go t.run()
+	return t
+}
+
+func (t *Tracer) start(name string, attrs []Attribute) *Span {
+	s := &Span{
+		Name:       name,
+		Sampled:    t.sample >= 1 || rand.Float64() < t.sample,
+		Start:      time.Now(),
+		Attributes: attrs,
+		tracer:     t,
+	}
+	for s.TraceID == (TraceID{}) {
+		putUint64(s.TraceID[:8], rand.Uint64())
+		putUint64(s.TraceID[8:], rand.Uint64())
+	}
+	for s.SpanID == (SpanID{}) {
+		putUint64(s.SpanID[:], rand.Uint64())
+	}
+	return s
+}
+
+func putUint64(b []byte, v uint64) {
+	for i := range b {
+		b[i] = byte(v >> (8 * i))
+	}
+}
+
+// enqueue queues a span for export, spans are dropped when the queue is full
+// rather than slowing down the run.
+func (t *Tracer) enqueue(s *Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return
+	}
+	select {
+	case t.queue <- s:
+	default:
+		t.dropped++
+	}
+}
+
+func (t *Tracer) run() {
+	defer close(t.done)
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	batch := make([]*Span, 0, batchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := t.exporter.Export(ctx, batch); err != nil {
+			log.Printf("trace export: %v", err)
+		}
+		batch = make([]*Span, 0, batchSize)
+	}
+	for {
+		select {
+		case s, ok := <-t.queue:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, s)
+			if len(batch) == batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// Close exports the queued spans and stops the Tracer, spans finished after
+// Close are discarded.
+func (t *Tracer) Close(ctx context.Context) error {
+	t.mu.Lock()
+	if !t.closed {
+		t.closed = true
+		close(t.queue)
+	}
+	dropped := t.dropped
+	t.mu.Unlock()
+	select {
+	case <-t.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if dropped > 0 {
+		return fmt.Errorf("%d spans dropped", dropped)
+	}
+	return nil
+}
+
+type tracerKey struct{}
+
+type attributesKey struct{}
+
+type spanKey struct{}
+
+// NewContext returns a context that carries a Tracer.
+func NewContext(ctx context.Context, t *Tracer) context.Context {
+	return context.WithValue(ctx, tracerKey{}, t)
+}
+
+// FromContext returns the Tracer of a context or nil.
+func FromContext(ctx context.Context) *Tracer {
+	t, _ := ctx.Value(tracerKey{}).(*Tracer)
+	return t
+}
+
+// WithAttributes returns a context with attributes that are added to the
+// spans started from it, attributes replace those of the parent context with
+// the same key.
+func WithAttributes(ctx context.Context, attrs ...Attribute) context.Context {
+	parent, _ := ctx.Value(attributesKey{}).([]Attribute)
+	merged := make([]Attribute, 0, len(parent)+len(attrs))
+	for _, p := range parent {
+		replaced := false
+		for _, a := range attrs {
+			if a.Key == p.Key {
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			merged = append(merged, p)
+		}
+	}
+	return context.WithValue(ctx, attributesKey{}, append(merged, attrs...))
+}
+
+// Start starts a span with the Tracer of the context. If the context has no
+// Tracer the returned span is nil, the methods of a nil span do nothing.
+func Start(ctx context.Context, name string) (context.Context, *Span) {
+	t := FromContext(ctx)
+	if t == nil {
+		return ctx, nil
+	}
+	attrs, _ := ctx.Value(attributesKey{}).([]Attribute)
+	s := t.start(name, attrs)
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+// SpanFromContext returns the span of a context or nil.
+func SpanFromContext(ctx context.Context) *Span {
+	s, _ := ctx.Value(spanKey{}).(*Span)
+	return s
+}
+
+// Inject calls set with the traceparent header of the span of the context,
+// if the context has a span.
+func Inject(ctx context.Context, set func(key, value string)) {
+	if s := SpanFromContext(ctx); s != nil {
+		set(TraceparentHeader, s.Traceparent())
+	}
+}
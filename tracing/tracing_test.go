@@ -0,0 +1,71 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testExporter struct {
+	mu    sync.Mutex
+	spans []*Span
+}
+
+func (e *testExporter) Export(_ context.Context, spans []*Span) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+// TestStart tests starting spans and propagating them.
+func TestStart(t *testing.T) {
+	exp := &testExporter{}
+	tracer := NewTracer(exp, 1)
+	ctx := NewContext(context.Background(), tracer)
+	ctx = WithAttributes(ctx, String("dlg.plan", "p"), String("dlg.stage", "a"))
+	ctx = WithAttributes(ctx, String("dlg.stage", "b"))
+
+	ctx, span := Start(ctx, "GET")
+	require.NotNil(t, span)
+	assert.Equal(t, span, SpanFromContext(ctx))
+	assert.Equal(t, []Attribute{String("dlg.plan", "p"), String("dlg.stage", "b")}, span.Attributes)
+
+	headers := map[string]string{}
+	Inject(ctx, func(k, v string) { headers[k] = v })
+	assert.Regexp(t, regexp.MustCompile(`^00-[0-9a-f]{32}-[0-9a-f]{16}-01$`), headers[TraceparentHeader])
+	assert.Equal(t, span.TraceID.String(), span.SampledTraceID())
+
+	span.Finish(errors.New("fail"))
+	require.NoError(t, tracer.Close(context.Background()))
+	require.Len(t, exp.spans, 1)
+	assert.EqualError(t, exp.spans[0].Err, "fail")
+	assert.False(t, exp.spans[0].End.Before(exp.spans[0].Start))
+}
+
+// TestStartUnsampled tests unsampled spans are propagated but not exported.
+func TestStartUnsampled(t *testing.T) {
+	exp := &testExporter{}
+	tracer := NewTracer(exp, 0)
+	ctx, span := Start(NewContext(context.Background(), tracer), "GET")
+	assert.Regexp(t, `-00$`, span.Traceparent())
+	assert.Empty(t, span.SampledTraceID())
+	span.Finish(nil)
+	require.NoError(t, tracer.Close(context.Background()))
+	assert.Empty(t, exp.spans)
+	assert.Equal(t, span, SpanFromContext(ctx))
+}
+
+// TestStartNoTracer tests spans are not started without a Tracer.
+func TestStartNoTracer(t *testing.T) {
+	ctx, span := Start(context.Background(), "GET")
+	assert.Nil(t, span)
+	span.Finish(nil)
+	assert.Empty(t, span.SampledTraceID())
+	Inject(ctx, func(k, v string) { t.Fatal("unexpected header") })
+}